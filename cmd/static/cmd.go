@@ -23,6 +23,7 @@ import (
 	"github.com/cloudwego/cwgo/pkg/client"
 	"github.com/cloudwego/cwgo/pkg/consts"
 	"github.com/cloudwego/cwgo/pkg/curd/doc"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/lsp"
 	"github.com/cloudwego/cwgo/pkg/fallback"
 	"github.com/cloudwego/cwgo/pkg/model"
 	"github.com/cloudwego/cwgo/pkg/server"
@@ -94,6 +95,33 @@ func Init() *cli.App {
 				}
 				return doc.Doc(globalArgs.DocArgument)
 			},
+			Subcommands: []*cli.Command{
+				{
+					Name:  DocCheckName,
+					Usage: DocCheckUsage,
+					Flags: docCheckFlags(),
+					Action: func(c *cli.Context) error {
+						if err := globalArgs.DocArgument.ParseCli(c); err != nil {
+							return err
+						}
+						if err := lsp.Check(globalArgs.DocArgument); err != nil {
+							return cli.Exit(err, 1)
+						}
+						return nil
+					},
+				},
+				{
+					Name:  DocLspName,
+					Usage: DocLspUsage,
+					Flags: docLspFlags(),
+					Action: func(c *cli.Context) error {
+						if err := globalArgs.DocArgument.ParseCli(c); err != nil {
+							return err
+						}
+						return lsp.Serve(globalArgs.DocArgument)
+					},
+				},
+			},
 		},
 		{
 			Name:  ApiListName,
@@ -191,6 +219,16 @@ Examples:
   cwgo doc --name mongodb --idl {{path/to/IDL_file.thrift}}
 `
 
+	DocCheckName  = "check"
+	DocCheckUsage = `validate the methods declared by the annotations of the IDL without generating code
+
+Examples:
+  cwgo doc check --idl {{path/to/IDL_file.thrift}}
+`
+
+	DocLspName  = "lsp"
+	DocLspUsage = "run the language server of the method annotations of the IDL over stdio"
+
 	ApiListName = "api-list"
 	ApiUsage    = `analyze router codes by golang ast
 
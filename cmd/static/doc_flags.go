@@ -28,10 +28,32 @@ func docFlags() []cli.Flag {
 		&cli.StringFlag{Name: consts.OutDir, Usage: "Specify output directory, default is current dir."},
 		&cli.StringFlag{Name: consts.ModelDir, Usage: "Specify model output directory, default is biz/doc/model."},
 		&cli.StringFlag{Name: consts.DaoDir, Usage: "Specify dao output directory, default is biz/doc/dao."},
-		&cli.StringFlag{Name: consts.Name, Usage: "Specify specific doc name, default is mongodb."},
+		&cli.StringFlag{Name: consts.Name, Usage: "Specify specific doc name, mongodb, redis, gorm, elasticsearch or the backend plugin cwgo-doc-{name} in PATH, default is mongodb."},
 		&cli.StringSliceFlag{Name: consts.ProtoSearchPath, Aliases: []string{"I"}, Usage: "Add an IDL search path for includes."},
 		&cli.StringSliceFlag{Name: consts.ThriftGo, Aliases: []string{"t"}, Usage: "Specify arguments for the thriftgo. ({flag}={value})"},
 		&cli.StringSliceFlag{Name: consts.Protoc, Aliases: []string{"p"}, Usage: "Specify arguments for the protoc. ({flag}={value})"},
 		&cli.BoolFlag{Name: consts.Verbose, Usage: "Turn on verbose mode, default is false."},
+		&cli.BoolFlag{Name: consts.Mock, Usage: "Generate gomock mock and in-memory fake for repositories, default is false."},
+		&cli.BoolFlag{Name: consts.UnitTest, Usage: "Generate integration tests for repositories which run against MONGO_URI, default is false."},
+		&cli.BoolFlag{Name: consts.Prune, Usage: "Delete the generated repository methods which are removed from the IDL instead of reporting them, default is false."},
+		&cli.BoolFlag{Name: consts.Cache, Usage: "Generate read-through cache decorators for repositories with in-memory LRU and redis caches, default is false."},
+		&cli.BoolFlag{Name: consts.Hook, Usage: "Generate instrumentation hook decorators for repositories with logging, OpenTelemetry and Prometheus hooks, default is false."},
+		&cli.BoolFlag{Name: consts.Generic, Usage: "Generate the shared generic repository mongorepo.Repository[T] with Insert, FindByID, DeleteByID and Count embedded in repositories, the models with mongo.options are not supported, default is false."},
+		&cli.BoolFlag{Name: consts.DumpAST, Usage: "Print the extracted structures and the parsed operations as JSON instead of generating repositories, default is false."},
+	}
+}
+
+func docCheckFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: consts.IDLPath, Usage: "Specify the IDL file path. (.thrift or .proto)"},
+		&cli.StringSliceFlag{Name: consts.ProtoSearchPath, Aliases: []string{"I"}, Usage: "Add an IDL search path for includes."},
+		&cli.StringSliceFlag{Name: consts.Protoc, Aliases: []string{"p"}, Usage: "Specify arguments for the protoc. ({flag}={value})"},
+	}
+}
+
+func docLspFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{Name: consts.ProtoSearchPath, Aliases: []string{"I"}, Usage: "Add an IDL search path for includes."},
+		&cli.StringSliceFlag{Name: consts.Protoc, Aliases: []string{"p"}, Usage: "Specify arguments for the protoc. ({flag}={value})"},
 	}
 }
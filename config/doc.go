@@ -35,6 +35,14 @@ type DocArgument struct {
 	ModelDir        string
 	DaoDir          string
 	Verbose         bool
+	Mock            bool   // generate gomock mock and in-memory fake repositories
+	UnitTest        bool   // generate integration tests of mongo repositories
+	Prune           bool   // delete the generated methods which are removed from the idl
+	Cache           bool   // generate read-through cache decorators of mongo repositories
+	Hook            bool   // generate instrumentation hook decorators of mongo repositories
+	Generic         bool   // embed the shared generic repository in mongo repositories
+	DumpAST         bool   // print the parsed structures and operations as JSON instead of generating repositories
+	DumpPath        string // the file which the thrift plugin writes the dump to, it is set by cwgo
 	ProtoSearchPath []string
 	ProtocOptions   []string // options to pass through to protoc
 	ThriftOptions   []string // options to pass through to thriftgo for go flag
@@ -52,6 +60,13 @@ func (d *DocArgument) ParseCli(ctx *cli.Context) error {
 	d.DaoDir = ctx.String(consts.DaoDir)
 	d.Name = ctx.String(consts.Name)
 	d.Verbose = ctx.Bool(consts.Verbose)
+	d.Mock = ctx.Bool(consts.Mock)
+	d.UnitTest = ctx.Bool(consts.UnitTest)
+	d.Prune = ctx.Bool(consts.Prune)
+	d.Cache = ctx.Bool(consts.Cache)
+	d.Hook = ctx.Bool(consts.Hook)
+	d.Generic = ctx.Bool(consts.Generic)
+	d.DumpAST = ctx.Bool(consts.DumpAST)
 	d.ProtoSearchPath = ctx.StringSlice(consts.ProtoSearchPath)
 	d.ProtocOptions = ctx.StringSlice(consts.Protoc)
 	d.ThriftOptions = ctx.StringSlice(consts.ThriftGo)
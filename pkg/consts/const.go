@@ -129,6 +129,12 @@ const (
 
 	ModelDir = "model_dir"
 	DaoDir   = "dao_dir"
+	Mock     = "mock"
+	Prune    = "prune"
+	Cache    = "cache"
+	Hook     = "hook"
+	Generic  = "generic"
+	DumpAST  = "dump-ast"
 
 	Service         = "service"
 	ServiceType     = "type"
@@ -159,7 +165,10 @@ const (
 )
 
 const (
-	MongoDb = "mongodb"
+	MongoDb       = "mongodb"
+	Redis         = "redis"
+	Gorm          = "gorm"
+	Elasticsearch = "elasticsearch"
 )
 
 const (
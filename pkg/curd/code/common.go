@@ -76,6 +76,9 @@ func (ps Params) GetCode() string {
 type Returns []Type
 
 func (rs Returns) GetCode() string {
+	if len(rs) == 0 {
+		return ""
+	}
 	if len(rs) == 1 {
 		return rs[0].RealName()
 	} else {
@@ -92,6 +95,9 @@ func (rs Returns) GetCode() string {
 }
 
 func getParamsCode(ps []Param) string {
+	if len(ps) == 0 {
+		return "()"
+	}
 	result := "("
 	for index, param := range ps {
 		if index != len(ps)-1 {
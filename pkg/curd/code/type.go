@@ -38,7 +38,11 @@
 
 package code
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 type Type interface {
 	RealName() string
@@ -92,3 +96,126 @@ type StarExprType struct {
 func (set StarExprType) RealName() string {
 	return "*" + set.RealType.RealName()
 }
+
+type ChanDir int
+
+const (
+	ChanBoth ChanDir = iota
+	ChanSend
+	ChanRecv
+)
+
+type ChanType struct {
+	Dir         ChanDir
+	ElementType Type
+}
+
+func (ct ChanType) RealName() string {
+	switch ct.Dir {
+	case ChanSend:
+		return "chan<- " + ct.ElementType.RealName()
+	case ChanRecv:
+		return "<-chan " + ct.ElementType.RealName()
+	default:
+		return "chan " + ct.ElementType.RealName()
+	}
+}
+
+// FuncType is the type of the function value, such as func(error).
+type FuncType struct {
+	Params  []Type
+	Returns []Type
+}
+
+func (ft FuncType) RealName() string {
+	params := make([]string, 0, len(ft.Params))
+	for _, param := range ft.Params {
+		params = append(params, param.RealName())
+	}
+	result := "func(" + strings.Join(params, ", ") + ")"
+	if len(ft.Returns) == 1 {
+		return result + " " + ft.Returns[0].RealName()
+	}
+	if len(ft.Returns) > 1 {
+		returns := make([]string, 0, len(ft.Returns))
+		for _, r := range ft.Returns {
+			returns = append(returns, r.RealName())
+		}
+		result += " (" + strings.Join(returns, ", ") + ")"
+	}
+	return result
+}
+
+// GenericType is the instantiation of the generic type, such as Repository[T] or Repository[user.User].
+type GenericType struct {
+	Type     Type
+	TypeArgs []Type
+}
+
+func (gt GenericType) RealName() string {
+	if len(gt.TypeArgs) == 0 {
+		return gt.Type.RealName()
+	}
+	args := make([]string, 0, len(gt.TypeArgs))
+	for _, arg := range gt.TypeArgs {
+		args = append(args, arg.RealName())
+	}
+	return gt.Type.RealName() + "[" + strings.Join(args, ", ") + "]"
+}
+
+// TypeParam is the type parameter of the generic type or function, such as T any.
+type TypeParam struct {
+	Name       string
+	Constraint Type
+}
+
+func (tp TypeParam) GetCode() string {
+	return tp.Name + " " + tp.Constraint.RealName()
+}
+
+type TypeParams []TypeParam
+
+func (tps TypeParams) GetCode() string {
+	if len(tps) == 0 {
+		return ""
+	}
+	params := make([]string, 0, len(tps))
+	for _, param := range tps {
+		params = append(params, param.GetCode())
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// the types except IdentType are encoded as the go source by JSON, such as the requests of the backend plugins
+
+func (set SelectorExprType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(set.RealName())
+}
+
+func (it InterfaceType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(it.RealName())
+}
+
+func (st SliceType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(st.RealName())
+}
+
+func (mt MapType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mt.RealName())
+}
+
+func (set StarExprType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(set.RealName())
+}
+
+func (ct ChanType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ct.RealName())
+}
+
+func (ft FuncType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ft.RealName())
+}
+
+func (gt GenericType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gt.RealName())
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backend
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/cwgo/config"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// File is the file generated by the backend, Name is the absolute path of the file.
+type File struct {
+	Name    string
+	Content string
+}
+
+// Request is passed to the backend after the idl is extracted and parsed.
+type Request struct {
+	Args *config.DocArgument
+	// ImportPaths are the import paths of the model packages used by the generated files
+	ImportPaths []string
+	// Structs are the structures with the repositories in the idl
+	Structs []*extract.IdlExtractStruct
+	// Operations are the parsing results of the methods declared in the idl, whose indexes are the same as Structs
+	Operations []*parse.InterfaceOperation
+}
+
+// Response is returned by the backend, the warnings are reported to the user.
+type Response struct {
+	Files    []File
+	Warnings []string
+}
+
+// Backend generates the repositories of the structures for the database specified by the doc name.
+type Backend interface {
+	Generate(req *Request) (*Response, error)
+}
+
+// TransactionClient is implemented by the backends generating the transactions,
+// TransactionClientType returns the type of the client param of the transactions, e.g. *mongo.Client.
+type TransactionClient interface {
+	TransactionClientType() string
+}
+
+// CheckTransactionClients returns an error if the client param type of a transaction is not the one of the backend,
+// the backends not implementing TransactionClient validate the transactions themselves.
+func CheckTransactionClients(name string, b Backend, operations []*parse.InterfaceOperation) error {
+	tc, ok := b.(TransactionClient)
+	if !ok {
+		return nil
+	}
+	clientType := tc.TransactionClientType()
+	if clientType == "" {
+		return nil
+	}
+	for _, ifOperation := range operations {
+		for _, operation := range ifOperation.Operations {
+			transaction, ok := operation.(*parse.TransactionParse)
+			if !ok {
+				continue
+			}
+			for _, param := range transaction.BelongedToMethod.Params {
+				if param.Name == transaction.ClientParamName && param.Type.RealName() != clientType {
+					return fmt.Errorf("%s: the second parameter of the Transaction should be %s for the %s backend",
+						transaction.BelongedToMethod.Name, clientType, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	mu       sync.RWMutex
+	backends = map[string]Backend{}
+)
+
+// Register registers the backend by the doc name, it panics if the name is registered twice.
+func Register(name string, b Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("backend %s is registered twice", name))
+	}
+	backends[name] = b
+}
+
+// Lookup returns the backend registered by the name, or the plugin named PluginPrefix + name in PATH.
+func Lookup(name string) (Backend, error) {
+	mu.RLock()
+	b, ok := backends[name]
+	mu.RUnlock()
+	if ok {
+		return b, nil
+	}
+
+	path, err := exec.LookPath(PluginPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("doc name %s not supported, neither the backend is registered nor the plugin %s "+
+			"is found in PATH", name, PluginPrefix+name)
+	}
+	return &pluginBackend{name: name, path: path}, nil
+}
+
+// Names returns the sorted names of the registered backends.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
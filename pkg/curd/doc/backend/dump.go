@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backend
+
+import (
+	"encoding/json"
+
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// Dump is printed by cwgo doc --dump-ast, it is encoded in the same way as PluginRequest.
+type Dump struct {
+	Version string
+	Structs []*DumpStruct
+}
+
+// DumpStruct is the annotated structure with its fields, indexes, methods and the parsed operations.
+type DumpStruct struct {
+	Name       string
+	Collection string
+	Database   string
+	Fields     []*extract.StructField
+	Indexes    []*extract.Index
+	Options    extract.ModelOptions
+	// Methods are the methods declared in the idl, the positions of the annotations are used by the editors
+	Methods    []*extract.InterfaceMethod
+	Operations []*PluginOperation
+}
+
+// GetDump returns the dump of the structures and the operations parsed from them, whose indexes are the same.
+func GetDump(structs []*extract.IdlExtractStruct, operations []*parse.InterfaceOperation) *Dump {
+	dump := &Dump{
+		Version: cwgoMeta.Version,
+		Structs: make([]*DumpStruct, 0, len(structs)),
+	}
+	for index, st := range structs {
+		dumpStruct := &DumpStruct{
+			Name:       st.Name,
+			Collection: st.GetCollectionName(),
+			Database:   st.Database,
+			Fields:     st.StructFields,
+			Indexes:    st.Indexes,
+			Options:    st.Options,
+			Methods:    st.InterfaceInfo.Methods,
+			Operations: []*PluginOperation{},
+		}
+		if index < len(operations) {
+			dumpStruct.Operations = newPluginOperations(operations[index : index+1])
+		}
+		dump.Structs = append(dump.Structs, dumpStruct)
+	}
+	return dump
+}
+
+// MarshalDump returns the indented JSON of the dump.
+func MarshalDump(dump *Dump) ([]byte, error) {
+	return json.MarshalIndent(dump, "", "  ")
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudwego/cwgo/config"
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// PluginPrefix is the prefix of the executables of the backends loaded as plugins, such as cwgo-doc-cassandra.
+const PluginPrefix = "cwgo-doc-"
+
+// PluginRequest is written to the stdin of the plugin as JSON, the types are encoded as the go source.
+type PluginRequest struct {
+	// Version is the version of cwgo which sends the request
+	Version     string
+	Args        *config.DocArgument
+	ImportPaths []string
+	Structs     []*extract.IdlExtractStruct
+	Operations  []*PluginOperation
+}
+
+// PluginOperation is the parsing result of the method of the structure.
+type PluginOperation struct {
+	Struct string
+	Method string
+	// Operation is the name of the operation, such as Insert, Find, Update, Delete, Count, Bulk, Transaction and Watch
+	Operation string
+	Parse     parse.Operation
+}
+
+// PluginResponse is read from the stdout of the plugin as JSON, the plugin fails if Error is not empty.
+type PluginResponse struct {
+	Files    []File
+	Warnings []string
+	Error    string
+}
+
+// pluginBackend runs the plugin as the subprocess for each generation, like the plugins of thriftgo and protoc.
+type pluginBackend struct {
+	name string
+	path string
+}
+
+func (b *pluginBackend) Generate(req *Request) (*Response, error) {
+	data, err := json.Marshal(newPluginRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("marshal request of plugin %s failed: %v", b.name, err)
+	}
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd := exec.Command(b.path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s returns error: %v, cause:\n%s", b.path, err, stderr.String())
+	}
+
+	res := new(PluginResponse)
+	if err = json.Unmarshal(stdout.Bytes(), res); err != nil {
+		return nil, fmt.Errorf("unmarshal response of plugin %s failed: %v", b.name, err)
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("plugin %s returns error: %s", b.name, res.Error)
+	}
+
+	// the plugins may return the names relative to the dao directory
+	for index := range res.Files {
+		if !filepath.IsAbs(res.Files[index].Name) {
+			res.Files[index].Name = filepath.Join(req.Args.DaoDir, res.Files[index].Name)
+		}
+	}
+	return &Response{Files: res.Files, Warnings: res.Warnings}, nil
+}
+
+func newPluginRequest(req *Request) *PluginRequest {
+	return &PluginRequest{
+		Version:     cwgoMeta.Version,
+		Args:        req.Args,
+		ImportPaths: req.ImportPaths,
+		Structs:     req.Structs,
+		Operations:  newPluginOperations(req.Operations),
+	}
+}
+
+func newPluginOperations(ifOperations []*parse.InterfaceOperation) []*PluginOperation {
+	operations := make([]*PluginOperation, 0, len(ifOperations))
+	for _, ifOperation := range ifOperations {
+		for _, operation := range ifOperation.Operations {
+			operations = append(operations, &PluginOperation{
+				Struct:    ifOperation.BelongedToStruct.Name,
+				Method:    parse.GetBelongedToMethod(operation).Name,
+				Operation: operation.GetOperationName(),
+				Parse:     operation,
+			})
+		}
+	}
+	return operations
+}
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
 	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/plugin"
 	"github.com/cloudwego/kitex/tool/internal_pkg/log"
 
@@ -38,13 +39,9 @@ func Doc(c *config.DocArgument) error {
 		return err
 	}
 
-	switch c.Name {
-	case consts.MongoDb:
-		setLogVerbose(c.Verbose)
-		if err := plugin.MongoTriggerPlugin(c); err != nil {
-			return err
-		}
-	default:
+	setLogVerbose(c.Verbose)
+	if err := plugin.MongoTriggerPlugin(c); err != nil {
+		return err
 	}
 
 	utils.ReplaceThriftVersion()
@@ -56,8 +53,9 @@ func check(c *config.DocArgument) (err error) {
 	if c.Name == "" {
 		c.Name = consts.MongoDb
 	}
-	if c.Name != consts.MongoDb {
-		return errors.New("doc name not supported")
+	// the backends are registered by the plugin package, the others are loaded from PATH
+	if _, err = backend.Lookup(c.Name); err != nil {
+		return err
 	}
 	if c.IdlPath == "" {
 		return errors.New("must specify idl path")
@@ -0,0 +1,269 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+var BaseElasticsearchImports = map[string]string{
+	"bytes":                                  "",
+	"context":                                "",
+	"encoding/json":                          "",
+	"fmt":                                    "",
+	"github.com/elastic/go-elasticsearch/v8": "",
+	"github.com/elastic/go-elasticsearch/v8/esapi": "",
+	"go.mongodb.org/mongo-driver/bson":             "",
+}
+
+// GetElasticsearchImports returns the imports of the elasticsearch file whose declarations are content.
+func GetElasticsearchImports(content string) map[string]string {
+	return BaseElasticsearchImports
+}
+
+// keyFieldNames are the mongo field names of the field used as the document id in order.
+var keyFieldNames = []string{"_id", "id"}
+
+// sourceKeyName is the name of the key in the document source, _id is a metadata field of elasticsearch.
+const sourceKeyName = "id"
+
+type esGenerator struct {
+	st       *extract.IdlExtractStruct
+	receiver code.MethodReceiver
+	// model is the alias of the model type which is not shadowed by the method params
+	model    string
+	keyField *extract.StructField
+	// keyName is the mongo field name of the key field
+	keyName string
+}
+
+// GetElasticsearchRenders returns the renders of the elasticsearch implementation of the repository interface.
+//
+//	input params description:
+//	ifOperation: the parsing results of all methods of the structure, including the methods generated before
+//	methods: all methods of the repository interface
+func GetElasticsearchRenders(ifOperation *parse.InterfaceOperation, methods code.InterfaceMethods) ([]template.Render, error) {
+	st := ifOperation.BelongedToStruct
+	repoName := st.Name + "RepositoryElasticsearch"
+	g := &esGenerator{
+		st: st,
+		receiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{RealType: code.IdentType(repoName)},
+		},
+		model: "es" + st.Name,
+	}
+	for _, name := range keyFieldNames {
+		if fields, err := st.GetFieldsByMongoName(name); err == nil {
+			g.keyField, g.keyName = fields[0], name
+			break
+		}
+	}
+	if g.keyField == nil {
+		return nil, fmt.Errorf("%s: the elasticsearch backend requires a field tagged with bson _id or id as the document id", st.Name)
+	}
+	if !isIntegerType(g.keyField.Type) && !isStringType(g.keyField.Type) {
+		return nil, fmt.Errorf("%s: the key field %s of the elasticsearch backend must be an integer or a string",
+			st.Name, g.keyName)
+	}
+
+	operations := make(map[string]parse.Operation, len(ifOperation.Operations))
+	for _, operation := range ifOperation.Operations {
+		switch operation.(type) {
+		case *parse.BulkParse, *parse.TransactionParse, *parse.WatchParse:
+			return nil, fmt.Errorf("%s: %s is not supported by the elasticsearch backend",
+				parse.GetBelongedToMethod(operation).Name, operation.GetOperationName())
+		}
+		operations[parse.GetBelongedToMethod(operation).Name] = operation
+	}
+
+	methodRenders := make([]template.Render, 0, len(methods))
+	for _, method := range methods {
+		operation, ok := operations[method.Name]
+		if !ok {
+			continue
+		}
+		body, err := g.operationCodegen(operation)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", method.Name, err.Error())
+		}
+		methodRenders = append(methodRenders, &template.MethodRender{
+			Name:           method.Name,
+			MethodReceiver: g.receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody:     body,
+		})
+	}
+
+	modelType := code.SelectorExprType{X: st.ModelPkg, Sel: st.Name}
+	clientType := code.StarExprType{RealType: code.SelectorExprType{X: "elasticsearch", Sel: "Client"}}
+	renders := []template.Render{
+		&template.TypeAliasRender{
+			Name:     g.model,
+			Comment:  fmt.Sprintf("// %s is an alias of %s which is not shadowed by the method params.", g.model, modelType.RealName()),
+			RealType: modelType,
+		},
+		&template.FuncRender{
+			Name: "New" + st.Name + "Repository",
+			Params: code.Params{
+				code.Param{Name: "client", Type: clientType},
+				code.Param{Name: "index", Type: code.IdentType("string")},
+			},
+			Returns: code.Returns{
+				code.IdentType(st.Name + "Repository"),
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("return &%s{\n\tclient: client,\n\tindex:  index,\n}", repoName)),
+			},
+		},
+		&template.StructRender{
+			Name: repoName,
+			Comment: fmt.Sprintf("// %s stores each entity in the index as a document whose id is the key and whose\n"+
+				"// source is the relaxed extended json of the entity, so the fields are named by their bson tags and the\n"+
+				"// field tagged with bson _id is stored as id. The writes refresh the index to be visible to the reads,\n"+
+				"// the queries without limit return at most 10000 entities which is the default max_result_window.",
+				repoName),
+			StructFields: code.StructFields{
+				code.StructField{Name: "client", Type: clientType},
+				code.StructField{Name: "index", Type: code.IdentType("string")},
+			},
+		},
+	}
+	renders = append(renders, methodRenders...)
+	return append(renders, g.storageRenders()...), nil
+}
+
+func (g *esGenerator) operationCodegen(operation parse.Operation) (code.Body, error) {
+	switch op := operation.(type) {
+	case *parse.InsertParse:
+		return g.insertCodegen(op), nil
+	case *parse.FindParse:
+		return g.findCodegen(op)
+	case *parse.UpdateParse:
+		return g.updateCodegen(op)
+	case *parse.DeleteParse:
+		return g.deleteCodegen(op)
+	case *parse.CountParse:
+		query, err := g.queryCodegen(op.Query)
+		if err != nil {
+			return nil, err
+		}
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("return r.count(%s, %s)", op.CtxParamName, query.Code())),
+		}, nil
+	default:
+		return code.Body{code.RawStmt("return nil")}, nil
+	}
+}
+
+func (g *esGenerator) insertCodegen(insert *parse.InsertParse) code.Body {
+	ctx, name := insert.MethodParamNames[0], insert.MethodParamNames[1]
+	if insert.OperateMode == parse.OperateOne {
+		param := name
+		if _, ok := getParamType(insert.BelongedToMethod, name).(code.StarExprType); !ok {
+			param = "&" + name
+		}
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("if err := r.insert(%s, %s); err != nil {\n\treturn nil, err\n}", ctx, param)),
+			code.RawStmt(fmt.Sprintf("return %s.%s, nil", name, g.keyField.Name)),
+		}
+	}
+
+	body := code.Body{}
+	entities := name
+	if sliceType, ok := getParamType(insert.BelongedToMethod, name).(code.SliceType); ok {
+		if _, ok = sliceType.ElementType.(code.StarExprType); !ok {
+			entities = "entities"
+			body = append(body,
+				code.RawStmt(fmt.Sprintf("entities := make([]*%s, 0, len(%s))", g.model, name)),
+				code.RawStmt(fmt.Sprintf("for i := range %s {\n\tentities = append(entities, &%s[i])\n}", name, name)),
+			)
+		}
+	}
+	return append(body,
+		code.RawStmt(fmt.Sprintf("if err := r.insert(%s, %s...); err != nil {\n\treturn nil, err\n}", ctx, entities)),
+		code.RawStmt(fmt.Sprintf("ids := make([]interface{}, 0, len(%s))", name)),
+		code.RawStmt(fmt.Sprintf("for _, entity := range %s {\n\tids = append(ids, entity.%s)\n}", name, g.keyField.Name)),
+		code.RawStmt("return ids, nil"),
+	)
+}
+
+func (g *esGenerator) deleteCodegen(del *parse.DeleteParse) (code.Body, error) {
+	query, err := g.queryCodegen(del.Query)
+	if err != nil {
+		return nil, err
+	}
+	if del.OperateMode == parse.OperateOne {
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("deleted, err := r.deleteByQuery(%s, %s, 1)", del.CtxParamName, query.Code())),
+			code.RawStmt("if err != nil {\n\treturn false, err\n}"),
+			code.RawStmt("return deleted > 0, nil"),
+		}, nil
+	}
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("return r.deleteByQuery(%s, %s, 0)", del.CtxParamName, query.Code())),
+	}, nil
+}
+
+// getSourceName returns the name of the field in the document source.
+func (g *esGenerator) getSourceName(mongoName string) (string, []*extract.StructField, error) {
+	fields, err := g.st.GetFieldsByMongoName(mongoName)
+	if err != nil {
+		return "", nil, err
+	}
+	if g.keyName == "_id" && mongoName == "_id" {
+		return sourceKeyName, fields, nil
+	}
+	return mongoName, fields, nil
+}
+
+func getParamType(method *extract.InterfaceMethod, name string) code.Type {
+	for _, param := range method.Params {
+		if param.Name == name {
+			return param.Type
+		}
+	}
+	return nil
+}
+
+func isIntegerType(t code.Type) bool {
+	switch t.RealName() {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		return true
+	}
+	return false
+}
+
+func isStringType(t code.Type) bool {
+	return t.RealName() == "string"
+}
+
+// quoteSource returns the painless access of the field in the document source.
+func quoteSource(name string) string {
+	result := "ctx._source"
+	for _, part := range strings.Split(name, ".") {
+		result += "['" + part + "']"
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// maxResultWindow is the default max_result_window of the index which limits the size of the queries.
+const maxResultWindow = "10000"
+
+func (g *esGenerator) findCodegen(find *parse.FindParse) (code.Body, error) {
+	errReturn := "nil, err"
+	if find.ReturnCursor {
+		errReturn = "nil, nextCursor, err"
+	}
+
+	query, err := g.queryCodegen(find.Query)
+	if err != nil {
+		return nil, err
+	}
+	body := code.Body{}
+	if find.Keyset != nil && find.Keyset.Nullable {
+		// the nil cursor queries the first page
+		keyset, err := g.dfsCodegen(find.Keyset.Condition())
+		if err != nil {
+			return nil, err
+		}
+		body = append(body,
+			code.RawStmt("query := "+query.Code()),
+			code.RawStmt(fmt.Sprintf("if %s != nil {\n\tquery = %s\n}", find.Keyset.ParamName,
+				mapCodegen(pairCodegen("bool", mapCodegen(pairCodegen("must", code.RawStmt(
+					fmt.Sprintf("[]bson.M{query, {\n%s\n}}", keyset.Code())))))).Code())),
+		)
+		query = code.RawStmt("query")
+	}
+	pairs := []code.MapPair{pairCodegen("query", query)}
+
+	order := find.Order
+	// Before queries the entities closest to the cursor in the reverse order
+	if find.OperateMode == parse.OperateMany && find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		order = parse.Order{Asc: find.Order.Desc, Desc: find.Order.Asc}
+	}
+	if len(order.Asc) != 0 || len(order.Desc) != 0 {
+		sort, err := g.sortCodegen(order)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pairCodegen("sort", sort))
+	}
+
+	if len(find.Project) != 0 {
+		// the key is always projected because it is the document id
+		key, _, _ := g.getSourceName(g.keyName)
+		names := []string{strconv.Quote(key)}
+		for _, field := range find.Project {
+			name, _, err := g.getSourceName(field)
+			if err != nil {
+				return nil, err
+			}
+			if quoted := strconv.Quote(name); quoted != names[0] {
+				names = append(names, quoted)
+			}
+		}
+		pairs = append(pairs, pairCodegen("_source", code.RawStmt(fmt.Sprintf("[]string{%s}", strings.Join(names, ", ")))))
+	}
+
+	if find.SkipParamName != "" {
+		pairs = append(pairs, pairCodegen("from", code.RawStmt(find.SkipParamName)))
+	}
+	switch {
+	case find.OperateMode == parse.OperateOne:
+		pairs = append(pairs, pairCodegen("size", code.RawStmt("1")))
+	case find.LimitParamName != "":
+		body = append(body, code.RawStmt(fmt.Sprintf("if %s == 0 {\n\t%s = 5\n}", find.LimitParamName, find.LimitParamName)))
+		pairs = append(pairs, pairCodegen("size", code.RawStmt(find.LimitParamName)))
+	default:
+		pairs = append(pairs, pairCodegen("size", code.RawStmt(maxResultWindow)))
+	}
+
+	if find.ReturnCursor {
+		body = append(body, code.DeclVarStmt{
+			Name: "nextCursor",
+			Type: find.Keyset.FieldType,
+		})
+	}
+	body = append(body,
+		code.RawStmt(fmt.Sprintf("entities, err := r.search(%s, %s)", find.CtxParamName, mapCodegen(pairs...).Code())),
+		code.RawStmt(fmt.Sprintf("if err != nil {\n\treturn %s\n}", errReturn)),
+	)
+
+	if find.OperateMode == parse.OperateOne {
+		return append(body,
+			code.RawStmt("if len(entities) == 0 {\n\treturn nil, fmt.Errorf(\"no document matched in the index %s\", r.index)\n}"),
+			code.RawStmt("return entities[0], nil"),
+		), nil
+	}
+
+	if find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		body = append(body, code.RawStmt("for i, j := 0, len(entities)-1; i < j; i, j = i+1, j-1 {\n"+
+			"\tentities[i], entities[j] = entities[j], entities[i]\n}"))
+	}
+	if !find.ReturnCursor {
+		return append(body, code.RawStmt("return entities, nil")), nil
+	}
+
+	// the next cursor of After is the last entity, the next cursor of Before is the first entity
+	index := "len(entities)-1"
+	if find.Keyset.KeysetMode == parse.Before {
+		index = "0"
+	}
+	return append(body,
+		code.RawStmt(fmt.Sprintf("if len(entities) > 0 {\n\tnextCursor = %s\n}", find.Keyset.NextCursor("entities["+index+"]"))),
+		code.RawStmt("return entities, nextCursor, nil"),
+	), nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+// scalarMappings maps the go types of the fields to the field types of elasticsearch,
+// the strings are keywords because they are compared by the term queries.
+var scalarMappings = map[string]string{
+	"string":  "keyword",
+	"bool":    "boolean",
+	"int8":    "byte",
+	"int16":   "short",
+	"int32":   "integer",
+	"int":     "long",
+	"int64":   "long",
+	"uint8":   "short",
+	"uint16":  "integer",
+	"uint32":  "long",
+	"uint64":  "unsigned_long",
+	"float32": "float",
+	"float64": "double",
+}
+
+// GetElasticsearchMapping returns the index mapping derived from the fields of the structure,
+// it can be used as the body of the create index api.
+func GetElasticsearchMapping(st *extract.IdlExtractStruct) (string, error) {
+	properties, err := propertiesCodegen(st, true)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": properties,
+		},
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// propertiesCodegen returns the properties of the fields which have bson names, the key field
+// of the top-level structure is renamed because _id is a metadata field.
+func propertiesCodegen(st *extract.IdlExtractStruct, isTop bool) (map[string]interface{}, error) {
+	properties := make(map[string]interface{}, len(st.StructFields))
+	for _, field := range st.StructFields {
+		name := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		property, err := propertyCodegen(field, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		if isTop && name == "_id" {
+			name = sourceKeyName
+		}
+		properties[name] = property
+	}
+	return properties, nil
+}
+
+func propertyCodegen(field *extract.StructField, t code.Type) (map[string]interface{}, error) {
+	switch fieldType := t.(type) {
+	case code.IdentType:
+		if mapping, ok := scalarMappings[string(fieldType)]; ok {
+			return map[string]interface{}{"type": mapping}, nil
+		}
+	case code.StarExprType:
+		return propertyCodegen(field, fieldType.RealType)
+	case code.SliceType:
+		// the arrays are mapped to the type of their elements
+		if fieldType.ElementType.RealName() == "byte" {
+			return map[string]interface{}{"type": "binary"}, nil
+		}
+		return propertyCodegen(field, fieldType.ElementType)
+	case code.SelectorExprType:
+		if field.IsBelongedToStruct {
+			properties, err := propertiesCodegen(field.BelongedToStruct, false)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"type": "object", "properties": properties}, nil
+		}
+		if _, ok := extract.WellKnownImportPaths[fieldType.X]; ok {
+			// the well-known types of proto are encoded as the documents of their fields
+			return map[string]interface{}{"type": "object"}, nil
+		}
+		// the enums are integers
+		return map[string]interface{}{"type": "long"}, nil
+	case code.MapType:
+		return map[string]interface{}{"type": "object"}, nil
+	case code.InterfaceType:
+		// the oneofs of proto are stored as is but not indexed, the members are different types
+		return map[string]interface{}{"type": "object", "enabled": false}, nil
+	}
+	return nil, fmt.Errorf("%s: the type %s is not supported by the elasticsearch mapping", field.Name, t.RealName())
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// queryCodegen converts the query to the elasticsearch query, the query tree is mapped to the bool queries.
+func (g *esGenerator) queryCodegen(query *parse.Query) (code.Statement, error) {
+	if query.QueryMode != parse.By {
+		return mapCodegen(pairCodegen("match_all", mapCodegen())), nil
+	}
+	pair, err := g.dfsCodegen(query.ConnectionOpTree)
+	if err != nil {
+		return nil, err
+	}
+	return mapCodegen(pair), nil
+}
+
+func (g *esGenerator) dfsCodegen(node *parse.ConnectionOpTree) (code.MapPair, error) {
+	// leaves node
+	if node.LeftChildren == nil {
+		return g.comparatorCodegen(node)
+	}
+
+	// none-leaves node
+	left, err := g.dfsCodegen(node.LeftChildren)
+	if err != nil {
+		return code.MapPair{}, err
+	}
+	right, err := g.dfsCodegen(node.RightChildren)
+	if err != nil {
+		return code.MapPair{}, err
+	}
+	if node.Name == string(parse.Or) {
+		return pairCodegen("bool", mapCodegen(
+			pairCodegen("should", sliceCodegen(left, right)),
+			pairCodegen("minimum_should_match", code.RawStmt("1")),
+		)), nil
+	}
+	return pairCodegen("bool", mapCodegen(pairCodegen("must", sliceCodegen(left, right)))), nil
+}
+
+func (g *esGenerator) comparatorCodegen(node *parse.ConnectionOpTree) (code.MapPair, error) {
+	name, fields, err := g.getSourceName(node.MongoFieldName)
+	if err != nil {
+		return code.MapPair{}, err
+	}
+	_, isSlice := fields[len(fields)-1].Type.(code.SliceType)
+
+	switch parse.QueryComparator(node.Name) {
+	case parse.Equal:
+		return termCodegen(name, node.Values()[0]), nil
+	case parse.NotEqual:
+		return notCodegen(termCodegen(name, node.Values()[0])), nil
+	case parse.LessThan:
+		return rangeCodegen(name, "lt", node.Values()[0]), nil
+	case parse.LessThanEqual:
+		return rangeCodegen(name, "lte", node.Values()[0]), nil
+	case parse.GreaterThan:
+		return rangeCodegen(name, "gt", node.Values()[0]), nil
+	case parse.GreaterThanEqual:
+		return rangeCodegen(name, "gte", node.Values()[0]), nil
+	case parse.Between:
+		return rangeCodegen(name, "gte", node.Values()[0], "lte", node.Values()[1]), nil
+	case parse.NotBetween:
+		return notCodegen(rangeCodegen(name, "gte", node.Values()[0], "lte", node.Values()[1])), nil
+	case parse.In, parse.NotIn:
+		// the param has the same type as the field, the array field matches any of the values
+		in := termCodegen(name, node.Values()[0])
+		if isSlice {
+			in = pairCodegen("terms", mapCodegen(pairCodegen(name, code.RawStmt(node.Values()[0]))))
+		}
+		if parse.QueryComparator(node.Name) == parse.In {
+			return in, nil
+		}
+		return notCodegen(in), nil
+	case parse.True:
+		return termCodegen(name, "true"), nil
+	case parse.False:
+		return termCodegen(name, "false"), nil
+	case parse.Exists:
+		return existsCodegen(name), nil
+	case parse.NotExists:
+		return notCodegen(existsCodegen(name)), nil
+	default:
+		return code.MapPair{}, fmt.Errorf("%s on %s is not supported by the elasticsearch backend",
+			node.Name, node.MongoFieldName)
+	}
+}
+
+// sortCodegen returns the sort of the find, the ascending fields are sorted before the descending fields
+// which is the same as the other backends.
+func (g *esGenerator) sortCodegen(order parse.Order) (code.Statement, error) {
+	pairs := make([]code.MapPair, 0, len(order.Asc)+len(order.Desc))
+	for _, key := range order.Asc {
+		name, _, err := g.getSourceName(key)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pairCodegen(name, code.RawStmt(`"asc"`)))
+	}
+	for _, key := range order.Desc {
+		name, _, err := g.getSourceName(key)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pairCodegen(name, code.RawStmt(`"desc"`)))
+	}
+	return sliceCodegen(pairs...), nil
+}
+
+func termCodegen(name, value string) code.MapPair {
+	return pairCodegen("term", mapCodegen(pairCodegen(name, code.RawStmt(value))))
+}
+
+// rangeCodegen returns the range query of the field, the bounds are pairs of the operator and the param.
+func rangeCodegen(name string, bounds ...string) code.MapPair {
+	pairs := make([]code.MapPair, 0, len(bounds)/2)
+	for index := 0; index+1 < len(bounds); index += 2 {
+		pairs = append(pairs, pairCodegen(bounds[index], code.RawStmt(bounds[index+1])))
+	}
+	return pairCodegen("range", mapCodegen(pairCodegen(name, mapCodegen(pairs...))))
+}
+
+func existsCodegen(name string) code.MapPair {
+	return pairCodegen("exists", mapCodegen(pairCodegen("field", code.RawStmt(strconv.Quote(name)))))
+}
+
+func notCodegen(pair code.MapPair) code.MapPair {
+	return pairCodegen("bool", mapCodegen(pairCodegen("must_not", sliceCodegen(pair))))
+}
+
+func pairCodegen(key string, value code.Statement) code.MapPair {
+	return code.MapPair{
+		Key:   code.RawStmt(key),
+		Value: value,
+	}
+}
+
+func mapCodegen(pairs ...code.MapPair) code.MapStmt {
+	return code.MapStmt{
+		Name: "bson.M",
+		Pair: pairs,
+	}
+}
+
+func sliceCodegen(values ...code.MapPair) code.SliceStmt {
+	return code.SliceStmt{
+		Name:   "[]bson.M",
+		Values: values,
+	}
+}
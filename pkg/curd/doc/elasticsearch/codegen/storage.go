@@ -0,0 +1,242 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// storageRenders returns the methods which encode, decode and send the requests of the documents.
+func (g *esGenerator) storageRenders() []template.Render {
+	ctxType := code.SelectorExprType{X: "context", Sel: "Context"}
+	entityType := code.StarExprType{RealType: code.IdentType(g.model)}
+	bsonType := code.SelectorExprType{X: "bson", Sel: "M"}
+	rawType := code.SelectorExprType{X: "json", Sel: "RawMessage"}
+	errType := code.IdentType("error")
+
+	encode := code.Body{
+		code.RawStmt("data, err := bson.MarshalExtJSON(e, false, false)"),
+		code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+	}
+	decode := code.Body{}
+	if g.keyName == "_id" {
+		encode = append(encode,
+			code.RawStmt("source := make(map[string]json.RawMessage)"),
+			code.RawStmt("if err = json.Unmarshal(data, &source); err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt(fmt.Sprintf("if id, ok := source[\"_id\"]; ok {\n"+
+				"\tsource[%s] = id\n"+
+				"\tdelete(source, \"_id\")\n"+
+				"}", strconv.Quote(sourceKeyName))),
+			code.RawStmt("return json.Marshal(source)"),
+		)
+		decode = append(decode,
+			code.RawStmt("fields := make(map[string]json.RawMessage)"),
+			code.RawStmt("if err := json.Unmarshal(source, &fields); err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt(fmt.Sprintf("if id, ok := fields[%s]; ok {\n"+
+				"\tfields[\"_id\"] = id\n"+
+				"\tdelete(fields, %s)\n"+
+				"}", strconv.Quote(sourceKeyName), strconv.Quote(sourceKeyName))),
+			code.RawStmt("data, err := json.Marshal(fields)"),
+			code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt("source = data"),
+		)
+	} else {
+		encode = append(encode, code.RawStmt("return data, nil"))
+	}
+	decode = append(decode,
+		code.RawStmt(fmt.Sprintf("e := new(%s)", g.model)),
+		code.RawStmt("if err := bson.UnmarshalExtJSON(source, false, e); err != nil {\n\treturn nil, err\n}"),
+		code.RawStmt("return e, nil"),
+	)
+
+	return []template.Render{
+		&template.MethodRender{
+			Name: "encode",
+			Comment: "// encode returns the source of the entity which is the relaxed extended json of the entity,\n" +
+				"// so the fields are named by their bson tags.",
+			MethodReceiver: g.receiver,
+			Params:         code.Params{code.Param{Name: "e", Type: entityType}},
+			Returns:        code.Returns{code.SliceType{ElementType: code.IdentType("byte")}, errType},
+			MethodBody:     encode,
+		},
+		&template.MethodRender{
+			Name:           "decode",
+			MethodReceiver: g.receiver,
+			Params:         code.Params{code.Param{Name: "source", Type: rawType}},
+			Returns:        code.Returns{entityType, errType},
+			MethodBody:     decode,
+		},
+		&template.MethodRender{
+			Name:           "do",
+			Comment:        "// do checks the response of the request and decodes its body to result if result is not nil.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "res", Type: code.StarExprType{RealType: code.SelectorExprType{X: "esapi", Sel: "Response"}}},
+				code.Param{Name: "err", Type: errType},
+				code.Param{Name: "result", Type: code.InterfaceType{}},
+			},
+			Returns: code.Returns{errType},
+			MethodBody: code.Body{
+				code.RawStmt("if err != nil {\n\treturn err\n}"),
+				code.RawStmt("defer res.Body.Close()"),
+				code.RawStmt("if res.IsError() {\n\treturn fmt.Errorf(\"elasticsearch: %s\", res.String())\n}"),
+				code.RawStmt("if result == nil {\n\treturn nil\n}"),
+				code.RawStmt("return json.NewDecoder(res.Body).Decode(result)"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "insert",
+			Comment:        "// insert creates the documents of the entities by the bulk api, an error is returned if a key exists.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "entities", Type: code.IdentType("..." + entityType.RealName())},
+			},
+			Returns: code.Returns{errType},
+			MethodBody: code.Body{
+				code.RawStmt("var buf bytes.Buffer"),
+				code.RawStmt(fmt.Sprintf("for _, e := range entities {\n"+
+					"\tsource, err := r.encode(e)\n"+
+					"\tif err != nil {\n"+
+					"\t\treturn err\n"+
+					"\t}\n"+
+					"\tfmt.Fprintf(&buf, \"{\\\"create\\\":{\\\"_id\\\":%%q}}\\n%%s\\n\", fmt.Sprint(e.%s), source)\n"+
+					"}", g.keyField.Name)),
+				code.RawStmt("res, err := r.client.Bulk(&buf, r.client.Bulk.WithContext(ctx), r.client.Bulk.WithIndex(r.index),\n" +
+					"\tr.client.Bulk.WithRefresh(\"true\"))"),
+				code.RawStmt("var result struct {\n" +
+					"\tErrors bool `json:\"errors\"`\n" +
+					"\tItems  []map[string]struct {\n" +
+					"\t\tError json.RawMessage `json:\"error\"`\n" +
+					"\t} `json:\"items\"`\n" +
+					"}"),
+				code.RawStmt("if err = r.do(res, err, &result); err != nil {\n\treturn err\n}"),
+				code.RawStmt("for _, item := range result.Items {\n" +
+					"\tfor _, action := range item {\n" +
+					"\t\tif action.Error != nil {\n" +
+					"\t\t\treturn fmt.Errorf(\"elasticsearch: %s\", action.Error)\n" +
+					"\t\t}\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("return nil"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "search",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "body", Type: bsonType},
+			},
+			Returns: code.Returns{code.SliceType{ElementType: entityType}, errType},
+			MethodBody: code.Body{
+				code.RawStmt("data, err := bson.MarshalExtJSON(body, false, false)"),
+				code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+				code.RawStmt("res, err := r.client.Search(r.client.Search.WithContext(ctx), r.client.Search.WithIndex(r.index),\n" +
+					"\tr.client.Search.WithBody(bytes.NewReader(data)))"),
+				code.RawStmt("var result struct {\n" +
+					"\tHits struct {\n" +
+					"\t\tHits []struct {\n" +
+					"\t\t\tSource json.RawMessage `json:\"_source\"`\n" +
+					"\t\t} `json:\"hits\"`\n" +
+					"\t} `json:\"hits\"`\n" +
+					"}"),
+				code.RawStmt("if err = r.do(res, err, &result); err != nil {\n\treturn nil, err\n}"),
+				code.RawStmt(fmt.Sprintf("entities := make([]*%s, 0, len(result.Hits.Hits))", g.model)),
+				code.RawStmt("for _, hit := range result.Hits.Hits {\n" +
+					"\te, err := r.decode(hit.Source)\n" +
+					"\tif err != nil {\n" +
+					"\t\treturn nil, err\n" +
+					"\t}\n" +
+					"\tentities = append(entities, e)\n" +
+					"}"),
+				code.RawStmt("return entities, nil"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "count",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "query", Type: bsonType},
+			},
+			Returns: code.Returns{code.IdentType("int"), errType},
+			MethodBody: code.Body{
+				code.RawStmt("data, err := bson.MarshalExtJSON(bson.M{\"query\": query}, false, false)"),
+				code.RawStmt("if err != nil {\n\treturn 0, err\n}"),
+				code.RawStmt("res, err := r.client.Count(r.client.Count.WithContext(ctx), r.client.Count.WithIndex(r.index),\n" +
+					"\tr.client.Count.WithBody(bytes.NewReader(data)))"),
+				code.RawStmt("var result struct {\n\tCount int `json:\"count\"`\n}"),
+				code.RawStmt("if err = r.do(res, err, &result); err != nil {\n\treturn 0, err\n}"),
+				code.RawStmt("return result.Count, nil"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "deleteByQuery",
+			Comment:        "// deleteByQuery deletes the documents matched by the query, at most maxDocs documents if it is positive.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "query", Type: bsonType},
+				code.Param{Name: "maxDocs", Type: code.IdentType("int")},
+			},
+			Returns: code.Returns{code.IdentType("int"), errType},
+			MethodBody: code.Body{
+				code.RawStmt("data, err := bson.MarshalExtJSON(bson.M{\"query\": query}, false, false)"),
+				code.RawStmt("if err != nil {\n\treturn 0, err\n}"),
+				code.RawStmt("options := []func(*esapi.DeleteByQueryRequest){\n" +
+					"\tr.client.DeleteByQuery.WithContext(ctx),\n" +
+					"\tr.client.DeleteByQuery.WithRefresh(true),\n" +
+					"}"),
+				code.RawStmt("if maxDocs > 0 {\n\toptions = append(options, r.client.DeleteByQuery.WithMaxDocs(maxDocs))\n}"),
+				code.RawStmt("res, err := r.client.DeleteByQuery([]string{r.index}, bytes.NewReader(data), options...)"),
+				code.RawStmt("var result struct {\n\tDeleted int `json:\"deleted\"`\n}"),
+				code.RawStmt("if err = r.do(res, err, &result); err != nil {\n\treturn 0, err\n}"),
+				code.RawStmt("return result.Deleted, nil"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "updateByQuery",
+			Comment:        "// updateByQuery runs the script of body on the documents matched by its query, at most maxDocs documents if it is positive.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "body", Type: bsonType},
+				code.Param{Name: "maxDocs", Type: code.IdentType("int")},
+			},
+			Returns: code.Returns{code.IdentType("int"), errType},
+			MethodBody: code.Body{
+				code.RawStmt("data, err := bson.MarshalExtJSON(body, false, false)"),
+				code.RawStmt("if err != nil {\n\treturn 0, err\n}"),
+				code.RawStmt("options := []func(*esapi.UpdateByQueryRequest){\n" +
+					"\tr.client.UpdateByQuery.WithContext(ctx),\n" +
+					"\tr.client.UpdateByQuery.WithBody(bytes.NewReader(data)),\n" +
+					"\tr.client.UpdateByQuery.WithRefresh(true),\n" +
+					"}"),
+				code.RawStmt("if maxDocs > 0 {\n\toptions = append(options, r.client.UpdateByQuery.WithMaxDocs(maxDocs))\n}"),
+				code.RawStmt("res, err := r.client.UpdateByQuery([]string{r.index}, options...)"),
+				code.RawStmt("var result struct {\n\tUpdated int `json:\"updated\"`\n}"),
+				code.RawStmt("if err = r.do(res, err, &result); err != nil {\n\treturn 0, err\n}"),
+				code.RawStmt("return result.Updated, nil"),
+			},
+		},
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// updateCodegen returns the update by query whose painless script sets the updated fields,
+// the fields of the entire structure except the key are copied to the source.
+func (g *esGenerator) updateCodegen(update *parse.UpdateParse) (code.Body, error) {
+	if update.Upsert {
+		return nil, fmt.Errorf("the Upsert is not supported by the elasticsearch backend")
+	}
+	query, err := g.queryCodegen(update.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	script := ""
+	params := make([]code.MapPair, 0, len(update.UpdateFields))
+	if update.UpdateStructObjName != "" {
+		script = fmt.Sprintf("for (entry in params.doc.entrySet()) { if (entry.getKey() != '%s') "+
+			"{ ctx._source[entry.getKey()] = entry.getValue(); } }", g.keyName)
+		params = append(params, pairCodegen("doc", code.RawStmt(update.UpdateStructObjName)))
+	} else {
+		for index, field := range update.UpdateFields {
+			name, _, err := g.getSourceName(field.MongoFieldName)
+			if err != nil {
+				return nil, err
+			}
+			param := "p" + strconv.Itoa(index)
+			script += fmt.Sprintf("%s = params.%s; ", quoteSource(name), param)
+			params = append(params, pairCodegen(param, code.RawStmt(field.ParamName)))
+		}
+	}
+
+	body := mapCodegen(
+		pairCodegen("query", query),
+		pairCodegen("script", mapCodegen(
+			pairCodegen("source", code.RawStmt(strconv.Quote(strings.TrimSpace(script)))),
+			pairCodegen("params", mapCodegen(params...)),
+		)),
+	)
+	if update.OperateMode == parse.OperateOne {
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("updated, err := r.updateByQuery(%s, %s, 1)", update.CtxParamName, body.Code())),
+			code.RawStmt("if err != nil {\n\treturn false, err\n}"),
+			code.RawStmt("return updated > 0, nil"),
+		}, nil
+	}
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("return r.updateByQuery(%s, %s, 0)", update.CtxParamName, body.Code())),
+	}, nil
+}
@@ -0,0 +1,338 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+var BaseGormImports = map[string]string{
+	"context":      "",
+	"gorm.io/gorm": "",
+}
+
+// GetGormImports returns the imports of the gorm file whose declarations are content.
+func GetGormImports(content string) map[string]string {
+	return BaseGormImports
+}
+
+// keyFieldNames are the mongo field names of the field used as the primary key in order.
+var keyFieldNames = []string{"_id", "id"}
+
+// AddGormImports adds the gorm import to the file content if gorm is used, such as the transaction params.
+func AddGormImports(data string) (string, error) {
+	if !strings.Contains(data, "gorm.") {
+		return data, nil
+	}
+
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, "", data, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	flag := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "\"gorm.io/gorm\"" {
+			flag = true
+			return false
+		}
+		return true
+	})
+	if !flag {
+		astutil.AddImport(fSet, file, "gorm.io/gorm")
+	}
+
+	buf := new(bytes.Buffer)
+	if err = printer.Fprint(buf, fSet, file); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+type gormGenerator struct {
+	st       *extract.IdlExtractStruct
+	receiver code.MethodReceiver
+	// model is the alias of the model type which is not shadowed by the method params
+	model    string
+	keyField *extract.StructField
+	// keyColumn is the column of the primary key, it is empty if the model has no key field
+	keyColumn string
+}
+
+// GetGormRenders returns the renders of the gorm implementation of the repository interface.
+//
+//	input params description:
+//	ifOperation: the parsing results of all methods of the structure, including the methods generated before
+//	methods: all methods of the repository interface
+func GetGormRenders(ifOperation *parse.InterfaceOperation, methods code.InterfaceMethods) ([]template.Render, error) {
+	st := ifOperation.BelongedToStruct
+	repoName := st.Name + "RepositoryGorm"
+	g := &gormGenerator{
+		st: st,
+		receiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{RealType: code.IdentType(repoName)},
+		},
+		model: "gorm" + st.Name,
+	}
+	for _, name := range keyFieldNames {
+		if fields, err := st.GetFieldsByMongoName(name); err == nil {
+			g.keyField = fields[0]
+			g.keyColumn = getFieldColumn(fields[0])
+			break
+		}
+	}
+
+	operations := make(map[string]parse.Operation, len(ifOperation.Operations))
+	for _, operation := range ifOperation.Operations {
+		operations[parse.GetBelongedToMethod(operation).Name] = operation
+	}
+
+	methodRenders := make([]template.Render, 0, len(methods))
+	for _, method := range methods {
+		operation, ok := operations[method.Name]
+		if !ok {
+			continue
+		}
+		body, err := g.operationCodegen(operation)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", method.Name, err.Error())
+		}
+		methodRenders = append(methodRenders, &template.MethodRender{
+			Name:           method.Name,
+			MethodReceiver: g.receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody:     body,
+		})
+	}
+
+	modelType := code.SelectorExprType{X: st.ModelPkg, Sel: st.Name}
+	renders := []template.Render{
+		&template.TypeAliasRender{
+			Name:     g.model,
+			Comment:  fmt.Sprintf("// %s is an alias of %s which is not shadowed by the method params.", g.model, modelType.RealName()),
+			RealType: modelType,
+		},
+		&template.FuncRender{
+			Name: "New" + st.Name + "Repository",
+			Params: code.Params{
+				code.Param{Name: "db", Type: code.StarExprType{RealType: code.SelectorExprType{X: "gorm", Sel: "DB"}}},
+			},
+			Returns: code.Returns{
+				code.IdentType(st.Name + "Repository"),
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("return &%s{\n\tdb: db,\n}", repoName)),
+			},
+		},
+		&template.StructRender{
+			Name: repoName,
+			Comment: fmt.Sprintf("// %s maps the fields to the columns declared in their gorm tags or named by the\n"+
+				"// default naming strategy of gorm, the db should not be configured with another naming strategy.",
+				repoName),
+			StructFields: code.StructFields{
+				code.StructField{Name: "db", Type: code.StarExprType{RealType: code.SelectorExprType{X: "gorm", Sel: "DB"}}},
+			},
+		},
+	}
+	return append(renders, methodRenders...), nil
+}
+
+func (g *gormGenerator) operationCodegen(operation parse.Operation) (code.Body, error) {
+	switch op := operation.(type) {
+	case *parse.InsertParse:
+		return g.insertCodegen(op, op.MethodParamNames[1], "r.db.WithContext("+op.MethodParamNames[0]+")"), nil
+	case *parse.FindParse:
+		return g.findCodegen(op)
+	case *parse.UpdateParse:
+		body, err := g.updateCodegen(op, "db", "", "")
+		if err != nil {
+			return nil, err
+		}
+		return append(code.Body{code.RawStmt(fmt.Sprintf("db := r.db.WithContext(%s)", op.CtxParamName))}, body...), nil
+	case *parse.DeleteParse:
+		body, err := g.deleteCodegen(op, "db", "", "")
+		if err != nil {
+			return nil, err
+		}
+		return append(code.Body{code.RawStmt(fmt.Sprintf("db := r.db.WithContext(%s)", op.CtxParamName))}, body...), nil
+	case *parse.CountParse:
+		return g.countCodegen(op)
+	case *parse.BulkParse:
+		return nil, fmt.Errorf("the Bulk operation is not supported by the gorm backend, use Transaction instead")
+	case *parse.TransactionParse:
+		return g.transactionCodegen(op)
+	case *parse.WatchParse:
+		return nil, fmt.Errorf("the Watch operation is not supported by the gorm backend")
+	default:
+		return code.Body{code.RawStmt("return nil")}, nil
+	}
+}
+
+func (g *gormGenerator) insertCodegen(insert *parse.InsertParse, name, db string) code.Body {
+	param := name
+	if _, ok := getParamType(insert.BelongedToMethod, name).(code.StarExprType); !ok && insert.OperateMode == parse.OperateOne {
+		param = "&" + name
+	}
+	body := code.Body{
+		code.RawStmt(fmt.Sprintf("if err := %s.Create(%s).Error; err != nil {\n\treturn nil, err\n}", db, param)),
+	}
+
+	if insert.OperateMode == parse.OperateOne {
+		if g.keyField == nil {
+			return append(body, code.RawStmt("return nil, nil"))
+		}
+		return append(body, code.RawStmt(fmt.Sprintf("return %s.%s, nil", name, g.keyField.Name)))
+	}
+
+	if g.keyField == nil {
+		return append(body, code.RawStmt(fmt.Sprintf("return make([]interface{}, len(%s)), nil", name)))
+	}
+	return append(body,
+		code.RawStmt(fmt.Sprintf("ids := make([]interface{}, 0, len(%s))", name)),
+		code.RawStmt(fmt.Sprintf("for _, entity := range %s {\n\tids = append(ids, entity.%s)\n}", name, g.keyField.Name)),
+		code.RawStmt("return ids, nil"),
+	)
+}
+
+func (g *gormGenerator) countCodegen(count *parse.CountParse) (code.Body, error) {
+	where, err := g.whereCodegen(count.Query)
+	if err != nil {
+		return nil, err
+	}
+	return code.Body{
+		code.RawStmt("var count int64"),
+		code.RawStmt(fmt.Sprintf("if err := r.db.WithContext(%s).Model(new(%s))%s.Count(&count).Error; err != nil {\n"+
+			"\treturn 0, err\n"+
+			"}", count.CtxParamName, g.model, where)),
+		code.RawStmt("return int(count), nil"),
+	}, nil
+}
+
+// idsCodegen returns the statements which pluck the primary key of the first entity matched by the query,
+// it is used to update or delete one entity because not all databases support limit in update and delete.
+func (g *gormGenerator) idsCodegen(query *parse.Query, db, ids, zero string) (code.Body, error) {
+	if g.keyField == nil {
+		return nil, fmt.Errorf("the gorm backend requires a field tagged with bson _id or id to operate one entity")
+	}
+	where, err := g.whereCodegen(query)
+	if err != nil {
+		return nil, err
+	}
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("var %s []%s", ids, g.keyField.Type.RealName())),
+		code.RawStmt(fmt.Sprintf("if err := %s.Model(new(%s))%s.Limit(1).Pluck(%q, &%s).Error; err != nil {\n"+
+			"\treturn %s\n"+
+			"}", db, g.model, where, g.keyColumn, ids, zero)),
+	}, nil
+}
+
+func (g *gormGenerator) deleteCodegen(del *parse.DeleteParse, db, suffix, errReturn string) (code.Body, error) {
+	result := "result" + suffix
+	zero, fail := "0, err", "0, "+result+".Error"
+	if del.OperateMode == parse.OperateOne {
+		zero, fail = "false, err", "false, "+result+".Error"
+	}
+	if errReturn != "" {
+		zero, fail = errReturn, result+".Error"
+	}
+
+	if del.OperateMode == parse.OperateOne {
+		ids := "ids" + suffix
+		body, err := g.idsCodegen(del.Query, db, ids, zero)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, code.RawStmt(fmt.Sprintf("%s := %s.Where(%q, %s).Delete(new(%s))",
+			result, db, g.keyColumn+" IN ?", ids, g.model)))
+		body = append(body, code.RawStmt(fmt.Sprintf("if %s.Error != nil {\n\treturn %s\n}", result, fail)))
+		if errReturn != "" {
+			return body, nil
+		}
+		return append(body, code.RawStmt(fmt.Sprintf("return %s.RowsAffected > 0, nil", result))), nil
+	}
+
+	where, err := g.whereCodegen(del.Query)
+	if err != nil {
+		return nil, err
+	}
+	body := code.Body{
+		code.RawStmt(fmt.Sprintf("%s := %s%s%s.Delete(new(%s))", result, db, allowGlobal(del.Query), where, g.model)),
+		code.RawStmt(fmt.Sprintf("if %s.Error != nil {\n\treturn %s\n}", result, fail)),
+	}
+	if errReturn != "" {
+		return body, nil
+	}
+	return append(body, code.RawStmt(fmt.Sprintf("return int(%s.RowsAffected), nil", result))), nil
+}
+
+// allowGlobal allows the update and the delete without conditions which are blocked by gorm by default.
+func allowGlobal(query *parse.Query) string {
+	if query.QueryMode == parse.All {
+		return ".Session(&gorm.Session{AllowGlobalUpdate: true})"
+	}
+	return ""
+}
+
+// typeName returns the type with the model type replaced by its alias, the other types are returned as they are.
+func (g *gormGenerator) typeName(t code.Type) string {
+	switch t := t.(type) {
+	case code.SliceType:
+		return "[]" + g.typeName(t.ElementType)
+	case code.StarExprType:
+		return "*" + g.typeName(t.RealType)
+	}
+	if t.RealName() == g.st.ModelPkg+"."+g.st.Name {
+		return g.model
+	}
+	return t.RealName()
+}
+
+// getLocalName returns the name of the local variable which is not shadowed by the method params.
+func getLocalName(method *extract.InterfaceMethod, name string) string {
+	for _, param := range method.Params {
+		if param.Name == name {
+			return getLocalName(method, name+"Value")
+		}
+	}
+	return name
+}
+
+func getParamType(method *extract.InterfaceMethod, name string) code.Type {
+	for _, param := range method.Params {
+		if param.Name == name {
+			return param.Type
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+func (g *gormGenerator) findCodegen(find *parse.FindParse) (code.Body, error) {
+	order := find.Order
+	// Before queries the entities closest to the cursor in the reverse order
+	if find.OperateMode == parse.OperateMany && find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		order = parse.Order{Asc: find.Order.Desc, Desc: find.Order.Asc}
+	}
+
+	chain := "r.db.WithContext(" + find.CtxParamName + ")"
+	if len(find.Project) != 0 {
+		columns := make([]string, 0, len(find.Project)+1)
+		if g.keyField != nil {
+			columns = append(columns, strconv.Quote(g.keyColumn))
+		}
+		for _, name := range find.Project {
+			column, _, err := g.getColumn(name)
+			if err != nil {
+				return nil, err
+			}
+			if column != g.keyColumn {
+				columns = append(columns, strconv.Quote(column))
+			}
+		}
+		chain += fmt.Sprintf(".Select([]string{%s})", strings.Join(columns, ", "))
+	}
+	where, err := g.whereCodegen(find.Query)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := g.orderCodegen(order)
+	if err != nil {
+		return nil, err
+	}
+	chain += where + orders
+	if find.SkipParamName != "" {
+		chain += fmt.Sprintf(".Offset(int(%s))", find.SkipParamName)
+	}
+
+	method := find.BelongedToMethod
+	entity, entities := getLocalName(method, "entity"), getLocalName(method, "entities")
+	if find.OperateMode == parse.OperateOne {
+		if returnType, ok := find.ReturnType.(code.StarExprType); ok {
+			return code.Body{
+				code.RawStmt(fmt.Sprintf("%s := new(%s)", entity, g.typeName(returnType.RealType))),
+				code.RawStmt(fmt.Sprintf("if err := %s.Take(%s).Error; err != nil {\n\treturn nil, err\n}", chain, entity)),
+				code.RawStmt(fmt.Sprintf("return %s, nil", entity)),
+			}, nil
+		}
+		return code.Body{
+			code.DeclVarStmt{
+				Name: entity,
+				Type: code.IdentType(g.typeName(find.ReturnType)),
+			},
+			code.RawStmt(fmt.Sprintf("if err := %s.Take(&%s).Error; err != nil {\n\treturn %s, err\n}", chain, entity, entity)),
+			code.RawStmt(fmt.Sprintf("return %s, nil", entity)),
+		}, nil
+	}
+
+	nextCursor := getLocalName(method, "nextCursor")
+	errReturn := "nil, err"
+	if find.ReturnCursor {
+		errReturn = "nil, " + nextCursor + ", err"
+	}
+	body := code.Body{}
+	if find.ReturnCursor {
+		body = append(body, code.DeclVarStmt{
+			Name: nextCursor,
+			Type: code.IdentType(g.typeName(find.Keyset.FieldType)),
+		})
+	}
+	if find.LimitParamName != "" {
+		body = append(body, code.RawStmt(fmt.Sprintf("if %s == 0 {\n\t%s = 5\n}", find.LimitParamName, find.LimitParamName)))
+		chain += fmt.Sprintf(".Limit(int(%s))", find.LimitParamName)
+	}
+	if find.Keyset != nil && find.Keyset.Nullable {
+		// the nil cursor queries the first page
+		keyset, err := g.whereCodegen(&parse.Query{QueryMode: parse.By, ConnectionOpTree: find.Keyset.Condition()})
+		if err != nil {
+			return nil, err
+		}
+		tx := getLocalName(method, "tx")
+		body = append(body,
+			code.RawStmt(tx+" := "+chain),
+			code.RawStmt(fmt.Sprintf("if %s != nil {\n\t%s = %s%s\n}", find.Keyset.ParamName, tx, tx, keyset)),
+		)
+		chain = tx
+	}
+	body = append(body,
+		code.DeclVarStmt{
+			Name: entities,
+			Type: code.IdentType(g.typeName(find.ReturnType)),
+		},
+		code.RawStmt(fmt.Sprintf("if err := %s.Find(&%s).Error; err != nil {\n\treturn %s\n}", chain, entities, errReturn)),
+	)
+
+	if find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		body = append(body, code.RawStmt(fmt.Sprintf("for i, j := 0, len(%s)-1; i < j; i, j = i+1, j-1 {\n"+
+			"\t%s[i], %s[j] = %s[j], %s[i]\n}", entities, entities, entities, entities, entities)))
+	}
+	if !find.ReturnCursor {
+		return append(body, code.RawStmt(fmt.Sprintf("return %s, nil", entities))), nil
+	}
+
+	// the next cursor of After is the last entity, the next cursor of Before is the first entity
+	index := "len(" + entities + ")-1"
+	if find.Keyset.KeysetMode == parse.Before {
+		index = "0"
+	}
+	return append(body,
+		code.RawStmt(fmt.Sprintf("if len(%s) > 0 {\n\t%s = %s\n}", entities, nextCursor,
+			find.Keyset.NextCursor(entities+"["+index+"]"))),
+		code.RawStmt(fmt.Sprintf("return %s, %s, nil", entities, nextCursor)),
+	), nil
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// getColumn returns the column and the field specified by the mongo field name,
+// the nested fields are not supported because they are not columns of the table.
+func (g *gormGenerator) getColumn(mongoName string) (string, *extract.StructField, error) {
+	if strings.Contains(mongoName, ".") {
+		return "", nil, fmt.Errorf("the nested field %s is not supported by the gorm backend", mongoName)
+	}
+	fields, err := g.st.GetFieldsByMongoName(mongoName)
+	if err != nil {
+		return "", nil, err
+	}
+	return getFieldColumn(fields[0]), fields[0], nil
+}
+
+// getFieldColumn returns the column of the field used by gorm in Create and Find, which is the column
+// declared in the gorm tag, or the name of the field converted by the default naming strategy of gorm.
+func getFieldColumn(field *extract.StructField) string {
+	for _, setting := range strings.Split(field.Tag.Get("gorm"), ";") {
+		if key, value, ok := strings.Cut(setting, ":"); ok && strings.EqualFold(strings.TrimSpace(key), "column") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return toDBName(field.Name)
+}
+
+// commonInitialisms are the initialisms which are converted as a single word by gorm, e.g. UserID is user_id.
+var commonInitialisms = []string{
+	"API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "LHS",
+	"QPS", "RAM", "RHS", "RPC", "SLA", "SMTP", "SSH", "TLS", "TTL", "UID", "UI", "UUID", "URI", "URL", "UTF8",
+	"VM", "XML", "XSRF", "XSS",
+}
+
+var commonInitialismsReplacer = func() *strings.Replacer {
+	oldnews := make([]string, 0, len(commonInitialisms)*2)
+	for _, initialism := range commonInitialisms {
+		oldnews = append(oldnews, initialism, initialism[:1]+strings.ToLower(initialism[1:]))
+	}
+	return strings.NewReplacer(oldnews...)
+}()
+
+// toDBName converts the field name to the snake case column in the same way as the default naming strategy of gorm.
+func toDBName(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	value := commonInitialismsReplacer.Replace(name)
+	isUpper := func(b byte) bool { return b >= 'A' && b <= 'Z' }
+	var buf strings.Builder
+	lastCase, curCase := false, isUpper(value[0])
+	for i := 0; i < len(value)-1; i++ {
+		nextCase := isUpper(value[i+1])
+		nextNumber := value[i+1] >= '0' && value[i+1] <= '9'
+		if curCase {
+			if !lastCase || !(nextCase || nextNumber) {
+				if i > 0 && value[i-1] != '_' && value[i+1] != '_' {
+					buf.WriteByte('_')
+				}
+			}
+			buf.WriteByte(value[i] + 'a' - 'A')
+		} else {
+			buf.WriteByte(value[i])
+		}
+		lastCase, curCase = curCase, nextCase
+	}
+
+	last := value[len(value)-1]
+	if curCase {
+		if !lastCase && len(value) > 1 {
+			buf.WriteByte('_')
+		}
+		buf.WriteByte(last + 'a' - 'A')
+	} else {
+		buf.WriteByte(last)
+	}
+	return buf.String()
+}
+
+// whereCodegen returns the Where call of the query, it is empty when the query is All.
+func (g *gormGenerator) whereCodegen(query *parse.Query) (string, error) {
+	if query.QueryMode != parse.By {
+		return "", nil
+	}
+	condition, args, err := g.conditionCodegen(query.ConnectionOpTree)
+	if err != nil {
+		return "", err
+	}
+	condition = strings.TrimSuffix(strings.TrimPrefix(condition, "("), ")")
+	if len(args) == 0 {
+		return fmt.Sprintf(".Where(%s)", strconv.Quote(condition)), nil
+	}
+	return fmt.Sprintf(".Where(%s, %s)", strconv.Quote(condition), strings.Join(args, ", ")), nil
+}
+
+// conditionCodegen converts the query tree to the sql condition and its args.
+func (g *gormGenerator) conditionCodegen(node *parse.ConnectionOpTree) (string, []string, error) {
+	// none-leaves node
+	if node.LeftChildren != nil {
+		left, leftArgs, err := g.conditionCodegen(node.LeftChildren)
+		if err != nil {
+			return "", nil, err
+		}
+		right, rightArgs, err := g.conditionCodegen(node.RightChildren)
+		if err != nil {
+			return "", nil, err
+		}
+		connection := " AND "
+		if node.Name == string(parse.Or) {
+			connection = " OR "
+		}
+		return "(" + left + connection + right + ")", append(leftArgs, rightArgs...), nil
+	}
+
+	column, field, err := g.getColumn(node.MongoFieldName)
+	if err != nil {
+		return "", nil, err
+	}
+	// the array fields are serialized to a single column, so they can not be compared by element
+	_, isSlice := field.Type.(code.SliceType)
+
+	switch parse.QueryComparator(node.Name) {
+	case parse.Equal:
+		return column + " = ?", node.Values(), nil
+	case parse.NotEqual:
+		return column + " <> ?", node.Values(), nil
+	case parse.LessThan:
+		return column + " < ?", node.Values(), nil
+	case parse.LessThanEqual:
+		return column + " <= ?", node.Values(), nil
+	case parse.GreaterThan:
+		return column + " > ?", node.Values(), nil
+	case parse.GreaterThanEqual:
+		return column + " >= ?", node.Values(), nil
+	case parse.Between:
+		return column + " BETWEEN ? AND ?", node.Values(), nil
+	case parse.NotBetween:
+		return column + " NOT BETWEEN ? AND ?", node.Values(), nil
+	case parse.In, parse.NotIn:
+		if isSlice {
+			return "", nil, fmt.Errorf("%s on the array field %s is not supported by the gorm backend",
+				node.Name, node.MongoFieldName)
+		}
+		// the param has the same type as the field, so the field is compared by equality
+		if parse.QueryComparator(node.Name) == parse.In {
+			return column + " = ?", node.Values(), nil
+		}
+		return column + " <> ?", node.Values(), nil
+	case parse.True:
+		return column + " = ?", []string{"true"}, nil
+	case parse.False:
+		return column + " = ?", []string{"false"}, nil
+	case parse.Exists:
+		return column + " IS NOT NULL", nil, nil
+	case parse.NotExists:
+		return column + " IS NULL", nil, nil
+	default:
+		return "", nil, fmt.Errorf("%s on %s is not supported by the gorm backend", node.Name, node.MongoFieldName)
+	}
+}
+
+// orderCodegen returns the Order calls of the sort fields.
+func (g *gormGenerator) orderCodegen(order parse.Order) (string, error) {
+	result := ""
+	for _, name := range order.Asc {
+		column, _, err := g.getColumn(name)
+		if err != nil {
+			return "", err
+		}
+		result += fmt.Sprintf(".Order(%s)", strconv.Quote(column))
+	}
+	for _, name := range order.Desc {
+		column, _, err := g.getColumn(name)
+		if err != nil {
+			return "", err
+		}
+		result += fmt.Sprintf(".Order(%s)", strconv.Quote(column+" DESC"))
+	}
+	return result, nil
+}
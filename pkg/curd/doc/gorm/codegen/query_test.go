@@ -0,0 +1,48 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+func TestGetFieldColumn(t *testing.T) {
+	tests := []struct {
+		name  string
+		tag   reflect.StructTag
+		field string
+		want  string
+	}{
+		{name: "id", field: "Id", want: "id"},
+		{name: "initialism", field: "UserID", want: "user_id"},
+		{name: "camel case", field: "CreatedAt", want: "created_at"},
+		{name: "upper words", field: "HTTPServerURL", want: "http_server_url"},
+		{name: "number", field: "Address2", want: "address2"},
+		{name: "bson tag is ignored", field: "AfterSaleId", tag: `bson:"sale"`, want: "after_sale_id"},
+		{name: "gorm column", field: "Username", tag: `bson:"username" gorm:"size:64;column:user_name"`, want: "user_name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getFieldColumn(&extract.StructField{Name: tt.field, Tag: tt.tag}); got != tt.want {
+				t.Errorf("getFieldColumn(%s) = %s, want %s", tt.field, got, tt.want)
+			}
+		})
+	}
+}
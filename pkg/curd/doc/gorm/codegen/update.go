@@ -0,0 +1,224 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// updateCodegen returns the statements of the update on db, the variables are suffixed to be unique
+// in the transaction and errReturn is returned on errors if it is not empty, otherwise the results
+// of the method are returned.
+func (g *gormGenerator) updateCodegen(update *parse.UpdateParse, db, suffix, errReturn string) (code.Body, error) {
+	inTransaction := errReturn != ""
+	zero, result := "0", "result"+suffix
+	if update.OperateMode == parse.OperateOne {
+		zero = "false"
+	}
+	if !inTransaction {
+		errReturn = zero + ", err"
+	}
+
+	updates, err := g.updatesCodegen(update)
+	if err != nil {
+		return nil, err
+	}
+	create := ""
+	if update.Upsert {
+		if create, err = g.createCodegen(update, db, errReturn); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.OperateMode == parse.OperateOne {
+		ids := "ids" + suffix
+		body, err := g.idsCodegen(update.Query, db, ids, errReturn)
+		if err != nil {
+			return nil, err
+		}
+		stmt := fmt.Sprintf("if len(%s) != 0 {\n"+
+			"\tif err := %s.Model(new(%s)).Where(%q, %s)%s.Error; err != nil {\n"+
+			"\t\treturn %s\n"+
+			"\t}\n"+
+			"}", ids, db, g.model, g.keyColumn+" IN ?", ids, updates, errReturn)
+		if create != "" {
+			stmt += " else {\n" + create + "}"
+		}
+		body = append(body, code.RawStmt(stmt))
+		if inTransaction {
+			return body, nil
+		}
+		return append(body, code.RawStmt(fmt.Sprintf("return len(%s) != 0, nil", ids))), nil
+	}
+
+	where, err := g.whereCodegen(update.Query)
+	if err != nil {
+		return nil, err
+	}
+	body := code.Body{}
+	// upsert inserts the entity if no entity is matched, the result is the same as the mongo
+	// implementation which only counts the matched entities
+	if create != "" {
+		count := "count" + suffix
+		body = append(body,
+			code.RawStmt(fmt.Sprintf("var %s int64", count)),
+			code.RawStmt(fmt.Sprintf("if err := %s.Model(new(%s))%s.Count(&%s).Error; err != nil {\n"+
+				"\treturn %s\n"+
+				"}", db, g.model, where, count, errReturn)),
+		)
+		if inTransaction {
+			return append(body, code.RawStmt(fmt.Sprintf("if %s == 0 {\n"+
+				"%s"+
+				"} else if err := %s.Model(new(%s))%s%s.Error; err != nil {\n"+
+				"\treturn %s\n"+
+				"}", count, create, db, g.model, where, updates, errReturn))), nil
+		}
+		body = append(body, code.RawStmt(fmt.Sprintf("if %s == 0 {\n%s\treturn 0, nil\n}", count, create)))
+	}
+
+	if inTransaction {
+		return append(body, code.RawStmt(fmt.Sprintf("if err := %s.Model(new(%s))%s%s%s.Error; err != nil {\n"+
+			"\treturn %s\n"+
+			"}", db, g.model, allowGlobal(update.Query), where, updates, errReturn))), nil
+	}
+	// RowsAffected of some databases only counts the changed rows
+	return append(body,
+		code.RawStmt(fmt.Sprintf("%s := %s.Model(new(%s))%s%s%s", result, db, g.model, allowGlobal(update.Query), where, updates)),
+		code.RawStmt(fmt.Sprintf("if %s.Error != nil {\n\treturn 0, %s.Error\n}", result, result)),
+		code.RawStmt(fmt.Sprintf("return int(%s.RowsAffected), nil", result)),
+	), nil
+}
+
+// updatesCodegen returns the Updates call of the updated fields, all fields except the primary key
+// are updated when updating the entire structure.
+func (g *gormGenerator) updatesCodegen(update *parse.UpdateParse) (string, error) {
+	if update.UpdateStructObjName != "" {
+		if g.keyColumn == "" {
+			return fmt.Sprintf(".Select(\"*\").Updates(%s)", update.UpdateStructObjName), nil
+		}
+		return fmt.Sprintf(".Select(\"*\").Omit(%q).Updates(%s)", g.keyColumn, update.UpdateStructObjName), nil
+	}
+
+	pairs := ""
+	for _, field := range update.UpdateFields {
+		column, _, err := g.getColumn(field.MongoFieldName)
+		if err != nil {
+			return "", err
+		}
+		pairs += fmt.Sprintf("\t%s: %s,\n", strconv.Quote(column), field.ParamName)
+	}
+	return fmt.Sprintf(".Updates(map[string]interface{}{\n%s})", pairs), nil
+}
+
+// createCodegen returns the statements which create the entity built from the equality conditions
+// and the updated fields when upsert matches no entity.
+func (g *gormGenerator) createCodegen(update *parse.UpdateParse, db, errReturn string) (string, error) {
+	result := fmt.Sprintf("\te := new(%s)\n", g.model)
+	if update.Query.QueryMode == parse.By {
+		for _, leaf := range getAndLeaves(update.Query.ConnectionOpTree) {
+			value := ""
+			switch parse.QueryComparator(leaf.Name) {
+			case parse.Equal:
+				value = leaf.Values()[0]
+			case parse.True:
+				value = "true"
+			case parse.False:
+				value = "false"
+			default:
+				continue
+			}
+			_, field, err := g.getColumn(leaf.MongoFieldName)
+			if err != nil {
+				return "", err
+			}
+			result += fmt.Sprintf("\te.%s = %s\n", field.Name, value)
+		}
+	}
+
+	if update.UpdateStructObjName != "" {
+		result += fmt.Sprintf("\t*e = *%s\n", update.UpdateStructObjName)
+	} else {
+		for _, updateField := range update.UpdateFields {
+			_, field, err := g.getColumn(updateField.MongoFieldName)
+			if err != nil {
+				return "", err
+			}
+			result += fmt.Sprintf("\te.%s = %s\n", field.Name, updateField.ParamName)
+		}
+	}
+
+	return result + fmt.Sprintf("\tif err := %s.Create(e).Error; err != nil {\n"+
+		"\t\treturn %s\n"+
+		"\t}\n", db, errReturn), nil
+}
+
+// getAndLeaves returns the leaves connected to the root by And.
+func getAndLeaves(node *parse.ConnectionOpTree) []*parse.ConnectionOpTree {
+	if node.LeftChildren == nil {
+		return []*parse.ConnectionOpTree{node}
+	}
+	if node.Name != string(parse.And) {
+		return nil
+	}
+	return append(getAndLeaves(node.LeftChildren), getAndLeaves(node.RightChildren)...)
+}
+
+func (g *gormGenerator) transactionCodegen(transaction *parse.TransactionParse) (code.Body, error) {
+	body := ""
+	for index, operation := range transaction.TransactionOperations {
+		if operation.CollectionParamName != parse.DefaultCollection {
+			return nil, fmt.Errorf("the Collection of the Transaction is not supported by the gorm backend, " +
+				"all operations run on the table of the model")
+		}
+
+		var stmts code.Body
+		var err error
+		suffix := strconv.Itoa(index)
+		switch op := operation.Operation.(type) {
+		case *parse.InsertParse:
+			param := op.MethodParamNames[0]
+			if _, ok := getParamType(transaction.BelongedToMethod, param).(code.StarExprType); !ok && op.OperateMode == parse.OperateOne {
+				param = "&" + param
+			}
+			stmts = code.Body{code.RawStmt(fmt.Sprintf("if err := tx.Create(%s).Error; err != nil {\n\treturn err\n}", param))}
+		case *parse.UpdateParse:
+			stmts, err = g.updateCodegen(op, "tx", suffix, "err")
+		case *parse.DeleteParse:
+			stmts, err = g.deleteCodegen(op, "tx", suffix, "err")
+		default:
+			err = fmt.Errorf("the %s operation of the Transaction is not supported by the gorm backend",
+				operation.Operation.GetOperationName())
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, stmt := range stmts {
+			body += fmt.Sprintf("%s\n", stmt.Code())
+		}
+	}
+
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("return %s.WithContext(%s).Transaction(func(tx *gorm.DB) error {\n"+
+			"%s"+
+			"\treturn nil\n"+
+			"})", transaction.ClientParamName, transaction.CtxParamName, body)),
+	}, nil
+}
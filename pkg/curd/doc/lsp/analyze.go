@@ -0,0 +1,156 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/cwgo/config"
+	"github.com/cloudwego/cwgo/pkg/common/utils"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/plugin"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/hertz/cmd/hz/meta"
+	"github.com/cloudwego/thriftgo/parser"
+	thriftgoPlugin "github.com/cloudwego/thriftgo/plugin"
+)
+
+// analyze extracts the structures of the idl and parses their methods, the failure of the extraction
+// is reported as the diagnostic of the idl file and no structure is returned. The idl file in the
+// overlay is read from it instead of the disk.
+func analyze(c *config.DocArgument, overlay extract.Overlay) ([]*extract.IdlExtractStruct, []parse.Diagnostic) {
+	structs, err := extractStructs(c, overlay)
+	if err != nil {
+		return nil, []parse.Diagnostic{{
+			Reason:     err.Error(),
+			Pos:        extract.Position{Filename: c.IdlPath},
+			TokenIndex: -1,
+		}}
+	}
+	operations, err := parse.HandleOperations(structs)
+	if err == nil {
+		// the client of the transactions is validated by the backend, the unknown backend is skipped
+		if b, lookupErr := backend.Lookup(c.Name); lookupErr == nil {
+			err = backend.CheckTransactionClients(c.Name, b, operations)
+		}
+	}
+	return structs, parse.GetDiagnostics(err)
+}
+
+// extractStructs extracts the structures without generating any file, the thrift idl is parsed in process
+// and the pb.go files of the proto idl are generated in a temporary directory.
+func extractStructs(c *config.DocArgument, overlay extract.Overlay) ([]*extract.IdlExtractStruct, error) {
+	idlType, err := utils.GetIdlType(c.IdlPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if idlType == meta.IdlThrift {
+		ast, err := parseThrift(c.IdlPath, c.ProtoSearchPath, overlay)
+		if err != nil {
+			return nil, err
+		}
+		info := &extract.ThriftUsedInfo{
+			Req:     &thriftgoPlugin.Request{AST: ast},
+			DocArgs: c,
+			Overlay: overlay,
+		}
+		return info.ParseThriftIdl()
+	}
+
+	modelDir, err := os.MkdirTemp("", "cwgo-doc-check-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(modelDir)
+
+	args := *c
+	args.IdlType = idlType
+	args.ModelDir = modelDir
+	if len(args.ProtoSearchPath) == 0 {
+		args.ProtoSearchPath = []string{filepath.Dir(c.IdlPath)}
+	}
+	if content, ok := overlay[c.IdlPath]; ok {
+		// protoc reads the content from the copy in the temporary directory searched first
+		if args.IdlPath, args.ProtoSearchPath, err = copyProto(c.IdlPath, content, modelDir, args.ProtoSearchPath); err != nil {
+			return nil, err
+		}
+	}
+	if err = plugin.RunProtoc(&args); err != nil {
+		return nil, err
+	}
+	args.IdlPath = c.IdlPath
+	info := &extract.PbUsedInfo{
+		DocArgs: &args,
+		Overlay: overlay,
+	}
+	return info.ParsePbIdl()
+}
+
+// parseThrift parses the thrift idl and the included files recursively, the idl in the overlay is parsed
+// from it and the included files are parsed from the disk.
+func parseThrift(path string, includeDirs []string, overlay extract.Overlay) (*parser.Thrift, error) {
+	content, ok := overlay[path]
+	if !ok {
+		return parser.ParseFile(path, includeDirs, true)
+	}
+	ast, err := parser.ParseString(path, content)
+	if err != nil {
+		return nil, err
+	}
+	for _, include := range ast.Includes {
+		includePath := ""
+		for _, dir := range append([]string{filepath.Dir(path)}, includeDirs...) {
+			if isExist, _ := utils.PathExist(filepath.Join(dir, include.Path)); isExist {
+				includePath = filepath.Join(dir, include.Path)
+				break
+			}
+		}
+		if includePath == "" {
+			return nil, fmt.Errorf("the included file %s of %s is not found", include.Path, path)
+		}
+		if include.Reference, err = parser.ParseFile(includePath, includeDirs, true); err != nil {
+			return nil, err
+		}
+	}
+	return ast, nil
+}
+
+// copyProto writes the content of the proto idl into the directory at its path relative to the search path
+// containing it, the directory is searched first so that the imports of the idl are resolved as before.
+func copyProto(path, content, dir string, searchPaths []string) (string, []string, error) {
+	rel := filepath.Base(path)
+	for _, searchPath := range searchPaths {
+		if r, err := filepath.Rel(searchPath, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = r
+			break
+		}
+	}
+	srcDir := filepath.Join(dir, "src")
+	copyPath := filepath.Join(srcDir, rel)
+	if err := os.MkdirAll(filepath.Dir(copyPath), 0o755); err != nil {
+		return "", nil, err
+	}
+	if err := os.WriteFile(copyPath, []byte(content), 0o644); err != nil {
+		return "", nil, err
+	}
+	return copyPath, append([]string{srcDir}, searchPaths...), nil
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/cwgo/config"
+)
+
+// Check validates the methods declared by the annotations of the idl without generating any file,
+// the diagnostics are printed one per line and an error is returned if there is any diagnostic.
+func Check(c *config.DocArgument) error {
+	if c.IdlPath == "" {
+		return errors.New("must specify idl path")
+	}
+
+	_, diagnostics := analyze(c, nil)
+	for _, diagnostic := range diagnostics {
+		fmt.Println(diagnostic.String())
+	}
+	if len(diagnostics) > 0 {
+		return fmt.Errorf("%d problems found in %s", len(diagnostics), c.IdlPath)
+	}
+	return nil
+}
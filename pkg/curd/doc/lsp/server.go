@@ -0,0 +1,426 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/cloudwego/cwgo/config"
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/hertz/cmd/hz/meta"
+	"github.com/cloudwego/hertz/cmd/hz/util"
+	"github.com/fatih/camelcase"
+)
+
+const (
+	mongoPrefix = "mongo."
+	source      = "cwgo doc"
+)
+
+var (
+	thriftStructRegexp  = regexp.MustCompile(`\bstruct\s+(\w+)`)
+	protoMessageRegexp  = regexp.MustCompile(`\bmessage\s+(\w+)`)
+	errUnknownDocuments = errors.New("the document is not opened")
+)
+
+// document is the idl opened by the editor, the structures are extracted from its text when it is opened,
+// changed or saved.
+type document struct {
+	uri  string
+	path string
+	text string
+	// structs are kept from the last successful extraction, they are used to complete the field names
+	structs []*extract.IdlExtractStruct
+}
+
+type server struct {
+	args      *config.DocArgument
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+// Serve speaks the language server protocol over stdin and stdout until the exit notification, the
+// annotated methods of the opened idl files are validated when they are opened, changed or saved, and the next
+// tokens of the method names written after mongo. are completed.
+func Serve(c *config.DocArgument) error {
+	s := &server{
+		args:      c,
+		in:        bufio.NewReader(os.Stdin),
+		out:       os.Stdout,
+		documents: make(map[string]*document),
+	}
+	return s.run()
+}
+
+func (s *server) run() error {
+	for {
+		msg, err := readMessage(s.in)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("the language server exits without shutdown")
+			}
+			return nil
+		}
+		result, err := s.handle(msg)
+		// the notifications are not responded
+		if msg.ID == nil {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", msg.Method, err)
+			}
+			continue
+		}
+		response := &message{ID: msg.ID}
+		if err != nil {
+			response.Error = toResponseError(err)
+		} else if response.Result, err = json.Marshal(result); err != nil {
+			return err
+		}
+		if err = writeMessage(s.out, response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(msg *message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    textDocumentSyncFull,
+					"save":      map[string]interface{}{"includeText": false},
+				},
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{"."},
+				},
+			},
+			"serverInfo": map[string]interface{}{
+				"name":    "cwgo-doc",
+				"version": cwgoMeta.Version,
+			},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		path, err := uriToPath(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		doc := &document{uri: params.TextDocument.URI, path: path, text: params.TextDocument.Text}
+		s.documents[doc.uri] = doc
+		return nil, s.validate(doc)
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, errUnknownDocuments
+		}
+		// the documents are synchronized in full, the last change is the whole text
+		if len(params.ContentChanges) > 0 {
+			doc.text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		}
+		return nil, s.validate(doc)
+
+	case "textDocument/didSave":
+		var params didSaveParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, errUnknownDocuments
+		}
+		if params.Text != nil {
+			doc.text = *params.Text
+		}
+		return nil, s.validate(doc)
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.publish(params.TextDocument.URI, []diagnostic{})
+
+	case "textDocument/completion":
+		var params completionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, errUnknownDocuments
+		}
+		return complete(doc, params.Position), nil
+
+	default:
+		if msg.ID == nil {
+			return nil, nil
+		}
+		return nil, &responseError{Code: methodNotFound, Message: "method not supported: " + msg.Method}
+	}
+}
+
+func (err *responseError) Error() string {
+	return err.Message
+}
+
+func toResponseError(err error) *responseError {
+	var respErr *responseError
+	if errors.As(err, &respErr) {
+		return respErr
+	}
+	return &responseError{Code: invalidParams, Message: err.Error()}
+}
+
+func unmarshalParams(msg *message, params interface{}) error {
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return &responseError{Code: invalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// validate extracts the structures from the text of the document and publishes the diagnostics of its methods,
+// the files included by the document are read from the disk.
+func (s *server) validate(doc *document) error {
+	args := *s.args
+	args.IdlPath = doc.path
+	structs, diagnostics := analyze(&args, extract.Overlay{doc.path: doc.text})
+	if structs != nil {
+		doc.structs = structs
+	}
+
+	result := make([]diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		result = append(result, toDiagnostic(doc, d))
+	}
+	return s.publish(doc.uri, result)
+}
+
+func (s *server) publish(uri string, diagnostics []diagnostic) error {
+	params, err := json.Marshal(&publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &message{Method: "textDocument/publishDiagnostics", Params: params})
+}
+
+// toDiagnostic locates the diagnostic to the offending token of the annotation key, the diagnostics
+// of the other files and the ones without the positions are located at the beginning of the document.
+func toDiagnostic(doc *document, d parse.Diagnostic) diagnostic {
+	result := diagnostic{
+		Severity: severityError,
+		Source:   source,
+		Message:  d.String(),
+	}
+	if !d.Pos.IsValid() || !samePath(d.Pos.Filename, doc.path) {
+		return result
+	}
+
+	result.Message = d.Reason
+	if d.Suggestion != "" {
+		result.Message += ", " + d.Suggestion
+	}
+	lines := strings.Split(doc.text, "\n")
+	if d.Pos.Line > len(lines) {
+		return result
+	}
+	line := strings.TrimSuffix(lines[d.Pos.Line-1], "\r")
+	start := d.Pos.Column - 1
+	if start < 0 || start > len(line) {
+		return result
+	}
+	end := start
+	if strings.HasPrefix(line[start:], mongoPrefix) {
+		end = scanIdentifier(line, start+len(mongoPrefix))
+		if d.TokenIndex >= 0 {
+			tokens := camelcase.Split(line[start+len(mongoPrefix) : end])
+			if d.TokenIndex < len(tokens) {
+				start += len(mongoPrefix)
+				for _, token := range tokens[:d.TokenIndex] {
+					start += len(token)
+				}
+				end = start + len(tokens[d.TokenIndex])
+			}
+		}
+	}
+	result.Range = lspRange{
+		Start: position{Line: d.Pos.Line - 1, Character: utf16Len(line[:start])},
+		End:   position{Line: d.Pos.Line - 1, Character: utf16Len(line[:end])},
+	}
+	return result
+}
+
+// complete returns the completions of the method name written after mongo. before the cursor.
+func complete(doc *document, pos position) []completionItem {
+	lines := strings.Split(doc.text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return []completionItem{}
+	}
+	line := strings.TrimSuffix(lines[pos.Line], "\r")
+	cursor := byteOffset(line, pos.Character)
+	start := cursor
+	for start > 0 && isIdentifierByte(line[start-1]) {
+		start--
+	}
+	// the keys such as mongo.query.Xxx are not completed
+	keyStart := start - len(mongoPrefix)
+	if keyStart < 0 || line[keyStart:start] != mongoPrefix ||
+		keyStart > 0 && (isIdentifierByte(line[keyStart-1]) || line[keyStart-1] == '.') {
+		return []completionItem{}
+	}
+
+	offset := keyStart
+	for _, l := range lines[:pos.Line] {
+		offset += len(l) + 1
+	}
+	st := findStruct(doc, offset)
+	completions := parse.Complete(st, line[start:cursor])
+	items := make([]completionItem, 0, len(completions))
+	for _, completion := range completions {
+		items = append(items, completionItem{
+			Label:  completion.Label,
+			Kind:   toCompletionKind(completion.Kind),
+			Detail: completion.Detail,
+			TextEdit: &textEdit{
+				Range: lspRange{
+					Start: position{Line: pos.Line, Character: utf16Len(line[:cursor-len(completion.Partial)])},
+					End:   position{Line: pos.Line, Character: utf16Len(line[:cursor])},
+				},
+				NewText: completion.Label,
+			},
+		})
+	}
+	return items
+}
+
+// findStruct returns the structure annotated at the offset, the annotations of thrift follow the
+// declaration of the struct and the ones of proto are the comments before the declaration of the message.
+func findStruct(doc *document, offset int) *extract.IdlExtractStruct {
+	name := ""
+	if strings.HasSuffix(doc.path, "."+meta.IdlProto) {
+		if match := protoMessageRegexp.FindStringSubmatch(doc.text[offset:]); match != nil {
+			name = match[1]
+		}
+	} else {
+		matches := thriftStructRegexp.FindAllStringSubmatch(doc.text[:offset], -1)
+		if len(matches) > 0 {
+			name = matches[len(matches)-1][1]
+		}
+	}
+	if name == "" {
+		return nil
+	}
+	for _, st := range doc.structs {
+		if st.Name == name || st.Name == util.CamelString(name) {
+			return st
+		}
+	}
+	return nil
+}
+
+func toCompletionKind(kind parse.CompletionKind) int {
+	switch kind {
+	case parse.CompletionField:
+		return completionKindField
+	case parse.CompletionComparator:
+		return completionKindOperator
+	case parse.CompletionCollection:
+		return completionKindClass
+	default:
+		return completionKindKeyword
+	}
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("the document %s is not a file", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+func samePath(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
+
+func scanIdentifier(s string, start int) int {
+	end := start
+	for end < len(s) && isIdentifierByte(s[end]) {
+		end++
+	}
+	return end
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// utf16Len returns the length of s in UTF-16 code units, which are the unit of the characters of LSP.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// byteOffset returns the byte offset of the character counted in UTF-16 code units.
+func byteOffset(s string, character int) int {
+	units := 0
+	for index, r := range s {
+		if units >= character {
+			return index
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return len(s)
+}
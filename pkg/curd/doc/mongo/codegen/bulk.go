@@ -18,74 +18,156 @@ package codegen
 
 import (
 	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
 	"github.com/cloudwego/cwgo/pkg/curd/parse"
 )
 
 func bulkCodegen(bulk *parse.BulkParse) []code.Statement {
-	return []code.Statement{
-		code.DeclVarStmt{
-			Name: "models",
-			Type: code.SliceType{
-				ElementType: code.SelectorExprType{
-					X:   "mongo",
-					Sel: "WriteModel",
-				},
+	statements := make([]code.Statement, 0, 10)
+	now := getLocalName(bulk.BelongedToMethod, "now")
+	if optionNowRequired(bulk.Operations) {
+		statements = append(statements, nowCodegen(now))
+	}
+	statements = append(statements, code.DeclVarStmt{
+		Name: "models",
+		Type: code.SliceType{
+			ElementType: code.SelectorExprType{
+				X:   "mongo",
+				Sel: "WriteModel",
 			},
 		},
-		bulkOperationsCodegen(bulk),
-		code.ReturnStmt{
-			ListCommaStmt: code.ListCommaStmt{
-				code.CallStmt{
-					Caller:   code.RawStmt("r.collection"),
-					CallName: "BulkWrite",
-					Args: code.ListCommaStmt{
-						code.RawStmt(bulk.CtxParamName),
-						code.RawStmt("models"),
-					},
-				},
+	})
+	statements = append(statements, bulkOperationsCodegen(bulk, now)...)
+	return append(statements, code.ReturnStmt{
+		ListCommaStmt: code.ListCommaStmt{
+			code.CallStmt{
+				Caller:   code.RawStmt("r.collection"),
+				CallName: "BulkWrite",
+				Args:     bulkWriteArgsCodegen(bulk, bulk.CtxParamName),
 			},
 		},
+	})
+}
+
+// bulkWriteArgsCodegen returns the args of BulkWrite, the options are passed if the bulk is unordered.
+func bulkWriteArgsCodegen(bulk *parse.BulkParse, ctx string) code.ListCommaStmt {
+	args := code.ListCommaStmt{
+		code.RawStmt(ctx),
+		code.RawStmt("models"),
+	}
+	if bulk.Unordered {
+		args = append(args, code.RawStmt("options.BulkWrite().SetOrdered(false)"))
 	}
+	return args
 }
 
-func bulkOperationsCodegen(bulk *parse.BulkParse) code.SliceAppendsStmt {
-	operations := make([]code.SliceAppendStmt, 0, 10)
+// bulkOperationsCodegen returns the statements which append the write models of the operations,
+// the model options are applied with now declared by the caller.
+func bulkOperationsCodegen(bulk *parse.BulkParse, now string) []code.Statement {
+	operations := make([]code.Statement, 0, 10)
 	for _, operation := range bulk.Operations {
 		if operation.GetOperationName() == parse.Insert {
-			operations = append(operations, bulkInsertCodegen(operation.(*parse.InsertParse)))
+			operations = append(operations, bulkInsertCodegen(operation.(*parse.InsertParse), now)...)
 		}
 		if operation.GetOperationName() == parse.Update {
-			operations = append(operations, bulkUpdateCodegen(operation.(*parse.UpdateParse)))
+			operations = append(operations, bulkUpdateCodegen(operation.(*parse.UpdateParse), now)...)
+		}
+		if operation.GetOperationName() == parse.Replace {
+			operations = append(operations, bulkReplaceCodegen(operation.(*parse.ReplaceParse), now)...)
 		}
 		if operation.GetOperationName() == parse.Delete {
-			operations = append(operations, bulkDeleteCodegen(operation.(*parse.DeleteParse)))
+			operations = append(operations, bulkDeleteCodegen(operation.(*parse.DeleteParse), now))
 		}
 	}
 	return operations
 }
 
-func bulkInsertCodegen(insert *parse.InsertParse) code.SliceAppendStmt {
+// bulkInsertCodegen returns the InsertOneModel of the entity, or the InsertOneModels of each entity
+// of the slice in Many mode.
+func bulkInsertCodegen(insert *parse.InsertParse, now string) []code.Statement {
+	st := insert.BelongedToMethod.BelongedToStruct
+	if insert.OperateMode == parse.OperateMany {
+		return []code.Statement{
+			code.ForRangeBlockStmt{
+				RangeName: insert.MethodParamNames[0],
+				Value:     "model",
+				Body:      append(createTimestampsCodegen(st, "model", now), bulkInsertOneCodegen("model")),
+			},
+		}
+	}
+	return append(createTimestampsCodegen(st, insert.MethodParamNames[0], now),
+		bulkInsertOneCodegen(insert.MethodParamNames[0]))
+}
+
+func bulkInsertOneCodegen(document string) code.SliceAppendStmt {
 	return code.SliceAppendStmt{
 		SliceName: "models",
 		AppendData: code.CallStmt{
 			Caller:   code.RawStmt("mongo.NewInsertOneModel()"),
 			CallName: "SetDocument",
 			Args: code.ListCommaStmt{
-				code.RawStmt(insert.MethodParamNames[0]),
+				code.RawStmt(document),
 			},
 		},
 	}
 }
 
-func bulkUpdateCodegen(update *parse.UpdateParse) code.SliceAppendStmt {
+func bulkReplaceCodegen(replace *parse.ReplaceParse, now string) []code.Statement {
+	st := replace.BelongedToMethod.BelongedToStruct
+	statements := make([]code.Statement, 0, 2)
+	if st.Options.Timestamps {
+		statements = append(statements, assignFieldCodegen(st, replace.ReplacementParamName, extract.UpdatedAtField, now))
+	}
+	chainCall := make(code.ChainStmt, 0, 5)
+	if st.Options.Timestamps {
+		// the replacement keeps created_at of the stored document
+		return append(statements, code.SliceAppendStmt{
+			SliceName: "models",
+			AppendData: chainCall.ChainCall(code.Chain{
+				CallName: "mongo.NewUpdateOneModel().SetFilter",
+				Args: code.ListCommaStmt{
+					queryCodegen(optionQuery(replace.Query, st)),
+				},
+			}).ChainCall(code.Chain{
+				CallName: "SetUpdate",
+				Args: code.ListCommaStmt{
+					entityPipelineCodegen(replace.ReplacementParamName, now, false),
+				},
+			}),
+		})
+	}
+	return append(statements, code.SliceAppendStmt{
+		SliceName: "models",
+		AppendData: chainCall.ChainCall(code.Chain{
+			CallName: "mongo.NewReplaceOneModel().SetFilter",
+			Args: code.ListCommaStmt{
+				queryCodegen(optionQuery(replace.Query, st)),
+			},
+		}).ChainCall(code.Chain{
+			CallName: "SetReplacement",
+			Args: code.ListCommaStmt{
+				code.RawStmt(replace.ReplacementParamName),
+			},
+		}),
+	})
+}
+
+func bulkUpdateCodegen(update *parse.UpdateParse, now string) []code.Statement {
 	if update.OperateMode == parse.OperateOne {
-		return getBulkUpdateCode(update, "mongo.NewUpdateOneModel().SetFilter")
+		return getBulkUpdateCode(update, "mongo.NewUpdateOneModel().SetFilter", now)
 	} else {
-		return getBulkUpdateCode(update, "mongo.NewUpdateManyModel().SetFilter")
+		return getBulkUpdateCode(update, "mongo.NewUpdateManyModel().SetFilter", now)
 	}
 }
 
-func bulkDeleteCodegen(delete *parse.DeleteParse) code.SliceAppendStmt {
+func bulkDeleteCodegen(delete *parse.DeleteParse, now string) code.SliceAppendStmt {
+	if delete.BelongedToMethod.BelongedToStruct.Options.SoftDelete {
+		// the soft deleted documents are updated instead of being removed
+		if delete.OperateMode == parse.OperateOne {
+			return getBulkSoftDeleteCode(delete, "mongo.NewUpdateOneModel().SetFilter", now)
+		}
+		return getBulkSoftDeleteCode(delete, "mongo.NewUpdateManyModel().SetFilter", now)
+	}
 	if delete.OperateMode == parse.OperateOne {
 		return getBulkDeleteCode(delete, "mongo.NewDeleteOneModel().SetFilter")
 	} else {
@@ -93,19 +175,20 @@ func bulkDeleteCodegen(delete *parse.DeleteParse) code.SliceAppendStmt {
 	}
 }
 
-func getBulkUpdateCode(update *parse.UpdateParse, callName string) code.SliceAppendStmt {
+func getBulkUpdateCode(update *parse.UpdateParse, callName, now string) []code.Statement {
+	statements, fields := optionUpdateCodegen(update, now)
 	chainCall := make(code.ChainStmt, 0, 5)
-	return code.SliceAppendStmt{
+	return append(statements, code.SliceAppendStmt{
 		SliceName: "models",
 		AppendData: chainCall.ChainCall(code.Chain{
 			CallName: callName,
 			Args: code.ListCommaStmt{
-				queryCodegen(update.Query),
+				queryCodegen(optionQuery(update.Query, update.BelongedToMethod.BelongedToStruct)),
 			},
 		}).ChainCall(code.Chain{
 			CallName: "SetUpdate",
 			Args: code.ListCommaStmt{
-				updateFieldsCodegen(update),
+				fields,
 			},
 		}).ChainCall(code.Chain{
 			CallName: "SetUpsert",
@@ -113,7 +196,7 @@ func getBulkUpdateCode(update *parse.UpdateParse, callName string) code.SliceApp
 				upsertCodegen(update.Upsert),
 			},
 		}),
-	}
+	})
 }
 
 func getBulkDeleteCode(delete *parse.DeleteParse, callName string) code.SliceAppendStmt {
@@ -128,3 +211,22 @@ func getBulkDeleteCode(delete *parse.DeleteParse, callName string) code.SliceApp
 		}),
 	}
 }
+
+func getBulkSoftDeleteCode(delete *parse.DeleteParse, callName, now string) code.SliceAppendStmt {
+	st := delete.BelongedToMethod.BelongedToStruct
+	chainCall := make(code.ChainStmt, 0, 5)
+	return code.SliceAppendStmt{
+		SliceName: "models",
+		AppendData: chainCall.ChainCall(code.Chain{
+			CallName: callName,
+			Args: code.ListCommaStmt{
+				queryCodegen(optionQuery(delete.Query, st)),
+			},
+		}).ChainCall(code.Chain{
+			CallName: "SetUpdate",
+			Args: code.ListCommaStmt{
+				softDeleteFieldsCodegen(st, now),
+			},
+		}),
+	}
+}
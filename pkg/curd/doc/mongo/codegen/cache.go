@@ -0,0 +1,438 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+var CacheImports = map[string]string{
+	"context": "",
+	"fmt":     "",
+	"time":    "",
+}
+
+// cacheField is the top-level field with the single-field unique index, the entities are cached by its value.
+type cacheField struct {
+	mongoName string
+	goPath    string
+}
+
+// getCacheFields returns the fields by which the entities can be cached, the unique indexes with
+// the partial filters are skipped because the values outside the filters are not unique.
+func getCacheFields(st *extract.IdlExtractStruct) []cacheField {
+	mongoNames := make([]string, 0, 2)
+	for _, field := range st.StructFields {
+		if field.Tag.Get("bson") == "_id" {
+			mongoNames = append(mongoNames, "_id")
+		}
+	}
+	for _, index := range st.Indexes {
+		if index.Unique && len(index.Keys) == 1 && index.PartialFilter == nil {
+			mongoNames = append(mongoNames, index.Keys[0].MongoFieldName)
+		}
+	}
+
+	result := make([]cacheField, 0, len(mongoNames))
+	seen := make(map[string]struct{}, len(mongoNames))
+	for _, mongoName := range mongoNames {
+		if _, ok := seen[mongoName]; ok || strings.Contains(mongoName, ".") {
+			continue
+		}
+		seen[mongoName] = struct{}{}
+		// the keys are formatted by the values, so only the scalar values are supported
+		goPath, t, err := st.GetFieldByMongoName(mongoName)
+		if err != nil || !isCacheKeyType(t) {
+			continue
+		}
+		result = append(result, cacheField{mongoName: mongoName, goPath: goPath})
+	}
+	return result
+}
+
+func isCacheKeyType(t code.Type) bool {
+	switch t.RealName() {
+	case "string", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckCacheFields returns the warning if the entities of the structure can not be cached.
+func CheckCacheFields(st *extract.IdlExtractStruct) []string {
+	if len(getCacheFields(st)) != 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: no field has a single-field unique index, so no method is cached by the "+
+		"cache decorator", st.Name)}
+}
+
+// GetCacheRenders returns the renders of the read-through cache decorator of the mongo repository.
+//
+//	input params description:
+//	ifOperation: the parsing results of all methods of the structure, including the methods generated before
+//	methods: all methods of the repository interface
+//	cachePkg: the name of the imported cache package
+func GetCacheRenders(ifOperation *parse.InterfaceOperation, methods code.InterfaceMethods, cachePkg string) []template.Render {
+	st := ifOperation.BelongedToStruct
+	cacheName := st.Name + "RepositoryCache"
+	fields := getCacheFields(st)
+	receiver := code.MethodReceiver{
+		Name: "r",
+		Type: code.StarExprType{RealType: code.IdentType(cacheName)},
+	}
+
+	renders := []template.Render{
+		&template.StructRender{
+			Name: cacheName,
+			Comment: fmt.Sprintf("// %s is the read-through cache of %sRepositoryMongo, the entities found by the fields\n"+
+				"// with the single-field unique indexes are cached, the keys of the entities matched by the updates\n"+
+				"// and the deletions are invalidated after them.", cacheName, st.Name),
+			StructFields: code.StructFields{
+				code.StructField{
+					Name: "next",
+					Type: code.StarExprType{RealType: code.IdentType(st.Name + "RepositoryMongo")},
+				},
+				code.StructField{
+					Name: "cache",
+					Type: code.SelectorExprType{X: cachePkg, Sel: "Cache"},
+				},
+				code.StructField{
+					Name: "ttl",
+					Type: code.SelectorExprType{X: "time", Sel: "Duration"},
+				},
+				code.StructField{
+					Name: "prefix",
+					Type: code.IdentType("string"),
+				},
+				code.StructField{
+					Name: "registry",
+					Type: code.StarExprType{RealType: code.SelectorExprType{X: "bsoncodec", Sel: "Registry"}},
+				},
+			},
+		},
+		&template.FuncRender{
+			Name: "New" + cacheName,
+			Comment: fmt.Sprintf("// New%s creates the repository on the collection whose entities are cached by c\n"+
+				"// for ttl, the keys are prefixed by the database and the collection names.", cacheName),
+			Params: code.Params{
+				code.Param{
+					Name: "collection",
+					Type: code.StarExprType{RealType: code.SelectorExprType{X: "mongo", Sel: "Collection"}},
+				},
+				code.Param{
+					Name: "c",
+					Type: code.SelectorExprType{X: cachePkg, Sel: "Cache"},
+				},
+				code.Param{
+					Name: "ttl",
+					Type: code.SelectorExprType{X: "time", Sel: "Duration"},
+				},
+			},
+			Returns: code.Returns{
+				code.IdentType(st.Name + "Repository"),
+			},
+			FuncBody: code.Body{
+				// the repository is built here because the constructor may be changed to return the other repository
+				code.RawStmt(fmt.Sprintf("registry := %s.%s()", st.ModelPkg, NewBsonRegistry)),
+				code.RawStmt("if cloned, err := collection.Clone(options.Collection().SetRegistry(registry)); err == nil {\n" +
+					"\tcollection = cloned\n}"),
+				code.RawStmt(fmt.Sprintf("return &%s{\n"+
+					"\tnext:     %s,\n"+
+					"\tcache:    c,\n"+
+					"\tttl:      ttl,\n"+
+					"\tprefix:   fmt.Sprintf(\"cwgo:%%s.%%s:\", collection.Database().Name(), collection.Name()),\n"+
+					"\tregistry: registry,\n"+
+					"}", cacheName, strings.ReplaceAll(getRepositoryMongoLiteral(st), "\n", "\n\t"))),
+			},
+		},
+	}
+
+	operations := make(map[string]parse.Operation, len(ifOperation.Operations))
+	for _, operation := range ifOperation.Operations {
+		operations[parse.GetBelongedToMethod(operation).Name] = operation
+	}
+	for _, method := range methods {
+		body := cacheMethodCodegen(operations[method.Name], method, fields)
+		// the entity deleted by the generic repository is matched by _id
+		if method.Name == GenericDeleteByID && IsGenericMethod(st, method.Name) && len(fields) != 0 {
+			body = cacheInvalidateCodegen(method, cacheCallCodegen(method), "keys", []string{"bson.M{\"_id\": id}"})
+		}
+		renders = append(renders, &template.MethodRender{
+			Name:           method.Name,
+			MethodReceiver: receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody:     body,
+		})
+	}
+
+	if len(fields) == 0 {
+		return renders
+	}
+	return append(renders, cacheHelperRenders(st, receiver, fields)...)
+}
+
+// cacheMethodCodegen returns the body of the method of the decorator, the methods which neither find
+// the entity by the cached field nor change the entities are passed through.
+func cacheMethodCodegen(operation parse.Operation, method code.InterfaceMethod, fields []cacheField) code.Body {
+	call := cacheCallCodegen(method)
+	passThrough := code.Body{code.RawStmt("return " + call)}
+	if operation == nil || len(fields) == 0 {
+		return passThrough
+	}
+
+	if find, ok := operation.(*parse.FindParse); ok {
+		field, paramName, ok := getCachedFindField(find, fields)
+		if !ok {
+			return passThrough
+		}
+		return cacheFindCodegen(find, field, paramName, call)
+	}
+
+	filters := cacheFiltersCodegen(operation)
+	if len(filters) == 0 {
+		return passThrough
+	}
+	filterArgs := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		filterArgs = append(filterArgs, filter.Code())
+	}
+	return cacheInvalidateCodegen(method, call, getLocalName(parse.GetBelongedToMethod(operation), "keys"), filterArgs)
+}
+
+// cacheCallCodegen returns the call of the method of the decorated repository.
+func cacheCallCodegen(method code.InterfaceMethod) string {
+	args := make([]string, 0, len(method.Params))
+	for _, param := range method.Params {
+		if strings.HasPrefix(param.Type.RealName(), "...") {
+			args = append(args, param.Name+"...")
+		} else {
+			args = append(args, param.Name)
+		}
+	}
+	return fmt.Sprintf("r.next.%s(%s)", method.Name, strings.Join(args, ", "))
+}
+
+// cacheInvalidateCodegen returns the body which invalidates the keys of the entities matched by the filters,
+// the keys are collected before the writes because the values of the cached fields may be changed by them.
+func cacheInvalidateCodegen(method code.InterfaceMethod, call, keys string, filterArgs []string) code.Body {
+	ctx := method.Params[0].Name
+	zeroValues := make([]string, 0, len(method.Returns))
+	for _, t := range method.Returns[:len(method.Returns)-1] {
+		zeroValues = append(zeroValues, zeroValueCodegen(t))
+	}
+	zeroValues = append(zeroValues, "err")
+
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("%s, err := r.matchedKeys(%s, %s)", keys, ctx, strings.Join(filterArgs, ", "))),
+		code.RawStmt(fmt.Sprintf("if err != nil {\n\treturn %s\n}", strings.Join(zeroValues, ", "))),
+		code.RawStmt(fmt.Sprintf("defer r.invalidate(%s, %s)", ctx, keys)),
+		code.RawStmt("return " + call),
+	}
+}
+
+// getCachedFindField returns the cached field and the param if the Find operation finds one entirely
+// decoded entity by the equality of the cached field.
+func getCachedFindField(find *parse.FindParse, fields []cacheField) (cacheField, string, bool) {
+	if find.OperateMode != parse.OperateOne || len(find.Project) != 0 || find.Keyset != nil ||
+		find.SkipParamName != "" || find.Query.QueryMode != parse.By {
+		return cacheField{}, "", false
+	}
+	node := find.Query.ConnectionOpTree
+	// the literal of the annotation query is not cached, it has no param
+	if node.LeftChildren != nil || node.RightChildren != nil || node.Name != string(parse.Equal) ||
+		len(node.ParamNames) != 1 {
+		return cacheField{}, "", false
+	}
+	for _, field := range fields {
+		if field.mongoName == node.MongoFieldName {
+			return field, node.ParamNames[0], true
+		}
+	}
+	return cacheField{}, "", false
+}
+
+func cacheFindCodegen(find *parse.FindParse, field cacheField, paramName, call string) code.Body {
+	key, entity := getLocalName(find.BelongedToMethod, "key"), getLocalName(find.BelongedToMethod, "entity")
+	cached, stored := entity, entity
+	if _, ok := find.ReturnType.(code.StarExprType); !ok {
+		cached, stored = "*"+entity, "&"+entity
+	}
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("%s := r.cacheKey(%q, %s)", key, field.mongoName, paramName)),
+		code.RawStmt(fmt.Sprintf("if %s, ok := r.get(%s, %s); ok {\n\treturn %s, nil\n}", entity, find.CtxParamName,
+			key, cached)),
+		code.RawStmt(fmt.Sprintf("%s, err := %s", entity, call)),
+		code.RawStmt(fmt.Sprintf("if err != nil {\n\treturn %s, err\n}", entity)),
+		code.RawStmt(fmt.Sprintf("r.set(%s, %s, %s)", find.CtxParamName, key, stored)),
+		code.RawStmt(fmt.Sprintf("return %s, nil", entity)),
+	}
+}
+
+// cacheFiltersCodegen returns the filters of the operations which change the entities of the repository,
+// the operations on the collections passed in by Transaction and the other models are skipped.
+func cacheFiltersCodegen(operation parse.Operation) []code.Statement {
+	switch op := operation.(type) {
+	case *parse.UpdateParse:
+		return []code.Statement{queryCodegen(op.Query)}
+	case *parse.ReplaceParse:
+		return []code.Statement{queryCodegen(op.Query)}
+	case *parse.DeleteParse:
+		return []code.Statement{queryCodegen(op.Query)}
+	case *parse.BulkParse:
+		filters := make([]code.Statement, 0, len(op.Operations))
+		for _, bulkOperation := range op.Operations {
+			filters = append(filters, cacheFiltersCodegen(bulkOperation)...)
+		}
+		return filters
+	case *parse.TransactionParse:
+		filters := make([]code.Statement, 0, len(op.TransactionOperations))
+		for _, taOperation := range op.TransactionOperations {
+			if taOperation.Model == nil && taOperation.CollectionParamName == parse.DefaultCollection {
+				filters = append(filters, cacheFiltersCodegen(taOperation.Operation)...)
+			}
+		}
+		return filters
+	default:
+		return nil
+	}
+}
+
+// zeroValueCodegen returns the zero value of the type returned with the error.
+func zeroValueCodegen(t code.Type) string {
+	switch t.(type) {
+	case code.StarExprType, code.SliceType, code.MapType, code.InterfaceType, code.ChanType:
+		return "nil"
+	}
+	switch t.RealName() {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64":
+		return "0"
+	default:
+		return fmt.Sprintf("*new(%s)", t.RealName())
+	}
+}
+
+func cacheHelperRenders(st *extract.IdlExtractStruct, receiver code.MethodReceiver, fields []cacheField) []template.Render {
+	entityType := code.StarExprType{RealType: code.SelectorExprType{X: st.ModelPkg, Sel: st.Name}}
+	ctxParam := code.Param{Name: "ctx", Type: code.SelectorExprType{X: "context", Sel: "Context"}}
+
+	projection, keys := "", ""
+	for _, field := range fields {
+		projection += fmt.Sprintf("%q: 1, ", field.mongoName)
+		keys += fmt.Sprintf(", r.cacheKey(%q, entity.%s)", field.mongoName, field.goPath)
+	}
+
+	return []template.Render{
+		&template.MethodRender{
+			Name:           "cacheKey",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				code.Param{Name: "field", Type: code.IdentType("string")},
+				code.Param{Name: "value", Type: code.InterfaceType{}},
+			},
+			Returns: code.Returns{code.IdentType("string")},
+			MethodBody: code.Body{
+				code.RawStmt("return fmt.Sprintf(\"%s%s:%v\", r.prefix, field, value)"),
+			},
+		},
+		&template.MethodRender{
+			Name: "get",
+			Comment: "// get returns the cached entity of the key decoded by the registry of the model, the errors\n" +
+				"// of the cache are treated as the misses.",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				ctxParam,
+				code.Param{Name: "key", Type: code.IdentType("string")},
+			},
+			Returns: code.Returns{entityType, code.IdentType("bool")},
+			MethodBody: code.Body{
+				code.RawStmt("value, ok, err := r.cache.Get(ctx, key)"),
+				code.RawStmt("if err != nil || !ok {\n\treturn nil, false\n}"),
+				code.RawStmt("decoder, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(value))\n" +
+					"if err != nil {\n\treturn nil, false\n}"),
+				code.RawStmt("if err = decoder.SetRegistry(r.registry); err != nil {\n\treturn nil, false\n}"),
+				code.RawStmt(fmt.Sprintf("entity := new(%s)", entityType.RealType.RealName())),
+				code.RawStmt("if err = decoder.Decode(entity); err != nil {\n\treturn nil, false\n}"),
+				code.RawStmt("return entity, true"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "set",
+			Comment:        "// set caches the entity encoded by the registry of the model, the errors of the cache are ignored.",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				ctxParam,
+				code.Param{Name: "key", Type: code.IdentType("string")},
+				code.Param{Name: "entity", Type: entityType},
+			},
+			MethodBody: code.Body{
+				code.RawStmt("buf := new(bytes.Buffer)"),
+				code.RawStmt("writer, err := bsonrw.NewBSONValueWriter(buf)\nif err != nil {\n\treturn\n}"),
+				code.RawStmt("encoder, err := bson.NewEncoder(writer)\nif err != nil {\n\treturn\n}"),
+				code.RawStmt("if err = encoder.SetRegistry(r.registry); err != nil {\n\treturn\n}"),
+				code.RawStmt("if err = encoder.Encode(entity); err == nil {\n" +
+					"\t_ = r.cache.Set(ctx, key, buf.Bytes(), r.ttl)\n}"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "matchedKeys",
+			Comment:        "// matchedKeys returns the keys of the entities matched by any of the filters.",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				ctxParam,
+				code.Param{Name: "filters", Type: code.IdentType("...bson.M")},
+			},
+			Returns: code.Returns{code.SliceType{ElementType: code.IdentType("string")}, code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt(fmt.Sprintf("cursor, err := r.next.collection.Find(ctx, bson.M{\"$or\": filters},\n"+
+					"\toptions.Find().SetProjection(bson.M{%s}))", strings.TrimSuffix(projection, ", "))),
+				code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+				code.RawStmt(fmt.Sprintf("var entities []%s", entityType.RealName())),
+				code.RawStmt("if err = cursor.All(ctx, &entities); err != nil {\n\treturn nil, err\n}"),
+				code.RawStmt(fmt.Sprintf("keys := make([]string, 0, len(entities)*%d)", len(fields))),
+				code.RawStmt(fmt.Sprintf("for _, entity := range entities {\n\tkeys = append(keys%s)\n}", keys)),
+				code.RawStmt("return keys, nil"),
+			},
+		},
+		&template.MethodRender{
+			Name: "invalidate",
+			Comment: "// invalidate deletes the keys, the errors of the cache are ignored and the stale entities\n" +
+				"// are expired after the ttl.",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				ctxParam,
+				code.Param{Name: "keys", Type: code.SliceType{ElementType: code.IdentType("string")}},
+			},
+			MethodBody: code.Body{
+				code.RawStmt("if len(keys) != 0 {\n\t_ = r.cache.Delete(ctx, keys...)\n}"),
+			},
+		},
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+// CachePkgName is the name of the package shared by the cache decorators, which is generated in the dao directory.
+const CachePkgName = "cache"
+
+// PackageFile is the file of the packages shared by the decorators, its code is the same for all idls.
+type PackageFile struct {
+	Name    string
+	Imports map[string]string
+	Code    string
+}
+
+// CachePackageFiles are the Cache interface and its in-memory LRU and redis implementations.
+var CachePackageFiles = []PackageFile{
+	{
+		Name: "cache.go",
+		Imports: map[string]string{
+			"context": "",
+			"time":    "",
+		},
+		Code: cacheInterfaceCode,
+	},
+	{
+		Name: "lru.go",
+		Imports: map[string]string{
+			"container/list": "",
+			"context":        "",
+			"sync":           "",
+			"time":           "",
+		},
+		Code: cacheLRUCode,
+	},
+	{
+		Name: "redis.go",
+		Imports: map[string]string{
+			"context":                      "",
+			"errors":                       "",
+			"time":                         "",
+			"github.com/redis/go-redis/v9": "",
+		},
+		Code: cacheRedisCode,
+	},
+}
+
+var cacheInterfaceCode = `
+// Cache stores the encoded entities of the repositories by the keys.
+type Cache interface {
+	// Get returns the value of the key, ok is false if it is not found or expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores the value of the key which expires after ttl, 0 means no expiration.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete deletes the keys, the keys which are not found are ignored.
+	Delete(ctx context.Context, keys ...string) error
+}
+`
+
+var cacheLRUCode = `
+// LRU is the in-memory Cache which evicts the least recently used entries when the capacity is exceeded.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	entries  *list.List
+	elements map[string]*list.Element
+}
+
+type lruEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+// NewLRU creates the LRU which stores capacity entries at most.
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		entries:  list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (c *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.elements[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*lruEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.entries.MoveToFront(element)
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (c *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &lruEntry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expireAt = time.Now().Add(ttl)
+	}
+	if element, ok := c.elements[key]; ok {
+		element.Value = entry
+		c.entries.MoveToFront(element)
+		return nil
+	}
+	c.elements[key] = c.entries.PushFront(entry)
+	for c.entries.Len() > c.capacity {
+		c.remove(c.entries.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.elements[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+func (c *LRU) remove(element *list.Element) {
+	c.entries.Remove(element)
+	delete(c.elements, element.Value.(*lruEntry).key)
+}
+`
+
+var cacheRedisCode = `
+// Redis is the Cache stored in redis, which is shared by the instances of the service.
+type Redis struct {
+	client redis.UniversalClient
+}
+
+// NewRedis creates the Cache on the redis client.
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
+}
+`
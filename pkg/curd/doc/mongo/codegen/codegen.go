@@ -18,6 +18,7 @@ package codegen
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/printer"
@@ -149,6 +150,22 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 				}
 				methods = append(methods, method)
 
+			case parse.Watch:
+				watch := operation.(*parse.WatchParse)
+				method := &template.MethodRender{
+					Name: watch.BelongedToMethod.Name,
+					MethodReceiver: code.MethodReceiver{
+						Name: "r",
+						Type: code.StarExprType{
+							RealType: code.IdentType(ifOperation.BelongedToStruct.Name + "RepositoryMongo"),
+						},
+					},
+					Params:     watch.BelongedToMethod.Params,
+					Returns:    watch.BelongedToMethod.Returns,
+					MethodBody: watchCodegen(watch),
+				}
+				methods = append(methods, method)
+
 			default:
 			}
 		}
@@ -168,7 +185,8 @@ func AddMongoImports(data string) (string, error) {
 		return "", err
 	}
 
-	flagBson, flagMongo, flagOption := false, false, false
+	flagBson, flagMongo, flagOption, flagTime := false, false, false, false
+	flagErrors, flagReadConcern, flagWriteConcern, flagReadPref := false, false, false, false
 	ast.Inspect(file, func(n ast.Node) bool {
 		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "go.mongodb.org/mongo-driver/bson" {
 			flagBson = true
@@ -182,6 +200,26 @@ func AddMongoImports(data string) (string, error) {
 			flagOption = true
 			return false
 		}
+		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "time" {
+			flagTime = true
+			return false
+		}
+		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "errors" {
+			flagErrors = true
+			return false
+		}
+		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "go.mongodb.org/mongo-driver/mongo/readconcern" {
+			flagReadConcern = true
+			return false
+		}
+		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "go.mongodb.org/mongo-driver/mongo/writeconcern" {
+			flagWriteConcern = true
+			return false
+		}
+		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "go.mongodb.org/mongo-driver/mongo/readpref" {
+			flagReadPref = true
+			return false
+		}
 		return true
 	})
 
@@ -201,6 +239,37 @@ func AddMongoImports(data string) (string, error) {
 		}
 	}
 
+	// the current time is used by the timestamps and the soft deletion of the model options,
+	// the durations are used by the transaction options
+	if strings.Contains(data, "time.Now()") || strings.Contains(data, "time.Second") ||
+		strings.Contains(data, "time.Millisecond") || strings.Contains(data, "time.Duration(") {
+		if !flagTime {
+			astutil.AddNamedImport(fSet, file, "", "time")
+		}
+	}
+
+	// the error labels are checked by the retries of the transactions
+	if strings.Contains(data, "errors.As(") {
+		if !flagErrors {
+			astutil.AddNamedImport(fSet, file, "", "errors")
+		}
+	}
+	if strings.Contains(data, "readconcern.") {
+		if !flagReadConcern {
+			astutil.AddNamedImport(fSet, file, "", "go.mongodb.org/mongo-driver/mongo/readconcern")
+		}
+	}
+	if strings.Contains(data, "writeconcern.") {
+		if !flagWriteConcern {
+			astutil.AddNamedImport(fSet, file, "", "go.mongodb.org/mongo-driver/mongo/writeconcern")
+		}
+	}
+	if strings.Contains(data, "readpref.") {
+		if !flagReadPref {
+			astutil.AddNamedImport(fSet, file, "", "go.mongodb.org/mongo-driver/mongo/readpref")
+		}
+	}
+
 	buf := new(bytes.Buffer)
 	if err = printer.Fprint(buf, fSet, file); err != nil {
 		return "", err
@@ -227,13 +296,81 @@ func GetFuncRender(extractStruct *extract.IdlExtractStruct) *template.FuncRender
 			code.IdentType(extractStruct.Name + "Repository"),
 		},
 		FuncBody: code.Body{
-			code.RawStmt("return &" + extractStruct.Name + "RepositoryMongo{\n\tcollection: collection,\n}"),
+			// the registry replaces the registry of the client, the other options of the collection are kept
+			code.RawStmt(fmt.Sprintf("if cloned, err := collection.Clone(options.Collection().SetRegistry(%s.%s())); err == nil {\n"+
+				"\tcollection = cloned\n}", extractStruct.ModelPkg, NewBsonRegistry)),
+			code.RawStmt(getNewRepositoryStmt(extractStruct)),
 		},
 	}
 }
 
+func getNewRepositoryStmt(extractStruct *extract.IdlExtractStruct) string {
+	return "return " + getRepositoryMongoLiteral(extractStruct)
+}
+
+// getRepositoryMongoLiteral returns the mongo repository on the variable collection, which is also
+// built by the decorators without the constructor returning the interface.
+func getRepositoryMongoLiteral(extractStruct *extract.IdlExtractStruct) string {
+	if !extractStruct.Generic {
+		return "&" + extractStruct.Name + "RepositoryMongo{\n\tcollection: collection,\n}"
+	}
+	return fmt.Sprintf("&%sRepositoryMongo{\n\t%s: %s.New[%s.%s](collection),\n\tcollection: collection,\n}",
+		extractStruct.Name, GenericRepository, GenericPkgName, extractStruct.ModelPkg, extractStruct.Name)
+}
+
+// GetFromDBFuncRenders returns the constructors of the repository on the collection declared by the
+// mongo.collection annotation, the constructor from the client is only returned when mongo.database is declared.
+func GetFromDBFuncRenders(extractStruct *extract.IdlExtractStruct) []*template.FuncRender {
+	repository := extractStruct.Name + "Repository"
+	renders := []*template.FuncRender{
+		{
+			Name: "New" + repository + "FromDB",
+			Params: code.Params{
+				code.Param{
+					Name: "db",
+					Type: code.StarExprType{
+						RealType: code.SelectorExprType{
+							X:   "mongo",
+							Sel: "Database",
+						},
+					},
+				},
+			},
+			Returns: code.Returns{
+				code.IdentType(repository),
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("return New%s(db.Collection(%q))", repository, extractStruct.GetCollectionName())),
+			},
+		},
+	}
+	if extractStruct.Database != "" {
+		renders = append(renders, &template.FuncRender{
+			Name: "New" + repository + "FromClient",
+			Params: code.Params{
+				code.Param{
+					Name: "client",
+					Type: code.StarExprType{
+						RealType: code.SelectorExprType{
+							X:   "mongo",
+							Sel: "Client",
+						},
+					},
+				},
+			},
+			Returns: code.Returns{
+				code.IdentType(repository),
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("return New%sFromDB(client.Database(%q))", repository, extractStruct.Database)),
+			},
+		})
+	}
+	return renders
+}
+
 func GetStructRender(extractStruct *extract.IdlExtractStruct) *template.StructRender {
-	return &template.StructRender{
+	render := &template.StructRender{
 		Name: extractStruct.Name + "RepositoryMongo",
 		StructFields: code.StructFields{
 			code.StructField{
@@ -247,4 +384,8 @@ func GetStructRender(extractStruct *extract.IdlExtractStruct) *template.StructRe
 			},
 		},
 	}
+	if extractStruct.Generic {
+		render.StructFields = append(code.StructFields{getGenericStructField(extractStruct)}, render.StructFields...)
+	}
+	return render
 }
@@ -33,7 +33,7 @@ func countCodegen(count *parse.CountParse) []code.Statement {
 				CallName: "CountDocuments",
 				Args: code.ListCommaStmt{
 					code.RawStmt(count.CtxParamName),
-					queryCodegen(count.Query),
+					queryCodegen(optionQuery(count.Query, count.BelongedToMethod.BelongedToStruct)),
 				},
 			},
 		},
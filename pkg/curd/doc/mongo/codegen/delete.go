@@ -22,6 +22,9 @@ import (
 )
 
 func deleteCodegen(delete *parse.DeleteParse) []code.Statement {
+	if delete.BelongedToMethod.BelongedToStruct.Options.SoftDelete {
+		return softDeleteCodegen(delete)
+	}
 	if delete.OperateMode == parse.OperateOne {
 		return []code.Statement{
 			code.DeclColonStmt{
@@ -72,3 +75,39 @@ func deleteCodegen(delete *parse.DeleteParse) []code.Statement {
 		}
 	}
 }
+
+// softDeleteCodegen sets deleted_at of the documents which are not deleted instead of removing them.
+func softDeleteCodegen(delete *parse.DeleteParse) []code.Statement {
+	st := delete.BelongedToMethod.BelongedToStruct
+	now := getLocalName(delete.BelongedToMethod, "now")
+
+	callName, errReturn, deleted := "UpdateOne", "false", "result.MatchedCount > 0"
+	if delete.OperateMode == parse.OperateMany {
+		callName, errReturn, deleted = "UpdateMany", "0", "int(result.MatchedCount)"
+	}
+	return []code.Statement{
+		nowCodegen(now),
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt("result"),
+				code.RawStmt("err"),
+			},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt("r.collection"),
+				CallName: callName,
+				Args: code.ListCommaStmt{
+					code.RawStmt(delete.CtxParamName),
+					queryCodegen(optionQuery(delete.Query, st)),
+					softDeleteFieldsCodegen(st, now),
+				},
+			},
+		},
+		code.RawStmt("if err != nil {\n\treturn " + errReturn + ", err\n}"),
+		code.ReturnStmt{
+			ListCommaStmt: code.ListCommaStmt{
+				code.RawStmt(deleted),
+				code.RawStmt("nil"),
+			},
+		},
+	}
+}
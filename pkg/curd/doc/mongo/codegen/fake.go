@@ -0,0 +1,1156 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+var FakeImports = map[string]string{
+	"context": "",
+	"go.mongodb.org/mongo-driver/bson/primitive": "",
+	"reflect": "",
+	"sort":    "",
+	"strings": "",
+	"sync":    "",
+}
+
+// GetFakeRenders returns the renders of the in-memory fake which implements the repository interface,
+// the fake evaluates the parsed queries, orders, skip, limit and update fields against a go slice,
+// the options of the model are applied the same as the mongo implementation.
+//
+//	input params description:
+//	ifOperation: the parsing results of all methods of the structure, including the methods generated before
+//	methods: all methods of the repository interface
+func GetFakeRenders(ifOperation *parse.InterfaceOperation, methods code.InterfaceMethods) []template.Render {
+	st := ifOperation.BelongedToStruct
+	fakeName := st.Name + "RepositoryFake"
+	modelType := code.StarExprType{RealType: code.SelectorExprType{X: st.ModelPkg, Sel: st.Name}}
+	// the alias is used in the method bodies where the model package may be shadowed by the method params
+	aliasName := "fake" + st.Name
+	entityType := code.StarExprType{RealType: code.IdentType(aliasName)}
+	receiver := code.MethodReceiver{
+		Name: "r",
+		Type: code.StarExprType{RealType: code.IdentType(fakeName)},
+	}
+
+	renders := []template.Render{
+		&template.TypeAliasRender{
+			Name:     aliasName,
+			Comment:  fmt.Sprintf("// %s is an alias of the model which is not shadowed by the method params.", aliasName),
+			RealType: modelType.RealType,
+		},
+		&template.StructRender{
+			Name: fakeName,
+			Comment: fmt.Sprintf("// %s is an in-memory implementation of %sRepository for unit tests,\n"+
+				"// the operations on the collections passed in by Transaction are not evaluated.", fakeName, st.Name),
+			StructFields: code.StructFields{
+				code.StructField{
+					Name: "mu",
+					Type: code.SelectorExprType{X: "sync", Sel: "Mutex"},
+				},
+				code.StructField{
+					Name: "entities",
+					Type: code.SliceType{ElementType: entityType},
+				},
+				code.StructField{
+					Name: "watchers",
+					Type: code.MapType{
+						KeyType:   code.IdentType("int"),
+						ValueType: code.IdentType(fmt.Sprintf("func(e %s)", entityType.RealName())),
+					},
+				},
+				code.StructField{
+					Name: "nextWatcher",
+					Type: code.IdentType("int"),
+				},
+			},
+		},
+		&template.FuncRender{
+			Name:    "New" + fakeName,
+			Comment: fmt.Sprintf("// New%s creates a fake repository which stores the copies of entities.", fakeName),
+			Params: code.Params{
+				code.Param{
+					Name: "entities",
+					Type: code.IdentType("..." + modelType.RealName()),
+				},
+			},
+			Returns: code.Returns{
+				code.StarExprType{RealType: code.IdentType(fakeName)},
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("r := &%s{}", fakeName)),
+				code.RawStmt("for _, entity := range entities {\n\tr.insert(entity)\n}"),
+				code.RawStmt("return r"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "Entities",
+			Comment:        "// Entities returns the copies of the stored entities in insertion order.",
+			MethodReceiver: receiver,
+			Returns: code.Returns{
+				code.SliceType{ElementType: modelType},
+			},
+			MethodBody: code.Body{
+				code.RawStmt("r.mu.Lock()"),
+				code.RawStmt("defer r.mu.Unlock()"),
+				code.RawStmt(fmt.Sprintf("return r.find(func(e %s) bool {\n\treturn true\n})", entityType.RealName())),
+			},
+		},
+	}
+	renders = append(renders, fakeStorageRenders(st, receiver, entityType)...)
+
+	operations := make(map[string]parse.Operation, len(ifOperation.Operations))
+	for _, operation := range ifOperation.Operations {
+		operations[parse.GetBelongedToMethod(operation).Name] = operation
+	}
+	for _, method := range methods {
+		var body code.Body
+		if operation, ok := operations[method.Name]; ok {
+			body = fakeOperationCodegen(operation, entityType)
+		} else if IsGenericMethod(st, method.Name) {
+			body = fakeGenericCodegen(method.Name, entityType)
+		} else {
+			// methods such as EnsureIndexes have no effect on the fake
+			body = code.Body{code.RawStmt("return nil")}
+		}
+		renders = append(renders, &template.MethodRender{
+			Name:           method.Name,
+			MethodReceiver: receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody:     body,
+		})
+	}
+
+	return append(renders, fakeHelperRenders()...)
+}
+
+// fakeStorageRenders returns the unlocked methods which operate the stored entities,
+// the exported methods hold the lock and call them.
+func fakeStorageRenders(st *extract.IdlExtractStruct, receiver code.MethodReceiver, entityType code.Type) []template.Render {
+	entity := entityType.RealName()
+	matchFunc := code.IdentType(fmt.Sprintf("func(e %s) bool", entity))
+	setFunc := code.IdentType(fmt.Sprintf("func(e %s)", entity))
+
+	renders := []template.Render{
+		&template.MethodRender{
+			Name:           "insert",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				code.Param{Name: "entity", Type: entityType},
+			},
+			Comment: "// insert returns the _id of the entity, the ObjectID is generated if _id is not set as the driver does.",
+			Returns: code.Returns{code.InterfaceType{}},
+			MethodBody: code.Body{
+				code.RawStmt("stored := *entity"),
+				code.RawStmt("id, ok := fakeField(entity, \"_id\")"),
+				code.RawStmt("if !ok || reflect.ValueOf(id).IsZero() {\n" +
+					"\tobjectID := primitive.NewObjectID()\n" +
+					"\tif _, isObjectID := id.(primitive.ObjectID); isObjectID {\n" +
+					"\t\tfakeSetField(&stored, \"_id\", objectID)\n" +
+					"\t}\n" +
+					"\tid = objectID\n" +
+					"}"),
+				code.RawStmt("r.entities = append(r.entities, &stored)"),
+				code.RawStmt("r.changed(&stored)"),
+				code.RawStmt("return id"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "find",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				code.Param{Name: "match", Type: matchFunc},
+			},
+			Returns: code.Returns{code.SliceType{ElementType: entityType}},
+			MethodBody: code.Body{
+				code.RawStmt(fmt.Sprintf("result := make([]%s, 0)", entity)),
+				code.RawStmt("for _, e := range r.entities {\n" +
+					"\tif match(e) {\n" +
+					"\t\tfound := *e\n" +
+					"\t\tresult = append(result, &found)\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("return result"),
+			},
+		},
+		&template.MethodRender{
+			Name: "update",
+			Comment: "// update returns the number of matched and upserted entities,\n" +
+				"// seed is used to initialize the entity inserted by upsert, nil means no upsert.",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				code.Param{Name: "match", Type: matchFunc},
+				code.Param{Name: "set", Type: setFunc},
+				code.Param{Name: "many", Type: code.IdentType("bool")},
+				code.Param{Name: "seed", Type: setFunc},
+			},
+			Returns: code.Returns{code.IdentType("int"), code.IdentType("int")},
+			MethodBody: code.Body{
+				code.RawStmt("matched := 0"),
+				code.RawStmt("for _, e := range r.entities {\n" +
+					"\tif !match(e) {\n" +
+					"\t\tcontinue\n" +
+					"\t}\n" +
+					"\tset(e)\n" +
+					"\tr.changed(e)\n" +
+					"\tif matched++; !many {\n" +
+					"\t\tbreak\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("if matched > 0 || seed == nil {\n\treturn matched, 0\n}"),
+				code.RawStmt(fmt.Sprintf("entity := &%s{}", entityType.(code.StarExprType).RealType.RealName())),
+				code.RawStmt("seed(entity)"),
+				code.RawStmt("set(entity)"),
+				code.RawStmt("r.entities = append(r.entities, entity)"),
+				code.RawStmt("r.changed(entity)"),
+				code.RawStmt("return 0, 1"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "delete",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				code.Param{Name: "match", Type: matchFunc},
+				code.Param{Name: "many", Type: code.IdentType("bool")},
+			},
+			Returns: code.Returns{code.IdentType("int")},
+			MethodBody: code.Body{
+				code.RawStmt("deleted := 0"),
+				code.RawStmt("for i := 0; i < len(r.entities); {\n" +
+					"\tif (many || deleted == 0) && match(r.entities[i]) {\n" +
+					"\t\tr.entities = append(r.entities[:i], r.entities[i+1:]...)\n" +
+					"\t\tdeleted++\n" +
+					"\t\tcontinue\n" +
+					"\t}\n" +
+					"\ti++\n" +
+					"}"),
+				code.RawStmt("return deleted"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "changed",
+			Comment:        "// changed notifies the watchers of the inserted or updated entity.",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				code.Param{Name: "entity", Type: entityType},
+			},
+			MethodBody: code.Body{
+				code.RawStmt("for _, watcher := range r.watchers {\n\twatcher(entity)\n}"),
+			},
+		},
+		&template.MethodRender{
+			Name: "watch",
+			Comment: "// watch sends the copies of the entities changed later which are matched to the returned channel\n" +
+				"// in order until ctx is done, the changes are queued so that the stored entities are not blocked,\n" +
+				"// the watcher is removed when ctx is done.",
+			MethodReceiver: receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: code.SelectorExprType{X: "context", Sel: "Context"}},
+				code.Param{Name: "match", Type: matchFunc},
+			},
+			Returns: code.Returns{code.ChanType{Dir: code.ChanRecv, ElementType: entityType}},
+			MethodBody: code.Body{
+				code.RawStmt(fmt.Sprintf("entities := make(chan %s)", entity)),
+				code.RawStmt("notify := make(chan struct{}, 1)"),
+				code.RawStmt("var mu sync.Mutex"),
+				code.RawStmt(fmt.Sprintf("var queue []%s", entity)),
+				code.RawStmt("if r.watchers == nil {\n" +
+					fmt.Sprintf("\tr.watchers = make(map[int]func(e %s))\n", entity) +
+					"}"),
+				code.RawStmt("id := r.nextWatcher"),
+				code.RawStmt("r.nextWatcher++"),
+				code.RawStmt(fmt.Sprintf("r.watchers[id] = func(e %s) {\n", entity) +
+					"\tif ctx.Err() != nil || !match(e) {\n" +
+					"\t\treturn\n" +
+					"\t}\n" +
+					"\tchanged := *e\n" +
+					"\tmu.Lock()\n" +
+					"\tqueue = append(queue, &changed)\n" +
+					"\tmu.Unlock()\n" +
+					"\tselect {\n" +
+					"\tcase notify <- struct{}{}:\n" +
+					"\tdefault:\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("go func() {\n" +
+					"\tdefer close(entities)\n" +
+					"\tdefer func() {\n" +
+					"\t\tr.mu.Lock()\n" +
+					"\t\tdelete(r.watchers, id)\n" +
+					"\t\tr.mu.Unlock()\n" +
+					"\t}()\n" +
+					"\tfor {\n" +
+					"\t\tmu.Lock()\n" +
+					"\t\tif len(queue) == 0 {\n" +
+					"\t\t\tmu.Unlock()\n" +
+					"\t\t\tselect {\n" +
+					"\t\t\tcase <-notify:\n" +
+					"\t\t\t\tcontinue\n" +
+					"\t\t\tcase <-ctx.Done():\n" +
+					"\t\t\t\treturn\n" +
+					"\t\t\t}\n" +
+					"\t\t}\n" +
+					"\t\te := queue[0]\n" +
+					"\t\tqueue = queue[1:]\n" +
+					"\t\tmu.Unlock()\n" +
+					"\t\tselect {\n" +
+					"\t\tcase entities <- e:\n" +
+					"\t\tcase <-ctx.Done():\n" +
+					"\t\t\treturn\n" +
+					"\t\t}\n" +
+					"\t}\n" +
+					"}()"),
+				code.RawStmt("return entities"),
+			},
+		},
+	}
+	if !st.Options.SoftDelete {
+		return renders
+	}
+
+	softDelete := code.Body{
+		code.RawStmt("deleted := 0"),
+	}
+	set := "\t" + assignFieldCodegen(st, "e", extract.DeletedAtField, "now").Code() + "\n"
+	if st.Options.Timestamps {
+		set += "\t" + assignFieldCodegen(st, "e", extract.UpdatedAtField, "now").Code() + "\n"
+	}
+	softDelete = append(softDelete,
+		code.RawStmt("for _, e := range r.entities {\n"+
+			"\tif !match(e) {\n"+
+			"\t\tcontinue\n"+
+			"\t}\n"+
+			set+
+			"\tr.changed(e)\n"+
+			"\tif deleted++; !many {\n"+
+			"\t\tbreak\n"+
+			"\t}\n"+
+			"}"),
+		code.RawStmt("return deleted"),
+	)
+	return append(renders, &template.MethodRender{
+		Name:           "softDelete",
+		Comment:        "// softDelete sets deleted_at of the matched entities instead of removing them.",
+		MethodReceiver: receiver,
+		Params: code.Params{
+			code.Param{Name: "match", Type: matchFunc},
+			code.Param{Name: "many", Type: code.IdentType("bool")},
+			code.Param{Name: "now", Type: code.IdentType("int64")},
+		},
+		Returns:    code.Returns{code.IdentType("int")},
+		MethodBody: softDelete,
+	})
+}
+
+func fakeOperationCodegen(operation parse.Operation, entityType code.Type) code.Body {
+	method := parse.GetBelongedToMethod(operation)
+	st := method.BelongedToStruct
+	now := getLocalName(method, "now")
+	body := code.Body{
+		code.RawStmt("r.mu.Lock()"),
+		code.RawStmt("defer r.mu.Unlock()"),
+	}
+	// the operations on the collections passed in by Transaction are not evaluated
+	operations := []parse.Operation{operation}
+	if transaction, ok := operation.(*parse.TransactionParse); ok {
+		operations = operations[:0]
+		for _, taOperation := range transaction.TransactionOperations {
+			if taOperation.CollectionParamName == parse.DefaultCollection {
+				operations = append(operations, taOperation.Operation)
+			}
+		}
+	}
+	if optionNowRequired(operations) {
+		body = append(body, nowCodegen(now))
+	}
+
+	switch op := operation.(type) {
+	case *parse.InsertParse:
+		if op.OperateMode == parse.OperateOne {
+			body = append(body, createTimestampsCodegen(st, op.MethodParamNames[1], now)...)
+			return append(body, code.RawStmt(fmt.Sprintf("return r.insert(%s), nil", op.MethodParamNames[1])))
+		}
+		return append(body,
+			code.RawStmt(fmt.Sprintf("ids := make([]interface{}, 0, len(%s))", op.MethodParamNames[1])),
+			code.RawStmt(fmt.Sprintf("for _, entity := range %s {\n%sids = append(ids, r.insert(entity))\n}",
+				op.MethodParamNames[1], fakeStatementsCode(createTimestampsCodegen(st, "entity", now)))),
+			code.RawStmt("return ids, nil"),
+		)
+
+	case *parse.FindParse:
+		return fakeFindCodegen(op, entityType)
+
+	case *parse.UpdateParse:
+		statements, call, restoreVersion := fakeUpdateCodegen(op, entityType, now)
+		body = append(body, statements...)
+		body = append(body, code.RawStmt("matched, _ := "+call))
+		if restoreVersion != "" {
+			body = append(body, code.RawStmt(fmt.Sprintf("if matched == 0 {\n\t%s\n}", restoreVersion)))
+		}
+		if op.OperateMode == parse.OperateOne {
+			return append(body, code.RawStmt("return matched > 0, nil"))
+		}
+		return append(body, code.RawStmt("return matched, nil"))
+
+	case *parse.DeleteParse:
+		body = append(body, code.RawStmt("deleted := "+fakeDeleteCallCodegen(op, entityType, now)))
+		if op.OperateMode == parse.OperateOne {
+			return append(body, code.RawStmt("return deleted > 0, nil"))
+		}
+		return append(body, code.RawStmt("return deleted, nil"))
+
+	case *parse.CountParse:
+		return append(body, code.RawStmt(fmt.Sprintf("return len(r.find(%s)), nil",
+			fakeMatchFuncCodegen(optionQuery(op.Query, st), entityType))))
+
+	case *parse.WatchParse:
+		return append(body, code.RawStmt(fmt.Sprintf("return r.watch(%s, %s), nil", op.CtxParamName,
+			fakeMatchFuncCodegen(optionQuery(op.Query, st), entityType))))
+
+	case *parse.BulkParse:
+		body = append(body, code.RawStmt("result := &mongo.BulkWriteResult{}"))
+		body = append(body, fakeBulkCodegen(op, entityType, "result", now)...)
+		return append(body, code.RawStmt("return result, nil"))
+
+	case *parse.TransactionParse:
+		for _, taOperation := range operations {
+			switch taOp := taOperation.(type) {
+			case *parse.InsertParse:
+				if taOp.OperateMode == parse.OperateOne {
+					body = append(body, createTimestampsCodegen(st, taOp.MethodParamNames[0], now)...)
+					body = append(body, code.RawStmt(fmt.Sprintf("r.insert(%s)", taOp.MethodParamNames[0])))
+				} else {
+					body = append(body, code.RawStmt(fmt.Sprintf("for _, entity := range %s {\n%sr.insert(entity)\n}",
+						taOp.MethodParamNames[0], fakeStatementsCode(createTimestampsCodegen(st, "entity", now)))))
+				}
+			case *parse.UpdateParse:
+				statements, call, _ := fakeUpdateCodegen(taOp, entityType, now)
+				body = append(body, statements...)
+				body = append(body, code.RawStmt(call))
+			case *parse.DeleteParse:
+				body = append(body, code.RawStmt(fakeDeleteCallCodegen(taOp, entityType, now)))
+			case *parse.BulkParse:
+				body = append(body, fakeBulkCodegen(taOp, entityType, "", now)...)
+			}
+		}
+		return append(body, code.RawStmt("return nil"))
+
+	default:
+		return append(body, code.RawStmt("return nil"))
+	}
+}
+
+// fakeStatementsCode returns the code of the statements in lines, which is put into the loops.
+func fakeStatementsCode(statements []code.Statement) string {
+	result := ""
+	for _, statement := range statements {
+		result += statement.Code() + "\n"
+	}
+	return result
+}
+
+// fakeGenericCodegen returns the body of the method of the generic repository, the entities are matched by _id.
+func fakeGenericCodegen(name string, entityType code.Type) code.Body {
+	body := code.Body{
+		code.RawStmt("r.mu.Lock()"),
+		code.RawStmt("defer r.mu.Unlock()"),
+	}
+	matchID := fmt.Sprintf("func(e %s) bool {\n\treturn fakeMatch(e, \"_id\", \"$eq\", id)\n}", entityType.RealName())
+
+	switch name {
+	case GenericInsert:
+		return append(body, code.RawStmt("return r.insert(entity), nil"))
+	case GenericFindByID:
+		return append(body,
+			code.RawStmt(fmt.Sprintf("entities := r.find(%s)", matchID)),
+			code.RawStmt("if len(entities) == 0 {\n\treturn nil, mongo.ErrNoDocuments\n}"),
+			code.RawStmt("return entities[0], nil"),
+		)
+	case GenericDeleteByID:
+		return append(body, code.RawStmt(fmt.Sprintf("return r.delete(%s, false) > 0, nil", matchID)))
+	default:
+		return append(body, code.RawStmt("return len(r.entities), nil"))
+	}
+}
+
+func fakeFindCodegen(find *parse.FindParse, entityType code.Type) code.Body {
+	entities, nextCursor := getLocalName(find.BelongedToMethod, "entities"), getLocalName(find.BelongedToMethod, "nextCursor")
+	body := code.Body{}
+	if find.ReturnCursor {
+		body = append(body, code.DeclVarStmt{
+			Name: nextCursor,
+			Type: find.Keyset.FieldType,
+		})
+	}
+	if pageRevealStmt := pageRevealCodegen(find); pageRevealStmt != nil && find.OperateMode == parse.OperateMany {
+		body = append(body, pageRevealStmt)
+	}
+	query := optionQuery(find.Query, find.BelongedToMethod.BelongedToStruct)
+	match := fakeMatchFuncCodegen(query, entityType)
+	if find.Keyset != nil && find.Keyset.Nullable {
+		// the nil cursor queries the first page
+		condition := "true"
+		if query.QueryMode == parse.By {
+			condition = fakeChildConditionCodegen(query.ConnectionOpTree)
+		}
+		match = fmt.Sprintf("func(e %s) bool {\nreturn %s && (%s == nil || %s)\n}", entityType.RealName(), condition,
+			find.Keyset.ParamName, fakeConditionCodegen(find.Keyset.Condition()))
+	}
+	body = append(body,
+		code.RawStmt("r.mu.Lock()"),
+		code.RawStmt("defer r.mu.Unlock()"),
+		code.RawStmt(entities+" := r.find("+match+")"),
+	)
+
+	order := find.Order
+	// Before sorts the entities closest to the cursor first, the same as the mongo implementation
+	if find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		order = parse.Order{Asc: find.Order.Desc, Desc: find.Order.Asc}
+	}
+	sortKeys := make([]string, 0, len(order.Asc)+len(order.Desc))
+	for _, field := range order.Asc {
+		sortKeys = append(sortKeys, strconv.Quote(field))
+	}
+	for _, field := range order.Desc {
+		sortKeys = append(sortKeys, strconv.Quote("-"+field))
+	}
+	if len(sortKeys) != 0 {
+		body = append(body, code.RawStmt(fmt.Sprintf("fakeSort(%s, %s)", entities, strings.Join(sortKeys, ", "))))
+	}
+
+	if find.SkipParamName != "" {
+		body = append(body, code.RawStmt(fmt.Sprintf("if int(%s) < len(%s) {\n"+
+			"\t%s = %s[%s:]\n"+
+			"} else {\n"+
+			"\t%s = %s[:0]\n"+
+			"}", find.SkipParamName, entities, entities, entities, find.SkipParamName, entities, entities)))
+	}
+	if find.LimitParamName != "" && find.OperateMode == parse.OperateMany {
+		body = append(body, code.RawStmt(fmt.Sprintf("if %s > 0 && int(%s) < len(%s) {\n"+
+			"\t%s = %s[:%s]\n"+
+			"}", find.LimitParamName, find.LimitParamName, entities, entities, entities, find.LimitParamName)))
+	}
+
+	project := ""
+	if len(find.Project) != 0 {
+		fields := make([]string, 0, len(find.Project))
+		for _, field := range find.Project {
+			fields = append(fields, strconv.Quote(field))
+		}
+		project = "fakeProject(entity, " + strings.Join(fields, ", ") + ")"
+	}
+
+	if find.OperateMode == parse.OperateOne {
+		body = append(body, code.RawStmt(fmt.Sprintf("if len(%s) == 0 {\n\treturn nil, mongo.ErrNoDocuments\n}", entities)))
+		if project != "" {
+			body = append(body, code.RawStmt(fmt.Sprintf("entity := %s[0]", entities)), code.RawStmt(project),
+				code.RawStmt("return entity, nil"))
+			return body
+		}
+		return append(body, code.RawStmt(fmt.Sprintf("return %s[0], nil", entities)))
+	}
+
+	if project != "" {
+		body = append(body, code.RawStmt(fmt.Sprintf("for _, entity := range %s {\n\t%s\n}", entities, project)))
+	}
+	return append(body, keysetCodegen(find, entities, nextCursor)...)
+}
+
+// fakeUpdateCodegen returns the statements before the call of the update method and the call, the equality
+// conditions of the query are used to initialize the entity inserted by upsert. The version of the whole entity
+// is increased before the call and restoreVersion restores it if no entity is matched, the same as updateCodegen.
+func fakeUpdateCodegen(update *parse.UpdateParse, entityType code.Type, now string) (statements []code.Statement,
+	call, restoreVersion string,
+) {
+	st := update.BelongedToMethod.BelongedToStruct
+	query := optionQuery(update.Query, st)
+	set, seed := "", ""
+	if update.UpdateStructObjName != "" {
+		entity := update.UpdateStructObjName
+		if st.Options.Timestamps {
+			statements = append(statements, assignFieldCodegen(st, entity, extract.UpdatedAtField, now))
+		}
+		if st.Options.Version {
+			version := getLocalName(update.BelongedToMethod, "version")
+			goPath, _, _ := st.GetFieldByMongoName(extract.VersionField)
+			statements = append(statements,
+				code.RawStmt(fmt.Sprintf("%s := %s.%s", version, entity, goPath)),
+				code.RawStmt(fmt.Sprintf("%s.%s = %s + 1", entity, goPath, version)),
+			)
+			if !update.Upsert {
+				query = andQuery(query, &parse.ConnectionOpTree{
+					Name:           string(parse.Equal),
+					MongoFieldName: extract.VersionField,
+					ParamNames:     []string{version},
+				})
+			}
+			restoreVersion = fmt.Sprintf("%s.%s = %s", entity, goPath, version)
+		}
+		set = fakeKeepCreatedAtCodegen(update.BelongedToMethod, fmt.Sprintf("fakeSetAll(e, %s)\n", entity), now)
+		if st.Options.Timestamps && update.Upsert {
+			seed += assignFieldCodegen(st, "e", extract.CreatedAtField, now).Code() + "\n"
+		}
+	} else {
+		isUpdated := func(mongoName string) bool {
+			for _, field := range update.UpdateFields {
+				if field.MongoFieldName == mongoName {
+					return true
+				}
+			}
+			return false
+		}
+		for _, field := range update.UpdateFields {
+			set += fmt.Sprintf("fakeSetField(e, %s, %s)\n", strconv.Quote(field.MongoFieldName), field.ParamName)
+		}
+		if st.Options.Timestamps && !isUpdated(extract.UpdatedAtField) {
+			set += assignFieldCodegen(st, "e", extract.UpdatedAtField, now).Code() + "\n"
+		}
+		if st.Options.Timestamps && update.Upsert && !isUpdated(extract.CreatedAtField) {
+			seed += assignFieldCodegen(st, "e", extract.CreatedAtField, now).Code() + "\n"
+		}
+		if st.Options.Version && !isUpdated(extract.VersionField) {
+			goPath, _, _ := st.GetFieldByMongoName(extract.VersionField)
+			set += fmt.Sprintf("e.%s++\n", goPath)
+		}
+	}
+
+	seedFunc := "nil"
+	if update.Upsert {
+		if update.Query.QueryMode == parse.By {
+			seed = fakeSeedCodegen(update.Query.ConnectionOpTree) + seed
+		}
+		seedFunc = fmt.Sprintf("func(e %s) {\n%s}", entityType.RealName(), seed)
+	}
+
+	return statements, fmt.Sprintf("r.update(%s, func(e %s) {\n%s}, %t, %s)", fakeMatchFuncCodegen(query, entityType),
+		entityType.RealName(), set, update.OperateMode == parse.OperateMany, seedFunc), restoreVersion
+}
+
+// fakeReplaceCodegen returns the statements before the call of the update method which replaces the matched entity
+// except its _id and the call, the same as ReplaceOne.
+func fakeReplaceCodegen(replace *parse.ReplaceParse, entityType code.Type, now string) ([]code.Statement, string) {
+	st := replace.BelongedToMethod.BelongedToStruct
+	var statements []code.Statement
+	if st.Options.Timestamps {
+		statements = append(statements, assignFieldCodegen(st, replace.ReplacementParamName, extract.UpdatedAtField, now))
+	}
+	set := fakeKeepCreatedAtCodegen(replace.BelongedToMethod, fmt.Sprintf("*e = *%s\n", replace.ReplacementParamName), now)
+	return statements, fmt.Sprintf("r.update(%s, func(e %s) {\n"+
+		"oid, _ := fakeField(e, \"_id\")\n"+
+		"%s"+
+		"fakeSetField(e, \"_id\", oid)\n"+
+		"}, false, nil)", fakeMatchFuncCodegen(optionQuery(replace.Query, st), entityType), entityType.RealName(), set)
+}
+
+// fakeKeepCreatedAtCodegen returns the statements which write the whole entity to e and keep created_at of e,
+// which is now if it is nil, the same as the update pipeline of the model with timestamps.
+func fakeKeepCreatedAtCodegen(method *extract.InterfaceMethod, write, now string) string {
+	st := method.BelongedToStruct
+	if !st.Options.Timestamps {
+		return write
+	}
+	createdAt := getLocalName(method, "createdAt")
+	goPath, t, _ := st.GetFieldByMongoName(extract.CreatedAtField)
+	result := fmt.Sprintf("%s := e.%s\n%se.%s = %s\n", createdAt, goPath, write, goPath, createdAt)
+	if _, ok := t.(code.StarExprType); ok {
+		result += fmt.Sprintf("if e.%s == nil {\n%s\n}\n", goPath, assignFieldCodegen(st, "e", extract.CreatedAtField, now).Code())
+	}
+	return result
+}
+
+// fakeDeleteCallCodegen returns the call which returns the number of the deleted entities,
+// the entities of the model with soft_delete are soft deleted.
+func fakeDeleteCallCodegen(del *parse.DeleteParse, entityType code.Type, now string) string {
+	st := del.BelongedToMethod.BelongedToStruct
+	if st.Options.SoftDelete {
+		return fmt.Sprintf("r.softDelete(%s, %t, %s)", fakeMatchFuncCodegen(optionQuery(del.Query, st), entityType),
+			del.OperateMode == parse.OperateMany, now)
+	}
+	return fmt.Sprintf("r.delete(%s, %t)", fakeMatchFuncCodegen(del.Query, entityType),
+		del.OperateMode == parse.OperateMany)
+}
+
+// fakeBulkCodegen returns the statements of the bulk operations, the counts are accumulated
+// to result when result is not empty, the soft deleted entities are counted as modified.
+func fakeBulkCodegen(bulk *parse.BulkParse, entityType code.Type, result, now string) code.Body {
+	body := code.Body{}
+	// upserted is only declared when it is accumulated by Update
+	declaration, declared := "matched := 0", false
+	for _, operation := range bulk.Operations {
+		if _, ok := operation.(*parse.UpdateParse); ok {
+			declaration = "matched, upserted := 0, 0"
+		}
+	}
+	declare := func() {
+		if !declared {
+			body = append(body, code.RawStmt(declaration))
+			declared = true
+		}
+	}
+	for _, operation := range bulk.Operations {
+		st := parse.GetBelongedToMethod(operation).BelongedToStruct
+		switch op := operation.(type) {
+		case *parse.InsertParse:
+			if op.OperateMode == parse.OperateMany {
+				body = append(body, code.RawStmt(fmt.Sprintf("for _, entity := range %s {\n%sr.insert(entity)\n}",
+					op.MethodParamNames[0], fakeStatementsCode(createTimestampsCodegen(st, "entity", now)))))
+				if result != "" {
+					body = append(body, code.RawStmt(fmt.Sprintf("%s.InsertedCount += int64(len(%s))", result,
+						op.MethodParamNames[0])))
+				}
+				continue
+			}
+			body = append(body, createTimestampsCodegen(st, op.MethodParamNames[0], now)...)
+			body = append(body, code.RawStmt(fmt.Sprintf("r.insert(%s)", op.MethodParamNames[0])))
+			if result != "" {
+				body = append(body, code.RawStmt(result+".InsertedCount++"))
+			}
+		case *parse.ReplaceParse:
+			statements, call := fakeReplaceCodegen(op, entityType, now)
+			body = append(body, statements...)
+			if result == "" {
+				body = append(body, code.RawStmt(call))
+				continue
+			}
+			declare()
+			body = append(body,
+				code.RawStmt("matched, _ = "+call),
+				code.RawStmt(fmt.Sprintf("%s.MatchedCount += int64(matched)", result)),
+				code.RawStmt(fmt.Sprintf("%s.ModifiedCount += int64(matched)", result)),
+			)
+		case *parse.UpdateParse:
+			statements, call, _ := fakeUpdateCodegen(op, entityType, now)
+			body = append(body, statements...)
+			if result == "" {
+				body = append(body, code.RawStmt(call))
+				continue
+			}
+			declare()
+			body = append(body,
+				code.RawStmt("matched, upserted = "+call),
+				code.RawStmt(fmt.Sprintf("%s.MatchedCount += int64(matched)", result)),
+				code.RawStmt(fmt.Sprintf("%s.ModifiedCount += int64(matched)", result)),
+				code.RawStmt(fmt.Sprintf("%s.UpsertedCount += int64(upserted)", result)),
+			)
+		case *parse.DeleteParse:
+			if result == "" {
+				body = append(body, code.RawStmt(fakeDeleteCallCodegen(op, entityType, now)))
+				continue
+			}
+			if !st.Options.SoftDelete {
+				body = append(body, code.RawStmt(fmt.Sprintf("%s.DeletedCount += int64(%s)", result,
+					fakeDeleteCallCodegen(op, entityType, now))))
+				continue
+			}
+			declare()
+			body = append(body,
+				code.RawStmt("matched = "+fakeDeleteCallCodegen(op, entityType, now)),
+				code.RawStmt(fmt.Sprintf("%s.MatchedCount += int64(matched)", result)),
+				code.RawStmt(fmt.Sprintf("%s.ModifiedCount += int64(matched)", result)),
+			)
+		}
+	}
+	return body
+}
+
+func fakeMatchFuncCodegen(query *parse.Query, entityType code.Type) string {
+	condition := "true"
+	if query.QueryMode == parse.By {
+		condition = fakeConditionCodegen(query.ConnectionOpTree)
+	}
+	return fmt.Sprintf("func(e %s) bool {\nreturn %s\n}", entityType.RealName(), condition)
+}
+
+// fakeConditionCodegen converts the query tree to the go boolean expression evaluated on the entity e.
+func fakeConditionCodegen(node *parse.ConnectionOpTree) string {
+	// none-leaves node
+	if node.LeftChildren != nil {
+		connection := " && "
+		if node.Name == string(parse.Or) {
+			connection = " || "
+		}
+		return fakeChildConditionCodegen(node.LeftChildren) + connection + fakeChildConditionCodegen(node.RightChildren)
+	}
+
+	field := strconv.Quote(node.MongoFieldName)
+	match := func(op, value string) string {
+		return fmt.Sprintf("fakeMatch(e, %s, %q, %s)", field, op, value)
+	}
+
+	switch parse.QueryComparator(node.Name) {
+	case parse.Equal:
+		return match("$eq", node.Values()[0])
+	case parse.NotEqual:
+		return match("$ne", node.Values()[0])
+	case parse.LessThan:
+		return match("$lt", node.Values()[0])
+	case parse.LessThanEqual:
+		return match("$lte", node.Values()[0])
+	case parse.GreaterThan:
+		return match("$gt", node.Values()[0])
+	case parse.GreaterThanEqual:
+		return match("$gte", node.Values()[0])
+	case parse.Between:
+		return match("$gte", node.Values()[0]) + " && " + match("$lte", node.Values()[1])
+	case parse.NotBetween:
+		return "(" + match("$lt", node.Values()[0]) + " || " + match("$gt", node.Values()[1]) + ")"
+	case parse.In:
+		return match("$in", node.Values()[0])
+	case parse.NotIn:
+		return match("$nin", node.Values()[0])
+	case parse.True:
+		return match("$eq", "true")
+	case parse.False:
+		return match("$eq", "false")
+	case parse.Exists:
+		return match("$exists", "true")
+	case parse.NotExists:
+		return match("$exists", "false")
+	default:
+		return "false"
+	}
+}
+
+func fakeChildConditionCodegen(node *parse.ConnectionOpTree) string {
+	if node.LeftChildren != nil {
+		return "(" + fakeConditionCodegen(node) + ")"
+	}
+	return fakeConditionCodegen(node)
+}
+
+// fakeSeedCodegen sets the fields compared by equality in the And conditions, the same as the upsert of mongo.
+func fakeSeedCodegen(node *parse.ConnectionOpTree) string {
+	if node.LeftChildren != nil {
+		if node.Name == string(parse.Or) {
+			return ""
+		}
+		return fakeSeedCodegen(node.LeftChildren) + fakeSeedCodegen(node.RightChildren)
+	}
+
+	field := strconv.Quote(node.MongoFieldName)
+	switch parse.QueryComparator(node.Name) {
+	case parse.Equal:
+		return fmt.Sprintf("fakeSetField(e, %s, %s)\n", field, node.Values()[0])
+	case parse.True:
+		return fmt.Sprintf("fakeSetField(e, %s, true)\n", field)
+	case parse.False:
+		return fmt.Sprintf("fakeSetField(e, %s, false)\n", field)
+	default:
+		return ""
+	}
+}
+
+// fakeHelperRenders returns the functions which access the fields of the entities by the bson tags,
+// they are generated into each fake file so that the generated code has no extra dependencies.
+func fakeHelperRenders() []template.Render {
+	interfaceType := code.InterfaceType{}
+	stringType := code.IdentType("string")
+
+	return []template.Render{
+		&template.FuncRender{
+			Name: "fakeField",
+			Comment: "// fakeField returns the value of the field specified by the dotted mongo field name,\n" +
+				"// the zero fields tagged with omitempty are missing as they are not stored.",
+			Params: code.Params{
+				code.Param{Name: "entity", Type: interfaceType},
+				code.Param{Name: "mongoName", Type: stringType},
+			},
+			Returns: code.Returns{interfaceType, code.IdentType("bool")},
+			FuncBody: code.Body{
+				code.RawStmt("v := reflect.ValueOf(entity)"),
+				code.RawStmt("for _, name := range strings.Split(mongoName, \".\") {\n" +
+					"\tfor v.Kind() == reflect.Ptr {\n" +
+					"\t\tif v.IsNil() {\n" +
+					"\t\t\treturn nil, false\n" +
+					"\t\t}\n" +
+					"\t\tv = v.Elem()\n" +
+					"\t}\n" +
+					"\tfield, omitempty := fakeStructField(v, name)\n" +
+					"\tif !field.IsValid() || omitempty && field.IsZero() {\n" +
+					"\t\treturn nil, false\n" +
+					"\t}\n" +
+					"\tv = field\n" +
+					"}"),
+				code.RawStmt("return v.Interface(), true"),
+			},
+		},
+		&template.FuncRender{
+			Name: "fakeSetField",
+			Comment: "// fakeSetField sets the field specified by the dotted mongo field name,\n" +
+				"// the nil structure pointers on the path are allocated.",
+			Params: code.Params{
+				code.Param{Name: "entity", Type: interfaceType},
+				code.Param{Name: "mongoName", Type: stringType},
+				code.Param{Name: "value", Type: interfaceType},
+			},
+			FuncBody: code.Body{
+				code.RawStmt("v := reflect.ValueOf(entity)"),
+				code.RawStmt("for _, name := range strings.Split(mongoName, \".\") {\n" +
+					"\tfor v.Kind() == reflect.Ptr {\n" +
+					"\t\tif v.IsNil() {\n" +
+					"\t\t\tv.Set(reflect.New(v.Type().Elem()))\n" +
+					"\t\t}\n" +
+					"\t\tv = v.Elem()\n" +
+					"\t}\n" +
+					"\tif v, _ = fakeStructField(v, name); !v.IsValid() {\n" +
+					"\t\treturn\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("if value == nil {\n" +
+					"\tv.Set(reflect.Zero(v.Type()))\n" +
+					"\treturn\n" +
+					"}"),
+				code.RawStmt("v.Set(reflect.ValueOf(value))"),
+			},
+		},
+		&template.FuncRender{
+			Name:    "fakeStructField",
+			Comment: "// fakeStructField returns the field of the structure by the bson tag and whether it is tagged with omitempty.",
+			Params: code.Params{
+				code.Param{Name: "v", Type: code.SelectorExprType{X: "reflect", Sel: "Value"}},
+				code.Param{Name: "name", Type: stringType},
+			},
+			Returns: code.Returns{code.SelectorExprType{X: "reflect", Sel: "Value"}, code.IdentType("bool")},
+			FuncBody: code.Body{
+				code.RawStmt("if v.Kind() != reflect.Struct {\n\treturn reflect.Value{}, false\n}"),
+				code.RawStmt("for i := 0; i < v.NumField(); i++ {\n" +
+					"\ttag := v.Type().Field(i).Tag.Get(\"bson\")\n" +
+					"\tif strings.Split(tag, \",\")[0] == name {\n" +
+					"\t\treturn v.Field(i), strings.Contains(tag, \",omitempty\")\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("return reflect.Value{}, false"),
+			},
+		},
+		&template.FuncRender{
+			Name: "fakeSetAll",
+			Comment: "// fakeSetAll sets all fields of src to dst except _id, the zero fields tagged with omitempty\n" +
+				"// are skipped, the same as $set a structure.",
+			Params: code.Params{
+				code.Param{Name: "dst", Type: interfaceType},
+				code.Param{Name: "src", Type: interfaceType},
+			},
+			FuncBody: code.Body{
+				code.RawStmt("d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()"),
+				code.RawStmt("for i := 0; i < s.NumField(); i++ {\n" +
+					"\ttag := s.Type().Field(i).Tag.Get(\"bson\")\n" +
+					"\tname := strings.Split(tag, \",\")[0]\n" +
+					"\tif name == \"_id\" || name == \"-\" || !d.Field(i).CanSet() {\n" +
+					"\t\tcontinue\n" +
+					"\t}\n" +
+					"\tif strings.Contains(tag, \",omitempty\") && s.Field(i).IsZero() {\n" +
+					"\t\tcontinue\n" +
+					"\t}\n" +
+					"\td.Field(i).Set(s.Field(i))\n" +
+					"}"),
+			},
+		},
+		&template.FuncRender{
+			Name:    "fakeProject",
+			Comment: "// fakeProject keeps _id and the projected fields of the entity.",
+			Params: code.Params{
+				code.Param{Name: "entity", Type: interfaceType},
+				code.Param{Name: "mongoNames", Type: code.IdentType("...string")},
+			},
+			FuncBody: code.Body{
+				code.RawStmt("v := reflect.ValueOf(entity).Elem()"),
+				code.RawStmt("projected := reflect.New(v.Type())"),
+				code.RawStmt("for _, name := range append([]string{\"_id\"}, mongoNames...) {\n" +
+					"\tif value, ok := fakeField(entity, name); ok {\n" +
+					"\t\tfakeSetField(projected.Interface(), name, value)\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("v.Set(projected.Elem())"),
+			},
+		},
+		&template.FuncRender{
+			Name:    "fakeMatch",
+			Comment: "// fakeMatch reports whether the field of the entity satisfies the query operator.",
+			Params: code.Params{
+				code.Param{Name: "entity", Type: interfaceType},
+				code.Param{Name: "mongoName", Type: stringType},
+				code.Param{Name: "op", Type: stringType},
+				code.Param{Name: "value", Type: interfaceType},
+			},
+			Returns: code.Returns{code.IdentType("bool")},
+			FuncBody: code.Body{
+				code.RawStmt("field, ok := fakeField(entity, mongoName)"),
+				code.RawStmt("switch op {\n" +
+					"case \"$exists\":\n" +
+					"\treturn (ok && fakeIndirect(field) != nil) == value.(bool)\n" +
+					"case \"$ne\":\n" +
+					"\treturn !ok || !fakeEqual(field, value)\n" +
+					"case \"$nin\":\n" +
+					"\treturn !ok || !fakeIn(field, value)\n" +
+					"}"),
+				code.RawStmt("if !ok {\n\treturn false\n}"),
+				code.RawStmt("switch op {\n" +
+					"case \"$eq\":\n" +
+					"\treturn fakeEqual(field, value)\n" +
+					"case \"$in\":\n" +
+					"\treturn fakeIn(field, value)\n" +
+					"}"),
+				code.RawStmt("result, comparable := fakeCompare(field, value)"),
+				code.RawStmt("if !comparable {\n\treturn false\n}"),
+				code.RawStmt("switch op {\n" +
+					"case \"$lt\":\n" +
+					"\treturn result < 0\n" +
+					"case \"$lte\":\n" +
+					"\treturn result <= 0\n" +
+					"case \"$gt\":\n" +
+					"\treturn result > 0\n" +
+					"case \"$gte\":\n" +
+					"\treturn result >= 0\n" +
+					"}"),
+				code.RawStmt("return false"),
+			},
+		},
+		&template.FuncRender{
+			Name: "fakeIndirect",
+			Params: code.Params{
+				code.Param{Name: "value", Type: interfaceType},
+			},
+			Returns: code.Returns{interfaceType},
+			FuncBody: code.Body{
+				code.RawStmt("v := reflect.ValueOf(value)"),
+				code.RawStmt("for v.Kind() == reflect.Ptr {\n" +
+					"\tif v.IsNil() {\n" +
+					"\t\treturn nil\n" +
+					"\t}\n" +
+					"\tv = v.Elem()\n" +
+					"}"),
+				code.RawStmt("if !v.IsValid() {\n\treturn nil\n}"),
+				code.RawStmt("return v.Interface()"),
+			},
+		},
+		&template.FuncRender{
+			Name: "fakeEqual",
+			Params: code.Params{
+				code.Param{Name: "a", Type: interfaceType},
+				code.Param{Name: "b", Type: interfaceType},
+			},
+			Returns: code.Returns{code.IdentType("bool")},
+			FuncBody: code.Body{
+				code.RawStmt("return reflect.DeepEqual(fakeIndirect(a), fakeIndirect(b))"),
+			},
+		},
+		&template.FuncRender{
+			Name: "fakeIn",
+			Comment: "// fakeIn reports whether the field equals any of the values, the array field matches\n" +
+				"// if any of its elements equals any of the values.",
+			Params: code.Params{
+				code.Param{Name: "field", Type: interfaceType},
+				code.Param{Name: "values", Type: interfaceType},
+			},
+			Returns: code.Returns{code.IdentType("bool")},
+			FuncBody: code.Body{
+				code.RawStmt("list := reflect.ValueOf(fakeIndirect(values))"),
+				code.RawStmt("if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {\n" +
+					"\treturn fakeEqual(field, values)\n" +
+					"}"),
+				code.RawStmt("if f := reflect.ValueOf(fakeIndirect(field)); f.Kind() == reflect.Slice || f.Kind() == reflect.Array {\n" +
+					"\tfor i := 0; i < f.Len(); i++ {\n" +
+					"\t\tif fakeIn(f.Index(i).Interface(), values) {\n" +
+					"\t\t\treturn true\n" +
+					"\t\t}\n" +
+					"\t}\n" +
+					"\treturn false\n" +
+					"}"),
+				code.RawStmt("for i := 0; i < list.Len(); i++ {\n" +
+					"\tif fakeEqual(field, list.Index(i).Interface()) {\n" +
+					"\t\treturn true\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("return false"),
+			},
+		},
+		&template.FuncRender{
+			Name:    "fakeCompare",
+			Comment: "// fakeCompare compares the numbers, strings and bools, returns false if they are not comparable.",
+			Params: code.Params{
+				code.Param{Name: "a", Type: interfaceType},
+				code.Param{Name: "b", Type: interfaceType},
+			},
+			Returns: code.Returns{code.IdentType("int"), code.IdentType("bool")},
+			FuncBody: code.Body{
+				code.RawStmt("va, vb := reflect.ValueOf(fakeIndirect(a)), reflect.ValueOf(fakeIndirect(b))"),
+				code.RawStmt("if !va.IsValid() || !vb.IsValid() || va.Kind() != vb.Kind() {\n\treturn 0, false\n}"),
+				code.RawStmt("var less, greater bool"),
+				code.RawStmt("switch va.Kind() {\n" +
+					"case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:\n" +
+					"\tless, greater = va.Int() < vb.Int(), va.Int() > vb.Int()\n" +
+					"case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:\n" +
+					"\tless, greater = va.Uint() < vb.Uint(), va.Uint() > vb.Uint()\n" +
+					"case reflect.Float32, reflect.Float64:\n" +
+					"\tless, greater = va.Float() < vb.Float(), va.Float() > vb.Float()\n" +
+					"case reflect.String:\n" +
+					"\tless, greater = va.String() < vb.String(), va.String() > vb.String()\n" +
+					"case reflect.Bool:\n" +
+					"\tless, greater = !va.Bool() && vb.Bool(), va.Bool() && !vb.Bool()\n" +
+					"default:\n" +
+					"\treturn 0, false\n" +
+					"}"),
+				code.RawStmt("switch {\n" +
+					"case less:\n" +
+					"\treturn -1, true\n" +
+					"case greater:\n" +
+					"\treturn 1, true\n" +
+					"}"),
+				code.RawStmt("return 0, true"),
+			},
+		},
+		&template.FuncRender{
+			Name: "fakeSort",
+			Comment: "// fakeSort sorts the entities by the mongo field names, the names prefixed with - are sorted\n" +
+				"// in descending order, the missing fields are sorted first.",
+			Params: code.Params{
+				code.Param{Name: "entities", Type: interfaceType},
+				code.Param{Name: "keys", Type: code.IdentType("...string")},
+			},
+			FuncBody: code.Body{
+				code.RawStmt("list := reflect.ValueOf(entities)"),
+				code.RawStmt("sort.SliceStable(entities, func(i, j int) bool {\n" +
+					"\tfor _, key := range keys {\n" +
+					"\t\tname := strings.TrimPrefix(key, \"-\")\n" +
+					"\t\ta, _ := fakeField(list.Index(i).Interface(), name)\n" +
+					"\t\tb, _ := fakeField(list.Index(j).Interface(), name)\n" +
+					"\t\tresult, _ := fakeCompare(a, b)\n" +
+					"\t\tswitch a, b = fakeIndirect(a), fakeIndirect(b); {\n" +
+					"\t\tcase a == nil && b != nil:\n" +
+					"\t\t\tresult = -1\n" +
+					"\t\tcase a != nil && b == nil:\n" +
+					"\t\t\tresult = 1\n" +
+					"\t\t}\n" +
+					"\t\tif result != 0 {\n" +
+					"\t\t\treturn (result < 0) != strings.HasPrefix(key, \"-\")\n" +
+					"\t\t}\n" +
+					"\t}\n" +
+					"\treturn false\n" +
+					"})"),
+			},
+		},
+	}
+}
@@ -25,11 +25,19 @@ import (
 
 func findCodegen(find *parse.FindParse) []code.Statement {
 	if find.OperateMode == parse.OperateOne {
+		// the entity must be allocated before decoding
+		var declEntity code.Statement = code.DeclVarStmt{
+			Name: "entity",
+			Type: find.ReturnType,
+		}
+		if returnType, ok := find.ReturnType.(code.StarExprType); ok {
+			declEntity = code.DeclColonStmt{
+				Left:  code.ListCommaStmt{code.RawStmt("entity")},
+				Right: code.RawStmt(fmt.Sprintf("new(%s)", returnType.RealType.RealName())),
+			}
+		}
 		return []code.Statement{
-			code.DeclVarStmt{
-				Name: "entity",
-				Type: find.ReturnType,
-			},
+			declEntity,
 			code.IfBlockStmt{
 				Condition: []code.Statement{
 					code.RawStmt("err := "),
@@ -39,7 +47,7 @@ func findCodegen(find *parse.FindParse) []code.Statement {
 							CallName: "FindOne",
 							Args: code.ListCommaStmt{
 								code.RawStmt(find.CtxParamName),
-								queryCodegen(find.Query),
+								queryCodegen(optionQuery(find.Query, find.BelongedToMethod.BelongedToStruct)),
 								findOptionsCodegen(find),
 							},
 						},
@@ -62,10 +70,39 @@ func findCodegen(find *parse.FindParse) []code.Statement {
 			},
 		}
 	} else {
-		baseFindStmt := []code.Statement{
+		// the locals are renamed if they conflict with the method parameters, such as the cursor of the keyset
+		cursor, entities := getLocalName(find.BelongedToMethod, "cursor"), getLocalName(find.BelongedToMethod, "entities")
+		nextCursor, filter := getLocalName(find.BelongedToMethod, "nextCursor"), getLocalName(find.BelongedToMethod, "filter")
+		errReturn := "nil, err"
+		if find.ReturnCursor {
+			errReturn = fmt.Sprintf("nil, %s, err", nextCursor)
+		}
+
+		var query code.Statement = queryCodegen(optionQuery(find.Query, find.BelongedToMethod.BelongedToStruct))
+		baseFindStmt := []code.Statement{}
+		if find.Keyset != nil && find.Keyset.Nullable {
+			// the nil cursor queries the first page
+			baseFindStmt = append(baseFindStmt,
+				code.DeclColonStmt{
+					Left:  code.ListCommaStmt{code.RawStmt(filter)},
+					Right: query,
+				},
+				code.IfBlockStmt{
+					Condition: []code.Statement{
+						code.RawStmt(find.Keyset.ParamName + " != nil "),
+					},
+					Body: code.Body{
+						code.RawStmt(fmt.Sprintf("%s = bson.M{\"$and\": []bson.M{%s, {\n%s\n}}}", filter, filter,
+							dfsCodegen(find.Keyset.Condition()).Code())),
+					},
+				},
+			)
+			query = code.RawStmt(filter)
+		}
+		baseFindStmt = append(baseFindStmt,
 			code.DeclColonStmt{
 				Left: code.ListCommaStmt{
-					code.RawStmt("cursor"),
+					code.RawStmt(cursor),
 					code.RawStmt("err"),
 				},
 				Right: code.CallStmt{
@@ -73,51 +110,96 @@ func findCodegen(find *parse.FindParse) []code.Statement {
 					CallName: "Find",
 					Args: code.ListCommaStmt{
 						code.RawStmt(find.CtxParamName),
-						queryCodegen(find.Query),
+						query,
 						findOptionsCodegen(find),
 					},
 				},
 			},
-			code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt(fmt.Sprintf("if err != nil {\n\treturn %s\n}", errReturn)),
 			code.DeclVarStmt{
-				Name: "entities",
+				Name: entities,
 				Type: find.ReturnType,
 			},
 			code.IfBlockStmt{
 				Condition: []code.Statement{
 					code.RawStmt("err = "),
 					code.CallStmt{
-						Caller:   code.RawStmt("cursor"),
+						Caller:   code.RawStmt(cursor),
 						CallName: "All",
 						Args: code.ListCommaStmt{
 							code.RawStmt(find.CtxParamName),
-							code.RawStmt("&entities"),
+							code.RawStmt("&" + entities),
 						},
 					},
 					code.RawStmt("; err != nil "),
 				},
 				Body: code.Body{
-					code.RawStmt("return nil, err"),
-				},
-			},
-			code.ReturnStmt{
-				ListCommaStmt: code.ListCommaStmt{
-					code.RawStmt("entities"),
-					code.RawStmt("nil"),
+					code.RawStmt("return " + errReturn),
 				},
 			},
-		}
+		)
+		baseFindStmt = append(baseFindStmt, keysetCodegen(find, entities, nextCursor)...)
 
 		pageRevealStmt := pageRevealCodegen(find)
 		if pageRevealStmt != nil {
-			findStmt := append([]code.Statement{pageRevealStmt}, baseFindStmt...)
-			return findStmt
-		} else {
-			return baseFindStmt
+			baseFindStmt = append([]code.Statement{pageRevealStmt}, baseFindStmt...)
 		}
+		if find.ReturnCursor {
+			baseFindStmt = append([]code.Statement{
+				code.DeclVarStmt{
+					Name: nextCursor,
+					Type: find.Keyset.FieldType,
+				},
+			}, baseFindStmt...)
+		}
+		return baseFindStmt
 	}
 }
 
+// keysetCodegen is used to restore the order of the entities queried by Before
+// and to return the next cursor.
+func keysetCodegen(find *parse.FindParse, entities, nextCursor string) []code.Statement {
+	result := make([]code.Statement, 0, 3)
+
+	if find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		result = append(result, code.RawStmt(fmt.Sprintf("for i, j := 0, len(%s)-1; i < j; i, j = i+1, j-1 {\n"+
+			"\t%s[i], %s[j] = %s[j], %s[i]\n}", entities, entities, entities, entities, entities)))
+	}
+
+	if !find.ReturnCursor {
+		return append(result, code.ReturnStmt{
+			ListCommaStmt: code.ListCommaStmt{
+				code.RawStmt(entities),
+				code.RawStmt("nil"),
+			},
+		})
+	}
+
+	// the next cursor of After is the last entity, the next cursor of Before is the first entity
+	index := fmt.Sprintf("len(%s)-1", entities)
+	if find.Keyset.KeysetMode == parse.Before {
+		index = "0"
+	}
+	result = append(result,
+		code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt(fmt.Sprintf("len(%s) > 0 ", entities)),
+			},
+			Body: code.Body{
+				code.RawStmt(fmt.Sprintf("%s = %s", nextCursor, find.Keyset.NextCursor(fmt.Sprintf("%s[%s]", entities, index)))),
+			},
+		},
+		code.ReturnStmt{
+			ListCommaStmt: code.ListCommaStmt{
+				code.RawStmt(entities),
+				code.RawStmt(nextCursor),
+				code.RawStmt("nil"),
+			},
+		},
+	)
+	return result
+}
+
 func findOptionsCodegen(find *parse.FindParse) code.Statement {
 	chainCall := make(code.ChainStmt, 0, 5)
 
@@ -148,12 +230,18 @@ func findOptionsCodegen(find *parse.FindParse) code.Statement {
 
 		return baseChain
 	} else {
+		order := find.Order
+		// Before queries the entities closest to the cursor in the reverse order
+		if find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+			order = parse.Order{Asc: find.Order.Desc, Desc: find.Order.Asc}
+		}
+
 		baseChain := chainCall.ChainCall(code.Chain{
 			CallName: "options.Find",
 			Args:     code.ListCommaStmt{},
 		}).ChainCall(code.Chain{
 			CallName: "SetSort",
-			Args:     code.ListCommaStmt{findOrderCodegen(find.Order)},
+			Args:     code.ListCommaStmt{findOrderCodegen(order)},
 		})
 
 		if len(find.Project) != 0 {
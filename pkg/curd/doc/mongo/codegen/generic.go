@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// GenericPkgName is the name of the package of the generic repository embedded in the mongo repositories,
+// which is generated in the dao directory.
+const GenericPkgName = "mongorepo"
+
+const (
+	GenericRepository = "Repository"
+	GenericInsert     = "Insert"
+	GenericFindByID   = "FindByID"
+	GenericDeleteByID = "DeleteByID"
+	GenericCount      = "Count"
+)
+
+// GenericMethodNames are the methods of the generic repository, which are promoted to the repositories.
+var GenericMethodNames = []string{GenericInsert, GenericFindByID, GenericDeleteByID, GenericCount}
+
+// IsGenericMethod reports whether the method of the repository is promoted from the generic repository.
+func IsGenericMethod(st *extract.IdlExtractStruct, name string) bool {
+	if !st.Generic {
+		return false
+	}
+	for _, genericName := range GenericMethodNames {
+		if name == genericName {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGenericPackageFiles returns the generic repository, its code is the same for all idls.
+func GetGenericPackageFiles() ([]PackageFile, error) {
+	typeParam := code.IdentType("T")
+	repository := code.GenericType{
+		Type:     code.IdentType(GenericRepository),
+		TypeArgs: []code.Type{typeParam},
+	}
+	receiver := code.MethodReceiver{
+		Name: "r",
+		Type: code.StarExprType{RealType: repository},
+	}
+	methods := getGenericMethods(typeParam)
+
+	tplGeneric := &template.Template{
+		Renders: []template.Render{
+			&template.StructRender{
+				Name:       GenericRepository,
+				Comment:    "// Repository is the base of the mongo repositories, it is embedded by the repository of the model T.",
+				TypeParams: code.TypeParams{{Name: "T", Constraint: code.IdentType("any")}},
+				StructFields: code.StructFields{
+					code.StructField{
+						Name: "collection",
+						Type: code.StarExprType{RealType: code.SelectorExprType{X: "mongo", Sel: "Collection"}},
+					},
+				},
+			},
+			&template.FuncRender{
+				Name:       "New",
+				Comment:    "// New returns the Repository of the model T on the collection.",
+				TypeParams: code.TypeParams{{Name: "T", Constraint: code.IdentType("any")}},
+				Params: code.Params{
+					code.Param{
+						Name: "collection",
+						Type: code.StarExprType{RealType: code.SelectorExprType{X: "mongo", Sel: "Collection"}},
+					},
+				},
+				Returns: code.Returns{code.StarExprType{RealType: repository}},
+				FuncBody: code.Body{
+					code.RawStmt("return &Repository[T]{\n\tcollection: collection,\n}"),
+				},
+			},
+		},
+	}
+	bodies := map[string]code.Body{
+		GenericInsert: {
+			code.RawStmt("result, err := r.collection.InsertOne(ctx, entity)\nif err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt("return result.InsertedID, nil"),
+		},
+		GenericFindByID: {
+			code.RawStmt("entity := new(T)\nif err := r.collection.FindOne(ctx, bson.M{\"_id\": id}).Decode(entity); err != nil {\n" +
+				"\treturn nil, err\n}"),
+			code.RawStmt("return entity, nil"),
+		},
+		GenericDeleteByID: {
+			code.RawStmt("result, err := r.collection.DeleteOne(ctx, bson.M{\"_id\": id})\nif err != nil {\n\treturn false, err\n}"),
+			code.RawStmt("return result.DeletedCount > 0, nil"),
+		},
+		GenericCount: {
+			code.RawStmt("result, err := r.collection.CountDocuments(ctx, bson.M{})\nif err != nil {\n\treturn 0, err\n}"),
+			code.RawStmt("return int(result), nil"),
+		},
+	}
+	for _, method := range methods {
+		tplGeneric.Renders = append(tplGeneric.Renders, &template.MethodRender{
+			Name:           method.Name,
+			Comment:        genericComments[method.Name],
+			MethodReceiver: receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody:     bodies[method.Name],
+		})
+	}
+
+	buff, err := tplGeneric.Build()
+	if err != nil {
+		return nil, err
+	}
+	return []PackageFile{
+		{
+			Name: "repository.go",
+			Imports: map[string]string{
+				"context":                           "",
+				"go.mongodb.org/mongo-driver/bson":  "",
+				"go.mongodb.org/mongo-driver/mongo": "",
+			},
+			Code: "\n" + buff.String(),
+		},
+	}, nil
+}
+
+var genericComments = map[string]string{
+	GenericInsert:     "// Insert inserts the entity and returns its _id.",
+	GenericFindByID:   "// FindByID returns the entity of the _id, mongo.ErrNoDocuments is returned if it is not found.",
+	GenericDeleteByID: "// DeleteByID deletes the entity of the _id and reports whether it is deleted.",
+	GenericCount:      "// Count returns the number of all entities.",
+}
+
+// genericHookInfos are the operations and the filters of the methods of the generic repository reported by the hooks.
+var genericHookInfos = map[string][2]string{
+	GenericInsert:     {strings.ToLower(parse.Insert), "nil"},
+	GenericFindByID:   {strings.ToLower(parse.Find), "bson.M{\"_id\": id}"},
+	GenericDeleteByID: {strings.ToLower(parse.Delete), "bson.M{\"_id\": id}"},
+	GenericCount:      {strings.ToLower(parse.Count), "bson.M{}"},
+}
+
+// GetGenericIfMethods returns the methods of the generic repository in the repository interface.
+func GetGenericIfMethods(st *extract.IdlExtractStruct) code.InterfaceMethods {
+	return getGenericMethods(code.SelectorExprType{X: st.ModelPkg, Sel: st.Name})
+}
+
+// getGenericMethods returns the methods of the generic repository of the model type.
+func getGenericMethods(modelType code.Type) code.InterfaceMethods {
+	ctx := code.Param{
+		Name: "ctx",
+		Type: code.SelectorExprType{X: "context", Sel: "Context"},
+	}
+	id := code.Param{
+		Name: "id",
+		Type: code.InterfaceType{},
+	}
+	return code.InterfaceMethods{
+		{
+			Name:    GenericInsert,
+			Params:  code.Params{ctx, {Name: "entity", Type: code.StarExprType{RealType: modelType}}},
+			Returns: code.Returns{code.InterfaceType{}, code.IdentType("error")},
+		},
+		{
+			Name:    GenericFindByID,
+			Params:  code.Params{ctx, id},
+			Returns: code.Returns{code.StarExprType{RealType: modelType}, code.IdentType("error")},
+		},
+		{
+			Name:    GenericDeleteByID,
+			Params:  code.Params{ctx, id},
+			Returns: code.Returns{code.IdentType("bool"), code.IdentType("error")},
+		},
+		{
+			Name:    GenericCount,
+			Params:  code.Params{ctx},
+			Returns: code.Returns{code.IdentType("int"), code.IdentType("error")},
+		},
+	}
+}
+
+// getGenericStructField returns the generic repository embedded in the repository of the structure.
+func getGenericStructField(st *extract.IdlExtractStruct) code.StructField {
+	return code.StructField{
+		Type: code.StarExprType{
+			RealType: code.GenericType{
+				Type:     code.SelectorExprType{X: GenericPkgName, Sel: GenericRepository},
+				TypeArgs: []code.Type{code.SelectorExprType{X: st.ModelPkg, Sel: st.Name}},
+			},
+		},
+	}
+}
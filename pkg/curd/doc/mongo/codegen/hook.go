@@ -0,0 +1,181 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+var HookImports = map[string]string{
+	"time": "",
+}
+
+// GetHookRenders returns the renders of the hook decorator of the repository, which calls the hook
+// around each method.
+//
+//	input params description:
+//	ifOperation: the parsing results of all methods of the structure, including the methods generated before
+//	methods: all methods of the repository interface
+//	hookPkg: the name of the imported hook package
+func GetHookRenders(ifOperation *parse.InterfaceOperation, methods code.InterfaceMethods, hookPkg string) []template.Render {
+	st := ifOperation.BelongedToStruct
+	repoName := st.Name + "Repository"
+	hookName := repoName + "Hook"
+	receiver := code.MethodReceiver{
+		Name: "r",
+		Type: code.StarExprType{RealType: code.IdentType(hookName)},
+	}
+
+	renders := []template.Render{
+		&template.StructRender{
+			Name: hookName,
+			Comment: fmt.Sprintf("// %s calls the hook around the methods of %s, the methods whose\n"+
+				"// first param is not context.Context are passed through.", hookName, repoName),
+			StructFields: code.StructFields{
+				code.StructField{
+					Name: "next",
+					Type: code.IdentType(repoName),
+				},
+				code.StructField{
+					Name: "hook",
+					Type: code.SelectorExprType{X: hookPkg, Sel: "Hook"},
+				},
+			},
+		},
+		&template.FuncRender{
+			Name: "New" + hookName,
+			Comment: fmt.Sprintf("// New%s wraps next with h, next may be the mongo repository or the other decorators.",
+				hookName),
+			Params: code.Params{
+				code.Param{
+					Name: "next",
+					Type: code.IdentType(repoName),
+				},
+				code.Param{
+					Name: "h",
+					Type: code.SelectorExprType{X: hookPkg, Sel: "Hook"},
+				},
+			},
+			Returns: code.Returns{
+				code.IdentType(repoName),
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("return &%s{next: next, hook: h}", hookName)),
+			},
+		},
+	}
+
+	operations := make(map[string]parse.Operation, len(ifOperation.Operations))
+	for _, operation := range ifOperation.Operations {
+		operations[parse.GetBelongedToMethod(operation).Name] = operation
+	}
+	for _, method := range methods {
+		renders = append(renders, &template.MethodRender{
+			Name:           method.Name,
+			MethodReceiver: receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody: hookMethodCodegen(operations[method.Name], method, repoName, hookPkg,
+				IsGenericMethod(st, method.Name)),
+		})
+	}
+	return renders
+}
+
+// hookMethodCodegen returns the body of the method of the decorator, the methods which are not parsed
+// are reported with the operation custom and without the filter, except the methods of the generic repository.
+func hookMethodCodegen(operation parse.Operation, method code.InterfaceMethod, repoName, hookPkg string,
+	generic bool,
+) code.Body {
+	args := make([]string, 0, len(method.Params))
+	for _, param := range method.Params {
+		if strings.HasPrefix(param.Type.RealName(), "...") {
+			args = append(args, param.Name+"...")
+		} else {
+			args = append(args, param.Name)
+		}
+	}
+	call := fmt.Sprintf("r.next.%s(%s)", method.Name, strings.Join(args, ", "))
+	if len(method.Params) == 0 || method.Params[0].Type.RealName() != "context.Context" ||
+		len(method.Returns) == 0 || method.Returns[len(method.Returns)-1].RealName() != "error" {
+		return code.Body{code.RawStmt("return " + call)}
+	}
+
+	localMethod := &extract.InterfaceMethod{Params: method.Params}
+	info, start := getLocalName(localMethod, "info"), getLocalName(localMethod, "start")
+	results := make([]string, 0, len(method.Returns))
+	for index := range method.Returns[:len(method.Returns)-1] {
+		name := "result"
+		if index != 0 {
+			name = fmt.Sprintf("result%d", index+1)
+		}
+		results = append(results, getLocalName(localMethod, name))
+	}
+	err := getLocalName(localMethod, "err")
+	results = append(results, err)
+
+	operationName, filter := "custom", "nil"
+	if operation != nil {
+		operationName = strings.ToLower(operation.GetOperationName())
+		if query := hookQuery(operation); query != nil {
+			filter = queryCodegen(query).Code()
+		}
+	} else if generic {
+		operationName, filter = genericHookInfos[method.Name][0], genericHookInfos[method.Name][1]
+	}
+
+	ctx := method.Params[0].Name
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("%s := &%s.Info{\n"+
+			"\tRepository: %q,\n"+
+			"\tMethod:     %q,\n"+
+			"\tOperation:  %q,\n"+
+			"\tFilter:     %s,\n"+
+			"}", info, hookPkg, repoName, method.Name, operationName, filter)),
+		code.RawStmt(fmt.Sprintf("%s = r.hook.Before(%s, %s)", ctx, ctx, info)),
+		code.RawStmt(fmt.Sprintf("%s := time.Now()", start)),
+		code.RawStmt(fmt.Sprintf("%s := %s", strings.Join(results, ", "), call)),
+		code.RawStmt(fmt.Sprintf("r.hook.After(%s, %s, time.Since(%s), %s)", ctx, info, start, err)),
+		code.RawStmt("return " + strings.Join(results, ", ")),
+	}
+}
+
+// hookQuery returns the query sent to mongodb by the operation, the operations which write the
+// entities without the filters and the ones composed of the other operations have no query.
+func hookQuery(operation parse.Operation) *parse.Query {
+	st := parse.GetBelongedToMethod(operation).BelongedToStruct
+	switch op := operation.(type) {
+	case *parse.FindParse:
+		return optionQuery(op.Query, st)
+	case *parse.CountParse:
+		return optionQuery(op.Query, st)
+	case *parse.UpdateParse:
+		return optionQuery(op.Query, st)
+	case *parse.DeleteParse:
+		return optionQuery(op.Query, st)
+	case *parse.WatchParse:
+		return prefixQuery(optionQuery(op.Query, st), fullDocument+".")
+	default:
+		return nil
+	}
+}
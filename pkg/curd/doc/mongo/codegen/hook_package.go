@@ -0,0 +1,225 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+// HookPkgName is the name of the package shared by the hook decorators, which is generated in the dao directory.
+const HookPkgName = "hook"
+
+// HookPackageFiles are the Hook interface and its logging, OpenTelemetry and Prometheus implementations.
+var HookPackageFiles = []PackageFile{
+	{
+		Name: "hook.go",
+		Imports: map[string]string{
+			"context":                           "",
+			"errors":                            "",
+			"time":                              "",
+			"go.mongodb.org/mongo-driver/mongo": "",
+		},
+		Code: hookInterfaceCode,
+	},
+	{
+		Name: "log.go",
+		Imports: map[string]string{
+			"context": "",
+			"time":    "",
+		},
+		Code: hookLogCode,
+	},
+	{
+		Name: "otel.go",
+		Imports: map[string]string{
+			"context":                            "",
+			"time":                               "",
+			"go.mongodb.org/mongo-driver/bson":   "",
+			"go.opentelemetry.io/otel/attribute": "",
+			"go.opentelemetry.io/otel/codes":     "",
+			"go.opentelemetry.io/otel/trace":     "",
+		},
+		Code: hookOtelCode,
+	},
+	{
+		Name: "prometheus.go",
+		Imports: map[string]string{
+			"context": "",
+			"errors":  "",
+			"time":    "",
+			"github.com/prometheus/client_golang/prometheus": "",
+		},
+		Code: hookPrometheusCode,
+	},
+}
+
+var hookInterfaceCode = `
+// Info describes the call of the repository method.
+type Info struct {
+	// Repository is the name of the repository interface, such as UserRepository.
+	Repository string
+	// Method is the name of the called method.
+	Method string
+	// Operation is the kind of the method, such as find, insert, update, delete, count, bulk, transaction and watch.
+	Operation string
+	// Filter is the filter of the method sent to mongodb, it is nil if the method has no filter.
+	Filter interface{}
+}
+
+// Hook is called around the methods of the repositories wrapped by the hook decorators.
+type Hook interface {
+	// Before is called before the method, the returned context is passed to the method and After.
+	Before(ctx context.Context, info *Info) context.Context
+	// After is called after the method with its duration and the returned error.
+	After(ctx context.Context, info *Info, duration time.Duration, err error)
+}
+
+// Hooks calls the hooks in order before the methods and in reverse order after them.
+type Hooks []Hook
+
+func (hs Hooks) Before(ctx context.Context, info *Info) context.Context {
+	for _, h := range hs {
+		ctx = h.Before(ctx, info)
+	}
+	return ctx
+}
+
+func (hs Hooks) After(ctx context.Context, info *Info, duration time.Duration, err error) {
+	for i := len(hs) - 1; i >= 0; i-- {
+		hs[i].After(ctx, info, duration, err)
+	}
+}
+
+// IsError reports whether the error returned by the method is the failure, mongo.ErrNoDocuments
+// returned by finding one entity is the normal result.
+func IsError(err error) bool {
+	return err != nil && !errors.Is(err, mongo.ErrNoDocuments)
+}
+`
+
+var hookLogCode = `
+// Logger is the logger used by LogHook, *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LogHook logs the failed calls and the calls slower than Slow, all calls are logged if Slow is 0.
+type LogHook struct {
+	Logger Logger
+	Slow   time.Duration
+}
+
+func (h *LogHook) Before(ctx context.Context, info *Info) context.Context {
+	return ctx
+}
+
+func (h *LogHook) After(ctx context.Context, info *Info, duration time.Duration, err error) {
+	if IsError(err) {
+		h.Logger.Printf("%s.%s %s failed in %s: %v", info.Repository, info.Method, info.Operation, duration, err)
+		return
+	}
+	if duration >= h.Slow {
+		h.Logger.Printf("%s.%s %s finished in %s", info.Repository, info.Method, info.Operation, duration)
+	}
+}
+`
+
+var hookOtelCode = `
+type otelSpanKey struct{}
+
+// OtelHook starts the client span of each call, the span is the child of the span in the context.
+type OtelHook struct {
+	tracer       trace.Tracer
+	recordFilter bool
+}
+
+// NewOtelHook creates the hook which traces the calls by tracer, the filters are recorded as
+// db.statement if recordFilter is true, they should not be recorded if they contain sensitive values.
+func NewOtelHook(tracer trace.Tracer, recordFilter bool) *OtelHook {
+	return &OtelHook{tracer: tracer, recordFilter: recordFilter}
+}
+
+func (h *OtelHook) Before(ctx context.Context, info *Info) context.Context {
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.operation", info.Operation),
+		attribute.String("code.function", info.Repository+"."+info.Method),
+	}
+	if h.recordFilter && info.Filter != nil {
+		if statement, err := bson.MarshalExtJSON(info.Filter, false, false); err == nil {
+			attributes = append(attributes, attribute.String("db.statement", string(statement)))
+		}
+	}
+	ctx, span := h.tracer.Start(ctx, info.Repository+"."+info.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	return context.WithValue(ctx, otelSpanKey{}, span)
+}
+
+func (h *OtelHook) After(ctx context.Context, info *Info, duration time.Duration, err error) {
+	span, ok := ctx.Value(otelSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if IsError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+`
+
+var hookPrometheusCode = `
+// PrometheusHook observes the durations of the calls by the histogram labeled by the repository,
+// the method, the operation and the status which is ok or error.
+type PrometheusHook struct {
+	durations *prometheus.HistogramVec
+}
+
+// NewPrometheusHook creates the hook whose histogram is registered by registerer, the histogram
+// registered before by the other hook with the same namespace is reused.
+func NewPrometheusHook(registerer prometheus.Registerer, namespace string) (*PrometheusHook, error) {
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "method_duration_seconds",
+		Help:      "The durations of the calls of the repository methods.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"repository", "method", "operation", "status"})
+	if err := registerer.Register(durations); err != nil {
+		var registeredErr prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registeredErr) {
+			return nil, err
+		}
+		existing, ok := registeredErr.ExistingCollector.(*prometheus.HistogramVec)
+		if !ok {
+			return nil, err
+		}
+		durations = existing
+	}
+	return &PrometheusHook{durations: durations}, nil
+}
+
+func (h *PrometheusHook) Before(ctx context.Context, info *Info) context.Context {
+	return ctx
+}
+
+func (h *PrometheusHook) After(ctx context.Context, info *Info, duration time.Duration, err error) {
+	status := "ok"
+	if IsError(err) {
+		status = "error"
+	}
+	h.durations.WithLabelValues(info.Repository, info.Method, info.Operation, status).Observe(duration.Seconds())
+}
+`
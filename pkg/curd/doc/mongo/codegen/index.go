@@ -0,0 +1,267 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+const EnsureIndexes = "EnsureIndexes"
+
+// GetEnsureIndexesRender returns the EnsureIndexes method which creates all indexes declared in the IDL,
+// returns nil if the structure has no index declared.
+func GetEnsureIndexesRender(extractStruct *extract.IdlExtractStruct) *template.MethodRender {
+	if len(extractStruct.Indexes) == 0 {
+		return nil
+	}
+
+	ifMethod := GetEnsureIndexesIfMethod()
+	return &template.MethodRender{
+		Name:    ifMethod.Name,
+		Comment: "// EnsureIndexes creates the indexes declared in the IDL, indexes that already exist are ignored.",
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType(extractStruct.Name + "RepositoryMongo"),
+			},
+		},
+		Params:     ifMethod.Params,
+		Returns:    ifMethod.Returns,
+		MethodBody: ensureIndexesCodegen(extractStruct.Indexes),
+	}
+}
+
+// GetEnsureIndexesIfMethod returns the EnsureIndexes method in the repository interface.
+func GetEnsureIndexesIfMethod() code.InterfaceMethod {
+	return code.InterfaceMethod{
+		Name: EnsureIndexes,
+		Params: code.Params{
+			code.Param{
+				Name: "ctx",
+				Type: code.SelectorExprType{
+					X:   "context",
+					Sel: "Context",
+				},
+			},
+		},
+		Returns: code.Returns{
+			code.IdentType("error"),
+		},
+	}
+}
+
+func ensureIndexesCodegen(indexes []*extract.Index) code.Body {
+	models := "[]mongo.IndexModel{\n"
+	for _, idx := range indexes {
+		models += "{\n" + indexKeysCodegen(idx) + indexOptionsCodegen(idx) + "},\n"
+	}
+	models += "}"
+
+	return code.Body{
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt("_"),
+				code.RawStmt("err"),
+			},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt("r.collection.Indexes()"),
+				CallName: "CreateMany",
+				Args: code.ListCommaStmt{
+					code.RawStmt("ctx"),
+					code.RawStmt(models),
+				},
+			},
+		},
+		code.ReturnStmt{
+			ListCommaStmt: code.ListCommaStmt{
+				code.RawStmt("err"),
+			},
+		},
+	}
+}
+
+func indexKeysCodegen(idx *extract.Index) string {
+	result := "Keys: bson.D{\n"
+	for _, key := range idx.Keys {
+		order := "1"
+		if key.Desc {
+			order = "-1"
+		}
+		result += fmt.Sprintf("{Key: %s, Value: %s},\n", strconv.Quote(key.MongoFieldName), order)
+	}
+	return result + "},\n"
+}
+
+func indexOptionsCodegen(idx *extract.Index) string {
+	chainCall := make(code.ChainStmt, 0, 5)
+	chainCall = chainCall.ChainCall(code.Chain{
+		CallName: "options.Index",
+		Args:     code.ListCommaStmt{},
+	})
+	if idx.Name != "" {
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetName",
+			Args:     code.ListCommaStmt{code.RawStmt(strconv.Quote(idx.Name))},
+		})
+	}
+	if idx.Unique {
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetUnique",
+			Args:     code.ListCommaStmt{code.RawStmt("true")},
+		})
+	}
+	if idx.Sparse {
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetSparse",
+			Args:     code.ListCommaStmt{code.RawStmt("true")},
+		})
+	}
+	if idx.ExpireAfterSeconds > 0 {
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetExpireAfterSeconds",
+			Args:     code.ListCommaStmt{code.RawStmt(strconv.Itoa(int(idx.ExpireAfterSeconds)))},
+		})
+	}
+	if idx.PartialFilter != nil {
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetPartialFilterExpression",
+			Args:     code.ListCommaStmt{code.RawStmt(bsonLiteralCodegen(idx.PartialFilter))},
+		})
+	}
+
+	if len(chainCall) == 1 {
+		return ""
+	}
+	return "Options: " + chainCall.Code() + ",\n"
+}
+
+// bsonLiteralCodegen converts the decoded JSON value to bson literal code.
+func bsonLiteralCodegen(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		result := "bson.M{\n"
+		for _, key := range keys {
+			result += fmt.Sprintf("%s: %s,\n", strconv.Quote(key), bsonLiteralCodegen(v[key]))
+		}
+		return result + "}"
+	case []interface{}:
+		elements := make([]string, 0, len(v))
+		for _, e := range v {
+			elements = append(elements, bsonLiteralCodegen(e))
+		}
+		return "bson.A{" + strings.Join(elements, ", ") + "}"
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return "nil"
+	}
+}
+
+// CheckIndexCoverage returns warnings for the Find, Count and Update operations whose queries
+// filter on fields not covered by any declared index, the first field of each index is regarded
+// as usable by the query.
+func CheckIndexCoverage(ifOperation *parse.InterfaceOperation) (warnings []string) {
+	st := ifOperation.BelongedToStruct
+	if st == nil {
+		return nil
+	}
+
+	prefixes := map[string]struct{}{"_id": {}}
+	for _, idx := range st.Indexes {
+		prefixes[idx.Keys[0].MongoFieldName] = struct{}{}
+	}
+
+	for _, operation := range ifOperation.Operations {
+		var query *parse.Query
+		var methodName string
+		switch op := operation.(type) {
+		case *parse.FindParse:
+			query, methodName = op.Query, op.BelongedToMethod.Name
+		case *parse.CountParse:
+			query, methodName = op.Query, op.BelongedToMethod.Name
+		case *parse.UpdateParse:
+			query, methodName = op.Query, op.BelongedToMethod.Name
+		default:
+			continue
+		}
+
+		if query.QueryMode != parse.By || isQueryCovered(query.ConnectionOpTree, prefixes) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("method %s of %s filters on fields %v not covered by any declared index",
+			methodName, st.Name, uncoveredFields(query.ConnectionOpTree, prefixes)))
+	}
+
+	return
+}
+
+// isQueryCovered reports whether the index can be used, the And node needs either side covered,
+// the Or node needs both sides covered.
+func isQueryCovered(node *parse.ConnectionOpTree, prefixes map[string]struct{}) bool {
+	if node.LeftChildren == nil {
+		_, ok := prefixes[node.MongoFieldName]
+		return ok
+	}
+
+	if node.Name == string(parse.Or) {
+		return isQueryCovered(node.LeftChildren, prefixes) && isQueryCovered(node.RightChildren, prefixes)
+	}
+	return isQueryCovered(node.LeftChildren, prefixes) || isQueryCovered(node.RightChildren, prefixes)
+}
+
+func uncoveredFields(node *parse.ConnectionOpTree, prefixes map[string]struct{}) (result []string) {
+	if node.LeftChildren == nil {
+		if _, ok := prefixes[node.MongoFieldName]; !ok {
+			return []string{node.MongoFieldName}
+		}
+		return nil
+	}
+
+	result = append(result, uncoveredFields(node.LeftChildren, prefixes)...)
+	for _, field := range uncoveredFields(node.RightChildren, prefixes) {
+		isRepeated := false
+		for _, r := range result {
+			if r == field {
+				isRepeated = true
+				break
+			}
+		}
+		if !isRepeated {
+			result = append(result, field)
+		}
+	}
+	return
+}
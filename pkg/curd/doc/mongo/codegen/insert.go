@@ -17,11 +17,38 @@
 package codegen
 
 import (
+	"fmt"
+
 	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
 	"github.com/cloudwego/cwgo/pkg/curd/parse"
 )
 
 func insertCodegen(insert *parse.InsertParse) []code.Statement {
+	return append(insertTimestampsCodegen(insert), insertCollectionCodegen(insert)...)
+}
+
+// insertTimestampsCodegen sets created_at and updated_at of the entities to be inserted.
+func insertTimestampsCodegen(insert *parse.InsertParse) []code.Statement {
+	st := insert.BelongedToMethod.BelongedToStruct
+	if !st.Options.Timestamps {
+		return nil
+	}
+
+	now := getLocalName(insert.BelongedToMethod, "now")
+	entities := insert.MethodParamNames[1]
+	if insert.OperateMode == parse.OperateOne {
+		return append([]code.Statement{nowCodegen(now)}, createTimestampsCodegen(st, entities, now)...)
+	}
+	return []code.Statement{
+		nowCodegen(now),
+		code.RawStmt(fmt.Sprintf("for i := range %s {\n\t%s\n\t%s\n}", entities,
+			assignFieldCodegen(st, entities+"[i]", extract.CreatedAtField, now).Code(),
+			assignFieldCodegen(st, entities+"[i]", extract.UpdatedAtField, now).Code())),
+	}
+}
+
+func insertCollectionCodegen(insert *parse.InsertParse) []code.Statement {
 	if insert.OperateMode == parse.OperateOne {
 		return []code.Statement{
 			code.DeclColonStmt{
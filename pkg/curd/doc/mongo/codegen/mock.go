@@ -0,0 +1,174 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+var MockImports = map[string]string{
+	"context":                 "",
+	"reflect":                 "",
+	"go.uber.org/mock/gomock": "",
+}
+
+// GetMockRenders returns the renders of the gomock compatible mock which implements the repository interface,
+// the generated code is the same as the code generated by mockgen.
+func GetMockRenders(extractStruct *extract.IdlExtractStruct, methods code.InterfaceMethods) []template.Render {
+	mockName := "Mock" + extractStruct.Name + "Repository"
+	recorderName := mockName + "MockRecorder"
+
+	renders := []template.Render{
+		&template.StructRender{
+			Name:    mockName,
+			Comment: fmt.Sprintf("// %s is a mock of %sRepository interface.", mockName, extractStruct.Name),
+			StructFields: code.StructFields{
+				code.StructField{
+					Name: "ctrl",
+					Type: code.StarExprType{RealType: code.SelectorExprType{X: "gomock", Sel: "Controller"}},
+				},
+				code.StructField{
+					Name: "recorder",
+					Type: code.StarExprType{RealType: code.IdentType(recorderName)},
+				},
+			},
+		},
+		&template.StructRender{
+			Name:    recorderName,
+			Comment: fmt.Sprintf("// %s is the mock recorder for %s.", recorderName, mockName),
+			StructFields: code.StructFields{
+				code.StructField{
+					Name: "mock",
+					Type: code.StarExprType{RealType: code.IdentType(mockName)},
+				},
+			},
+		},
+		&template.FuncRender{
+			Name:    "New" + mockName,
+			Comment: "// New" + mockName + " creates a new mock instance.",
+			Params: code.Params{
+				code.Param{
+					Name: "ctrl",
+					Type: code.StarExprType{RealType: code.SelectorExprType{X: "gomock", Sel: "Controller"}},
+				},
+			},
+			Returns: code.Returns{
+				code.StarExprType{RealType: code.IdentType(mockName)},
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("mock := &%s{ctrl: ctrl}", mockName)),
+				code.RawStmt(fmt.Sprintf("mock.recorder = &%s{mock}", recorderName)),
+				code.RawStmt("return mock"),
+			},
+		},
+		&template.MethodRender{
+			Name:    "EXPECT",
+			Comment: "// EXPECT returns an object that allows the caller to indicate expected use.",
+			MethodReceiver: code.MethodReceiver{
+				Name: "m",
+				Type: code.StarExprType{RealType: code.IdentType(mockName)},
+			},
+			Returns: code.Returns{
+				code.StarExprType{RealType: code.IdentType(recorderName)},
+			},
+			MethodBody: code.Body{
+				code.RawStmt("return m.recorder"),
+			},
+		},
+	}
+
+	for _, method := range methods {
+		renders = append(renders, mockMethodCodegen(mockName, method), mockRecorderCodegen(mockName, recorderName, method))
+	}
+
+	return renders
+}
+
+// mockMethodCodegen generates the mock method, params are renamed to arg0, arg1... to avoid
+// conflicts with package names.
+func mockMethodCodegen(mockName string, method code.InterfaceMethod) *template.MethodRender {
+	params := make(code.Params, 0, len(method.Params))
+	args := []string{"m", fmt.Sprintf("%q", method.Name)}
+	for index, param := range method.Params {
+		params = append(params, code.Param{
+			Name: fmt.Sprintf("arg%d", index),
+			Type: param.Type,
+		})
+		args = append(args, fmt.Sprintf("arg%d", index))
+	}
+
+	body := code.Body{
+		code.RawStmt("m.ctrl.T.Helper()"),
+		code.RawStmt(fmt.Sprintf("ret := m.ctrl.Call(%s)", strings.Join(args, ", "))),
+	}
+	rets := make([]string, 0, len(method.Returns))
+	for index, ret := range method.Returns {
+		body = append(body, code.RawStmt(fmt.Sprintf("ret%d, _ := ret[%d].(%s)", index, index, ret.RealName())))
+		rets = append(rets, fmt.Sprintf("ret%d", index))
+	}
+	body = append(body, code.RawStmt("return "+strings.Join(rets, ", ")))
+
+	return &template.MethodRender{
+		Name:    method.Name,
+		Comment: fmt.Sprintf("// %s mocks base method.", method.Name),
+		MethodReceiver: code.MethodReceiver{
+			Name: "m",
+			Type: code.StarExprType{RealType: code.IdentType(mockName)},
+		},
+		Params:     params,
+		Returns:    method.Returns,
+		MethodBody: body,
+	}
+}
+
+func mockRecorderCodegen(mockName, recorderName string, method code.InterfaceMethod) *template.MethodRender {
+	params := make(code.Params, 0, len(method.Params))
+	args := []string{
+		"mr.mock",
+		fmt.Sprintf("%q", method.Name),
+		fmt.Sprintf("reflect.TypeOf((*%s)(nil).%s)", mockName, method.Name),
+	}
+	for index := range method.Params {
+		params = append(params, code.Param{
+			Name: fmt.Sprintf("arg%d", index),
+			Type: code.InterfaceType{},
+		})
+		args = append(args, fmt.Sprintf("arg%d", index))
+	}
+
+	return &template.MethodRender{
+		Name:    method.Name,
+		Comment: fmt.Sprintf("// %s indicates an expected call of %s.", method.Name, method.Name),
+		MethodReceiver: code.MethodReceiver{
+			Name: "mr",
+			Type: code.StarExprType{RealType: code.IdentType(recorderName)},
+		},
+		Params: params,
+		Returns: code.Returns{
+			code.StarExprType{RealType: code.SelectorExprType{X: "gomock", Sel: "Call"}},
+		},
+		MethodBody: code.Body{
+			code.RawStmt("mr.mock.ctrl.T.Helper()"),
+			code.RawStmt(fmt.Sprintf("return mr.mock.ctrl.RecordCallWithMethodType(%s)", strings.Join(args, ", "))),
+		},
+	}
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// CheckModelOptions returns an error if the Bulk or Transaction operation of the structure updates or replaces
+// the whole entity of the model with the version option, whose version can not be checked in the batch.
+func CheckModelOptions(ifOperation *parse.InterfaceOperation) error {
+	for _, operation := range ifOperation.Operations {
+		method := parse.GetBelongedToMethod(operation)
+		switch op := operation.(type) {
+		case *parse.BulkParse:
+			if err := checkBatchOptions(method, op.Operations); err != nil {
+				return err
+			}
+		case *parse.TransactionParse:
+			for _, taOperation := range op.TransactionOperations {
+				operations := []parse.Operation{taOperation.Operation}
+				if bulk, ok := taOperation.Operation.(*parse.BulkParse); ok {
+					operations = bulk.Operations
+				}
+				if err := checkBatchOptions(method, operations); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkBatchOptions(method *extract.InterfaceMethod, operations []parse.Operation) error {
+	for _, operation := range operations {
+		st := parse.GetBelongedToMethod(operation).BelongedToStruct
+		if !st.Options.Version {
+			continue
+		}
+		switch op := operation.(type) {
+		case *parse.UpdateParse:
+			if op.UpdateStructObjName == "" {
+				continue
+			}
+		case *parse.ReplaceParse:
+		default:
+			continue
+		}
+		return fmt.Errorf("%s: the whole entity of %s with the version option can not be updated or replaced "+
+			"in Bulk or Transaction, update its fields instead", method.Name, st.Name)
+	}
+	return nil
+}
+
+// optionNowRequired reports whether the operations set the timestamps or deleted_at, which requires the current time.
+func optionNowRequired(operations []parse.Operation) bool {
+	for _, operation := range operations {
+		options := parse.GetBelongedToMethod(operation).BelongedToStruct.Options
+		switch op := operation.(type) {
+		case *parse.InsertParse, *parse.UpdateParse, *parse.ReplaceParse:
+			if options.Timestamps {
+				return true
+			}
+		case *parse.DeleteParse:
+			if options.SoftDelete {
+				return true
+			}
+		case *parse.BulkParse:
+			if optionNowRequired(op.Operations) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// createTimestampsCodegen sets created_at and updated_at of the entity to be inserted, it is empty without timestamps.
+func createTimestampsCodegen(st *extract.IdlExtractStruct, entity, now string) []code.Statement {
+	if !st.Options.Timestamps {
+		return nil
+	}
+	return []code.Statement{
+		assignFieldCodegen(st, entity, extract.CreatedAtField, now),
+		assignFieldCodegen(st, entity, extract.UpdatedAtField, now),
+	}
+}
+
+// softDeleteFieldsCodegen returns the update which sets deleted_at and updated_at of the soft deleted documents.
+func softDeleteFieldsCodegen(st *extract.IdlExtractStruct, now string) code.MapStmt {
+	fields := []code.MapPair{
+		{
+			Key:   code.RawStmt(extract.DeletedAtField),
+			Value: code.RawStmt(now),
+		},
+	}
+	if st.Options.Timestamps {
+		fields = append(fields, code.MapPair{
+			Key:   code.RawStmt(extract.UpdatedAtField),
+			Value: code.RawStmt(now),
+		})
+	}
+	return code.MapStmt{
+		Name: "bson.M",
+		Pair: []code.MapPair{
+			{
+				Key: code.RawStmt("$set"),
+				Value: code.MapStmt{
+					Name: "bson.M",
+					Pair: fields,
+				},
+			},
+		},
+	}
+}
+
+// optionUpdateCodegen returns the update of the Update operation with the model options, updated_at of the entity
+// is set by the returned statements if the whole entity is updated.
+func optionUpdateCodegen(update *parse.UpdateParse, now string) ([]code.Statement, code.Statement) {
+	st := update.BelongedToMethod.BelongedToStruct
+	if update.UpdateStructObjName == "" {
+		return nil, updateOptionFieldsCodegen(update, updateFieldsCodegen(update), now)
+	}
+	if !st.Options.Timestamps {
+		return nil, updateFieldsCodegen(update)
+	}
+	return []code.Statement{assignFieldCodegen(st, update.UpdateStructObjName, extract.UpdatedAtField, now)},
+		entityPipelineCodegen(update.UpdateStructObjName, now, true)
+}
+
+// entityPipelineCodegen returns the update pipeline which writes the whole entity of the model with timestamps,
+// created_at of the entity is replaced by the stored one, or now if the document is inserted by upsert. The fields
+// of the entity are set to the stored document if merge is true, the same as $set, otherwise the document except
+// its _id is replaced by the entity, the same as ReplaceOne. The entity is a $literal, so its strings starting
+// with $ are not evaluated as the expressions.
+func entityPipelineCodegen(entity, now string, merge bool) code.Statement {
+	kept := []code.MapPair{
+		{
+			Key:   code.RawStmt(extract.CreatedAtField),
+			Value: code.RawStmt(fmt.Sprintf("bson.M{\"$ifNull\": bson.A{\"$%s\", %s}}", extract.CreatedAtField, now)),
+		},
+	}
+	documents := fmt.Sprintf("bson.M{\"$literal\": %s}", entity)
+	if merge {
+		documents = "\"$$ROOT\", " + documents
+	} else {
+		kept = append([]code.MapPair{{Key: code.RawStmt("_id"), Value: code.RawStmt("\"$_id\"")}}, kept...)
+	}
+	documents += ", " + code.MapStmt{Name: "bson.M", Pair: kept}.Code()
+
+	return code.RawStmt("bson.A{\n" + code.MapStmt{
+		Name: "bson.M",
+		Pair: []code.MapPair{
+			{
+				Key: code.RawStmt("$replaceWith"),
+				Value: code.MapStmt{
+					Name: "bson.M",
+					Pair: []code.MapPair{
+						{
+							Key:   code.RawStmt("$mergeObjects"),
+							Value: code.RawStmt("bson.A{" + documents + "}"),
+						},
+					},
+				},
+			},
+		},
+	}.Code() + ",\n}")
+}
+
+// getLocalName returns the name of the local variable which is not shadowed by the method params.
+func getLocalName(method *extract.InterfaceMethod, name string) string {
+	for _, param := range method.Params {
+		if param.Name == name {
+			return getLocalName(method, name+"Value")
+		}
+	}
+	return name
+}
+
+// nowCodegen returns the declaration of the current time used by the timestamps and the soft deletion.
+func nowCodegen(now string) code.Statement {
+	return code.RawStmt(now + " := time.Now().UnixMilli()")
+}
+
+// assignFieldCodegen returns the assignment of the top-level field of the entity specified by mongo field name.
+func assignFieldCodegen(st *extract.IdlExtractStruct, entity, mongoName, value string) code.Statement {
+	goPath, t, _ := st.GetFieldByMongoName(mongoName)
+	if _, ok := t.(code.StarExprType); ok {
+		value = "&" + value
+	}
+	return code.RawStmt(fmt.Sprintf("%s.%s = %s", entity, goPath, value))
+}
+
+// optionQuery returns the query which skips the soft deleted documents, the query is not modified.
+func optionQuery(query *parse.Query, st *extract.IdlExtractStruct) *parse.Query {
+	if !st.Options.SoftDelete {
+		return query
+	}
+
+	notDeleted := &parse.ConnectionOpTree{
+		Name:           string(parse.NotExists),
+		MongoFieldName: extract.DeletedAtField,
+	}
+	return andQuery(query, notDeleted)
+}
+
+// andQuery returns the query whose conditions are connected with the leaf by And.
+func andQuery(query *parse.Query, leaf *parse.ConnectionOpTree) *parse.Query {
+	if query.QueryMode == parse.All {
+		return &parse.Query{QueryMode: parse.By, ConnectionOpTree: leaf}
+	}
+	return &parse.Query{
+		QueryMode: parse.By,
+		ConnectionOpTree: &parse.ConnectionOpTree{
+			Name:          string(parse.And),
+			LeftChildren:  query.ConnectionOpTree,
+			RightChildren: leaf,
+		},
+	}
+}
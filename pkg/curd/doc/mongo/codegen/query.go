@@ -17,6 +17,8 @@
 package codegen
 
 import (
+	"strings"
+
 	"github.com/cloudwego/cwgo/pkg/curd/code"
 	"github.com/cloudwego/cwgo/pkg/curd/parse"
 )
@@ -44,7 +46,7 @@ func dfsCodegen(node *parse.ConnectionOpTree) code.MapPair {
 	} else {
 		// none-leaves node
 		return code.MapPair{
-			Key: code.RawStmt(node.Name),
+			Key: code.RawStmt("$" + strings.ToLower(node.Name)),
 			Value: code.SliceStmt{
 				Name: "[]bson.M",
 				Values: []code.MapPair{
@@ -59,27 +61,36 @@ func dfsCodegen(node *parse.ConnectionOpTree) code.MapPair {
 func comparatorCodegen(node *parse.ConnectionOpTree) code.MapPair {
 	switch parse.QueryComparator(node.Name) {
 	case parse.Equal:
-		return singleMapCodegen(node.MongoFieldName, node.ParamNames[0])
+		return singleMapCodegen(node.MongoFieldName, node.Values()[0])
 	case parse.NotEqual:
-		return oneMapParamCodegen(node.MongoFieldName, "$ne", node.ParamNames[0])
+		return oneMapParamCodegen(node.MongoFieldName, "$ne", node.Values()[0])
 	case parse.LessThan:
-		return oneMapParamCodegen(node.MongoFieldName, "$lt", node.ParamNames[0])
+		return oneMapParamCodegen(node.MongoFieldName, "$lt", node.Values()[0])
 	case parse.LessThanEqual:
-		return oneMapParamCodegen(node.MongoFieldName, "$lte", node.ParamNames[0])
+		return oneMapParamCodegen(node.MongoFieldName, "$lte", node.Values()[0])
 	case parse.GreaterThan:
-		return oneMapParamCodegen(node.MongoFieldName, "$gt", node.ParamNames[0])
+		return oneMapParamCodegen(node.MongoFieldName, "$gt", node.Values()[0])
 	case parse.GreaterThanEqual:
-		return oneMapParamCodegen(node.MongoFieldName, "$gte", node.ParamNames[0])
+		return oneMapParamCodegen(node.MongoFieldName, "$gte", node.Values()[0])
 	case parse.Between:
-		return twoMapParamsCodegen(node.MongoFieldName, "$gte", node.ParamNames[0],
-			"$lte", node.ParamNames[1])
+		return twoMapParamsCodegen(node.MongoFieldName, "$gte", node.Values()[0],
+			"$lte", node.Values()[1])
 	case parse.NotBetween:
-		return twoMapParamsCodegen(node.MongoFieldName, "$lt", node.ParamNames[0],
-			"$gt", node.ParamNames[1])
+		// the field is either less than the lower bound or greater than the upper bound
+		return code.MapPair{
+			Key: code.RawStmt("$or"),
+			Value: code.SliceStmt{
+				Name: "[]bson.M",
+				Values: []code.MapPair{
+					oneMapParamCodegen(node.MongoFieldName, "$lt", node.Values()[0]),
+					oneMapParamCodegen(node.MongoFieldName, "$gt", node.Values()[1]),
+				},
+			},
+		}
 	case parse.In:
-		return oneMapParamCodegen(node.MongoFieldName, "$in", node.ParamNames[0])
+		return oneMapParamCodegen(node.MongoFieldName, "$in", node.Values()[0])
 	case parse.NotIn:
-		return oneMapParamCodegen(node.MongoFieldName, "$n"+"in", node.ParamNames[0])
+		return oneMapParamCodegen(node.MongoFieldName, "$n"+"in", node.Values()[0])
 	case parse.True:
 		return singleMapCodegen(node.MongoFieldName, "true")
 	case parse.False:
@@ -0,0 +1,369 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+const (
+	NewBsonRegistry    = "NewBsonRegistry"
+	RegisterBsonCodecs = "RegisterBsonCodecs"
+)
+
+// wellKnownCodecs are the encoders and the decoders of the well-known types of proto.
+var wellKnownCodecs = map[string][2]string{
+	"timestamppb.Timestamp":  {"encodeTimestamp", "decodeTimestamp"},
+	"durationpb.Duration":    {"encodeDuration", "decodeDuration"},
+	"wrapperspb.StringValue": {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.BoolValue":   {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.Int32Value":  {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.Int64Value":  {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.UInt32Value": {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.UInt64Value": {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.FloatValue":  {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.DoubleValue": {"encodeWrapper", "decodeWrapper"},
+	"wrapperspb.BytesValue":  {"encodeWrapper", "decodeWrapper"},
+}
+
+// registryCollector collects the types of the fields which need the codecs.
+type registryCollector struct {
+	// modelPkg is the package of the registry, whose types are referenced without the package name
+	modelPkg       string
+	wellKnownTypes map[string]struct{}
+	enums          map[string]struct{}
+	oneofs         []*registryOneof
+	visited        map[string]struct{}
+}
+
+// registryOneof is the oneof field of the structure whose go type is owner.
+type registryOneof struct {
+	owner code.SelectorExprType
+	field *extract.StructField
+}
+
+// GetRegistryRenders returns the registry builder of the model package of the structures, which registers
+// the codecs of the enums, the oneofs and the well-known types of proto used by the models.
+func GetRegistryRenders(structs []*extract.IdlExtractStruct) []template.Render {
+	collector := &registryCollector{
+		modelPkg:       structs[0].ModelPkg,
+		wellKnownTypes: make(map[string]struct{}),
+		enums:          make(map[string]struct{}),
+		visited:        make(map[string]struct{}),
+	}
+	for _, st := range structs {
+		collector.collectFields(st.StructFields, code.SelectorExprType{X: st.ModelPkg, Sel: st.Name})
+	}
+
+	helpers := make(map[string]struct{})
+	body := make(code.Body, 0, 10)
+	for _, typeName := range sortedKeys(collector.enums) {
+		body = append(body, code.RawStmt(fmt.Sprintf(
+			"registry.RegisterTypeEncoder(reflect.TypeOf(%s(0)), bsoncodec.ValueEncoderFunc(encodeEnum))", typeName)))
+		helpers["encodeEnum"] = struct{}{}
+	}
+	for _, typeName := range sortedKeys(collector.wellKnownTypes) {
+		codecs := wellKnownCodecs[typeName]
+		reflectType := fmt.Sprintf("reflect.TypeOf((*%s)(nil))", typeName)
+		body = append(body,
+			code.RawStmt(fmt.Sprintf("registry.RegisterTypeEncoder(%s, bsoncodec.ValueEncoderFunc(%s))", reflectType, codecs[0])),
+			code.RawStmt(fmt.Sprintf("registry.RegisterTypeDecoder(%s, bsoncodec.ValueDecoderFunc(%s))", reflectType, codecs[1])),
+		)
+		helpers[codecs[0]] = struct{}{}
+		helpers[codecs[1]] = struct{}{}
+	}
+	for _, oneof := range collector.oneofs {
+		wrappers := ""
+		for _, member := range oneof.field.Oneof.Members {
+			wrappers += fmt.Sprintf("%s: reflect.TypeOf(%s{}),\n", strconv.Quote(member.Field.Tag.Get("bson")),
+				collector.typeName(member.WrapperType.(code.StarExprType).RealType))
+		}
+		// the interfaces of the oneofs of the other packages are unexported, they are got by the fields
+		reflectType := fmt.Sprintf("reflect.TypeOf((*%s)(nil)).Elem()", oneof.field.Oneof.InterfaceName)
+		if oneof.owner.X != collector.modelPkg {
+			fieldName := lowerFirst(oneof.owner.Sel) + oneof.field.Name + "Field"
+			body = append(body, code.RawStmt(fmt.Sprintf("%s, _ := reflect.TypeOf((*%s)(nil)).Elem().FieldByName(%q)",
+				fieldName, oneof.owner.RealName(), oneof.field.Name)))
+			reflectType = fieldName + ".Type"
+		}
+		body = append(body,
+			code.RawStmt(fmt.Sprintf("registry.RegisterTypeEncoder(%s, bsoncodec.ValueEncoderFunc(encodeOneof))", reflectType)),
+			code.RawStmt(fmt.Sprintf("registry.RegisterTypeDecoder(%s, oneofDecoder(map[string]reflect.Type{\n%s}))",
+				reflectType, wrappers)),
+		)
+		helpers["encodeOneof"] = struct{}{}
+		helpers["oneofDecoder"] = struct{}{}
+	}
+
+	registryType := code.StarExprType{
+		RealType: code.SelectorExprType{
+			X:   "bsoncodec",
+			Sel: "Registry",
+		},
+	}
+	renders := []template.Render{
+		&template.FuncRender{
+			Name: NewBsonRegistry,
+			Comment: fmt.Sprintf("// %s returns the default registry with the codecs of the models, which is set to the\n"+
+				"// collections of the repositories, the unexported internals of the messages such as state and sizeCache\n"+
+				"// are skipped by the default struct codec.", NewBsonRegistry),
+			Returns: code.Returns{registryType},
+			FuncBody: code.Body{
+				code.RawStmt("registry := bson.NewRegistry()"),
+				code.RawStmt(RegisterBsonCodecs + "(registry)"),
+				code.RawStmt("return registry"),
+			},
+		},
+		&template.FuncRender{
+			Name: RegisterBsonCodecs,
+			Comment: fmt.Sprintf("// %s registers the codecs of the enums, the oneofs and the well-known types of proto\n"+
+				"// used by the models, it is used if the registry of the client is customized.", RegisterBsonCodecs),
+			Params: code.Params{
+				code.Param{
+					Name: "registry",
+					Type: registryType,
+				},
+			},
+			FuncBody: body,
+		},
+	}
+
+	helperNames := sortedKeys(helpers)
+	if _, ok := helpers["decodeTimestamp"]; ok {
+		helperNames = append(helperNames, "decodeMessage")
+	} else if _, ok = helpers["decodeDuration"]; ok {
+		helperNames = append(helperNames, "decodeMessage")
+	}
+	for _, helper := range helperNames {
+		renders = append(renders, codecHelperRenders[helper])
+	}
+	return renders
+}
+
+// collectFields collects the enums, the oneofs and the well-known types of the fields recursively,
+// owner is the go type of the structure of the fields.
+func (c *registryCollector) collectFields(fields []*extract.StructField, owner code.SelectorExprType) {
+	// the structures may be nested in several fields
+	if _, ok := c.visited[owner.RealName()]; ok {
+		return
+	}
+	c.visited[owner.RealName()] = struct{}{}
+
+	for _, field := range fields {
+		if field.Oneof != nil {
+			c.oneofs = append(c.oneofs, &registryOneof{owner: owner, field: field})
+			for _, member := range field.Oneof.Members {
+				c.collectField(member.Field)
+			}
+			continue
+		}
+		c.collectField(field)
+	}
+}
+
+func (c *registryCollector) collectField(field *extract.StructField) {
+	baseType := elementType(field.Type)
+	if _, ok := wellKnownCodecs[baseType.RealName()]; ok {
+		c.wellKnownTypes[baseType.RealName()] = struct{}{}
+		return
+	}
+	selectorType, ok := baseType.(code.SelectorExprType)
+	if !ok {
+		return
+	}
+	if field.Enum != nil {
+		c.enums[c.typeName(selectorType)] = struct{}{}
+	}
+	if field.IsBelongedToStruct && field.BelongedToStruct != nil {
+		c.collectFields(field.BelongedToStruct.StructFields, selectorType)
+	}
+	if field.ElementStruct != nil {
+		c.collectFields(field.ElementStruct.StructFields, selectorType)
+	}
+}
+
+// typeName returns the name of the type in the package of the registry.
+func (c *registryCollector) typeName(t code.Type) string {
+	if selectorType, ok := t.(code.SelectorExprType); ok && selectorType.X == c.modelPkg {
+		return selectorType.Sel
+	}
+	return t.RealName()
+}
+
+// elementType returns the type of the pointers, or the elements of the slices and the maps.
+func elementType(t code.Type) code.Type {
+	switch tt := t.(type) {
+	case code.StarExprType:
+		return elementType(tt.RealType)
+	case code.SliceType:
+		return elementType(tt.ElementType)
+	case code.MapType:
+		return elementType(tt.ValueType)
+	}
+	return t
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return string(s[0]|0x20) + s[1:]
+}
+
+var (
+	encodeContextParam = code.Param{Name: "ec", Type: code.SelectorExprType{X: "bsoncodec", Sel: "EncodeContext"}}
+	decodeContextParam = code.Param{Name: "dc", Type: code.SelectorExprType{X: "bsoncodec", Sel: "DecodeContext"}}
+	valueWriterParam   = code.Param{Name: "vw", Type: code.SelectorExprType{X: "bsonrw", Sel: "ValueWriter"}}
+	valueReaderParam   = code.Param{Name: "vr", Type: code.SelectorExprType{X: "bsonrw", Sel: "ValueReader"}}
+	reflectValueParam  = code.Param{Name: "val", Type: code.SelectorExprType{X: "reflect", Sel: "Value"}}
+	errorReturns       = code.Returns{code.IdentType("error")}
+)
+
+func encoderRender(name, comment string, body ...code.Statement) *template.FuncRender {
+	return &template.FuncRender{
+		Name:     name,
+		Comment:  comment,
+		Params:   code.Params{encodeContextParam, valueWriterParam, reflectValueParam},
+		Returns:  errorReturns,
+		FuncBody: body,
+	}
+}
+
+func decoderRender(name, comment string, body ...code.Statement) *template.FuncRender {
+	return &template.FuncRender{
+		Name:     name,
+		Comment:  comment,
+		Params:   code.Params{decodeContextParam, valueReaderParam, reflectValueParam},
+		Returns:  errorReturns,
+		FuncBody: body,
+	}
+}
+
+const (
+	writeNullCodegen = "if val.IsNil() {\n\treturn vw.WriteNull()\n}"
+	readNullCodegen  = "if vr.Type() == bsontype.Null {\n\tval.Set(reflect.Zero(val.Type()))\n\treturn vr.ReadNull()\n}"
+)
+
+var codecHelperRenders = map[string]template.Render{
+	"encodeEnum": encoderRender("encodeEnum",
+		"// encodeEnum stores the enum as int32 which is the type of the enums in the idls.",
+		code.RawStmt("return vw.WriteInt32(int32(val.Int()))"),
+	),
+	"encodeTimestamp": encoderRender("encodeTimestamp",
+		"// encodeTimestamp stores the timestamp as the date whose precision is milliseconds.",
+		code.RawStmt(writeNullCodegen),
+		code.RawStmt("return vw.WriteDateTime(val.Interface().(*timestamppb.Timestamp).AsTime().UnixMilli())"),
+	),
+	"decodeTimestamp": decoderRender("decodeTimestamp",
+		"// decodeTimestamp reads the date, the documents of seconds and nanos stored without the codec are also accepted.",
+		code.RawStmt(readNullCodegen),
+		code.RawStmt("if vr.Type() == bsontype.EmbeddedDocument {\n\treturn decodeMessage(dc, vr, val)\n}"),
+		code.RawStmt("dateTime, err := vr.ReadDateTime()\nif err != nil {\n\treturn err\n}"),
+		code.RawStmt("val.Set(reflect.ValueOf(timestamppb.New(time.UnixMilli(dateTime))))"),
+		code.RawStmt("return nil"),
+	),
+	"encodeDuration": encoderRender("encodeDuration",
+		"// encodeDuration stores the duration as the int64 nanoseconds.",
+		code.RawStmt(writeNullCodegen),
+		code.RawStmt("return vw.WriteInt64(int64(val.Interface().(*durationpb.Duration).AsDuration()))"),
+	),
+	"decodeDuration": decoderRender("decodeDuration",
+		"// decodeDuration reads the nanoseconds, the documents of seconds and nanos stored without the codec are also accepted.",
+		code.RawStmt(readNullCodegen),
+		code.RawStmt("if vr.Type() == bsontype.EmbeddedDocument {\n\treturn decodeMessage(dc, vr, val)\n}"),
+		code.RawStmt("var nanoseconds int64"),
+		code.RawStmt("decoder, err := dc.LookupDecoder(reflect.TypeOf(nanoseconds))\nif err != nil {\n\treturn err\n}"),
+		code.RawStmt("if err = decoder.DecodeValue(dc, vr, reflect.ValueOf(&nanoseconds).Elem()); err != nil {\n\treturn err\n}"),
+		code.RawStmt("val.Set(reflect.ValueOf(durationpb.New(time.Duration(nanoseconds))))"),
+		code.RawStmt("return nil"),
+	),
+	"decodeMessage": decoderRender("decodeMessage",
+		"// decodeMessage decodes the document into the message by the fields.",
+		code.RawStmt("message := reflect.New(val.Type().Elem())"),
+		code.RawStmt("decoder, err := dc.LookupDecoder(message.Elem().Type())\nif err != nil {\n\treturn err\n}"),
+		code.RawStmt("if err = decoder.DecodeValue(dc, vr, message.Elem()); err != nil {\n\treturn err\n}"),
+		code.RawStmt("val.Set(message)"),
+		code.RawStmt("return nil"),
+	),
+	"encodeWrapper": encoderRender("encodeWrapper",
+		"// encodeWrapper stores the wrapper as its value, null if it is nil.",
+		code.RawStmt(writeNullCodegen),
+		code.RawStmt("value := val.Elem().FieldByName(\"Value\")"),
+		code.RawStmt("encoder, err := ec.LookupEncoder(value.Type())\nif err != nil {\n\treturn err\n}"),
+		code.RawStmt("return encoder.EncodeValue(ec, vw, value)"),
+	),
+	"decodeWrapper": decoderRender("decodeWrapper",
+		"// decodeWrapper reads the value of the wrapper, the wrapper is nil if the value is null.",
+		code.RawStmt(readNullCodegen),
+		code.RawStmt("wrapper := reflect.New(val.Type().Elem())"),
+		code.RawStmt("value := wrapper.Elem().FieldByName(\"Value\")"),
+		code.RawStmt("decoder, err := dc.LookupDecoder(value.Type())\nif err != nil {\n\treturn err\n}"),
+		code.RawStmt("if err = decoder.DecodeValue(dc, vr, value); err != nil {\n\treturn err\n}"),
+		code.RawStmt("val.Set(wrapper)"),
+		code.RawStmt("return nil"),
+	),
+	"encodeOneof": encoderRender("encodeOneof",
+		"// encodeOneof stores the oneof as the document of the member set in it, null if it is not set.",
+		code.RawStmt(writeNullCodegen),
+		code.RawStmt("wrapper := val.Elem()"),
+		code.RawStmt("encoder, err := ec.LookupEncoder(wrapper.Type())\nif err != nil {\n\treturn err\n}"),
+		code.RawStmt("return encoder.EncodeValue(ec, vw, wrapper)"),
+	),
+	"oneofDecoder": &template.FuncRender{
+		Name: "oneofDecoder",
+		Comment: "// oneofDecoder returns the decoder of the oneof, the wrapper is chosen by the member set in the document,\n" +
+			"// the oneof is not set if no member is found.",
+		Params: code.Params{
+			code.Param{Name: "wrappers", Type: code.MapType{
+				KeyType:   code.IdentType("string"),
+				ValueType: code.SelectorExprType{X: "reflect", Sel: "Type"},
+			}},
+		},
+		Returns: code.Returns{code.SelectorExprType{X: "bsoncodec", Sel: "ValueDecoderFunc"}},
+		FuncBody: code.Body{
+			code.RawStmt("return func(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {\n" +
+				readNullCodegen + "\n" +
+				"var raw bson.Raw\n" +
+				"decoder, err := dc.LookupDecoder(reflect.TypeOf(raw))\nif err != nil {\n\treturn err\n}\n" +
+				"if err = decoder.DecodeValue(dc, vr, reflect.ValueOf(&raw).Elem()); err != nil {\n\treturn err\n}\n" +
+				"elements, err := raw.Elements()\nif err != nil {\n\treturn err\n}\n" +
+				"for _, element := range elements {\n" +
+				"wrapperType, ok := wrappers[element.Key()]\nif !ok {\n\tcontinue\n}\n" +
+				"wrapper := reflect.New(wrapperType)\n" +
+				"if decoder, err = dc.LookupDecoder(wrapperType); err != nil {\n\treturn err\n}\n" +
+				"if err = decoder.DecodeValue(dc, bsonrw.NewBSONDocumentReader(raw), wrapper.Elem()); err != nil {\n\treturn err\n}\n" +
+				"val.Set(wrapper)\nreturn nil\n}\n" +
+				"val.Set(reflect.Zero(val.Type()))\n" +
+				"return nil\n}"),
+		},
+	},
+}
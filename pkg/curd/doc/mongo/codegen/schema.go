@@ -0,0 +1,328 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+const ApplyValidator = "ApplyValidator"
+
+var SchemaImports = map[string]string{
+	"context":                           "",
+	"errors":                            "",
+	"go.mongodb.org/mongo-driver/bson":  "",
+	"go.mongodb.org/mongo-driver/mongo": "",
+	"go.mongodb.org/mongo-driver/mongo/options": "",
+}
+
+// namespaceExists is the error code returned by mongodb when the collection to be created exists.
+const namespaceExists = 48
+
+// GetSchemaFuncRenders returns the function which returns the $jsonSchema of the collection
+// and ApplyValidator which applies it to the collection.
+func GetSchemaFuncRenders(extractStruct *extract.IdlExtractStruct) []*template.FuncRender {
+	schemaName := extractStruct.Name + "JSONSchema"
+	collection := strconv.Quote(extractStruct.GetCollectionName())
+
+	return []*template.FuncRender{
+		{
+			Name: schemaName,
+			Comment: fmt.Sprintf("// %s returns the $jsonSchema of the collection %s, the fields are required if they are\n"+
+				"// declared required in the idl and the enums only accept the values declared in the idl.",
+				schemaName, extractStruct.GetCollectionName()),
+			Returns: code.Returns{
+				code.SelectorExprType{
+					X:   "bson",
+					Sel: "M",
+				},
+			},
+			FuncBody: code.Body{
+				code.ReturnStmt{
+					ListCommaStmt: code.ListCommaStmt{
+						code.RawStmt(bsonLiteralCodegen(documentSchema(extractStruct.StructFields))),
+					},
+				},
+			},
+		},
+		{
+			Name: ApplyValidator,
+			Comment: fmt.Sprintf("// %s creates the collection %s with the validator of %s, the validator of the\n"+
+				"// existing collection is replaced, so the malformed documents written by the others are rejected.",
+				ApplyValidator, extractStruct.GetCollectionName(), schemaName),
+			Params: code.Params{
+				code.Param{
+					Name: "ctx",
+					Type: code.SelectorExprType{
+						X:   "context",
+						Sel: "Context",
+					},
+				},
+				code.Param{
+					Name: "db",
+					Type: code.StarExprType{
+						RealType: code.SelectorExprType{
+							X:   "mongo",
+							Sel: "Database",
+						},
+					},
+				},
+			},
+			Returns: code.Returns{
+				code.IdentType("error"),
+			},
+			FuncBody: applyValidatorCodegen(schemaName, collection),
+		},
+	}
+}
+
+func applyValidatorCodegen(schemaName, collection string) code.Body {
+	return code.Body{
+		code.DeclColonStmt{
+			Left:  code.ListCommaStmt{code.RawStmt("validator")},
+			Right: code.RawStmt(fmt.Sprintf("bson.M{\"$jsonSchema\": %s()}", schemaName)),
+		},
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{code.RawStmt("err")},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt("db"),
+				CallName: "CreateCollection",
+				Args: code.ListCommaStmt{
+					code.RawStmt("ctx"),
+					code.RawStmt(collection),
+					code.RawStmt("options.CreateCollection().SetValidator(validator)"),
+				},
+			},
+		},
+		code.DeclVarStmt{
+			Name: "cmdErr",
+			Type: code.SelectorExprType{
+				X:   "mongo",
+				Sel: "CommandError",
+			},
+		},
+		code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt(fmt.Sprintf("!errors.As(err, &cmdErr) || cmdErr.Code != %d", namespaceExists)),
+			},
+			Body: code.Body{
+				code.ReturnStmt{
+					ListCommaStmt: code.ListCommaStmt{
+						code.RawStmt("err"),
+					},
+				},
+			},
+		},
+		code.RawStmt(fmt.Sprintf("return db.RunCommand(ctx, bson.D{\n{Key: \"collMod\", Value: %s},\n"+
+			"{Key: \"validator\", Value: validator},\n}).Err()", collection)),
+	}
+}
+
+// documentSchema returns the schema of the documents of the collection. The _id with omitempty is not written
+// if it is empty and the driver generates an ObjectID for it, so the ObjectID is accepted, and _id is not
+// validated if its values are limited by the enum.
+func documentSchema(fields []*extract.StructField) map[string]interface{} {
+	schema := structSchema(fields, false)
+	for _, field := range fields {
+		if field.Tag.Get("bson") != "_id" || !field.OmitEmpty {
+			continue
+		}
+		properties := schema["properties"].(map[string]interface{})
+		property, ok := properties["_id"].(map[string]interface{})
+		if !ok {
+			break
+		}
+		if _, ok = property["enum"]; ok {
+			delete(properties, "_id")
+			break
+		}
+		switch bsonType := property["bsonType"].(type) {
+		case []interface{}:
+			if !containsBsonType(bsonType, "objectId") {
+				property["bsonType"] = append(bsonType, "objectId")
+			}
+		case string:
+			if bsonType != "objectId" {
+				property["bsonType"] = []interface{}{bsonType, "objectId"}
+			}
+		}
+	}
+	return schema
+}
+
+func containsBsonType(types []interface{}, bsonType string) bool {
+	for _, t := range types {
+		if t == bsonType {
+			return true
+		}
+	}
+	return false
+}
+
+// structSchema returns the object schema of the fields, the nested structures are nullable because
+// the nil pointers are encoded as null.
+func structSchema(fields []*extract.StructField, nullable bool) map[string]interface{} {
+	properties := make(map[string]interface{}, len(fields))
+	required := make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		mongoName := field.Tag.Get("bson")
+		if mongoName == "" || mongoName == "-" {
+			continue
+		}
+
+		if field.Oneof != nil {
+			// the oneof is stored as the document of the member set in it
+			properties[mongoName] = map[string]interface{}{"bsonType": bsonTypes(true, "object")}
+		} else if field.IsBelongedToStruct && field.BelongedToStruct != nil {
+			properties[mongoName] = structSchema(field.BelongedToStruct.StructFields, true)
+		} else if field.ElementStruct != nil {
+			properties[mongoName] = elementSchema(field.Type, structSchema(field.ElementStruct.StructFields, true))
+		} else {
+			properties[mongoName] = typeSchema(field.Type, field.Enum)
+		}
+		// the empty values of the fields with omitempty are not written
+		if field.Required && !field.OmitEmpty {
+			required = append(required, mongoName)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"bsonType":   bsonTypes(nullable, "object"),
+		"properties": properties,
+	}
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// elementSchema returns the schema of the slice or the map whose elements are the structures.
+func elementSchema(t code.Type, schema map[string]interface{}) map[string]interface{} {
+	if _, ok := t.(code.MapType); ok {
+		return map[string]interface{}{
+			"bsonType":             bsonTypes(true, "object"),
+			"additionalProperties": schema,
+		}
+	}
+	return map[string]interface{}{
+		"bsonType": bsonTypes(true, "array"),
+		"items":    schema,
+	}
+}
+
+// typeSchema returns the schema of the type, the enum values are used by the enum type of the elements.
+func typeSchema(t code.Type, enum []int64) map[string]interface{} {
+	switch tt := t.(type) {
+	case code.StarExprType:
+		schema := typeSchema(tt.RealType, enum)
+		if types, ok := schema["bsonType"].([]interface{}); ok {
+			schema["bsonType"] = append(types, "null")
+		} else if bsonType, ok := schema["bsonType"]; ok {
+			schema["bsonType"] = []interface{}{bsonType, "null"}
+		}
+		if values, ok := schema["enum"].([]interface{}); ok {
+			schema["enum"] = append(values, nil)
+		}
+		return schema
+	case code.SliceType:
+		if tt.ElementType.RealName() == "byte" {
+			return map[string]interface{}{"bsonType": "binData"}
+		}
+		// the nil slices are encoded as null
+		return map[string]interface{}{
+			"bsonType": bsonTypes(true, "array"),
+			"items":    typeSchema(tt.ElementType, enum),
+		}
+	case code.MapType:
+		return map[string]interface{}{
+			"bsonType":             bsonTypes(true, "object"),
+			"additionalProperties": typeSchema(tt.ValueType, enum),
+		}
+	case code.InterfaceType:
+		return map[string]interface{}{}
+	}
+
+	switch t.RealName() {
+	case "string":
+		return map[string]interface{}{"bsonType": "string"}
+	case "bool":
+		return map[string]interface{}{"bsonType": "bool"}
+	case "float32", "float64":
+		return map[string]interface{}{"bsonType": "double"}
+	case "int8", "int16", "int32", "uint8", "uint16":
+		return map[string]interface{}{"bsonType": "int"}
+	case "int64", "uint32", "uint64":
+		return map[string]interface{}{"bsonType": "long"}
+	case "time.Time", "primitive.DateTime", "timestamppb.Timestamp":
+		return map[string]interface{}{"bsonType": "date"}
+	case "durationpb.Duration":
+		return map[string]interface{}{"bsonType": "long"}
+	case "primitive.ObjectID":
+		return map[string]interface{}{"bsonType": "objectId"}
+	}
+
+	if selectorType, ok := t.(code.SelectorExprType); ok && selectorType.X == "wrapperspb" {
+		// the wrappers are stored as their values by the codecs
+		return map[string]interface{}{"bsonType": wrapperBsonTypes[selectorType.Sel]}
+	}
+	if _, ok := t.(code.SelectorExprType); ok && enum == nil {
+		// the structures of the other packages such as the well-known types of proto
+		return map[string]interface{}{"bsonType": "object"}
+	}
+	// the enums are stored as int32 by the bson registry, long is accepted for the documents written without it
+	schema := map[string]interface{}{"bsonType": bsonTypes(false, "int", "long")}
+	if enum != nil {
+		values := make([]interface{}, 0, len(enum))
+		for _, value := range enum {
+			values = append(values, json.Number(strconv.FormatInt(value, 10)))
+		}
+		schema["enum"] = values
+	}
+	return schema
+}
+
+var wrapperBsonTypes = map[string]string{
+	"StringValue": "string",
+	"BoolValue":   "bool",
+	"Int32Value":  "int",
+	"Int64Value":  "long",
+	"UInt32Value": "long",
+	"UInt64Value": "long",
+	"FloatValue":  "double",
+	"DoubleValue": "double",
+	"BytesValue":  "binData",
+}
+
+func bsonTypes(nullable bool, types ...string) interface{} {
+	if len(types) == 1 && !nullable {
+		return types[0]
+	}
+	result := make([]interface{}, 0, len(types)+1)
+	for _, t := range types {
+		result = append(result, t)
+	}
+	if nullable {
+		result = append(result, "null")
+	}
+	return result
+}
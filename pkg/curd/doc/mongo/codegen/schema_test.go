@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+func TestDocumentSchema(t *testing.T) {
+	objectID := code.SelectorExprType{X: "primitive", Sel: "ObjectID"}
+	tests := []struct {
+		name  string
+		field *extract.StructField
+		// want is the schema of the field, nil if the field is not validated
+		want interface{}
+	}{
+		{
+			name:  "generated _id",
+			field: &extract.StructField{Name: "Id", Type: code.IdentType("string"), Tag: `bson:"_id"`, OmitEmpty: true},
+			want:  map[string]interface{}{"bsonType": []interface{}{"string", "objectId"}},
+		},
+		{
+			name:  "nullable generated _id",
+			field: &extract.StructField{Name: "Id", Type: code.StarExprType{RealType: code.IdentType("int64")}, Tag: `bson:"_id"`, OmitEmpty: true},
+			want:  map[string]interface{}{"bsonType": []interface{}{"long", "null", "objectId"}},
+		},
+		{
+			name:  "generated ObjectID",
+			field: &extract.StructField{Name: "Id", Type: objectID, Tag: `bson:"_id"`, OmitEmpty: true},
+			want:  map[string]interface{}{"bsonType": "objectId"},
+		},
+		{
+			name:  "generated enum _id",
+			field: &extract.StructField{Name: "Id", Type: code.SelectorExprType{X: "user", Sel: "Kind"}, Tag: `bson:"_id"`, OmitEmpty: true, Enum: []int64{1, 2}},
+		},
+		{
+			name:  "written _id",
+			field: &extract.StructField{Name: "Id", Type: code.IdentType("string"), Tag: `bson:"_id"`},
+			want:  map[string]interface{}{"bsonType": "string"},
+		},
+		{
+			name:  "other field with omitempty",
+			field: &extract.StructField{Name: "Id", Type: code.IdentType("string"), Tag: `bson:"id"`, OmitEmpty: true},
+			want:  map[string]interface{}{"bsonType": "string"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := documentSchema([]*extract.StructField{tt.field})
+			properties := schema["properties"].(map[string]interface{})
+			got, ok := properties[tt.field.Tag.Get("bson")]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("the schema of %s = %v, want it not validated", tt.field.Tag.Get("bson"), got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("the schema of %s = %v, want %v", tt.field.Tag.Get("bson"), got, tt.want)
+			}
+		})
+	}
+}
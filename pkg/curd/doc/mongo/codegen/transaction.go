@@ -17,90 +17,269 @@
 package codegen
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
 	"github.com/cloudwego/cwgo/pkg/curd/parse"
 )
 
 func taCodegen(transaction *parse.TransactionParse) []code.Statement {
-	taOperations := taOperationsCodegen(transaction)
+	txnOptions := transaction.BelongedToMethod.TransactionOptions
+	result := taModelCollectionsCodegen(transaction)
+
+	startArgs := code.ListCommaStmt{}
+	if txnOptions != nil && txnOptions.HasOptions() {
+		optionsName := getLocalName(transaction.BelongedToMethod, "transactionOptions")
+		result = append(result, taOptionsCodegen(transaction.BelongedToMethod, optionsName, txnOptions)...)
+		startArgs = append(startArgs, code.RawStmt(optionsName))
+	}
+
+	now := getLocalName(transaction.BelongedToMethod, "now")
+	taOperations := taOperationsCodegen(transaction, now)
 	body := code.Body{
-		code.RawStmt("if err := sessionContext.StartTransaction(); err != nil {\n\treturn err\n}\n"),
+		code.RawStmt(fmt.Sprintf("if err := sessionContext.StartTransaction(%s); err != nil {\n\treturn err\n}\n",
+			startArgs.Code())),
+	}
+	operations := make([]parse.Operation, 0, len(transaction.TransactionOperations))
+	for _, taOperation := range transaction.TransactionOperations {
+		operations = append(operations, taOperation.Operation)
 	}
-	for _, taOperation := range taOperations {
-		body = append(body, taOperation)
+	if optionNowRequired(operations) {
+		body = append(body, nowCodegen(now))
+	}
+	for _, statements := range taOperations {
+		body = append(body, statements...)
 		body = append(body, code.RawStmt("\n"))
 	}
-	body = append(body, code.RawStmt("return sessionContext.CommitTransaction(context.Background())"))
 
-	return []code.Statement{
-		code.IfBlockStmt{
-			Condition: []code.Statement{
-				code.DeclColonStmt{
-					Left: code.ListCommaStmt{
-						code.RawStmt("err"),
+	if txnOptions == nil || txnOptions.MaxRetries == 0 {
+		body = append(body, code.RawStmt("return sessionContext.CommitTransaction(context.Background())"))
+		return append(result,
+			code.IfBlockStmt{
+				Condition: []code.Statement{
+					code.DeclColonStmt{
+						Left: code.ListCommaStmt{
+							code.RawStmt("err"),
+						},
+						Right: useSessionCodegen(transaction, body),
 					},
-					Right: code.CallStmt{
-						Caller:   code.RawStmt(transaction.ClientParamName),
-						CallName: "UseSession",
-						Args: code.ListCommaStmt{
-							code.RawStmt(transaction.CtxParamName),
-							code.AnonymousFuncStmt{
-								Params: code.Params{
-									code.Param{
-										Name: "sessionContext",
-										Type: code.SelectorExprType{
-											X:   "mongo",
-											Sel: "SessionContext",
-										},
-									},
-								},
-								Returns: code.Returns{
-									code.IdentType("error"),
-								},
-								Body: body,
-							},
+					code.RawStmt("; err != nil "),
+				},
+				Body: code.Body{
+					code.RawStmt("return err"),
+				},
+			},
+			code.RawStmt("return nil"),
+		)
+	}
+
+	return append(result, taRetryCodegen(transaction, body)...)
+}
+
+func useSessionCodegen(transaction *parse.TransactionParse, body code.Body) code.CallStmt {
+	return code.CallStmt{
+		Caller:   code.RawStmt(transaction.ClientParamName),
+		CallName: "UseSession",
+		Args: code.ListCommaStmt{
+			code.RawStmt(transaction.CtxParamName),
+			code.AnonymousFuncStmt{
+				Params: code.Params{
+					code.Param{
+						Name: "sessionContext",
+						Type: code.SelectorExprType{
+							X:   "mongo",
+							Sel: "SessionContext",
 						},
 					},
 				},
-				code.RawStmt("; err != nil "),
+				Returns: code.Returns{
+					code.IdentType("error"),
+				},
+				Body: body,
 			},
-			Body: code.Body{
-				code.RawStmt("return err"),
+		},
+	}
+}
+
+// taRetryCodegen returns the loop which retries the transaction labeled with TransientTransactionError
+// and the commit labeled with UnknownTransactionCommitResult, the delay between the retries of the transaction
+// is doubled until it reaches the bound.
+func taRetryCodegen(transaction *parse.TransactionParse, body code.Body) []code.Statement {
+	method := transaction.BelongedToMethod
+	txnOptions := method.TransactionOptions
+	backoff, attempt, commitAttempt, serverErr := getLocalName(method, "backoff"), getLocalName(method, "attempt"),
+		getLocalName(method, "commitAttempt"), getLocalName(method, "serverErr")
+
+	body = append(body, code.RawStmt(fmt.Sprintf("for %[1]s := 0; ; %[1]s++ {\n"+
+		"\terr := sessionContext.CommitTransaction(context.Background())\n"+
+		"\tvar %[2]s mongo.ServerError\n"+
+		"\tif err == nil || %[1]s >= %[3]d || !errors.As(err, &%[2]s) || "+
+		"!%[2]s.HasErrorLabel(\"UnknownTransactionCommitResult\") {\n"+
+		"\t\treturn err\n"+
+		"\t}\n"+
+		"}", commitAttempt, serverErr, txnOptions.MaxRetries)))
+
+	return []code.Statement{
+		code.RawStmt(fmt.Sprintf("%s := %s", backoff, durationCodegen(txnOptions.Backoff))),
+		code.RawStmt(fmt.Sprintf("for %[1]s := 0; ; %[1]s++ {\n"+
+			"\terr := %[2]s\n"+
+			"\tvar %[3]s mongo.ServerError\n"+
+			"\tif err == nil || %[1]s >= %[4]d || !errors.As(err, &%[3]s) || "+
+			"!%[3]s.HasErrorLabel(\"TransientTransactionError\") {\n"+
+			"\t\treturn err\n"+
+			"\t}\n"+
+			"\tselect {\n"+
+			"\tcase <-time.After(%[5]s):\n"+
+			"\tcase <-%[6]s.Done():\n"+
+			"\t\treturn %[6]s.Err()\n"+
+			"\t}\n"+
+			"\tif %[5]s *= 2; %[5]s > %[7]s {\n"+
+			"\t\t%[5]s = %[7]s\n"+
+			"\t}\n"+
+			"}", attempt, useSessionCodegen(transaction, body).Code(), serverErr, txnOptions.MaxRetries, backoff, transaction.CtxParamName,
+			durationCodegen(txnOptions.MaxBackoff))),
+	}
+}
+
+// taOptionsCodegen returns the declaration of the options of the transaction.
+func taOptionsCodegen(method *extract.InterfaceMethod, optionsName string, txnOptions *extract.TransactionOptions) []code.Statement {
+	result := make([]code.Statement, 0, 2)
+	chainCall := make(code.ChainStmt, 0, 5)
+	chainCall = chainCall.ChainCall(code.Chain{
+		CallName: "options.Transaction",
+		Args:     code.ListCommaStmt{},
+	})
+
+	if txnOptions.ReadConcern != "" {
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetReadConcern",
+			Args: code.ListCommaStmt{
+				code.RawStmt("readconcern." + upperFirst(txnOptions.ReadConcern) + "()"),
 			},
+		})
+	}
+	if txnOptions.WriteConcern != "" {
+		writeConcern := "writeconcern.Majority()"
+		if txnOptions.WriteConcern != "majority" {
+			writeConcern = "&writeconcern.WriteConcern{W: " + txnOptions.WriteConcern + "}"
+		}
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetWriteConcern",
+			Args: code.ListCommaStmt{
+				code.RawStmt(writeConcern),
+			},
+		})
+	}
+	if txnOptions.ReadPreference != "" {
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetReadPreference",
+			Args: code.ListCommaStmt{
+				code.RawStmt("readpref." + upperFirst(txnOptions.ReadPreference) + "()"),
+			},
+		})
+	}
+	if txnOptions.MaxCommitTime != 0 {
+		maxCommitTime := getLocalName(method, "maxCommitTime")
+		result = append(result, code.RawStmt(fmt.Sprintf("%s := %s", maxCommitTime,
+			durationCodegen(txnOptions.MaxCommitTime))))
+		chainCall = chainCall.ChainCall(code.Chain{
+			CallName: "SetMaxCommitTime",
+			Args: code.ListCommaStmt{
+				code.RawStmt("&" + maxCommitTime),
+			},
+		})
+	}
+
+	return append(result, code.DeclColonStmt{
+		Left: code.ListCommaStmt{
+			code.RawStmt(optionsName),
 		},
-		code.RawStmt("return nil"),
+		Right: chainCall,
+	})
+}
+
+// durationCodegen returns the expression of the duration in the largest unit which divides it.
+func durationCodegen(d time.Duration) string {
+	switch {
+	case d%time.Second == 0:
+		return fmt.Sprintf("%d * time.Second", d/time.Second)
+	case d%time.Millisecond == 0:
+		return fmt.Sprintf("%d * time.Millisecond", d/time.Millisecond)
+	default:
+		return fmt.Sprintf("time.Duration(%d)", d)
 	}
 }
 
-func taOperationsCodegen(transaction *parse.TransactionParse) []code.Statement {
-	operations := make([]code.Statement, 0, 10)
+func upperFirst(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// taModelCollectionsCodegen declares the collections of the other models which the operations belong to,
+// the collections in the database of the repository are got from it, the others are got from the client.
+func taModelCollectionsCodegen(transaction *parse.TransactionParse) []code.Statement {
+	st := transaction.BelongedToMethod.BelongedToStruct
+	result := make([]code.Statement, 0, 5)
+	declared := make(map[*extract.IdlExtractStruct]struct{})
 	for _, operation := range transaction.TransactionOperations {
-		if operation.Operation.GetOperationName() == parse.Insert {
-			operations = append(operations, taInsertCodegen(operation)...)
+		model := operation.Model
+		if model == nil {
+			continue
 		}
-		if operation.Operation.GetOperationName() == parse.Update {
-			operations = append(operations, taUpdateCodegen(operation))
+		if _, ok := declared[model]; ok {
+			continue
 		}
-		if operation.Operation.GetOperationName() == parse.Delete {
-			operations = append(operations, taDeleteCodegen(operation))
+		declared[model] = struct{}{}
+
+		database := "r.collection.Database()"
+		if model.Database != "" && model.Database != st.Database {
+			database = fmt.Sprintf("%s.Database(%q)", transaction.ClientParamName, model.Database)
 		}
-		if operation.Operation.GetOperationName() == parse.Bulk {
-			operations = append(operations, taBulkCodegen(operation)...)
+		result = append(result, code.RawStmt(fmt.Sprintf("%s := %s.Collection(%q)",
+			taModelCollectionName(transaction, model), database, model.GetCollectionName())))
+	}
+	return result
+}
+
+func taModelCollectionName(transaction *parse.TransactionParse, model *extract.IdlExtractStruct) string {
+	return getLocalName(transaction.BelongedToMethod, strings.ToLower(model.Name[:1])+model.Name[1:]+"Collection")
+}
+
+// taOperationsCodegen returns the statements of each operation of the transaction, the options of the model which
+// each operation belongs to are applied with now declared by the caller.
+func taOperationsCodegen(transaction *parse.TransactionParse, now string) [][]code.Statement {
+	operations := make([][]code.Statement, 0, len(transaction.TransactionOperations))
+	for _, operation := range transaction.TransactionOperations {
+		if operation.Model != nil {
+			operation.CollectionParamName = taModelCollectionName(transaction, operation.Model)
+		}
+		switch operation.Operation.GetOperationName() {
+		case parse.Insert:
+			operations = append(operations, taInsertCodegen(operation, now))
+		case parse.Update:
+			operations = append(operations, taUpdateCodegen(operation, now))
+		case parse.Delete:
+			operations = append(operations, []code.Statement{taDeleteCodegen(operation, now)})
+		case parse.Bulk:
+			operations = append(operations, taBulkCodegen(operation, now))
 		}
 	}
 	return operations
 }
 
-func taInsertCodegen(tsOperation parse.TransactionOperation) []code.Statement {
+func taInsertCodegen(tsOperation parse.TransactionOperation, now string) []code.Statement {
 	insert := tsOperation.Operation.(*parse.InsertParse)
 	if insert.OperateMode == parse.OperateOne {
-		return getInsertCode(tsOperation, insert, "InsertOne", insert.MethodParamNames[0])
+		return getInsertCode(tsOperation, insert, "InsertOne", insert.MethodParamNames[0], now)
 	} else {
-		return getInsertCode(tsOperation, insert, "InsertMany", "entities")
+		return getInsertCode(tsOperation, insert, "InsertMany", "entities", now)
 	}
 }
 
-func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertParse, callName, param string) []code.Statement {
+func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertParse, callName, param, now string) []code.Statement {
+	st := insert.BelongedToMethod.BelongedToStruct
 	baseInsertCode := code.IfBlockStmt{
 		Condition: []code.Statement{
 			code.DeclColonStmt{
@@ -125,7 +304,7 @@ func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertP
 	}
 
 	if insert.OperateMode == parse.OperateOne {
-		return []code.Statement{baseInsertCode}
+		return append(createTimestampsCodegen(st, param, now), baseInsertCode)
 	} else {
 		return []code.Statement{
 			code.DeclVarStmt{
@@ -137,27 +316,27 @@ func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertP
 			code.ForRangeBlockStmt{
 				RangeName: insert.MethodParamNames[0],
 				Value:     "model",
-				Body: []code.Statement{
-					code.RawStmt("entities = append(entities, model)"),
-				},
+				Body: append(createTimestampsCodegen(st, "model", now),
+					code.RawStmt("entities = append(entities, model)")),
 			},
 			baseInsertCode,
 		}
 	}
 }
 
-func taUpdateCodegen(tsOperation parse.TransactionOperation) code.Statement {
+func taUpdateCodegen(tsOperation parse.TransactionOperation, now string) []code.Statement {
 	update := tsOperation.Operation.(*parse.UpdateParse)
 	if update.OperateMode == parse.OperateOne {
-		return getUpdateCode(tsOperation, update, "UpdateOne")
+		return getUpdateCode(tsOperation, update, "UpdateOne", now)
 	} else {
-		return getUpdateCode(tsOperation, update, "UpdateMany")
+		return getUpdateCode(tsOperation, update, "UpdateMany", now)
 	}
 }
 
-func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateParse, callName string) code.Statement {
+func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateParse, callName, now string) []code.Statement {
+	statements, fields := optionUpdateCodegen(update, now)
 	chainCall := make(code.ChainStmt, 0, 5)
-	return code.IfBlockStmt{
+	return append(statements, code.IfBlockStmt{
 		Condition: []code.Statement{
 			code.DeclColonStmt{
 				Left: code.ListCommaStmt{
@@ -169,8 +348,8 @@ func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateP
 					CallName: callName,
 					Args: code.ListCommaStmt{
 						code.RawStmt("sessionContext"),
-						queryCodegen(update.Query),
-						updateFieldsCodegen(update),
+						queryCodegen(optionQuery(update.Query, update.BelongedToMethod.BelongedToStruct)),
+						fields,
 						chainCall.ChainCall(code.Chain{
 							CallName: "options.Update",
 							Args:     code.ListCommaStmt{},
@@ -188,19 +367,37 @@ func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateP
 		Body: code.Body{
 			code.RawStmt(abortTa),
 		},
-	}
+	})
 }
 
-func taDeleteCodegen(tsOperation parse.TransactionOperation) code.Statement {
+func taDeleteCodegen(tsOperation parse.TransactionOperation, now string) code.Statement {
 	del := tsOperation.Operation.(*parse.DeleteParse)
+	if del.BelongedToMethod.BelongedToStruct.Options.SoftDelete {
+		// the soft deleted documents are updated instead of being removed
+		if del.OperateMode == parse.OperateOne {
+			return getTaDeleteCode(tsOperation, del, "UpdateOne", now)
+		}
+		return getTaDeleteCode(tsOperation, del, "UpdateMany", now)
+	}
 	if del.OperateMode == parse.OperateOne {
-		return getTaDeleteCode(tsOperation, del, "DeleteOne")
+		return getTaDeleteCode(tsOperation, del, "DeleteOne", now)
 	} else {
-		return getTaDeleteCode(tsOperation, del, "DeleteMany")
+		return getTaDeleteCode(tsOperation, del, "DeleteMany", now)
 	}
 }
 
-func getTaDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeleteParse, callName string) code.Statement {
+func getTaDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeleteParse, callName, now string) code.Statement {
+	args := code.ListCommaStmt{
+		code.RawStmt("sessionContext"),
+		queryCodegen(del.Query),
+	}
+	if st := del.BelongedToMethod.BelongedToStruct; st.Options.SoftDelete {
+		args = code.ListCommaStmt{
+			code.RawStmt("sessionContext"),
+			queryCodegen(optionQuery(del.Query, st)),
+			softDeleteFieldsCodegen(st, now),
+		}
+	}
 	return code.IfBlockStmt{
 		Condition: []code.Statement{
 			code.DeclColonStmt{
@@ -211,10 +408,7 @@ func getTaDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeletePa
 				Right: code.CallStmt{
 					Caller:   code.RawStmt(tsOperation.CollectionParamName),
 					CallName: callName,
-					Args: code.ListCommaStmt{
-						code.RawStmt("sessionContext"),
-						queryCodegen(del.Query),
-					},
+					Args:     args,
 				},
 			},
 			code.RawStmt("; err != nil "),
@@ -225,10 +419,10 @@ func getTaDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeletePa
 	}
 }
 
-func taBulkCodegen(tsOperation parse.TransactionOperation) []code.Statement {
+func taBulkCodegen(tsOperation parse.TransactionOperation, now string) []code.Statement {
 	bulk := tsOperation.Operation.(*parse.BulkParse)
 
-	return []code.Statement{
+	statements := []code.Statement{
 		code.DeclVarStmt{
 			Name: "models",
 			Type: code.SliceType{
@@ -238,7 +432,9 @@ func taBulkCodegen(tsOperation parse.TransactionOperation) []code.Statement {
 				},
 			},
 		},
-		bulkOperationsCodegen(bulk),
+	}
+	statements = append(statements, bulkOperationsCodegen(bulk, now)...)
+	return append(statements,
 		code.IfBlockStmt{
 			Condition: []code.Statement{
 				code.DeclColonStmt{
@@ -249,10 +445,7 @@ func taBulkCodegen(tsOperation parse.TransactionOperation) []code.Statement {
 					Right: code.CallStmt{
 						Caller:   code.RawStmt(tsOperation.CollectionParamName),
 						CallName: "BulkWrite",
-						Args: code.ListCommaStmt{
-							code.RawStmt("sessionContext"),
-							code.RawStmt("models"),
-						},
+						Args:     bulkWriteArgsCodegen(bulk, "sessionContext"),
 					},
 				},
 				code.RawStmt("; err != nil "),
@@ -261,10 +454,9 @@ func taBulkCodegen(tsOperation parse.TransactionOperation) []code.Statement {
 				code.RawStmt(abortTa),
 			},
 		},
-	}
+	)
 }
 
-var abortTa = `if err = sessionContext.AbortTransaction(context.Background()); err != nil {
-    return err
-}
+// abortTa aborts the transaction and returns the error of the operation, which carries the error labels
+var abortTa = `_ = sessionContext.AbortTransaction(context.Background())
 return err`
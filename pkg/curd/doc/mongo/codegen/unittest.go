@@ -0,0 +1,777 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+var UnitTestImports = map[string]string{
+	"context":                           "",
+	"fmt":                               "",
+	"os":                                "",
+	"testing":                           "",
+	"time":                              "",
+	"go.mongodb.org/mongo-driver/mongo": "",
+	"go.mongodb.org/mongo-driver/mongo/options": "",
+}
+
+const (
+	// fixtureCount is the number of fixtures inserted before each test, the fixtures are numbered from 1.
+	fixtureCount = 3
+	// queryFixture is the fixture whose fields are used as the query params,
+	// the second param of Between and NotBetween uses the next fixture.
+	queryFixture = 2
+	// updateFixture is the fixture whose fields are used as the update params, it is never inserted.
+	updateFixture = 9
+	// findSkip and findLimit are the skip and limit params of Find.
+	findSkip  = 1
+	findLimit = 2
+)
+
+type fixtureKind int
+
+const (
+	fixtureUnknown  fixtureKind = iota // left the zero value, such as enums and maps
+	fixtureOrdered                     // integers, floats and strings, ordered by the fixture number
+	fixtureBool                        // true if the fixture number is even
+	fixtureIdentity                    // slices and structures, only equality is meaningful
+)
+
+// fixtureValue describes how the field value of the fixture i is derived from i.
+type fixtureValue struct {
+	code    string
+	kind    fixtureKind
+	pointer bool
+	slice   bool
+}
+
+// unitTestGenerator generates the integration tests of the repository, the expected results are
+// computed by evaluating the parsed queries against the fixtures when generating.
+type unitTestGenerator struct {
+	st          *extract.IdlExtractStruct
+	fixtureFunc string
+	params      map[string]string
+	insertCount int
+}
+
+// GetUnitTestRenders returns the renders of the tests which exercise each method of the repository
+// against the mongo specified by MONGO_URI, the tests are skipped if MONGO_URI is not set.
+//
+//	input params description:
+//	ifOperation: the parsing results of all methods of the structure, including the methods generated before
+//	methods: all methods of the repository interface
+func GetUnitTestRenders(ifOperation *parse.InterfaceOperation, methods code.InterfaceMethods) []template.Render {
+	st := ifOperation.BelongedToStruct
+	g := &unitTestGenerator{
+		st:          st,
+		fixtureFunc: "new" + st.Name + "Fixture",
+	}
+	setupFunc := "new" + st.Name + "TestCollection"
+	modelType := code.StarExprType{RealType: code.SelectorExprType{X: st.ModelPkg, Sel: st.Name}}
+
+	fixtures := make([]string, 0, fixtureCount)
+	for i := 1; i <= fixtureCount; i++ {
+		fixtures = append(fixtures, fmt.Sprintf("%s(%d)", g.fixtureFunc, i))
+	}
+
+	renders := []template.Render{
+		&template.FuncRender{
+			Name: g.fixtureFunc,
+			Comment: fmt.Sprintf("// %s returns the i-th fixture, the fields are derived from i so that\n"+
+				"// the expected results of the tests are known in advance.", g.fixtureFunc),
+			Params: code.Params{
+				code.Param{
+					Name: "i",
+					Type: code.IdentType("int"),
+				},
+			},
+			Returns: code.Returns{modelType},
+			FuncBody: code.Body{
+				code.RawStmt("return " + g.structFixtureCodegen(modelType, st, map[*extract.IdlExtractStruct]bool{})),
+			},
+		},
+		&template.FuncRender{
+			Name: setupFunc,
+			Comment: fmt.Sprintf("// %s connects to MONGO_URI and inserts the fixtures 1 to %d into a new collection\n"+
+				"// which is dropped when the test finishes, the test is skipped if MONGO_URI is not set.",
+				setupFunc, fixtureCount),
+			Params: code.Params{
+				code.Param{
+					Name: "t",
+					Type: code.StarExprType{RealType: code.SelectorExprType{X: "testing", Sel: "T"}},
+				},
+			},
+			Returns: code.Returns{
+				code.SelectorExprType{X: "context", Sel: "Context"},
+				code.StarExprType{RealType: code.SelectorExprType{X: "mongo", Sel: "Client"}},
+				code.StarExprType{RealType: code.SelectorExprType{X: "mongo", Sel: "Collection"}},
+			},
+			FuncBody: code.Body{
+				code.RawStmt("uri := os.Getenv(\"MONGO_URI\")"),
+				code.RawStmt("if uri == \"\" {\n\tt.Skip(\"MONGO_URI is not set\")\n}"),
+				code.RawStmt("ctx := context.Background()"),
+				code.RawStmt("client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))"),
+				code.RawStmt("if err != nil {\n\tt.Fatalf(\"connect to mongo failed: %v\", err)\n}"),
+				code.RawStmt(fmt.Sprintf("collection := client.Database(\"cwgo_test\").Collection(fmt.Sprintf(\"%s_%%d\", "+
+					"time.Now().UnixNano()))", extract.GetPkgName(st.Name))),
+				code.RawStmt("t.Cleanup(func() {\n\t_ = collection.Drop(context.Background())\n" +
+					"\t_ = client.Disconnect(context.Background())\n})"),
+				code.RawStmt(fmt.Sprintf("if _, err = collection.InsertMany(ctx, []interface{}{%s}); err != nil {\n"+
+					"\tt.Fatalf(\"insert fixtures failed: %%v\", err)\n}", strings.Join(fixtures, ", "))),
+				code.RawStmt("return ctx, client, collection"),
+			},
+		},
+	}
+
+	operations := make(map[string]parse.Operation, len(ifOperation.Operations))
+	for _, operation := range ifOperation.Operations {
+		operations[parse.GetBelongedToMethod(operation).Name] = operation
+	}
+	for _, method := range methods {
+		g.params = make(map[string]string, len(method.Params))
+		g.insertCount = 0
+
+		var body code.Body
+		if operation, ok := operations[method.Name]; ok {
+			g.assignParams(operation)
+			body = g.operationCodegen(operation, method)
+		} else if IsGenericMethod(st, method.Name) {
+			body = g.genericCodegen(method.Name)
+		} else {
+			// methods such as EnsureIndexes have no operation, only the error is asserted
+			body = code.Body{
+				code.RawStmt(fmt.Sprintf("if err := repo.%s; err != nil {\n\tt.Fatalf(\"%s failed: %%v\", err)\n}",
+					g.callCodegen(method), method.Name)),
+			}
+		}
+
+		client := "_"
+		for _, param := range method.Params {
+			if param.Type.RealName() == "*mongo.Client" {
+				client = "client"
+			}
+		}
+		body = append(code.Body{
+			code.RawStmt(fmt.Sprintf("ctx, %s, collection := %s(t)", client, setupFunc)),
+			code.RawStmt(fmt.Sprintf("repo := New%sRepository(collection)", st.Name)),
+		}, body...)
+
+		renders = append(renders, &template.FuncRender{
+			Name: fmt.Sprintf("Test%sRepositoryMongo_%s", st.Name, method.Name),
+			Params: code.Params{
+				code.Param{
+					Name: "t",
+					Type: code.StarExprType{RealType: code.SelectorExprType{X: "testing", Sel: "T"}},
+				},
+			},
+			FuncBody: body,
+		})
+	}
+
+	return renders
+}
+
+// assignParams is used to choose the value of each param of the operation, the query params
+// come from the fields of the queried fixture and the update params come from the update fixture.
+func (g *unitTestGenerator) assignParams(operation parse.Operation) {
+	switch op := operation.(type) {
+	case *parse.InsertParse:
+		method := op.BelongedToMethod
+		name := op.MethodParamNames[1]
+		if name == "" {
+			name = op.MethodParamNames[0]
+		}
+		paramType := getParamType(method, name)
+		if op.OperateMode == parse.OperateOne {
+			g.assignParam(name, g.fixtureArgCodegen(paramType, fixtureCount+1+g.insertCount))
+			g.insertCount++
+			return
+		}
+		sliceType, ok := paramType.(code.SliceType)
+		if !ok {
+			return
+		}
+		args := make([]string, 0, 2)
+		for i := 0; i < 2; i++ {
+			args = append(args, g.fixtureArgCodegen(sliceType.ElementType, fixtureCount+1+g.insertCount))
+			g.insertCount++
+		}
+		g.assignParam(name, fmt.Sprintf("%s{%s}", paramType.RealName(), strings.Join(args, ", ")))
+
+	case *parse.FindParse:
+		g.assignQueryParams(op.Query)
+		if op.SkipParamName != "" {
+			g.assignParam(op.SkipParamName, strconv.Itoa(findSkip))
+		}
+		if op.LimitParamName != "" {
+			g.assignParam(op.LimitParamName, strconv.Itoa(findLimit))
+		}
+
+	case *parse.UpdateParse:
+		if op.UpdateStructObjName != "" {
+			// the whole entity is set to the queried fixture, so that the immutable _id stays unchanged
+			g.assignParam(op.UpdateStructObjName,
+				g.fixtureArgCodegen(getParamType(op.BelongedToMethod, op.UpdateStructObjName), queryFixture))
+		}
+		for _, field := range op.UpdateFields {
+			g.assignParam(field.ParamName, g.fieldFixtureCodegen(field.MongoFieldName, updateFixture))
+		}
+		g.assignQueryParams(op.Query)
+
+	case *parse.ReplaceParse:
+		// the queried fixture is replaced by itself, so that the unique fields stay unique
+		g.assignParam(op.ReplacementParamName,
+			g.fixtureArgCodegen(getParamType(op.BelongedToMethod, op.ReplacementParamName), queryFixture))
+		g.assignQueryParams(op.Query)
+
+	case *parse.DeleteParse:
+		g.assignQueryParams(op.Query)
+
+	case *parse.CountParse:
+		g.assignQueryParams(op.Query)
+
+	case *parse.WatchParse:
+		g.assignParam(op.CtxParamName, "watchCtx")
+		if op.OptionsParamName != "" {
+			g.assignParam(op.OptionsParamName, "nil")
+		}
+		if op.ErrorHandlerParamName != "" {
+			g.assignParam(op.ErrorHandlerParamName, "func(err error) {\n\tt.Error(err)\n}")
+		}
+		g.assignQueryParams(op.Query)
+
+	case *parse.BulkParse:
+		for _, bulkOperation := range op.Operations {
+			g.assignParams(bulkOperation)
+		}
+
+	case *parse.TransactionParse:
+		g.assignParam(op.ClientParamName, "client")
+		for _, taOperation := range op.TransactionOperations {
+			if taOperation.Model != nil {
+				g.assignModelParams(taOperation.Operation)
+				continue
+			}
+			g.assignParam(taOperation.CollectionParamName, "collection")
+			g.assignParams(taOperation.Operation)
+		}
+	}
+}
+
+// assignModelParams assigns the empty documents to the inserted params of the operation on the collection
+// of the other model, the fixtures are only generated for the model of the repository.
+func (g *unitTestGenerator) assignModelParams(operation parse.Operation) {
+	insert, ok := operation.(*parse.InsertParse)
+	if !ok {
+		return
+	}
+	paramType := getParamType(insert.BelongedToMethod, insert.MethodParamNames[0])
+	switch t := paramType.(type) {
+	case code.StarExprType:
+		g.assignParam(insert.MethodParamNames[0], "&"+t.RealType.RealName()+"{}")
+	case code.SliceType:
+		g.assignParam(insert.MethodParamNames[0], t.RealName()+"{{}}")
+	case nil:
+	default:
+		g.assignParam(insert.MethodParamNames[0], t.RealName()+"{}")
+	}
+}
+
+func (g *unitTestGenerator) assignQueryParams(query *parse.Query) {
+	if query == nil || query.QueryMode != parse.By {
+		return
+	}
+	g.assignNodeParams(query.ConnectionOpTree)
+}
+
+func (g *unitTestGenerator) assignNodeParams(node *parse.ConnectionOpTree) {
+	if node.LeftChildren != nil {
+		g.assignNodeParams(node.LeftChildren)
+		g.assignNodeParams(node.RightChildren)
+		return
+	}
+	for index, name := range node.ParamNames {
+		g.assignParam(name, g.fieldFixtureCodegen(node.MongoFieldName, queryFixture+index))
+	}
+}
+
+// assignParam is used to assign the value to the param, the first assignment wins.
+func (g *unitTestGenerator) assignParam(name, value string) {
+	if name == "" || value == "" {
+		return
+	}
+	if _, ok := g.params[name]; !ok {
+		g.params[name] = value
+	}
+}
+
+// callCodegen returns the call of the method with the assigned params, the params which are not
+// assigned are passed in by the zero value.
+func (g *unitTestGenerator) callCodegen(method code.InterfaceMethod) string {
+	args := make([]string, 0, len(method.Params))
+	for _, param := range method.Params {
+		if value, ok := g.params[param.Name]; ok {
+			args = append(args, value)
+			continue
+		}
+		switch param.Type.RealName() {
+		case "context.Context":
+			args = append(args, "ctx")
+		case "*mongo.Client":
+			args = append(args, "client")
+		case "*mongo.Collection":
+			args = append(args, "collection")
+		default:
+			if _, ok := param.Type.(code.StarExprType); ok {
+				// such as the nil cursor of the keyset which queries the first page
+				args = append(args, "nil")
+				continue
+			}
+			args = append(args, fmt.Sprintf("*new(%s)", param.Type.RealName()))
+		}
+	}
+	return fmt.Sprintf("%s(%s)", method.Name, strings.Join(args, ", "))
+}
+
+func (g *unitTestGenerator) operationCodegen(operation parse.Operation, method code.InterfaceMethod) code.Body {
+	call := "repo." + g.callCodegen(method)
+	failed := fmt.Sprintf("if err != nil {\n\tt.Fatalf(\"%s failed: %%v\", err)\n}", method.Name)
+
+	switch op := operation.(type) {
+	case *parse.InsertParse:
+		if op.OperateMode == parse.OperateOne {
+			return code.Body{
+				code.RawStmt("id, err := " + call),
+				code.RawStmt(failed),
+				code.RawStmt(fmt.Sprintf("if id == nil {\n\tt.Fatal(\"%s returned a nil id\")\n}", method.Name)),
+				documentCountCodegen(method.Name, fixtureCount+g.insertCount),
+			}
+		}
+		return code.Body{
+			code.RawStmt("ids, err := " + call),
+			code.RawStmt(failed),
+			code.RawStmt(fmt.Sprintf("if len(ids) != %d {\n\tt.Fatalf(\"%s should return %d ids, got %%d\", len(ids))\n}",
+				g.insertCount, method.Name, g.insertCount)),
+			documentCountCodegen(method.Name, fixtureCount+g.insertCount),
+		}
+
+	case *parse.FindParse:
+		return g.findCodegen(op, method.Name, call, failed)
+
+	case *parse.UpdateParse:
+		matched, ok := g.countMatches(op.Query)
+		return resultCodegen(method.Name, call, failed, op.OperateMode, matched, ok, "updated", "matched")
+
+	case *parse.DeleteParse:
+		matched, ok := g.countMatches(op.Query)
+		return resultCodegen(method.Name, call, failed, op.OperateMode, matched, ok, "deleted", "deleted")
+
+	case *parse.CountParse:
+		matched, ok := g.countMatches(op.Query)
+		return resultCodegen(method.Name, call, failed, parse.OperateMany, matched, ok, "", "count")
+
+	case *parse.BulkParse:
+		return code.Body{
+			code.RawStmt("result, err := " + call),
+			code.RawStmt(failed),
+			code.RawStmt(fmt.Sprintf("if result.InsertedCount != %d {\n"+
+				"\tt.Fatalf(\"%s should insert %d documents, got %%d\", result.InsertedCount)\n}",
+				g.insertCount, method.Name, g.insertCount)),
+		}
+
+	case *parse.WatchParse:
+		// change streams are only supported by replica sets and sharded clusters, Location40573 is returned by
+		// the standalone mongod, the channel is closed after the context is canceled
+		return code.Body{
+			code.RawStmt("watchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)"),
+			code.RawStmt("defer cancel()"),
+			code.RawStmt("entities, err := " + call),
+			code.RawStmt("if err != nil {\n" +
+				"\tvar cmdErr mongo.CommandError\n" +
+				"\tif errors.As(err, &cmdErr) && cmdErr.Code == 40573 {\n" +
+				"\t\tt.Skipf(\"change streams are not supported by the server: %v\", err)\n" +
+				"\t}\n" +
+				fmt.Sprintf("\tt.Fatalf(\"%s failed: %%v\", err)\n}", method.Name)),
+			code.RawStmt("cancel()"),
+			code.RawStmt("for range entities {\n}"),
+		}
+
+	case *parse.TransactionParse:
+		// transactions are only supported by replica sets and sharded clusters, IllegalOperation(20) is
+		// returned by the standalone mongod
+		return code.Body{
+			code.RawStmt("if err := " + call + "; err != nil {\n" +
+				"\tvar cmdErr mongo.CommandError\n" +
+				"\tif errors.As(err, &cmdErr) && cmdErr.Code == 20 {\n" +
+				"\t\tt.Skipf(\"transactions are not supported by the server: %v\", err)\n" +
+				"\t}\n" +
+				fmt.Sprintf("\tt.Fatalf(\"%s failed: %%v\", err)\n}", method.Name)),
+		}
+
+	default:
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("if _, err := %s; err != nil {\n\tt.Fatalf(\"%s failed: %%v\", err)\n}",
+				call, method.Name)),
+		}
+	}
+}
+
+// genericCodegen returns the test of the method of the generic repository, the entities found and
+// deleted by _id are inserted by Insert first.
+func (g *unitTestGenerator) genericCodegen(methodName string) code.Body {
+	failed := fmt.Sprintf("if err != nil {\n\tt.Fatalf(\"%s failed: %%v\", err)\n}", methodName)
+	insert := code.Body{
+		code.RawStmt(fmt.Sprintf("id, err := repo.%s(ctx, %s(%d))", GenericInsert, g.fixtureFunc, fixtureCount+1)),
+		code.RawStmt(fmt.Sprintf("if err != nil {\n\tt.Fatalf(\"%s failed: %%v\", err)\n}", GenericInsert)),
+	}
+
+	switch methodName {
+	case GenericInsert:
+		return append(insert,
+			code.RawStmt(fmt.Sprintf("if id == nil {\n\tt.Fatal(\"%s returned a nil id\")\n}", methodName)),
+			documentCountCodegen(methodName, fixtureCount+1),
+		)
+	case GenericFindByID:
+		return append(insert,
+			code.RawStmt(fmt.Sprintf("entity, err := repo.%s(ctx, id)", methodName)),
+			code.RawStmt(failed),
+			code.RawStmt(fmt.Sprintf("if entity == nil {\n\tt.Fatal(\"%s returned a nil entity\")\n}", methodName)),
+		)
+	case GenericDeleteByID:
+		return append(insert,
+			code.RawStmt(fmt.Sprintf("deleted, err := repo.%s(ctx, id)", methodName)),
+			code.RawStmt(failed),
+			code.RawStmt(fmt.Sprintf("if !deleted {\n\tt.Fatal(\"%s should return true\")\n}", methodName)),
+			documentCountCodegen(methodName, fixtureCount),
+		)
+	default:
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("count, err := repo.%s(ctx)", methodName)),
+			code.RawStmt(failed),
+			code.RawStmt(fmt.Sprintf("if count != %d {\n\tt.Fatalf(\"%s should return %d, got %%d\", count)\n}",
+				fixtureCount, methodName, fixtureCount)),
+		}
+	}
+}
+
+func (g *unitTestGenerator) findCodegen(find *parse.FindParse, methodName, call, failed string) code.Body {
+	matched, ok := g.countMatches(find.Query)
+	if find.SkipParamName != "" {
+		matched -= findSkip
+		if matched < 0 {
+			matched = 0
+		}
+	}
+
+	if find.OperateMode == parse.OperateOne {
+		if !ok {
+			return code.Body{
+				code.RawStmt(fmt.Sprintf("if _, err := %s; err != nil && !errors.Is(err, mongo.ErrNoDocuments) {\n"+
+					"\tt.Fatalf(\"%s failed: %%v\", err)\n}", call, methodName)),
+			}
+		}
+		if matched == 0 {
+			return code.Body{
+				code.RawStmt(fmt.Sprintf("if _, err := %s; !errors.Is(err, mongo.ErrNoDocuments) {\n"+
+					"\tt.Fatalf(\"%s should return mongo.ErrNoDocuments, got %%v\", err)\n}", call, methodName)),
+			}
+		}
+		return code.Body{
+			code.RawStmt("entity, err := " + call),
+			code.RawStmt(failed),
+			code.RawStmt(fmt.Sprintf("if entity == nil {\n\tt.Fatal(\"%s returned a nil entity\")\n}", methodName)),
+		}
+	}
+
+	cursor := ""
+	if find.ReturnCursor {
+		cursor = "_, "
+	}
+	if !ok {
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("if _, %serr := %s; err != nil {\n\tt.Fatalf(\"%s failed: %%v\", err)\n}",
+				cursor, call, methodName)),
+		}
+	}
+	if find.LimitParamName != "" && matched > findLimit {
+		matched = findLimit
+	}
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("entities, %serr := %s", cursor, call)),
+		code.RawStmt(failed),
+		code.RawStmt(fmt.Sprintf("if len(entities) != %d {\n"+
+			"\tt.Fatalf(\"%s should return %d entities, got %%d\", len(entities))\n}", matched, methodName, matched)),
+	}
+}
+
+// resultCodegen returns the assertion of the methods which return whether the entity is operated
+// in One mode and the number of operated entities in Many mode.
+func resultCodegen(methodName, call, failed string, mode parse.OperateMode, matched int, ok bool,
+	oneName, manyName string,
+) code.Body {
+	if !ok {
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("if _, err := %s; err != nil {\n\tt.Fatalf(\"%s failed: %%v\", err)\n}",
+				call, methodName)),
+		}
+	}
+	if mode == parse.OperateOne {
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("%s, err := %s", oneName, call)),
+			code.RawStmt(failed),
+			code.RawStmt(fmt.Sprintf("if %s != %t {\n\tt.Fatalf(\"%s should return %t, got %%v\", %s)\n}",
+				oneName, matched > 0, methodName, matched > 0, oneName)),
+		}
+	}
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("%s, err := %s", manyName, call)),
+		code.RawStmt(failed),
+		code.RawStmt(fmt.Sprintf("if %s != %d {\n\tt.Fatalf(\"%s should return %d, got %%d\", %s)\n}",
+			manyName, matched, methodName, matched, manyName)),
+	}
+}
+
+func documentCountCodegen(methodName string, expected int) code.Statement {
+	return code.RawStmt(fmt.Sprintf("if count, err := collection.CountDocuments(ctx, bson.M{}); err != nil || count != %d {\n"+
+		"\tt.Fatalf(\"expected %d documents after %s, got %%d: %%v\", count, err)\n}", expected, expected, methodName))
+}
+
+// countMatches returns the number of fixtures matched by the query, false is returned
+// if the query can not be evaluated.
+func (g *unitTestGenerator) countMatches(query *parse.Query) (int, bool) {
+	if query == nil || query.QueryMode != parse.By {
+		return fixtureCount, true
+	}
+	count := 0
+	for i := 1; i <= fixtureCount; i++ {
+		matched, ok := g.evalNode(query.ConnectionOpTree, i)
+		if !ok {
+			return 0, false
+		}
+		if matched {
+			count++
+		}
+	}
+	return count, true
+}
+
+func (g *unitTestGenerator) evalNode(node *parse.ConnectionOpTree, i int) (bool, bool) {
+	if node.LeftChildren != nil {
+		left, ok := g.evalNode(node.LeftChildren, i)
+		if !ok {
+			return false, false
+		}
+		right, ok := g.evalNode(node.RightChildren, i)
+		if !ok {
+			return false, false
+		}
+		if parse.QueryConnectionOp(node.Name) == parse.And {
+			return left && right, true
+		}
+		return left || right, true
+	}
+
+	fields, err := g.st.GetFieldsByMongoName(node.MongoFieldName)
+	if err != nil {
+		return false, false
+	}
+	field := fields[len(fields)-1]
+	value := g.fieldFixture(field, map[*extract.IdlExtractStruct]bool{})
+	present := !strings.Contains(field.Tag.Get("bson"), ",omitempty") || !value.isZero(i)
+
+	comparator := parse.QueryComparator(node.Name)
+	switch comparator {
+	case parse.Exists:
+		return present, true
+	case parse.NotExists:
+		return !present, true
+	}
+	if value.kind == fixtureUnknown {
+		return false, false
+	}
+	if !present {
+		return comparator == parse.NotEqual || comparator == parse.NotIn, true
+	}
+
+	v := value.value(i)
+	p := value.value(queryFixture)
+	switch comparator {
+	case parse.Equal:
+		return v == p, true
+	case parse.NotEqual:
+		return v != p, true
+	case parse.True:
+		return v == 1, true
+	case parse.False:
+		return v == 0, true
+	case parse.In, parse.NotIn:
+		// the param of In is an array only if the field is an array
+		if !value.slice {
+			return false, false
+		}
+		return (v == p) == (comparator == parse.In), true
+	}
+	if value.kind == fixtureIdentity {
+		return false, false
+	}
+	switch comparator {
+	case parse.LessThan:
+		return v < p, true
+	case parse.LessThanEqual:
+		return v <= p, true
+	case parse.GreaterThan:
+		return v > p, true
+	case parse.GreaterThanEqual:
+		return v >= p, true
+	case parse.Between:
+		return v >= p && v <= value.value(queryFixture+1), true
+	case parse.NotBetween:
+		return v < p || v > value.value(queryFixture+1), true
+	}
+	return false, false
+}
+
+// fieldFixtureCodegen returns the field of the fixture i specified by mongo field name.
+func (g *unitTestGenerator) fieldFixtureCodegen(mongoName string, i int) string {
+	goPath, _, err := g.st.GetFieldByMongoName(mongoName)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s(%d).%s", g.fixtureFunc, i, goPath)
+}
+
+// fixtureArgCodegen returns the fixture i as the value of t which is the model or the pointer of the model.
+func (g *unitTestGenerator) fixtureArgCodegen(t code.Type, i int) string {
+	if t == nil {
+		return ""
+	}
+	if _, ok := t.(code.StarExprType); ok {
+		return fmt.Sprintf("%s(%d)", g.fixtureFunc, i)
+	}
+	return fmt.Sprintf("*%s(%d)", g.fixtureFunc, i)
+}
+
+func (g *unitTestGenerator) structFixtureCodegen(t code.Type, st *extract.IdlExtractStruct,
+	visited map[*extract.IdlExtractStruct]bool,
+) string {
+	visited[st] = true
+	defer delete(visited, st)
+
+	fields := ""
+	for _, field := range st.StructFields {
+		value := g.fieldFixture(field, visited)
+		if value.kind == fixtureUnknown {
+			continue
+		}
+		fields += fmt.Sprintf("%s: %s,\n", field.Name, value.code)
+	}
+
+	if star, ok := t.(code.StarExprType); ok {
+		return fmt.Sprintf("&%s{\n%s}", star.RealType.RealName(), fields)
+	}
+	return fmt.Sprintf("%s{\n%s}", t.RealName(), fields)
+}
+
+func (g *unitTestGenerator) fieldFixture(field *extract.StructField, visited map[*extract.IdlExtractStruct]bool) fixtureValue {
+	if field.IsBelongedToStruct {
+		// recursive structures are left nil
+		if visited[field.BelongedToStruct] {
+			return fixtureValue{kind: fixtureUnknown}
+		}
+		return fixtureValue{
+			code:    g.structFixtureCodegen(field.Type, field.BelongedToStruct, visited),
+			kind:    fixtureIdentity,
+			pointer: true,
+		}
+	}
+	name := strings.Split(field.Tag.Get("bson"), ",")[0]
+	return typeFixture(field.Type, name)
+}
+
+func typeFixture(t code.Type, name string) fixtureValue {
+	switch ty := t.(type) {
+	case code.IdentType:
+		switch string(ty) {
+		case "string":
+			return fixtureValue{code: fmt.Sprintf("fmt.Sprintf(\"%s_%%d\", i)", name), kind: fixtureOrdered}
+		case "bool":
+			return fixtureValue{code: "i%2 == 0", kind: fixtureBool}
+		case "int":
+			return fixtureValue{code: "i", kind: fixtureOrdered}
+		case "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte",
+			"float32", "float64":
+			return fixtureValue{code: fmt.Sprintf("%s(i)", ty), kind: fixtureOrdered}
+		}
+	case code.SliceType:
+		elem := typeFixture(ty.ElementType, name)
+		if elem.kind == fixtureOrdered || elem.kind == fixtureBool {
+			return fixtureValue{code: fmt.Sprintf("%s{%s}", ty.RealName(), elem.code), kind: fixtureIdentity, slice: true}
+		}
+	case code.StarExprType:
+		elem := typeFixture(ty.RealType, name)
+		if elem.kind == fixtureOrdered || elem.kind == fixtureBool {
+			return fixtureValue{
+				code:    fmt.Sprintf("func() %s {\n\tv := %s\n\treturn &v\n}()", ty.RealName(), elem.code),
+				kind:    elem.kind,
+				pointer: true,
+			}
+		}
+	}
+	return fixtureValue{kind: fixtureUnknown}
+}
+
+// value returns the comparable value of the fixture i, the values of the ordered fields increase with i.
+func (fv fixtureValue) value(i int) int {
+	if fv.kind == fixtureBool {
+		if i%2 == 0 {
+			return 1
+		}
+		return 0
+	}
+	return i
+}
+
+func (fv fixtureValue) isZero(i int) bool {
+	if fv.pointer {
+		return false
+	}
+	switch fv.kind {
+	case fixtureUnknown:
+		return true
+	case fixtureBool:
+		return i%2 != 0
+	default:
+		return false
+	}
+}
+
+func getParamType(method *extract.InterfaceMethod, name string) code.Type {
+	for _, param := range method.Params {
+		if param.Name == name {
+			return param.Type
+		}
+	}
+	return nil
+}
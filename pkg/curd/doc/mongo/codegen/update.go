@@ -17,81 +17,149 @@
 package codegen
 
 import (
+	"fmt"
+
 	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
 	"github.com/cloudwego/cwgo/pkg/curd/parse"
 )
 
 func updateCodegen(update *parse.UpdateParse) []code.Statement {
+	st := update.BelongedToMethod.BelongedToStruct
+	query := optionQuery(update.Query, st)
+	var fields code.Statement = updateFieldsCodegen(update)
+	body := make([]code.Statement, 0, 8)
+
+	now := getLocalName(update.BelongedToMethod, "now")
+	if st.Options.Timestamps {
+		body = append(body, nowCodegen(now))
+	}
+	// restoreVersion restores the version of the entity if it is not updated
+	restoreVersion := ""
+	if update.UpdateStructObjName != "" {
+		entity := update.UpdateStructObjName
+		if st.Options.Timestamps {
+			body = append(body, assignFieldCodegen(st, entity, extract.UpdatedAtField, now))
+			fields = entityPipelineCodegen(entity, now, true)
+		}
+		if st.Options.Version {
+			// the entity is updated only if its version is not changed by others
+			version := getLocalName(update.BelongedToMethod, "version")
+			goPath, _, _ := st.GetFieldByMongoName(extract.VersionField)
+			body = append(body,
+				code.RawStmt(fmt.Sprintf("%s := %s.%s", version, entity, goPath)),
+				code.RawStmt(fmt.Sprintf("%s.%s = %s + 1", entity, goPath, version)),
+			)
+			if !update.Upsert {
+				query = andQuery(query, &parse.ConnectionOpTree{
+					Name:           string(parse.Equal),
+					MongoFieldName: extract.VersionField,
+					ParamNames:     []string{version},
+				})
+			}
+			restoreVersion = fmt.Sprintf("%s.%s = %s", entity, goPath, version)
+		}
+	} else {
+		fields = updateOptionFieldsCodegen(update, updateFieldsCodegen(update), now)
+	}
+
 	chainCall := make(code.ChainStmt, 0, 5)
-	if update.OperateMode == parse.OperateOne {
-		return []code.Statement{
-			code.DeclColonStmt{
-				Left: code.ListCommaStmt{
-					code.RawStmt("result"),
-					code.RawStmt("err"),
-				},
-				Right: code.CallStmt{
-					Caller:   code.RawStmt("r.collection"),
-					CallName: "UpdateOne",
-					Args: code.ListCommaStmt{
-						code.RawStmt(update.CtxParamName),
-						queryCodegen(update.Query),
-						updateFieldsCodegen(update),
-						chainCall.ChainCall(code.Chain{
-							CallName: "options.Update",
-							Args:     code.ListCommaStmt{},
-						}).ChainCall(code.Chain{
-							CallName: "SetUpsert",
-							Args: code.ListCommaStmt{
-								upsertCodegen(update.Upsert),
-							},
-						}),
-					},
-				},
+	callName, errReturn, matched := "UpdateOne", "false", "result.MatchedCount > 0"
+	if update.OperateMode == parse.OperateMany {
+		callName, errReturn, matched = "UpdateMany", "0", "int(result.MatchedCount)"
+	}
+	body = append(body,
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt("result"),
+				code.RawStmt("err"),
 			},
-			code.RawStmt("if err != nil {\n\treturn false, err\n}"),
-			code.ReturnStmt{
-				ListCommaStmt: code.ListCommaStmt{
-					code.RawStmt("result.MatchedCount > 0"),
-					code.RawStmt("nil"),
+			Right: code.CallStmt{
+				Caller:   code.RawStmt("r.collection"),
+				CallName: callName,
+				Args: code.ListCommaStmt{
+					code.RawStmt(update.CtxParamName),
+					queryCodegen(query),
+					fields,
+					chainCall.ChainCall(code.Chain{
+						CallName: "options.Update",
+						Args:     code.ListCommaStmt{},
+					}).ChainCall(code.Chain{
+						CallName: "SetUpsert",
+						Args: code.ListCommaStmt{
+							upsertCodegen(update.Upsert),
+						},
+					}),
 				},
 			},
-		}
+		},
+	)
+	if restoreVersion == "" {
+		body = append(body, code.RawStmt(fmt.Sprintf("if err != nil {\n\treturn %s, err\n}", errReturn)))
 	} else {
-		return []code.Statement{
-			code.DeclColonStmt{
-				Left: code.ListCommaStmt{
-					code.RawStmt("result"),
-					code.RawStmt("err"),
-				},
-				Right: code.CallStmt{
-					Caller:   code.RawStmt("r.collection"),
-					CallName: "UpdateMany",
-					Args: code.ListCommaStmt{
-						code.RawStmt(update.CtxParamName),
-						queryCodegen(update.Query),
-						updateFieldsCodegen(update),
-						chainCall.ChainCall(code.Chain{
-							CallName: "options.Update",
-							Args:     code.ListCommaStmt{},
-						}).ChainCall(code.Chain{
-							CallName: "SetUpsert",
-							Args: code.ListCommaStmt{
-								upsertCodegen(update.Upsert),
-							},
-						}),
+		body = append(body,
+			code.RawStmt(fmt.Sprintf("if err != nil {\n\t%s\n\treturn %s, err\n}", restoreVersion, errReturn)),
+			code.RawStmt(fmt.Sprintf("if result.MatchedCount == 0 {\n\t%s\n}", restoreVersion)),
+		)
+	}
+	return append(body, code.ReturnStmt{
+		ListCommaStmt: code.ListCommaStmt{
+			code.RawStmt(matched),
+			code.RawStmt("nil"),
+		},
+	})
+}
+
+// updateOptionFieldsCodegen adds updated_at, created_at of the upserted document and the increment of version
+// to the update fields, the fields updated by the method explicitly are not overwritten.
+func updateOptionFieldsCodegen(update *parse.UpdateParse, fields code.MapStmt, now string) code.MapStmt {
+	st := update.BelongedToMethod.BelongedToStruct
+	isUpdated := func(mongoName string) bool {
+		for _, field := range update.UpdateFields {
+			if field.MongoFieldName == mongoName {
+				return true
+			}
+		}
+		return false
+	}
+
+	if st.Options.Timestamps && !isUpdated(extract.UpdatedAtField) {
+		set := fields.Pair[0].Value.(code.MapStmt)
+		set.Pair = append(set.Pair, code.MapPair{
+			Key:   code.RawStmt(extract.UpdatedAtField),
+			Value: code.RawStmt(now),
+		})
+		fields.Pair[0].Value = set
+	}
+	if st.Options.Timestamps && update.Upsert && !isUpdated(extract.CreatedAtField) {
+		fields.Pair = append(fields.Pair, code.MapPair{
+			Key: code.RawStmt("$setOnInsert"),
+			Value: code.MapStmt{
+				Name: "bson.M",
+				Pair: []code.MapPair{
+					{
+						Key:   code.RawStmt(extract.CreatedAtField),
+						Value: code.RawStmt(now),
 					},
 				},
 			},
-			code.RawStmt("if err != nil {\n\treturn 0, err\n}"),
-			code.ReturnStmt{
-				ListCommaStmt: code.ListCommaStmt{
-					code.RawStmt("int(result.MatchedCount)"),
-					code.RawStmt("nil"),
+		})
+	}
+	if st.Options.Version && !isUpdated(extract.VersionField) {
+		fields.Pair = append(fields.Pair, code.MapPair{
+			Key: code.RawStmt("$inc"),
+			Value: code.MapStmt{
+				Name: "bson.M",
+				Pair: []code.MapPair{
+					{
+						Key:   code.RawStmt(extract.VersionField),
+						Value: code.RawStmt("1"),
+					},
 				},
 			},
-		}
+		})
 	}
+	return fields
 }
 
 func updateFieldsCodegen(update *parse.UpdateParse) code.MapStmt {
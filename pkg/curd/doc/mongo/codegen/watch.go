@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// fullDocument is the field of the change event which stores the document after the change.
+const fullDocument = "fullDocument"
+
+// watchCodegen returns the change stream whose full documents are matched with the query, the documents are sent
+// to the returned channel until the context is canceled, then the change stream is closed with the channel.
+// The errors of decoding and the error which ends the change stream are passed to the error handler param,
+// the stream is ended by the error of decoding if the error handler is not declared.
+func watchCodegen(watch *parse.WatchParse) []code.Statement {
+	method := watch.BelongedToMethod
+	stream, entities, event := getLocalName(method, "stream"), getLocalName(method, "entities"),
+		getLocalName(method, "event")
+
+	pipeline := "mongo.Pipeline{}"
+	query := optionQuery(watch.Query, method.BelongedToStruct)
+	if query.QueryMode != parse.All {
+		pipeline = fmt.Sprintf("mongo.Pipeline{\nbson.D{{Key: \"$match\", Value: %s}},\n}",
+			queryCodegen(prefixQuery(query, fullDocument+".")).Code())
+	}
+
+	// the updates carry the current documents, so that they can be matched with the query
+	args := code.ListCommaStmt{
+		code.RawStmt(watch.CtxParamName),
+		code.RawStmt(pipeline),
+		code.RawStmt("options.ChangeStream().SetFullDocument(options.UpdateLookup)"),
+	}
+	if watch.OptionsParamName != "" {
+		args = append(args, code.RawStmt(watch.OptionsParamName))
+	}
+
+	decodeFailed, streamErr := "\t\t\treturn\n", ""
+	if watch.ErrorHandlerParamName != "" {
+		decodeFailed = fmt.Sprintf("\t\t\t%s(err)\n\t\t\tcontinue\n", watch.ErrorHandlerParamName)
+		// the error of the canceled context is expected
+		streamErr = fmt.Sprintf("\tif err := %s.Err(); err != nil && %s.Err() == nil {\n"+
+			"\t\t%s(err)\n"+
+			"\t}\n", stream, watch.CtxParamName, watch.ErrorHandlerParamName)
+	}
+
+	return []code.Statement{
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt(stream),
+				code.RawStmt("err"),
+			},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt("r.collection"),
+				CallName: "Watch",
+				Args:     args,
+			},
+		},
+		code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+		code.RawStmt(fmt.Sprintf("%s := make(chan %s)", entities, watch.ElementType.RealName())),
+		code.RawStmt(fmt.Sprintf("go func() {\n"+
+			"\tdefer close(%[1]s)\n"+
+			"\tdefer %[2]s.Close(context.Background())\n"+
+			"\tfor %[2]s.Next(%[3]s) {\n"+
+			"\t\tvar %[4]s struct {\n"+
+			"\t\t\tFullDocument %[5]s `bson:\"%[6]s\"`\n"+
+			"\t\t}\n"+
+			"\t\tif err := %[2]s.Decode(&%[4]s); err != nil {\n"+
+			"%[7]s"+
+			"\t\t}\n"+
+			"\t\t// the deletions have no full document\n"+
+			"\t\tif %[4]s.FullDocument == nil {\n"+
+			"\t\t\tcontinue\n"+
+			"\t\t}\n"+
+			"\t\tselect {\n"+
+			"\t\tcase %[1]s <- %[4]s.FullDocument:\n"+
+			"\t\tcase <-%[3]s.Done():\n"+
+			"\t\t\treturn\n"+
+			"\t\t}\n"+
+			"\t}\n"+
+			"%[8]s"+
+			"}()", entities, stream, watch.CtxParamName, event, watch.ElementType.RealName(), fullDocument,
+			decodeFailed, streamErr)),
+		code.ReturnStmt{
+			ListCommaStmt: code.ListCommaStmt{
+				code.RawStmt(entities),
+				code.RawStmt("nil"),
+			},
+		},
+	}
+}
+
+// prefixQuery returns the query whose field names are prefixed, the query is not modified.
+func prefixQuery(query *parse.Query, prefix string) *parse.Query {
+	if query.QueryMode == parse.All {
+		return query
+	}
+	return &parse.Query{
+		QueryMode:        query.QueryMode,
+		ConnectionOpTree: prefixNode(query.ConnectionOpTree, prefix),
+	}
+}
+
+func prefixNode(node *parse.ConnectionOpTree, prefix string) *parse.ConnectionOpTree {
+	if node == nil {
+		return nil
+	}
+	result := *node
+	if node.MongoFieldName != "" {
+		result.MongoFieldName = prefix + node.MongoFieldName
+	}
+	result.LeftChildren = prefixNode(node.LeftChildren, prefix)
+	result.RightChildren = prefixNode(node.RightChildren, prefix)
+	return &result
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudwego/cwgo/config"
+	"github.com/cloudwego/cwgo/pkg/common/utils"
+	"github.com/cloudwego/cwgo/pkg/consts"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
+	esCodegen "github.com/cloudwego/cwgo/pkg/curd/doc/elasticsearch/codegen"
+	gormCodegen "github.com/cloudwego/cwgo/pkg/curd/doc/gorm/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
+	redisCodegen "github.com/cloudwego/cwgo/pkg/curd/doc/redis/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+)
+
+func init() {
+	backend.Register(consts.MongoDb, mongoBackend{})
+	backend.Register(consts.Redis, repositoryBackend{name: consts.Redis})
+	backend.Register(consts.Gorm, repositoryBackend{name: consts.Gorm})
+	backend.Register(consts.Elasticsearch, repositoryBackend{name: consts.Elasticsearch})
+}
+
+// repositoryBackend generates the repositories of the backends other than mongodb, which only implement
+// the methods of the idl.
+type repositoryBackend struct {
+	name string
+}
+
+// TransactionClientType returns *gorm.DB for the gorm backend, the other backends reject the transactions.
+func (b repositoryBackend) TransactionClientType() string {
+	if b.name == consts.Gorm {
+		return "*gorm.DB"
+	}
+	return ""
+}
+
+func (b repositoryBackend) Generate(req *backend.Request) (*backend.Response, error) {
+	res := &backend.Response{}
+	for _, st := range req.Structs {
+		files, warnings, err := getBackendFiles(st, b.name, req.Args, req.ImportPaths)
+		if err != nil {
+			return nil, err
+		}
+		res.Files = append(res.Files, files...)
+		res.Warnings = append(res.Warnings, warnings...)
+	}
+	return res, nil
+}
+
+// getBackendFiles returns the implementation, the interface, the optional mock and the other files
+// of the repository of the backend specified by the name, they are regenerated every time because
+// the implementation can not be updated partially.
+func getBackendFiles(st *extract.IdlExtractStruct, name string, docArgs *config.DocArgument, importPaths []string) (files []backend.File, warnings []string, err error) {
+	_, fileIfName := extract.GetFileName(st.Name, docArgs.DaoDir)
+
+	ifOperation, err := parse.HandleAllOperations(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fileImplName string
+	var renders []template.Render
+	var getImports func(content string) map[string]string
+	// otherFiles are the files generated for the backend except the go files
+	var otherFiles []backend.File
+	switch name {
+	case consts.Redis:
+		fileImplName = extract.GetRedisFileName(st.Name, docArgs.DaoDir)
+		renders, err = redisCodegen.GetRedisRenders(ifOperation, getRawIfMethods(st))
+		getImports = redisCodegen.GetRedisImports
+	case consts.Gorm:
+		fileImplName = extract.GetGormFileName(st.Name, docArgs.DaoDir)
+		renders, err = gormCodegen.GetGormRenders(ifOperation, getRawIfMethods(st))
+		getImports = gormCodegen.GetGormImports
+	case consts.Elasticsearch:
+		var fileMappingName, mapping string
+		fileImplName, fileMappingName = extract.GetElasticsearchFileName(st.Name, docArgs.DaoDir)
+		if mapping, err = esCodegen.GetElasticsearchMapping(st); err != nil {
+			return nil, nil, err
+		}
+		otherFiles = append(otherFiles, backend.File{Name: fileMappingName, Content: mapping})
+		renders, err = esCodegen.GetElasticsearchRenders(ifOperation, getRawIfMethods(st))
+		getImports = esCodegen.GetElasticsearchImports
+	default:
+		return nil, nil, fmt.Errorf("doc name %s not supported", name)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	implCode, err := getBackendCode(st, renders, getImports)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, backend.File{Name: fileImplName, Content: implCode})
+
+	ifCode, err := getBackendIfCode(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, backend.File{Name: fileIfName, Content: ifCode})
+
+	if docArgs.Mock {
+		fileMockName, _ := extract.GetMockFileName(st.Name, docArgs.DaoDir)
+		mockCode, err := getMockCode(st, getRawIfMethods(st))
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, backend.File{Name: fileMockName, Content: mockCode})
+		warnings = append(warnings, fmt.Sprintf("%s: the in-memory fake is only generated for the mongo backend", st.Name))
+	}
+	if docArgs.UnitTest {
+		warnings = append(warnings, fmt.Sprintf("%s: the integration tests are only generated for the mongo backend", st.Name))
+	}
+	if docArgs.Cache {
+		warnings = append(warnings, fmt.Sprintf("%s: the cache decorator is only generated for the mongo backend", st.Name))
+	}
+	if docArgs.Hook {
+		warnings = append(warnings, fmt.Sprintf("%s: the hook decorator is only generated for the mongo backend", st.Name))
+	}
+	if docArgs.Generic {
+		warnings = append(warnings, fmt.Sprintf("%s: the generic repository is only generated for the mongo backend", st.Name))
+	}
+	if !st.Options.IsEmpty() {
+		warnings = append(warnings, fmt.Sprintf("%s: mongo.options are only supported by the mongo backend", st.Name))
+	}
+	for _, methods := range [][]*extract.InterfaceMethod{st.PreIfMethods, st.InterfaceInfo.Methods} {
+		for _, method := range methods {
+			if method.TransactionOptions != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: the transaction options of %s are only supported by "+
+					"the mongo backend", st.Name, method.Name))
+			}
+		}
+	}
+
+	for index := range files {
+		if files[index].Content, err = gormCodegen.AddGormImports(files[index].Content); err != nil {
+			return nil, nil, err
+		}
+		if files[index].Content, err = extract.AddMongoModelImports(files[index].Content, importPaths); err != nil {
+			return nil, nil, err
+		}
+	}
+	return append(files, otherFiles...), warnings, nil
+}
+
+// createFiles writes the files generated by the backend, the directories are created if they do not exist.
+func createFiles(files []backend.File) error {
+	for _, file := range files {
+		if isExist, _ := utils.PathExist(filepath.Dir(file.Name)); !isExist {
+			if err := os.MkdirAll(filepath.Dir(file.Name), 0o755); err != nil {
+				return err
+			}
+		}
+		if err := utils.CreateFile(file.Name, file.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getBackendCode returns the implementation of the repository interface whose imports are returned by getImports.
+func getBackendCode(st *extract.IdlExtractStruct, renders []template.Render, getImports func(content string) map[string]string) (string, error) {
+	tplImpl := &template.Template{
+		Renders: renders,
+	}
+	buff, err := tplImpl.Build()
+	if err != nil {
+		return "", err
+	}
+	baseBuff := new(bytes.Buffer)
+	baseRender := &template.BaseRender{
+		Version:     cwgoMeta.Version,
+		PackageName: extract.GetPkgName(st.Name),
+		Imports:     getImports(buff.String()),
+	}
+	if err = baseRender.RenderObj(baseBuff); err != nil {
+		return "", err
+	}
+
+	formattedCode, err := format.Source(append(baseBuff.Bytes(), buff.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
+
+// getBackendIfCode returns the repository interface implemented by the backend, which has no EnsureIndexes.
+func getBackendIfCode(st *extract.IdlExtractStruct) (string, error) {
+	tplIf := &template.Template{
+		Renders: []template.Render{
+			getBaseRender(st),
+			&template.InterfaceRender{
+				Name:    st.Name + "Repository",
+				Methods: getRawIfMethods(st),
+			},
+		},
+	}
+
+	buff, err := tplIf.Build()
+	if err != nil {
+		return "", err
+	}
+	formattedCode, err := format.Source(buff.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	return codegen.AddMongoImports(string(formattedCode))
+}
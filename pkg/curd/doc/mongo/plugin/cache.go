@@ -0,0 +1,128 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"bytes"
+	"go/format"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/cwgo/config"
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// getDaoPkgPath returns the import path of the package generated in the dao directory,
+// the package prefix is the import path of the model directory.
+func getDaoPkgPath(docArgs *config.DocArgument, pkgName string) (string, error) {
+	rel, err := filepath.Rel(docArgs.ModelDir, docArgs.DaoDir)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(docArgs.PackagePrefix, filepath.ToSlash(rel), pkgName), nil
+}
+
+// getPackageFiles returns the files of the package shared by the decorators, they are regenerated every time.
+func getPackageFiles(daoDir, pkgName string, packageFiles []codegen.PackageFile) ([]backend.File, error) {
+	files := make([]backend.File, 0, len(packageFiles))
+	for _, file := range packageFiles {
+		buff := new(bytes.Buffer)
+		baseRender := &template.BaseRender{
+			Version:     cwgoMeta.Version,
+			PackageName: pkgName,
+			Imports:     file.Imports,
+		}
+		if err := baseRender.RenderObj(buff); err != nil {
+			return nil, err
+		}
+		buff.WriteString(file.Code)
+		formattedCode, err := format.Source(buff.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, backend.File{
+			Name:    filepath.Join(daoDir, pkgName, file.Name),
+			Content: string(formattedCode),
+		})
+	}
+	return files, nil
+}
+
+// getCacheCode returns the cache decorator of the mongo repository, it is regenerated every time,
+// so all methods including the methods generated before are parsed.
+func getCacheCode(st *extract.IdlExtractStruct, cachePkgPath string) (string, error) {
+	ifOperation, err := parse.HandleAllOperations(st)
+	if err != nil {
+		return "", err
+	}
+
+	imports := make(map[string]string, len(codegen.CacheImports)+7)
+	for importPath, name := range codegen.CacheImports {
+		imports[importPath] = name
+	}
+	// the cache package is renamed if the model package has the same name
+	cachePkg := codegen.CachePkgName
+	if st.ModelPkg == cachePkg {
+		cachePkg = "dao" + cachePkg
+		imports[cachePkgPath] = cachePkg
+	} else {
+		imports[cachePkgPath] = ""
+	}
+
+	tplCache := &template.Template{
+		Renders: codegen.GetCacheRenders(ifOperation, getIfMethods(st), cachePkg),
+	}
+	buff, err := tplCache.Build()
+	if err != nil {
+		return "", err
+	}
+
+	imports["go.mongodb.org/mongo-driver/mongo"] = ""
+	if strings.Contains(buff.String(), "bson.") {
+		imports["go.mongodb.org/mongo-driver/bson"] = ""
+	}
+	if strings.Contains(buff.String(), "options.") {
+		imports["go.mongodb.org/mongo-driver/mongo/options"] = ""
+	}
+	imports["go.mongodb.org/mongo-driver/bson/bsoncodec"] = ""
+	if strings.Contains(buff.String(), "bsonrw.") {
+		imports["bytes"] = ""
+		imports["go.mongodb.org/mongo-driver/bson/bsonrw"] = ""
+	}
+	baseBuff := new(bytes.Buffer)
+	baseRender := &template.BaseRender{
+		Version:     cwgoMeta.Version,
+		PackageName: extract.GetPkgName(st.Name),
+		Imports:     imports,
+	}
+	if err = baseRender.RenderObj(baseBuff); err != nil {
+		return "", err
+	}
+
+	formattedCode, err := format.Source(append(baseBuff.Bytes(), buff.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
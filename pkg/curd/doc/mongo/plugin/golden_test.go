@@ -0,0 +1,134 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/cwgo/config"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/plugin"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the generated code")
+
+// TestGenerateGolden generates the repositories of the idls in testdata and compares the files with the golden
+// files in testdata/<name>, which are rewritten by go test -update.
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		idl  string
+		mock bool
+	}{
+		{name: "keyset", idl: "keyset.thrift"},
+		{name: "keyset_mock", idl: "keyset.thrift", mock: true},
+		{name: "options", idl: "options.thrift", mock: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			args := &config.DocArgument{
+				DaoDir:        filepath.Join(dir, "dao"),
+				ModelDir:      filepath.Join(dir, "model"),
+				PackagePrefix: "example/model",
+				Mock:          tt.mock,
+				UnitTest:      tt.mock,
+			}
+			files := generateTestFiles(t, filepath.Join("testdata", tt.idl), args)
+
+			goldenDir := filepath.Join("testdata", tt.name)
+			if *update {
+				if err := os.RemoveAll(goldenDir); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, file := range files {
+				rel, err := filepath.Rel(dir, file.Name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join(goldenDir, rel+".golden")
+				if *update {
+					if err = os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err = os.WriteFile(golden, []byte(file.Content), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("read the golden file, run go test -update to create it: %v", err)
+				}
+				if file.Content != string(want) {
+					t.Errorf("%s differs from %s, run go test -update to accept the changes", rel, golden)
+				}
+			}
+
+			// the golden files of the files which are not generated any more are reported
+			count := 0
+			_ = filepath.Walk(goldenDir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					count++
+				}
+				return err
+			})
+			if count != len(files) {
+				t.Errorf("%d golden files in %s, %d files generated", count, goldenDir, len(files))
+			}
+		})
+	}
+}
+
+// generateTestFiles extracts and parses the thrift idl, and returns the files generated by the mongo backend.
+func generateTestFiles(t *testing.T, idlPath string, args *config.DocArgument) []backend.File {
+	t.Helper()
+	ast, err := parser.ParseFile(idlPath, nil, true)
+	if err != nil {
+		t.Fatalf("parse the idl: %v", err)
+	}
+	info := &extract.ThriftUsedInfo{
+		Req:     &plugin.Request{AST: ast},
+		DocArgs: args,
+	}
+	structs, err := info.ParseThriftIdl()
+	if err != nil {
+		t.Fatalf("extract the idl: %v", err)
+	}
+	operations, err := parse.HandleOperations(structs)
+	if err != nil {
+		t.Fatalf("parse the methods: %v", err)
+	}
+	resp, err := mongoBackend{}.Generate(&backend.Request{
+		Args:        args,
+		ImportPaths: info.ImportPaths,
+		Structs:     structs,
+		Operations:  operations,
+	})
+	if err != nil {
+		t.Fatalf("generate the repositories: %v", err)
+	}
+	return resp.Files
+}
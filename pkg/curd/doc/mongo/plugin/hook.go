@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// getHookCode returns the hook decorator of the mongo repository, it is regenerated every time,
+// so all methods including the methods generated before are wrapped.
+func getHookCode(st *extract.IdlExtractStruct, hookPkgPath string) (string, error) {
+	ifOperation, err := parse.HandleAllOperations(st)
+	if err != nil {
+		return "", err
+	}
+
+	imports := make(map[string]string, len(codegen.HookImports)+4)
+	for importPath, name := range codegen.HookImports {
+		imports[importPath] = name
+	}
+	// the hook package is renamed if the model package has the same name
+	hookPkg := codegen.HookPkgName
+	if st.ModelPkg == hookPkg {
+		hookPkg = "dao" + hookPkg
+		imports[hookPkgPath] = hookPkg
+	} else {
+		imports[hookPkgPath] = ""
+	}
+
+	tplHook := &template.Template{
+		Renders: codegen.GetHookRenders(ifOperation, getIfMethods(st), hookPkg),
+	}
+	buff, err := tplHook.Build()
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(buff.String(), "context.") {
+		imports["context"] = ""
+	}
+	if strings.Contains(buff.String(), "mongo.") {
+		imports["go.mongodb.org/mongo-driver/mongo"] = ""
+	}
+	if strings.Contains(buff.String(), "bson.") {
+		imports["go.mongodb.org/mongo-driver/bson"] = ""
+	}
+	if strings.Contains(buff.String(), "options.") {
+		imports["go.mongodb.org/mongo-driver/mongo/options"] = ""
+	}
+	baseBuff := new(bytes.Buffer)
+	baseRender := &template.BaseRender{
+		Version:     cwgoMeta.Version,
+		PackageName: extract.GetPkgName(st.Name),
+		Imports:     imports,
+	}
+	if err = baseRender.RenderObj(baseBuff); err != nil {
+		return "", err
+	}
+
+	formattedCode, err := format.Source(append(baseBuff.Bytes(), buff.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// getRenderName returns the name of the generated region of the method or the function render.
+func getRenderName(render template.Render) string {
+	switch r := render.(type) {
+	case *template.MethodRender:
+		return r.Name
+	case *template.FuncRender:
+		return r.Name
+	default:
+		return ""
+	}
+}
+
+// getRenderCode returns the formatted code of the method or the function render, the blank lines
+// around it are removed.
+func getRenderCode(render template.Render) (string, error) {
+	tpl := &template.Template{
+		Renders: []template.Render{render},
+	}
+	buff, err := tpl.Build()
+	if err != nil {
+		return "", err
+	}
+	formattedCode, err := format.Source(buff.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(formattedCode)) + "\n", nil
+}
+
+// mergeMongoCode merges the methods generated from the idl into the repository file generated before.
+// The three versions of each generated region are compared, they are the code when it was generated
+// which is recorded by the hash, the code in the file and the code generated now:
+//
+//	the region is not edited by hand: it is replaced by the code generated now
+//	the region is edited by hand and the generated code is not changed: the edited code is kept
+//	both of them are changed: the edited code is kept and the conflict is reported
+//
+// The regions whose methods are removed from the idl are deleted if prune is true, otherwise they are reported.
+// The code outside the regions is kept verbatim and the new methods are appended to the end of the file.
+func mergeMongoCode(fileContent string, regions []*extract.GeneratedRegion, renders []template.Render,
+	stName string, prune bool,
+) (string, []string, error) {
+	generated := make(map[string]string, len(renders))
+	names := make([]string, 0, len(renders))
+	for _, render := range renders {
+		renderCode, err := getRenderCode(render)
+		if err != nil {
+			return "", nil, err
+		}
+		generated[getRenderName(render)] = renderCode
+		names = append(names, getRenderName(render))
+	}
+
+	var warnings []string
+	var sb strings.Builder
+	offset := 0
+	merged := make(map[string]struct{}, len(regions))
+	for _, region := range regions {
+		if _, ok := merged[region.Name]; ok {
+			return "", nil, fmt.Errorf("%s: the method %s is generated more than once", stName, region.Name)
+		}
+		merged[region.Name] = struct{}{}
+
+		sb.WriteString(fileContent[offset:region.Start])
+		offset = region.End
+
+		methodCode, ok := generated[region.Name]
+		switch {
+		case ok && !region.IsEdited():
+			sb.WriteString(extract.GetGeneratedRegion(region.Name, methodCode))
+		case ok:
+			sb.WriteString(fileContent[region.Start:region.End])
+			if extract.HashGeneratedCode(methodCode) != region.Hash {
+				warnings = append(warnings, fmt.Sprintf("method %s of %s is edited by hand and changed in the idl, "+
+					"the edited code is kept, delete its generated region to regenerate it", region.Name, stName))
+			}
+		case prune && !region.IsEdited():
+		case prune:
+			sb.WriteString(fileContent[region.Start:region.End])
+			warnings = append(warnings, fmt.Sprintf("method %s of %s is removed from the idl but edited by hand, "+
+				"it is not pruned", region.Name, stName))
+		default:
+			sb.WriteString(fileContent[region.Start:region.End])
+			warnings = append(warnings, fmt.Sprintf("method %s of %s is removed from the idl, "+
+				"use --prune to delete it", region.Name, stName))
+		}
+	}
+	sb.WriteString(fileContent[offset:])
+
+	for _, name := range names {
+		if _, ok := merged[name]; ok {
+			continue
+		}
+		sb.WriteString("\n")
+		sb.WriteString(extract.GetGeneratedRegion(name, generated[name]))
+	}
+
+	formattedCode, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(formattedCode), warnings, nil
+}
+
+func hasGeneratedRegion(regions []*extract.GeneratedRegion, name string) bool {
+	for _, region := range regions {
+		if region.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+func newTestRender(name, stmt string) template.Render {
+	return &template.MethodRender{
+		Name: name,
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{RealType: code.IdentType("UserRepositoryMongo")},
+		},
+		Returns:    code.Returns{code.IdentType("error")},
+		MethodBody: code.Body{code.RawStmt(stmt)},
+	}
+}
+
+// newTestRegion returns the generated region of the render as it is written into the file.
+func newTestRegion(t *testing.T, render template.Render) string {
+	renderCode, err := getRenderCode(render)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return extract.GetGeneratedRegion(getRenderName(render), renderCode)
+}
+
+func TestMergeMongoCode(t *testing.T) {
+	ping := newTestRender("Ping", "return nil")
+	pingChanged := newTestRender("Ping", "return errors.New(\"changed\")")
+	count := newTestRender("Count", "return nil")
+	header := "package user\n\n"
+	userMethod := "\n// Close is written by hand.\nfunc (r *UserRepositoryMongo) Close() error {\n\treturn nil\n}\n"
+
+	pingRegion, countRegion := newTestRegion(t, ping), newTestRegion(t, count)
+	editedPing := strings.Replace(pingRegion, "return nil", "// audited by hand\n\treturn nil", 1)
+	editedCount := strings.Replace(countRegion, "return nil", "// tuned by hand\n\treturn nil", 1)
+
+	tests := []struct {
+		name     string
+		content  string
+		renders  []template.Render
+		prune    bool
+		contains []string
+		excludes []string
+		warnings []string
+		wantErr  string
+	}{
+		{
+			name:     "regenerated",
+			content:  header + pingRegion,
+			renders:  []template.Render{pingChanged},
+			contains: []string{"changed"},
+			excludes: []string{"return nil"},
+		},
+		{
+			name:     "edited by hand",
+			content:  header + editedPing,
+			renders:  []template.Render{ping},
+			contains: []string{"// audited by hand"},
+		},
+		{
+			name:     "edited by hand and changed in the idl",
+			content:  header + editedPing,
+			renders:  []template.Render{pingChanged},
+			contains: []string{"// audited by hand"},
+			excludes: []string{"changed"},
+			warnings: []string{"method Ping of User is edited by hand and changed in the idl"},
+		},
+		{
+			name:     "new method and user method",
+			content:  header + pingRegion + userMethod,
+			renders:  []template.Render{ping, count},
+			contains: []string{"// Close is written by hand.", "// End of code generated by cwgo (Count)."},
+		},
+		{
+			name:     "removed from the idl",
+			content:  header + pingRegion + "\n" + countRegion,
+			renders:  []template.Render{ping},
+			contains: []string{"// End of code generated by cwgo (Count)."},
+			warnings: []string{"method Count of User is removed from the idl, use --prune to delete it"},
+		},
+		{
+			name:     "removed from the idl and pruned",
+			content:  header + pingRegion + "\n" + countRegion + userMethod,
+			renders:  []template.Render{ping},
+			prune:    true,
+			contains: []string{"// Close is written by hand."},
+			excludes: []string{"Count"},
+		},
+		{
+			name:     "removed from the idl and edited by hand",
+			content:  header + pingRegion + "\n" + editedCount,
+			renders:  []template.Render{ping},
+			prune:    true,
+			contains: []string{"// tuned by hand"},
+			warnings: []string{"method Count of User is removed from the idl but edited by hand, it is not pruned"},
+		},
+		{
+			name:    "generated more than once",
+			content: header + pingRegion + "\n" + pingRegion,
+			renders: []template.Render{ping},
+			wantErr: "User: the method Ping is generated more than once",
+		},
+		{
+			name:    "damaged marker",
+			content: header + strings.Replace(pingRegion, "// End of code generated by cwgo (Ping).", "// End of code (Ping).", 1),
+			renders: []template.Render{ping},
+			wantErr: "the generated region of Ping is not ended",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions, err := extract.GetGeneratedRegions(tt.content)
+			var merged string
+			var warnings []string
+			if err == nil {
+				merged, warnings, err = mergeMongoCode(tt.content, regions, tt.renders, "User", tt.prune)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range tt.contains {
+				if !strings.Contains(merged, s) {
+					t.Errorf("expected %q in the merged code:\n%s", s, merged)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(merged, s) {
+					t.Errorf("unexpected %q in the merged code:\n%s", s, merged)
+				}
+			}
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("expected warnings %q, got %q", tt.warnings, warnings)
+			}
+			for i, warning := range tt.warnings {
+				if !strings.HasPrefix(warnings[i], warning) {
+					t.Errorf("expected warning %q, got %q", warning, warnings[i])
+				}
+			}
+
+			// the merged code is stable, merging it again changes nothing
+			regions, err = extract.GetGeneratedRegions(merged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, _, err := mergeMongoCode(merged, regions, tt.renders, "User", tt.prune)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != merged {
+				t.Errorf("merging again changes the code:\n%s\nto:\n%s", merged, again)
+			}
+		})
+	}
+}
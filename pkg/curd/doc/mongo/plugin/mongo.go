@@ -0,0 +1,395 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/config"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// mongoBackend generates the mongo repositories, the methods generated before are kept.
+type mongoBackend struct{}
+
+func (mongoBackend) TransactionClientType() string {
+	return "*mongo.Client"
+}
+
+func (mongoBackend) Generate(req *backend.Request) (*backend.Response, error) {
+	for _, operation := range req.Operations {
+		if err := codegen.CheckModelOptions(operation); err != nil {
+			return nil, err
+		}
+	}
+	references, err := getRepositoryReferences(req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	methodRenders := codegen.HandleCodegen(req.Operations)
+	files, warnings, err := getMongoFiles(req.Structs, methodRenders, req.Args, req.ImportPaths, references)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, operation := range req.Operations {
+		warnings = append(warnings, codegen.CheckIndexCoverage(operation)...)
+	}
+	return &backend.Response{Files: files, Warnings: warnings}, nil
+}
+
+// getRepositoryReferences returns the models whose repositories are referenced by the transactions of
+// each structure, the packages of the repositories are imported by the structure. The repositories
+// referencing each other are reported because their packages can not import each other.
+func getRepositoryReferences(operations []*parse.InterfaceOperation) (map[*extract.IdlExtractStruct][]*extract.IdlExtractStruct, error) {
+	references := make(map[*extract.IdlExtractStruct][]*extract.IdlExtractStruct)
+	for _, ifOperation := range operations {
+		for _, operation := range ifOperation.Operations {
+			transaction, ok := operation.(*parse.TransactionParse)
+			if !ok {
+				continue
+			}
+			for _, taOperation := range transaction.TransactionOperations {
+				if taOperation.Model == nil || containsStruct(references[ifOperation.BelongedToStruct], taOperation.Model) {
+					continue
+				}
+				references[ifOperation.BelongedToStruct] = append(references[ifOperation.BelongedToStruct], taOperation.Model)
+			}
+		}
+	}
+
+	// the repositories being visited are gray and the visited ones are black
+	const gray, black = 1, 2
+	colors := make(map[*extract.IdlExtractStruct]int, len(references))
+	var visit func(st *extract.IdlExtractStruct, path []string) error
+	visit = func(st *extract.IdlExtractStruct, path []string) error {
+		colors[st] = gray
+		path = append(path, st.Name)
+		for _, model := range references[st] {
+			switch colors[model] {
+			case gray:
+				cycle := path
+				for index, name := range path {
+					if name == model.Name {
+						cycle = path[index:]
+						break
+					}
+				}
+				return fmt.Errorf("%s: the repositories referenced by the transactions make an import cycle %s -> %s, "+
+					"pass the collections as *mongo.Collection instead", model.Name, strings.Join(cycle, " -> "), model.Name)
+			case black:
+			default:
+				if err := visit(model, path); err != nil {
+					return err
+				}
+			}
+		}
+		colors[st] = black
+		return nil
+	}
+	for _, ifOperation := range operations {
+		if colors[ifOperation.BelongedToStruct] == 0 {
+			if err := visit(ifOperation.BelongedToStruct, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return references, nil
+}
+
+func containsStruct(structs []*extract.IdlExtractStruct, st *extract.IdlExtractStruct) bool {
+	for _, s := range structs {
+		if s == st {
+			return true
+		}
+	}
+	return false
+}
+
+// getRepositoryImportPaths returns the import paths of the models and the repositories referenced by the structure.
+func getRepositoryImportPaths(docArgs *config.DocArgument, importPaths []string, models []*extract.IdlExtractStruct) ([]string, error) {
+	if len(models) == 0 {
+		return importPaths, nil
+	}
+	result := append(make([]string, 0, len(importPaths)+len(models)), importPaths...)
+	for _, model := range models {
+		pkgPath, err := getDaoPkgPath(docArgs, extract.GetPkgName(model.Name))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pkgPath)
+	}
+	return result, nil
+}
+
+// getMongoFiles returns the repositories of the structures and the packages shared by them,
+// the repositories generated before are merged with the methods generated now.
+func getMongoFiles(structs []*extract.IdlExtractStruct, methodRenders [][]*template.MethodRender,
+	docArgs *config.DocArgument, modelImportPaths []string, references map[*extract.IdlExtractStruct][]*extract.IdlExtractStruct,
+) (files []backend.File, warnings []string, err error) {
+	cachePkgPath := ""
+	if docArgs.Cache {
+		if cachePkgPath, err = getDaoPkgPath(docArgs, codegen.CachePkgName); err != nil {
+			return nil, nil, err
+		}
+		pkgFiles, err := getPackageFiles(docArgs.DaoDir, codegen.CachePkgName, codegen.CachePackageFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, pkgFiles...)
+	}
+
+	hookPkgPath := ""
+	if docArgs.Hook {
+		if hookPkgPath, err = getDaoPkgPath(docArgs, codegen.HookPkgName); err != nil {
+			return nil, nil, err
+		}
+		pkgFiles, err := getPackageFiles(docArgs.DaoDir, codegen.HookPkgName, codegen.HookPackageFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, pkgFiles...)
+	}
+
+	genericPkgPath := ""
+	if docArgs.Generic {
+		if genericPkgPath, err = getDaoPkgPath(docArgs, codegen.GenericPkgName); err != nil {
+			return nil, nil, err
+		}
+		packageFiles, err := codegen.GetGenericPackageFiles()
+		if err != nil {
+			return nil, nil, err
+		}
+		pkgFiles, err := getPackageFiles(docArgs.DaoDir, codegen.GenericPkgName, packageFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, pkgFiles...)
+
+		stWarnings, err := setGeneric(structs)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, stWarnings...)
+	}
+
+	// the registries are used by the constructors of all repositories
+	registryFiles, err := getRegistryFiles(structs, modelImportPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, registryFiles...)
+
+	for index, st := range structs {
+		// the repositories referenced by the transactions are imported by the files with the methods
+		importPaths, err := getRepositoryImportPaths(docArgs, modelImportPaths, references[st])
+		if err != nil {
+			return nil, nil, err
+		}
+		// the generic repository is imported by the repositories which embed it
+		mongoImportPaths := importPaths
+		if genericPkgPath != "" {
+			mongoImportPaths = append(append([]string{}, importPaths...), genericPkgPath)
+		}
+
+		// get base render
+		baseRender := getBaseRender(st)
+		// get fileMongoName and fileIfName
+		fileMongoName, fileIfName := extract.GetFileName(st.Name, docArgs.DaoDir)
+
+		if docArgs.Mock {
+			fileMockName, fileFakeName := extract.GetMockFileName(st.Name, docArgs.DaoDir)
+
+			formattedCode, err := getMockCode(st, getIfMethods(st))
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, importPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileMockName, Content: formattedCode})
+
+			formattedCode, err = getFakeCode(st)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, importPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileFakeName, Content: formattedCode})
+		}
+
+		fileSchemaName := extract.GetSchemaFileName(st.Name, docArgs.DaoDir)
+		schemaCode, err := getSchemaCode(st)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, backend.File{Name: fileSchemaName, Content: schemaCode})
+
+		if docArgs.Cache {
+			fileCacheName := extract.GetCacheFileName(st.Name, docArgs.DaoDir)
+
+			formattedCode, err := getCacheCode(st, cachePkgPath)
+			if err != nil {
+				return nil, nil, err
+			}
+			// the cache builds the repository which may embed the generic repository
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, mongoImportPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileCacheName, Content: formattedCode})
+			warnings = append(warnings, codegen.CheckCacheFields(st)...)
+		}
+
+		if docArgs.Hook {
+			fileHookName := extract.GetHookFileName(st.Name, docArgs.DaoDir)
+
+			formattedCode, err := getHookCode(st, hookPkgPath)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, importPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileHookName, Content: formattedCode})
+		}
+
+		if docArgs.UnitTest {
+			fileTestName := extract.GetTestFileName(st.Name, docArgs.DaoDir)
+
+			formattedCode, err := getUnitTestCode(st)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, importPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileTestName, Content: formattedCode})
+		}
+
+		if st.Update {
+			// build update mongo file
+			formattedCode, stWarnings, err := getUpdateMongoCode(methodRenders[index], st, docArgs.Prune)
+			if err != nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, stWarnings...)
+			formattedCode, err = codegen.AddMongoImports(formattedCode)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, mongoImportPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileMongoName, Content: formattedCode})
+
+			// build update interface file
+			formattedCode, err = getUpdateIfCode(st, baseRender)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = codegen.AddMongoImports(formattedCode)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, importPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileIfName, Content: formattedCode})
+		} else {
+			// build new mongo file
+			formattedCode, err := getNewMongoCode(methodRenders[index], st, baseRender)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = codegen.AddMongoImports(formattedCode)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, mongoImportPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileMongoName, Content: formattedCode})
+
+			// build new interface file
+			formattedCode, err = getNewIfCode(st, baseRender)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = codegen.AddMongoImports(formattedCode)
+			if err != nil {
+				return nil, nil, err
+			}
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, importPaths)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, backend.File{Name: fileIfName, Content: formattedCode})
+		}
+	}
+
+	return
+}
+
+// setGeneric decides whether the repositories embed the generic repository, the repositories generated
+// before without it are kept unchanged because their constructors and structures are not regenerated, and the
+// models with mongo.options are rejected because the generic methods do not apply the options.
+func setGeneric(structs []*extract.IdlExtractStruct) (warnings []string, err error) {
+	for _, st := range structs {
+		if st.ModelPkg == codegen.GenericPkgName {
+			return nil, fmt.Errorf("%s: the model package %s conflicts with the generic repository package",
+				st.Name, st.ModelPkg)
+		}
+		for _, methods := range [][]*extract.InterfaceMethod{st.PreIfMethods, st.InterfaceInfo.Methods} {
+			for _, method := range methods {
+				for _, name := range codegen.GenericMethodNames {
+					if method.Name == name {
+						return nil, fmt.Errorf("%s: the method %s conflicts with the method of the generic repository",
+							st.Name, method.Name)
+					}
+				}
+			}
+		}
+
+		// the methods of the generic repository are shared by the models, they do not know the options of the model
+		if !st.Options.IsEmpty() {
+			return nil, fmt.Errorf("%s: the methods of the generic repository do not apply mongo.options, "+
+				"generate the repository without --generic", st.Name)
+		}
+
+		if st.Update && !strings.Contains(string(st.UpdateCurdFileContent), codegen.GenericPkgName+"."+codegen.GenericRepository+"[") {
+			warnings = append(warnings, fmt.Sprintf("%s: the repository generated before does not embed the generic "+
+				"repository, delete its constructor and structure to regenerate them", st.Name))
+			continue
+		}
+		st.Generic = true
+	}
+	return warnings, nil
+}
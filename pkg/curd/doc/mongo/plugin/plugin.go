@@ -20,23 +20,32 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/cloudwego/cwgo/pkg/common/parser"
 
-	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
 	"github.com/cloudwego/cwgo/pkg/curd/extract"
 	"github.com/cloudwego/cwgo/pkg/curd/parse"
-	"github.com/cloudwego/cwgo/pkg/curd/template"
 
 	"github.com/cloudwego/cwgo/config"
 	"github.com/cloudwego/cwgo/pkg/common/utils"
 	"github.com/cloudwego/cwgo/pkg/consts"
 	"github.com/cloudwego/hertz/cmd/hz/meta"
+	"github.com/cloudwego/hertz/cmd/hz/util/logs"
 )
 
 func MongoTriggerPlugin(c *config.DocArgument) error {
+	if c.DumpAST && c.IdlType == meta.IdlThrift {
+		dumpFile, err := os.CreateTemp("", "cwgo-doc-ast-*.json")
+		if err != nil {
+			return err
+		}
+		dumpFile.Close()
+		c.DumpPath = dumpFile.Name()
+		defer os.Remove(c.DumpPath)
+	}
+
 	cmd, err := buildPluginCmd(c)
 	if err != nil {
 		return fmt.Errorf("build plugin command failed: %v", err)
@@ -49,7 +58,21 @@ func MongoTriggerPlugin(c *config.DocArgument) error {
 
 	// If len(buf) != 0, the plugin returned the log.
 	if len(buf) != 0 {
-		fmt.Println(string(buf))
+		if c.DumpAST {
+			// the dump is printed to stdout alone
+			fmt.Fprintln(os.Stderr, string(buf))
+		} else {
+			fmt.Println(string(buf))
+		}
+	}
+
+	if c.DumpAST && c.IdlType == meta.IdlThrift {
+		data, err := os.ReadFile(c.DumpPath)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
 	}
 
 	if c.IdlType == meta.IdlProto {
@@ -64,18 +87,57 @@ func MongoTriggerPlugin(c *config.DocArgument) error {
 		if err != nil {
 			return err
 		}
-		methodRenders := codegen.HandleCodegen(operations)
+		if c.DumpAST {
+			data, err := backend.MarshalDump(backend.GetDump(rawStructs, operations))
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		b, err := backend.Lookup(c.Name)
+		if err != nil {
+			return err
+		}
+		if err = backend.CheckTransactionClients(c.Name, b, operations); err != nil {
+			return err
+		}
+		res, err := b.Generate(&backend.Request{
+			Args:        c,
+			ImportPaths: info.ImportPaths,
+			Structs:     rawStructs,
+			Operations:  operations,
+		})
+		if err != nil {
+			return err
+		}
+		// the pb files are tagged after the repositories are generated successfully
 		if err = info.GeneratePbFile(); err != nil {
 			return err
 		}
-		if err = generatePbMongoFile(rawStructs, methodRenders, info); err != nil {
+		if err = createFiles(res.Files); err != nil {
 			return err
 		}
+		for _, warning := range res.Warnings {
+			logs.Warn(warning)
+		}
 	}
 
 	return nil
 }
 
+// RunProtoc generates the pb.go files of the proto idl in c.ModelDir, which are extracted by extract.PbUsedInfo.
+func RunProtoc(c *config.DocArgument) error {
+	cmd, err := buildPluginCmd(c)
+	if err != nil {
+		return fmt.Errorf("build protoc command failed: %v", err)
+	}
+	if buf, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("protoc returns error: %v, cause:\n%v", err, string(buf))
+	}
+	return nil
+}
+
 func buildPluginCmd(args *config.DocArgument) (*exec.Cmd, error) {
 	exe, err := os.Executable()
 	if err != nil {
@@ -157,94 +219,3 @@ func MongoPluginMode() {
 		}
 	}
 }
-
-func generatePbMongoFile(structs []*extract.IdlExtractStruct, methodRenders [][]*template.MethodRender, info *extract.PbUsedInfo) error {
-	for index, st := range structs {
-		// get base render
-		baseRender := getBaseRender(st)
-		// get fileMongoName and fileIfName
-		fileMongoName, fileIfName := extract.GetFileName(st.Name, info.DocArgs.DaoDir)
-		if isExist, _ := utils.PathExist(filepath.Dir(fileMongoName)); !isExist {
-			if err := os.MkdirAll(filepath.Dir(fileMongoName), 0o755); err != nil {
-				return err
-			}
-		}
-		if isExist, _ := utils.PathExist(filepath.Dir(fileIfName)); !isExist {
-			if err := os.MkdirAll(filepath.Dir(fileIfName), 0o755); err != nil {
-				return err
-			}
-		}
-
-		if st.Update {
-			// build update mongo file
-			formattedCode, err := getUpdateMongoCode(methodRenders[index], string(st.UpdateCurdFileContent))
-			if err != nil {
-				return err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return err
-			}
-			if err = utils.CreateFile(fileMongoName, formattedCode); err != nil {
-				return err
-			}
-
-			// build update interface file
-			formattedCode, err = getUpdateIfCode(st, baseRender)
-			if err != nil {
-				return err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return err
-			}
-			if err = utils.CreateFile(fileIfName, formattedCode); err != nil {
-				return err
-			}
-		} else {
-			// build new mongo file
-			formattedCode, err := getNewMongoCode(methodRenders[index], st, baseRender)
-			if err != nil {
-				return err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return err
-			}
-			if err = utils.CreateFile(fileMongoName, formattedCode); err != nil {
-				return err
-			}
-
-			// build new interface file
-			formattedCode, err = getNewIfCode(st, baseRender)
-			if err != nil {
-				return err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return err
-			}
-			if err = utils.CreateFile(fileIfName, formattedCode); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
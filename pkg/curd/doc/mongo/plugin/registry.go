@@ -0,0 +1,109 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"bytes"
+	"go/format"
+	"path/filepath"
+	"strings"
+
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// getRegistryFiles returns the bson registries of the model packages of the structures, the registry
+// is generated into the model package, so the unexported oneof interfaces are referenced.
+func getRegistryFiles(structs []*extract.IdlExtractStruct, importPaths []string) ([]backend.File, error) {
+	modelDirs := make([]string, 0, len(structs))
+	packages := make(map[string][]*extract.IdlExtractStruct, len(structs))
+	for _, st := range structs {
+		if _, ok := packages[st.ModelDir]; !ok {
+			modelDirs = append(modelDirs, st.ModelDir)
+		}
+		packages[st.ModelDir] = append(packages[st.ModelDir], st)
+	}
+
+	files := make([]backend.File, 0, len(modelDirs))
+	for _, modelDir := range modelDirs {
+		formattedCode, err := getRegistryCode(packages[modelDir])
+		if err != nil {
+			return nil, err
+		}
+		// the types of the other model packages are imported
+		formattedCode, err = extract.AddMongoModelImports(formattedCode, importPaths)
+		if err != nil {
+			return nil, err
+		}
+		// the map literals of the oneofs are aligned after the imports are added
+		content, err := format.Source([]byte(formattedCode))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, backend.File{
+			Name:    filepath.Join(modelDir, extract.RegistryFileName),
+			Content: string(content),
+		})
+	}
+	return files, nil
+}
+
+func getRegistryCode(structs []*extract.IdlExtractStruct) (string, error) {
+	tplRegistry := &template.Template{
+		Renders: codegen.GetRegistryRenders(structs),
+	}
+	buff, err := tplRegistry.Build()
+	if err != nil {
+		return "", err
+	}
+
+	imports := map[string]string{
+		"go.mongodb.org/mongo-driver/bson":           "",
+		"go.mongodb.org/mongo-driver/bson/bsoncodec": "",
+	}
+	if strings.Contains(buff.String(), "reflect.") {
+		imports["reflect"] = ""
+	}
+	if strings.Contains(buff.String(), "bsonrw.") {
+		imports["go.mongodb.org/mongo-driver/bson/bsonrw"] = ""
+	}
+	if strings.Contains(buff.String(), "bsontype.") {
+		imports["go.mongodb.org/mongo-driver/bson/bsontype"] = ""
+	}
+	if strings.Contains(buff.String(), "time.") {
+		imports["time"] = ""
+	}
+	baseBuff := new(bytes.Buffer)
+	baseRender := &template.BaseRender{
+		Version:     cwgoMeta.Version,
+		PackageName: structs[0].ModelPkg,
+		Imports:     imports,
+	}
+	if err = baseRender.RenderObj(baseBuff); err != nil {
+		return "", err
+	}
+
+	formattedCode, err := format.Source(append(baseBuff.Bytes(), buff.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
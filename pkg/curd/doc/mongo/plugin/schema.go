@@ -0,0 +1,57 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"bytes"
+	"go/format"
+
+	cwgoMeta "github.com/cloudwego/cwgo/meta"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// getSchemaCode returns the $jsonSchema validator of the collection, it is regenerated every time
+// because the fields may be changed.
+func getSchemaCode(st *extract.IdlExtractStruct) (string, error) {
+	tplSchema := &template.Template{}
+	for _, render := range codegen.GetSchemaFuncRenders(st) {
+		tplSchema.AddRender(render)
+	}
+	buff, err := tplSchema.Build()
+	if err != nil {
+		return "", err
+	}
+
+	baseBuff := new(bytes.Buffer)
+	baseRender := &template.BaseRender{
+		Version:     cwgoMeta.Version,
+		PackageName: extract.GetPkgName(st.Name),
+		Imports:     codegen.SchemaImports,
+	}
+	if err = baseRender.RenderObj(baseBuff); err != nil {
+		return "", err
+	}
+
+	formattedCode, err := format.Source(append(baseBuff.Bytes(), buff.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
@@ -0,0 +1,20 @@
+namespace go user
+
+struct Contact {
+    1: string Phone (go.tag="bson:\"phone\"")
+    2: string City (go.tag="bson:\"city\"")
+}
+
+struct User {
+    1: i64 Id (go.tag="bson:\"id,omitempty\"")
+    2: string Username (go.tag="bson:\"username\"")
+    3: bool Banned (go.tag="bson:\"banned\"")
+    4: Contact Contact (go.tag="bson:\"contact\"")
+    5: i64 CreatedAt (go.tag="bson:\"created_at\"")
+}(
+    mongo.index = "created_at"
+    mongo.FindOrderbyCreatedAtDescLimitAfterByBannedFalse = "ListAfter(ctx context.Context, limit int64, cursor int64) ([]*user.User, int64, error)"
+    mongo.FindOrderbyCreatedAtLimitBeforeAll = "ListBefore(ctx context.Context, limit int64, cursor int64) ([]*user.User, error)"
+    mongo.FindOrderbyCreatedAtDescLimitAfterAll = "ListPage(ctx context.Context, limit int64, cursor *int64) ([]*user.User, *int64, error)"
+    mongo.FindOrderbyContactCityAfterAll = "ListByCity(ctx context.Context, city string) ([]*user.User, string, error)"
+)
@@ -0,0 +1,21 @@
+namespace go user
+
+struct User {
+    1: i64 Id (go.tag="bson:\"id,omitempty\"")
+    2: string Username (go.tag="bson:\"username\"")
+    3: optional i32 Age (go.tag="bson:\"age,omitempty\"")
+    4: optional i64 CreatedAt (go.tag="bson:\"created_at,omitempty\"")
+    5: optional i64 UpdatedAt (go.tag="bson:\"updated_at,omitempty\"")
+    6: optional i64 DeletedAt (go.tag="bson:\"deleted_at,omitempty\"")
+}(
+    mongo.options = "timestamps,soft_delete"
+    mongo.InsertOne = "InsertUser(ctx context.Context, user *user.User) (interface{}, error)"
+    mongo.InsertMany = "InsertUsers(ctx context.Context, users []*user.User) ([]interface{}, error)"
+    mongo.FindByAgeEqual = "ListByAge(ctx context.Context, age *int32) ([]*user.User, error)"
+    mongo.UpdateByIdEqual = "UpdateUser(ctx context.Context, user *user.User, id int64) (bool, error)"
+    mongo.UpdateUpsertUsernameByIdEqual = "UpsertUsername(ctx context.Context, username string, id int64) (bool, error)"
+    mongo.DeleteByIdEqual = "DeleteUser(ctx context.Context, id int64) (bool, error)"
+    mongo.BulkInsertOneReplaceOneByIdEqualDeleteManyByUsernameEqual = "BulkOp(ctx context.Context, user *user.User, replacement *user.User, id int64, username string) (*mongo.BulkWriteResult, error)"
+    mongo.TransactionInsertOneUpdateOneUsernameByIdEqualDeleteOneByIdEqual = "TxOp(ctx context.Context, client *mongo.Client, user *user.User, username string, id int64, deleted int64) error"
+    mongo.TransactionUpdateOneByIdEqualBulkLbReplaceOneByIdEqualRb = "TxReplace(ctx context.Context, client *mongo.Client, user *user.User, id int64, replacement *user.User, rid int64) error"
+)
@@ -17,12 +17,18 @@
 package plugin
 
 import (
+	"bytes"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/token"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/doc/backend"
 	"github.com/cloudwego/cwgo/pkg/curd/doc/mongo/codegen"
 	"github.com/cloudwego/cwgo/pkg/curd/extract"
 	"github.com/cloudwego/cwgo/pkg/curd/parse"
@@ -69,15 +75,53 @@ func thriftPluginRun() int {
 		return meta.PluginError
 	}
 
-	methodRenders := codegen.HandleCodegen(operations)
-	generated, err := plu.buildResponse(rawStructs, methodRenders, tfUsedInfo)
+	if plu.docArgs.DumpAST {
+		data, err := backend.MarshalDump(backend.GetDump(rawStructs, operations))
+		if err != nil {
+			logs.Error(err.Error())
+			return meta.PluginError
+		}
+		// the dump is read and printed by cwgo after thriftgo writes it
+		res := &plugin.Response{
+			Contents: []*plugin.Generated{{Content: string(data), Name: &plu.docArgs.DumpPath}},
+		}
+		if err = response(res); err != nil {
+			logs.Error(err.Error())
+			return meta.PluginError
+		}
+		return 0
+	}
+
+	b, err := backend.Lookup(plu.docArgs.Name)
+	if err != nil {
+		logs.Error(err.Error())
+		return meta.PluginError
+	}
+	if err = backend.CheckTransactionClients(plu.docArgs.Name, b, operations); err != nil {
+		logs.Error(err.Error())
+		return meta.PluginError
+	}
+	backendRes, err := b.Generate(&backend.Request{
+		Args:        plu.docArgs,
+		ImportPaths: tfUsedInfo.ImportPaths,
+		Structs:     rawStructs,
+		Operations:  operations,
+	})
 	if err != nil {
 		logs.Error(err.Error())
 		return meta.PluginError
 	}
+	generated := make([]*plugin.Generated, 0, len(backendRes.Files))
+	for index := range backendRes.Files {
+		generated = append(generated, &plugin.Generated{
+			Content: backendRes.Files[index].Content,
+			Name:    &backendRes.Files[index].Name,
+		})
+	}
 
 	res := &plugin.Response{
 		Contents: generated,
+		Warnings: backendRes.Warnings,
 	}
 	if err = response(res); err != nil {
 		logs.Error(err.Error())
@@ -127,93 +171,6 @@ func response(res *plugin.Response) error {
 	return nil
 }
 
-func (plu *thriftGoPlugin) buildResponse(structs []*extract.IdlExtractStruct, methodRenders [][]*template.MethodRender,
-	info *extract.ThriftUsedInfo,
-) (result []*plugin.Generated, err error) {
-	for index, st := range structs {
-		// get base render
-		baseRender := getBaseRender(st)
-		// get fileMongoName and fileIfName
-		fileMongoName, fileIfName := extract.GetFileName(st.Name, plu.docArgs.DaoDir)
-
-		if st.Update {
-			// build update mongo file
-			formattedCode, err := getUpdateMongoCode(methodRenders[index], string(st.UpdateCurdFileContent))
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, &plugin.Generated{
-				Content: formattedCode,
-				Name:    &fileMongoName,
-			})
-
-			// build update interface file
-			formattedCode, err = getUpdateIfCode(st, baseRender)
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, &plugin.Generated{
-				Content: formattedCode,
-				Name:    &fileIfName,
-			})
-		} else {
-			// build new mongo file
-			formattedCode, err := getNewMongoCode(methodRenders[index], st, baseRender)
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, &plugin.Generated{
-				Content: formattedCode,
-				Name:    &fileMongoName,
-			})
-
-			// build new interface file
-			formattedCode, err = getNewIfCode(st, baseRender)
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = codegen.AddMongoImports(formattedCode)
-			if err != nil {
-				return nil, err
-			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, &plugin.Generated{
-				Content: formattedCode,
-				Name:    &fileIfName,
-			})
-		}
-	}
-
-	return
-}
-
 func getBaseRender(st *extract.IdlExtractStruct) *template.BaseRender {
 	pkgName := extract.GetPkgName(st.Name)
 	return &template.BaseRender{
@@ -223,25 +180,41 @@ func getBaseRender(st *extract.IdlExtractStruct) *template.BaseRender {
 	}
 }
 
-func getUpdateMongoCode(methodRenders []*template.MethodRender, fileContent string) (string, error) {
-	tplMongo := &template.Template{
-		Renders: []template.Render{},
-	}
-	for _, methodRender := range methodRenders {
-		tplMongo.Renders = append(tplMongo.Renders, methodRender)
+// getUpdateMongoCode merges the methods generated now into the repository file generated before,
+// the warnings report the methods which are not merged.
+func getUpdateMongoCode(methodRenders []*template.MethodRender, st *extract.IdlExtractStruct, prune bool) (string, []string, error) {
+	fileContent := string(st.UpdateCurdFileContent)
+	regions, err := extract.GetGeneratedRegions(fileContent)
+	if err != nil {
+		return "", nil, err
 	}
 
-	buff, err := tplMongo.Build()
-	if err != nil {
-		return "", err
+	renders := make([]template.Render, 0, len(methodRenders)+3)
+	// the constructors are added to the files generated before them, unless they are written by hand
+	for _, funcRender := range codegen.GetFromDBFuncRenders(st) {
+		if !hasGeneratedRegion(regions, funcRender.Name) && strings.Contains(fileContent, "func "+funcRender.Name+"(") {
+			continue
+		}
+		renders = append(renders, funcRender)
 	}
-	data := fileContent + "\n" + buff.String()
-	formattedCode, err := format.Source([]byte(data))
-	if err != nil {
-		return "", err
+	for _, methodRender := range methodRenders {
+		renders = append(renders, methodRender)
+	}
+	// EnsureIndexes is always regenerated because the indexes may be changed, unless it is written by hand
+	_, isUserMethod := st.UserMethodNamesMap[codegen.EnsureIndexes]
+	if len(regions) == 0 {
+		// the file is generated by the versions of cwgo without the generated regions
+		fileContent, err = removeMethod(fileContent, codegen.EnsureIndexes)
+		if err != nil {
+			return "", nil, err
+		}
+		isUserMethod = false
+	}
+	if indexesRender := codegen.GetEnsureIndexesRender(st); indexesRender != nil && !isUserMethod {
+		renders = append(renders, indexesRender)
 	}
 
-	return string(formattedCode), nil
+	return mergeMongoCode(fileContent, regions, renders, st.Name, prune)
 }
 
 func getUpdateIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRender) (string, error) {
@@ -250,25 +223,9 @@ func getUpdateIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRend
 	}
 	tplIf.Renders = append(tplIf.Renders, baseRender)
 
-	methods := make(code.InterfaceMethods, 0, 10)
-	for _, preMethod := range st.PreIfMethods {
-		methods = append(methods, code.InterfaceMethod{
-			Name:    preMethod.Name,
-			Params:  preMethod.Params,
-			Returns: preMethod.Returns,
-		})
-	}
-	for _, rawMethod := range st.InterfaceInfo.Methods {
-		methods = append(methods, code.InterfaceMethod{
-			Name:    rawMethod.Name,
-			Params:  rawMethod.Params,
-			Returns: rawMethod.Returns,
-		})
-	}
-
 	ifRender := &template.InterfaceRender{
 		Name:    st.Name + "Repository",
-		Methods: methods,
+		Methods: getIfMethods(st),
 	}
 	tplIf.Renders = append(tplIf.Renders, ifRender)
 
@@ -292,14 +249,33 @@ func getNewMongoCode(methodRenders []*template.MethodRender, st *extract.IdlExtr
 	tplMongo.Renders = append(tplMongo.Renders, baseRender)
 	tplMongo.Renders = append(tplMongo.Renders, codegen.GetFuncRender(st))
 	tplMongo.Renders = append(tplMongo.Renders, codegen.GetStructRender(st))
-	for _, methodRender := range methodRenders {
-		tplMongo.Renders = append(tplMongo.Renders, methodRender)
-	}
 
 	buff, err := tplMongo.Build()
 	if err != nil {
 		return "", err
 	}
+
+	renders := make([]template.Render, 0, len(methodRenders)+3)
+	for _, funcRender := range codegen.GetFromDBFuncRenders(st) {
+		renders = append(renders, funcRender)
+	}
+	for _, methodRender := range methodRenders {
+		renders = append(renders, methodRender)
+	}
+	if indexesRender := codegen.GetEnsureIndexesRender(st); indexesRender != nil {
+		renders = append(renders, indexesRender)
+	}
+	// the functions and the methods are enclosed by the generated regions, so they can be merged
+	// when the file is updated
+	for _, render := range renders {
+		renderCode, err := getRenderCode(render)
+		if err != nil {
+			return "", err
+		}
+		buff.WriteString("\n")
+		buff.WriteString(extract.GetGeneratedRegion(getRenderName(render), renderCode))
+	}
+
 	formattedCode, err := format.Source(buff.Bytes())
 	if err != nil {
 		return "", err
@@ -314,7 +290,46 @@ func getNewIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRender)
 	}
 	tplIf.Renders = append(tplIf.Renders, baseRender)
 
+	ifRender := &template.InterfaceRender{
+		Name:    st.Name + "Repository",
+		Methods: getIfMethods(st),
+	}
+	tplIf.Renders = append(tplIf.Renders, ifRender)
+
+	buff, err := tplIf.Build()
+	if err != nil {
+		return "", err
+	}
+	formattedCode, err := format.Source(buff.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
+
+// getIfMethods returns all methods of the repository interface, including the methods generated before.
+func getIfMethods(st *extract.IdlExtractStruct) code.InterfaceMethods {
+	methods := getRawIfMethods(st)
+	if len(st.Indexes) != 0 {
+		methods = append(methods, codegen.GetEnsureIndexesIfMethod())
+	}
+	if st.Generic {
+		methods = append(methods, codegen.GetGenericIfMethods(st)...)
+	}
+	return methods
+}
+
+// getRawIfMethods returns the methods declared in the idl and generated before, excluding EnsureIndexes.
+func getRawIfMethods(st *extract.IdlExtractStruct) code.InterfaceMethods {
 	methods := make(code.InterfaceMethods, 0, 10)
+	for _, preMethod := range st.PreIfMethods {
+		methods = append(methods, code.InterfaceMethod{
+			Name:    preMethod.Name,
+			Params:  preMethod.Params,
+			Returns: preMethod.Returns,
+		})
+	}
 	for _, rawMethod := range st.InterfaceInfo.Methods {
 		methods = append(methods, code.InterfaceMethod{
 			Name:    rawMethod.Name,
@@ -322,13 +337,23 @@ func getNewIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRender)
 			Returns: rawMethod.Returns,
 		})
 	}
-	ifRender := &template.InterfaceRender{
-		Name:    st.Name + "Repository",
-		Methods: methods,
+	return methods
+}
+
+// getMockCode returns the gomock mock of the repository interface, it is regenerated every time.
+func getMockCode(st *extract.IdlExtractStruct, methods code.InterfaceMethods) (string, error) {
+	tplMock := &template.Template{
+		Renders: []template.Render{
+			&template.BaseRender{
+				Version:     cwgoMeta.Version,
+				PackageName: extract.GetPkgName(st.Name),
+				Imports:     codegen.MockImports,
+			},
+		},
 	}
-	tplIf.Renders = append(tplIf.Renders, ifRender)
+	tplMock.Renders = append(tplMock.Renders, codegen.GetMockRenders(st, methods)...)
 
-	buff, err := tplIf.Build()
+	buff, err := tplMock.Build()
 	if err != nil {
 		return "", err
 	}
@@ -337,5 +362,122 @@ func getNewIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRender)
 		return "", err
 	}
 
+	return codegen.AddMongoImports(string(formattedCode))
+}
+
+// getFakeCode returns the in-memory fake of the repository interface, it is regenerated every time,
+// so all methods including the methods generated before are parsed.
+func getFakeCode(st *extract.IdlExtractStruct) (string, error) {
+	ifOperation, err := parse.HandleAllOperations(st)
+	if err != nil {
+		return "", err
+	}
+
+	tplFake := &template.Template{
+		Renders: codegen.GetFakeRenders(ifOperation, getIfMethods(st)),
+	}
+	buff, err := tplFake.Build()
+	if err != nil {
+		return "", err
+	}
+
+	imports := make(map[string]string, len(codegen.FakeImports)+3)
+	for path, name := range codegen.FakeImports {
+		imports[path] = name
+	}
+	// the helpers of the fake mention bson tags, so the mongo import is decided here instead of AddMongoImports
+	if strings.Contains(buff.String(), "mongo.") {
+		imports["go.mongodb.org/mongo-driver/mongo"] = ""
+	}
+	// the options of Watch are ignored by the fake
+	if strings.Contains(buff.String(), "options.") {
+		imports["go.mongodb.org/mongo-driver/mongo/options"] = ""
+	}
+	// the current time is only used by the timestamps and the soft deletion
+	if strings.Contains(buff.String(), "time.Now()") {
+		imports["time"] = ""
+	}
+	baseBuff := new(bytes.Buffer)
+	baseRender := &template.BaseRender{
+		Version:     cwgoMeta.Version,
+		PackageName: extract.GetPkgName(st.Name),
+		Imports:     imports,
+	}
+	if err = baseRender.RenderObj(baseBuff); err != nil {
+		return "", err
+	}
+
+	formattedCode, err := format.Source(append(baseBuff.Bytes(), buff.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
+
+// getUnitTestCode returns the integration tests of the mongo repository, it is regenerated every time.
+func getUnitTestCode(st *extract.IdlExtractStruct) (string, error) {
+	ifOperation, err := parse.HandleAllOperations(st)
+	if err != nil {
+		return "", err
+	}
+
+	tplTest := &template.Template{
+		Renders: codegen.GetUnitTestRenders(ifOperation, getIfMethods(st)),
+	}
+	buff, err := tplTest.Build()
+	if err != nil {
+		return "", err
+	}
+
+	imports := make(map[string]string, len(codegen.UnitTestImports)+2)
+	for path, name := range codegen.UnitTestImports {
+		imports[path] = name
+	}
+	if strings.Contains(buff.String(), "bson.") {
+		imports["go.mongodb.org/mongo-driver/bson"] = ""
+	}
+	if strings.Contains(buff.String(), "errors.") {
+		imports["errors"] = ""
+	}
+	baseBuff := new(bytes.Buffer)
+	baseRender := &template.BaseRender{
+		Version:     cwgoMeta.Version,
+		PackageName: extract.GetPkgName(st.Name),
+		Imports:     imports,
+	}
+	if err = baseRender.RenderObj(baseBuff); err != nil {
+		return "", err
+	}
+
+	formattedCode, err := format.Source(append(baseBuff.Bytes(), buff.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+
 	return string(formattedCode), nil
 }
+
+// removeMethod is used to remove the method and its doc comment from the file content.
+func removeMethod(fileContent, methodName string) (string, error) {
+	fSet := token.NewFileSet()
+	f, err := parser.ParseFile(fSet, "", fileContent, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	for _, decl := range f.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || funcDecl.Name.Name != methodName {
+			continue
+		}
+
+		start := funcDecl.Pos()
+		if funcDecl.Doc != nil {
+			start = funcDecl.Doc.Pos()
+		}
+		return fileContent[:fSet.Position(start).Offset] + fileContent[fSet.Position(funcDecl.End()).Offset:], nil
+	}
+
+	return fileContent, nil
+}
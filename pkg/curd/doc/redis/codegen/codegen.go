@@ -0,0 +1,292 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+var BaseRedisImports = map[string]string{
+	"context":                      "",
+	"github.com/redis/go-redis/v9": "",
+}
+
+// optionalImports are added to the redis file only if they are used.
+var optionalImports = map[string]string{
+	"encoding/json": "json.",
+	"fmt":           "fmt.",
+	"reflect":       "reflect.",
+	"sort":          "sort.",
+	"strconv":       "strconv.",
+	"strings":       "strings.",
+}
+
+// keyFieldNames are the mongo field names of the field used as the key of the entity in order.
+var keyFieldNames = []string{"_id", "id"}
+
+// GetRedisImports returns the imports of the redis file whose declarations are content.
+func GetRedisImports(content string) map[string]string {
+	imports := make(map[string]string, len(BaseRedisImports)+len(optionalImports))
+	for path, name := range BaseRedisImports {
+		imports[path] = name
+	}
+	for path, usage := range optionalImports {
+		if strings.Contains(content, usage) {
+			imports[path] = ""
+		}
+	}
+	return imports
+}
+
+type redisGenerator struct {
+	st         *extract.IdlExtractStruct
+	receiver   code.MethodReceiver
+	entityType code.Type
+	keyField   *redisField
+	// indexFields are the mongo field names compared by equality in the queries, the ids of the entities
+	// are indexed by their values
+	indexFields []string
+	// aliases are the aliases of the structures allocated in the method bodies, key is the real name
+	aliases map[string]string
+}
+
+// GetRedisRenders returns the renders of the redis implementation of the repository interface.
+//
+//	input params description:
+//	ifOperation: the parsing results of all methods of the structure, including the methods generated before
+//	methods: all methods of the repository interface
+func GetRedisRenders(ifOperation *parse.InterfaceOperation, methods code.InterfaceMethods) ([]template.Render, error) {
+	st := ifOperation.BelongedToStruct
+	repoName := st.Name + "RepositoryRedis"
+	modelType := code.SelectorExprType{X: st.ModelPkg, Sel: st.Name}
+	g := &redisGenerator{
+		st: st,
+		receiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{RealType: code.IdentType(repoName)},
+		},
+		aliases: map[string]string{},
+	}
+	g.entityType = code.StarExprType{RealType: code.IdentType(g.aliasCodegen(modelType))}
+
+	for _, name := range keyFieldNames {
+		if field, err := g.getField(name); err == nil {
+			g.keyField = field
+			break
+		}
+	}
+	if g.keyField == nil {
+		return nil, fmt.Errorf("%s: the redis backend requires a field tagged with bson _id or id as the key", st.Name)
+	}
+	if !isIntegerType(g.keyField.fieldType) && !isStringType(g.keyField.fieldType) {
+		return nil, fmt.Errorf("%s: the key field %s of the redis backend must be an integer or a string",
+			st.Name, g.keyField.mongoName)
+	}
+
+	operations := make(map[string]parse.Operation, len(ifOperation.Operations))
+	for _, operation := range ifOperation.Operations {
+		switch operation.(type) {
+		case *parse.BulkParse, *parse.TransactionParse, *parse.WatchParse:
+			return nil, fmt.Errorf("%s: %s is not supported by the redis backend",
+				parse.GetBelongedToMethod(operation).Name, operation.GetOperationName())
+		}
+		operations[parse.GetBelongedToMethod(operation).Name] = operation
+		g.collectIndexFields(operation)
+	}
+	sort.Strings(g.indexFields)
+
+	methodRenders := make([]template.Render, 0, len(methods))
+	for _, method := range methods {
+		operation, ok := operations[method.Name]
+		if !ok {
+			continue
+		}
+		body, err := g.operationCodegen(operation)
+		if err != nil {
+			return nil, err
+		}
+		methodRenders = append(methodRenders, &template.MethodRender{
+			Name:           method.Name,
+			MethodReceiver: g.receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody:     body,
+		})
+	}
+
+	renders := []template.Render{
+		&template.FuncRender{
+			Name: "New" + st.Name + "Repository",
+			Params: code.Params{
+				code.Param{Name: "client", Type: code.SelectorExprType{X: "redis", Sel: "UniversalClient"}},
+				code.Param{Name: "prefix", Type: code.IdentType("string")},
+			},
+			Returns: code.Returns{
+				code.IdentType(st.Name + "Repository"),
+			},
+			FuncBody: code.Body{
+				code.RawStmt(fmt.Sprintf("return &%s{\n\tclient: client,\n\tprefix: prefix,\n}", repoName)),
+			},
+		},
+		&template.StructRender{
+			Name: repoName,
+			Comment: fmt.Sprintf("// %s stores each entity in the hash prefix:id whose fields are the json encoded\n"+
+				"// top-level fields, the ids are stored in the set prefix:ids and the sets prefix:idx:field:value\n"+
+				"// index the ids by the fields compared by equality, the entities saved before a field is indexed\n"+
+				"// have to be saved again. The queries are evaluated on the entities loaded from the indexed ids.",
+				repoName),
+			StructFields: code.StructFields{
+				code.StructField{Name: "client", Type: code.SelectorExprType{X: "redis", Sel: "UniversalClient"}},
+				code.StructField{Name: "prefix", Type: code.IdentType("string")},
+			},
+		},
+	}
+	renders = append(renders, methodRenders...)
+	renders = append(renders, g.storageRenders()...)
+
+	// the aliases are collected while generating the method bodies
+	aliasNames := make([]string, 0, len(g.aliases))
+	for realName := range g.aliases {
+		aliasNames = append(aliasNames, realName)
+	}
+	sort.Strings(aliasNames)
+	aliasRenders := make([]template.Render, 0, len(aliasNames))
+	for _, realName := range aliasNames {
+		aliasRenders = append(aliasRenders, &template.TypeAliasRender{
+			Name:     g.aliases[realName],
+			Comment:  fmt.Sprintf("// %s is an alias of %s which is not shadowed by the method params.", g.aliases[realName], realName),
+			RealType: code.IdentType(realName),
+		})
+	}
+
+	return append(aliasRenders, renders...), nil
+}
+
+func (g *redisGenerator) operationCodegen(operation parse.Operation) (code.Body, error) {
+	switch op := operation.(type) {
+	case *parse.InsertParse:
+		return g.insertCodegen(op), nil
+	case *parse.FindParse:
+		return g.findCodegen(op)
+	case *parse.UpdateParse:
+		return g.updateCodegen(op)
+	case *parse.DeleteParse:
+		return g.deleteCodegen(op)
+	case *parse.CountParse:
+		find, err := g.matchedCodegen(op.Query, op.CtxParamName, "0")
+		if err != nil {
+			return nil, err
+		}
+		return append(find, code.RawStmt("return len(entities), nil")), nil
+	default:
+		return code.Body{code.RawStmt("return nil")}, nil
+	}
+}
+
+func (g *redisGenerator) insertCodegen(insert *parse.InsertParse) code.Body {
+	name := insert.MethodParamNames[1]
+	param := name
+	if _, ok := getParamType(insert.BelongedToMethod, name).(code.StarExprType); !ok {
+		param = "&" + name
+	}
+	ctx := insert.BelongedToMethod.Params[0].Name
+
+	if insert.OperateMode == parse.OperateOne {
+		return code.Body{
+			code.RawStmt(fmt.Sprintf("if err := r.insert(%s, %s); err != nil {\n\treturn nil, err\n}", ctx, param)),
+			code.RawStmt(fmt.Sprintf("return %s.%s, nil", name, g.keyField.goPath)),
+		}
+	}
+
+	entity := "entity"
+	if sliceType, ok := getParamType(insert.BelongedToMethod, name).(code.SliceType); ok {
+		if _, ok = sliceType.ElementType.(code.StarExprType); !ok {
+			entity = "&" + name + "[i]"
+		}
+	}
+	return code.Body{
+		code.RawStmt(fmt.Sprintf("ids := make([]interface{}, 0, len(%s))", name)),
+		code.RawStmt(fmt.Sprintf("for i, entity := range %s {\n"+
+			"\tif err := r.insert(%s, %s); err != nil {\n"+
+			"\t\treturn ids, err\n"+
+			"\t}\n"+
+			"\tids = append(ids, %s[i].%s)\n"+
+			"}", name, ctx, entity, name, g.keyField.goPath)),
+		code.RawStmt("return ids, nil"),
+	}
+}
+
+func (g *redisGenerator) deleteCodegen(del *parse.DeleteParse) (code.Body, error) {
+	zero := "0"
+	if del.OperateMode == parse.OperateOne {
+		zero = "false"
+	}
+	body, err := g.matchedCodegen(del.Query, del.CtxParamName, zero)
+	if err != nil {
+		return nil, err
+	}
+	if del.OperateMode == parse.OperateOne {
+		body = append(body, code.RawStmt("if len(entities) > 1 {\n\tentities = entities[:1]\n}"))
+	}
+	body = append(body,
+		code.RawStmt(fmt.Sprintf("if _, err = r.client.TxPipelined(%s, func(pipe redis.Pipeliner) error {\n"+
+			"\tfor _, entity := range entities {\n"+
+			"\t\tr.remove(%s, pipe, entity)\n"+
+			"\t}\n"+
+			"\treturn nil\n"+
+			"}); err != nil {\n"+
+			"\treturn %s, err\n"+
+			"}", del.CtxParamName, del.CtxParamName, zero)),
+	)
+	if del.OperateMode == parse.OperateOne {
+		return append(body, code.RawStmt("return len(entities) > 0, nil")), nil
+	}
+	return append(body, code.RawStmt("return len(entities), nil")), nil
+}
+
+// aliasCodegen returns the alias of the structure type.
+func (g *redisGenerator) aliasCodegen(t code.SelectorExprType) string {
+	realName := t.RealName()
+	if alias, ok := g.aliases[realName]; ok {
+		return alias
+	}
+	alias := "redis" + t.Sel
+	for _, used := range g.aliases {
+		if used == alias {
+			alias = "redis" + strings.ToUpper(t.X[:1]) + t.X[1:] + t.Sel
+			break
+		}
+	}
+	g.aliases[realName] = alias
+	return alias
+}
+
+func getParamType(method *extract.InterfaceMethod, name string) code.Type {
+	for _, param := range method.Params {
+		if param.Name == name {
+			return param.Type
+		}
+	}
+	return nil
+}
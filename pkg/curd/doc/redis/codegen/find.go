@@ -0,0 +1,198 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+func (g *redisGenerator) findCodegen(find *parse.FindParse) (code.Body, error) {
+	errReturn := "nil"
+	if find.ReturnCursor {
+		errReturn = "nil, nextCursor"
+	}
+
+	body := code.Body{}
+	if find.ReturnCursor {
+		body = append(body, code.DeclVarStmt{
+			Name: "nextCursor",
+			Type: find.Keyset.FieldType,
+		})
+	}
+	if find.LimitParamName != "" && find.OperateMode == parse.OperateMany {
+		body = append(body, code.RawStmt(fmt.Sprintf("if %s == 0 {\n\t%s = 5\n}", find.LimitParamName, find.LimitParamName)))
+	}
+	matched, err := g.matchedCodegen(find.Query, find.CtxParamName, errReturn)
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, matched...)
+
+	if find.Keyset != nil && find.Keyset.Nullable {
+		// the nil cursor queries the first page
+		condition := find.Keyset.Condition()
+		if find.Keyset.Indirect {
+			condition.ParamNames = []string{"*" + find.Keyset.ParamName}
+		}
+		keyset, err := g.conditionCodegen(condition)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, code.RawStmt(fmt.Sprintf("if %s != nil {\n"+
+			"\tfiltered := entities[:0]\n"+
+			"\tfor _, e := range entities {\n"+
+			"\t\tif %s {\n"+
+			"\t\t\tfiltered = append(filtered, e)\n"+
+			"\t\t}\n"+
+			"\t}\n"+
+			"\tentities = filtered\n"+
+			"}", find.Keyset.ParamName, keyset)))
+	}
+
+	order := find.Order
+	// Before sorts the entities closest to the cursor first, the same as the mongo implementation
+	if find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		order = parse.Order{Asc: find.Order.Desc, Desc: find.Order.Asc}
+	}
+	if len(order.Asc) != 0 || len(order.Desc) != 0 {
+		sortStmt, err := g.sortCodegen(order)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, sortStmt)
+	}
+
+	if find.SkipParamName != "" {
+		body = append(body, code.RawStmt(fmt.Sprintf("if int(%s) < len(entities) {\n"+
+			"\tentities = entities[%s:]\n"+
+			"} else {\n"+
+			"\tentities = entities[:0]\n"+
+			"}", find.SkipParamName, find.SkipParamName)))
+	}
+	if find.LimitParamName != "" && find.OperateMode == parse.OperateMany {
+		body = append(body, code.RawStmt(fmt.Sprintf("if int(%s) < len(entities) {\n"+
+			"\tentities = entities[:%s]\n"+
+			"}", find.LimitParamName, find.LimitParamName)))
+	}
+
+	project := ""
+	if len(find.Project) != 0 {
+		fields := make([]string, 0, len(find.Project))
+		for _, field := range find.Project {
+			fields = append(fields, strconv.Quote(field))
+		}
+		project = strings.Join(fields, ", ")
+	}
+
+	if find.OperateMode == parse.OperateOne {
+		body = append(body, code.RawStmt("if len(entities) == 0 {\n\treturn nil, redis.Nil\n}"))
+		if project != "" {
+			return append(body, code.RawStmt(fmt.Sprintf("return r.project(entities[0], %s)", project))), nil
+		}
+		return append(body, code.RawStmt("return entities[0], nil")), nil
+	}
+
+	if project != "" {
+		body = append(body, code.RawStmt(fmt.Sprintf("for i, entity := range entities {\n"+
+			"\tif entities[i], err = r.project(entity, %s); err != nil {\n"+
+			"\t\treturn %s, err\n"+
+			"\t}\n"+
+			"}", project, errReturn)))
+	}
+
+	if find.Keyset != nil && find.Keyset.KeysetMode == parse.Before {
+		body = append(body, code.RawStmt("for i, j := 0, len(entities)-1; i < j; i, j = i+1, j-1 {\n"+
+			"\tentities[i], entities[j] = entities[j], entities[i]\n}"))
+	}
+	if !find.ReturnCursor {
+		return append(body, code.RawStmt("return entities, nil")), nil
+	}
+
+	// the next cursor of After is the last entity, the next cursor of Before is the first entity
+	index := "len(entities)-1"
+	if find.Keyset.KeysetMode == parse.Before {
+		index = "0"
+	}
+	return append(body,
+		code.RawStmt(fmt.Sprintf("if len(entities) > 0 {\n\tnextCursor = %s\n}", find.Keyset.NextCursor("entities["+index+"]"))),
+		code.RawStmt("return entities, nextCursor, nil"),
+	), nil
+}
+
+// sortCodegen returns the stable sort of the entities, the missing nested fields are sorted as zero values.
+func (g *redisGenerator) sortCodegen(order parse.Order) (code.Statement, error) {
+	compares := ""
+	for _, key := range append(append([]string{}, order.Asc...), prefixDesc(order.Desc)...) {
+		desc := strings.HasPrefix(key, "-")
+		name := strings.TrimPrefix(key, "-")
+		field, err := g.getField(name)
+		if err != nil {
+			return nil, err
+		}
+		if !isComparableType(field.fieldType) {
+			return nil, fmt.Errorf("sorting by %s is not supported by the redis backend", name)
+		}
+
+		a, b := field.access("a"), field.access("b")
+		if guard := field.guard("a"); guard != "" {
+			getter := func(entity, guard string) string {
+				return fmt.Sprintf("func() %s {\n"+
+					"\tif %s {\n"+
+					"\t\treturn %s\n"+
+					"\t}\n"+
+					"\tvar zero %s\n"+
+					"\treturn zero\n"+
+					"}()", field.fieldType.RealName(), guard, field.access(entity), field.fieldType.RealName())
+			}
+			a, b = getter("a", guard), getter("b", field.guard("b"))
+			compares += fmt.Sprintf("if av, bv := %s, %s; av != bv {\n", a, b)
+		} else {
+			compares += fmt.Sprintf("if av, bv := %s, %s; av != bv {\n", a, b)
+		}
+
+		less := "av < bv"
+		if isBoolType(field.fieldType) {
+			less = "!av && bv"
+		}
+		if desc {
+			less = "bv < av"
+			if isBoolType(field.fieldType) {
+				less = "av && !bv"
+			}
+		}
+		compares += fmt.Sprintf("\treturn %s\n}\n", less)
+	}
+
+	return code.RawStmt(fmt.Sprintf("sort.SliceStable(entities, func(i, j int) bool {\n"+
+		"\ta, b := entities[i], entities[j]\n"+
+		"%s"+
+		"\treturn false\n"+
+		"})", compares)), nil
+}
+
+func prefixDesc(fields []string) []string {
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		result = append(result, "-"+field)
+	}
+	return result
+}
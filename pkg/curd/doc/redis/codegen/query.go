@@ -0,0 +1,329 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+// redisField is the field of the entity specified by the mongo field name.
+type redisField struct {
+	mongoName string
+	goPath    string
+	fieldType code.Type
+	// parents are the go paths of the structure pointers passed from the entity to the field
+	parents []string
+	// parentTypes are the types of parents
+	parentTypes []code.Type
+}
+
+func (g *redisGenerator) getField(mongoName string) (*redisField, error) {
+	fields, err := g.st.GetFieldsByMongoName(mongoName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &redisField{mongoName: mongoName}
+	for index, field := range fields {
+		if index != 0 {
+			result.goPath += "."
+		}
+		result.goPath += field.Name
+		if index != len(fields)-1 {
+			if _, ok := field.Type.(code.StarExprType); ok {
+				result.parents = append(result.parents, result.goPath)
+				result.parentTypes = append(result.parentTypes, field.Type)
+			}
+		}
+	}
+	result.fieldType = fields[len(fields)-1].Type
+	return result, nil
+}
+
+// access returns the field of the entity.
+func (f *redisField) access(entity string) string {
+	return entity + "." + f.goPath
+}
+
+// guard returns the condition that the structure pointers passed from the entity to the field are not nil.
+func (f *redisField) guard(entity string) string {
+	conditions := make([]string, 0, len(f.parents))
+	for _, parent := range f.parents {
+		conditions = append(conditions, entity+"."+parent+" != nil")
+	}
+	return strings.Join(conditions, " && ")
+}
+
+// collectIndexFields collects the fields compared by equality in the And conditions of the query,
+// the key field is not indexed because the entities are loaded by the key directly.
+func (g *redisGenerator) collectIndexFields(operation parse.Operation) {
+	var query *parse.Query
+	switch op := operation.(type) {
+	case *parse.FindParse:
+		query = op.Query
+	case *parse.UpdateParse:
+		query = op.Query
+	case *parse.DeleteParse:
+		query = op.Query
+	case *parse.CountParse:
+		query = op.Query
+	}
+	if query == nil || query.QueryMode != parse.By {
+		return
+	}
+
+	for _, leaf := range getAndLeaves(query.ConnectionOpTree) {
+		if _, ok := g.indexValue(leaf); !ok || leaf.MongoFieldName == g.keyField.mongoName {
+			continue
+		}
+		isExist := false
+		for _, name := range g.indexFields {
+			if name == leaf.MongoFieldName {
+				isExist = true
+				break
+			}
+		}
+		if !isExist {
+			g.indexFields = append(g.indexFields, leaf.MongoFieldName)
+		}
+	}
+}
+
+// getAndLeaves returns the leaves connected to the root by And.
+func getAndLeaves(node *parse.ConnectionOpTree) []*parse.ConnectionOpTree {
+	if node.LeftChildren == nil {
+		return []*parse.ConnectionOpTree{node}
+	}
+	if node.Name != string(parse.And) {
+		return nil
+	}
+	return append(getAndLeaves(node.LeftChildren), getAndLeaves(node.RightChildren)...)
+}
+
+// indexValue returns the value compared by equality in the leaf if the field can be indexed.
+func (g *redisGenerator) indexValue(leaf *parse.ConnectionOpTree) (string, bool) {
+	field, err := g.getField(leaf.MongoFieldName)
+	if err != nil || !isComparableType(field.fieldType) {
+		return "", false
+	}
+	switch parse.QueryComparator(leaf.Name) {
+	case parse.Equal:
+		return leaf.Values()[0], true
+	case parse.True:
+		return "true", true
+	case parse.False:
+		return "false", true
+	default:
+		return "", false
+	}
+}
+
+// matchedCodegen returns the statements which declare the entities matched by the query,
+// the entities are loaded by the key or the index sets of the equality conditions.
+func (g *redisGenerator) matchedCodegen(query *parse.Query, ctx, zero string) (code.Body, error) {
+	condition := "true"
+	source := ""
+	if query.QueryMode == parse.By {
+		var err error
+		if condition, err = g.conditionCodegen(query.ConnectionOpTree); err != nil {
+			return nil, err
+		}
+
+		sets := make([]string, 0, 2)
+		for _, leaf := range getAndLeaves(query.ConnectionOpTree) {
+			value, ok := g.indexValue(leaf)
+			if !ok {
+				continue
+			}
+			if leaf.MongoFieldName == g.keyField.mongoName {
+				source = fmt.Sprintf("r.load(%s, []string{fmt.Sprint(%s)}, %%s)", ctx, value)
+				break
+			}
+			sets = append(sets, fmt.Sprintf("r.indexKey(%s, %s)", strconv.Quote(leaf.MongoFieldName), value))
+		}
+		if source == "" && len(sets) != 0 {
+			source = fmt.Sprintf("r.find(%s, %%s, %s)", ctx, strings.Join(sets, ", "))
+		}
+	}
+	if source == "" {
+		source = fmt.Sprintf("r.find(%s, %%s)", ctx)
+	}
+
+	match := fmt.Sprintf("func(e %s) bool {\nreturn %s\n}", g.entityType.RealName(), condition)
+	return code.Body{
+		code.RawStmt("entities, err := " + fmt.Sprintf(source, match)),
+		code.RawStmt(fmt.Sprintf("if err != nil {\n\treturn %s, err\n}", zero)),
+	}, nil
+}
+
+// conditionCodegen converts the query tree to the go boolean expression evaluated on the entity e.
+func (g *redisGenerator) conditionCodegen(node *parse.ConnectionOpTree) (string, error) {
+	// none-leaves node
+	if node.LeftChildren != nil {
+		left, err := g.conditionCodegen(node.LeftChildren)
+		if err != nil {
+			return "", err
+		}
+		right, err := g.conditionCodegen(node.RightChildren)
+		if err != nil {
+			return "", err
+		}
+		connection := " && "
+		if node.Name == string(parse.Or) {
+			connection = " || "
+		}
+		return "(" + left + connection + right + ")", nil
+	}
+
+	field, err := g.getField(node.MongoFieldName)
+	if err != nil {
+		return "", err
+	}
+	v := field.access("e")
+	comparable := isComparableType(field.fieldType)
+	ordered := isOrderedType(field.fieldType)
+	unsupported := fmt.Errorf("%s on %s is not supported by the redis backend", node.Name, node.MongoFieldName)
+
+	equal := func(value string) string {
+		if comparable {
+			return v + " == " + value
+		}
+		return fmt.Sprintf("reflect.DeepEqual(%s, %s)", v, value)
+	}
+	compare := func(op, value string) (string, error) {
+		if !ordered {
+			return "", unsupported
+		}
+		return v + " " + op + " " + value, nil
+	}
+
+	var condition string
+	negative := false
+	switch parse.QueryComparator(node.Name) {
+	case parse.Equal:
+		condition = equal(node.Values()[0])
+	case parse.NotEqual:
+		condition = "!(" + equal(node.Values()[0]) + ")"
+		negative = true
+	case parse.LessThan:
+		condition, err = compare("<", node.Values()[0])
+	case parse.LessThanEqual:
+		condition, err = compare("<=", node.Values()[0])
+	case parse.GreaterThan:
+		condition, err = compare(">", node.Values()[0])
+	case parse.GreaterThanEqual:
+		condition, err = compare(">=", node.Values()[0])
+	case parse.Between:
+		if condition, err = compare(">=", node.Values()[0]); err == nil {
+			condition += " && " + v + " <= " + node.Values()[1]
+		}
+	case parse.NotBetween:
+		if condition, err = compare("<", node.Values()[0]); err == nil {
+			condition += " || " + v + " > " + node.Values()[1]
+		}
+		negative = true
+	case parse.In, parse.NotIn:
+		// the array field matches if any of its elements is in the values, the other fields are
+		// compared by equality because the param has the same type as the field
+		condition = equal(node.Values()[0])
+		if sliceType, ok := field.fieldType.(code.SliceType); ok {
+			elemEqual := "a == b"
+			if !isComparableType(sliceType.ElementType) {
+				elemEqual = "reflect.DeepEqual(a, b)"
+			}
+			condition = fmt.Sprintf("func() bool {\n"+
+				"\tfor _, a := range %s {\n"+
+				"\t\tfor _, b := range %s {\n"+
+				"\t\t\tif %s {\n"+
+				"\t\t\t\treturn true\n"+
+				"\t\t\t}\n"+
+				"\t\t}\n"+
+				"\t}\n"+
+				"\treturn false\n"+
+				"}()", v, node.Values()[0], elemEqual)
+		}
+		if parse.QueryComparator(node.Name) == parse.NotIn {
+			condition = "!(" + condition + ")"
+			negative = true
+		}
+	case parse.True, parse.False:
+		if !isBoolType(field.fieldType) {
+			return "", unsupported
+		}
+		condition = v
+		if parse.QueryComparator(node.Name) == parse.False {
+			condition = "!" + v
+		}
+	case parse.Exists, parse.NotExists:
+		condition = "true"
+		switch field.fieldType.(type) {
+		case code.StarExprType, code.SliceType, code.MapType:
+			condition = v + " != nil"
+		}
+		if parse.QueryComparator(node.Name) == parse.NotExists {
+			condition = "!(" + condition + ")"
+			negative = true
+		}
+	default:
+		return "", unsupported
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// the negative conditions are satisfied if the field is missing
+	if guard := field.guard("e"); guard != "" {
+		if negative {
+			return "(!(" + guard + ") || " + condition + ")", nil
+		}
+		return "(" + guard + " && " + condition + ")", nil
+	}
+	return "(" + condition + ")", nil
+}
+
+func isIntegerType(t code.Type) bool {
+	switch t.RealName() {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		return true
+	}
+	return false
+}
+
+func isStringType(t code.Type) bool {
+	return t.RealName() == "string"
+}
+
+func isBoolType(t code.Type) bool {
+	return t.RealName() == "bool"
+}
+
+// isOrderedType reports whether the type supports the order operators, the enums are integers.
+func isOrderedType(t code.Type) bool {
+	if _, ok := t.(code.SelectorExprType); ok {
+		return true
+	}
+	return isIntegerType(t) || isStringType(t) || t.RealName() == "float32" || t.RealName() == "float64"
+}
+
+func isComparableType(t code.Type) bool {
+	return isOrderedType(t) || isBoolType(t)
+}
@@ -0,0 +1,289 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/template"
+)
+
+// storageRenders returns the methods which encode, save, load and remove the entities.
+func (g *redisGenerator) storageRenders() []template.Render {
+	entity := g.entityType.RealName()
+	ctxType := code.SelectorExprType{X: "context", Sel: "Context"}
+	entityType := g.entityType
+	pipeType := code.SelectorExprType{X: "redis", Sel: "Pipeliner"}
+	matchFunc := code.IdentType(fmt.Sprintf("func(e %s) bool", entity))
+	valuesType := code.MapType{KeyType: code.IdentType("string"), ValueType: code.IdentType("string")}
+	indexesType := code.MapType{KeyType: code.IdentType("string"), ValueType: code.InterfaceType{}}
+	key := "e." + g.keyField.goPath
+
+	encodeFields := ""
+	decodeFields := ""
+	for _, field := range g.st.StructFields {
+		name := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		encodeFields += fmt.Sprintf("%s: e.%s,\n", strconv.Quote(name), field.Name)
+		decodeFields += fmt.Sprintf("%s: &e.%s,\n", strconv.Quote(name), field.Name)
+	}
+
+	// the id of the new entity is generated by the sequence if it is not set
+	seq := fmt.Sprintf("%s = %s(seq)", key, g.keyField.fieldType.RealName())
+	zero := "0"
+	if isStringType(g.keyField.fieldType) {
+		seq = fmt.Sprintf("%s = strconv.FormatInt(seq, 10)", key)
+		zero = `""`
+	}
+
+	indexes := code.Body{
+		code.RawStmt(fmt.Sprintf("indexes := make(map[string]interface{}, %d)", len(g.indexFields))),
+	}
+	for _, name := range g.indexFields {
+		field, _ := g.getField(name)
+		stmt := fmt.Sprintf("indexes[%s] = %s", strconv.Quote(name), field.access("e"))
+		if guard := field.guard("e"); guard != "" {
+			stmt = fmt.Sprintf("if %s {\n\t%s\n}", guard, stmt)
+		}
+		indexes = append(indexes, code.RawStmt(stmt))
+	}
+	indexes = append(indexes, code.RawStmt("return indexes"))
+
+	return []template.Render{
+		&template.MethodRender{
+			Name:           "key",
+			MethodReceiver: g.receiver,
+			Params:         code.Params{code.Param{Name: "id", Type: code.InterfaceType{}}},
+			Returns:        code.Returns{code.IdentType("string")},
+			MethodBody: code.Body{
+				code.RawStmt("return fmt.Sprintf(\"%s:%v\", r.prefix, id)"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "indexKey",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "field", Type: code.IdentType("string")},
+				code.Param{Name: "value", Type: code.InterfaceType{}},
+			},
+			Returns: code.Returns{code.IdentType("string")},
+			MethodBody: code.Body{
+				code.RawStmt("data, _ := json.Marshal(value)"),
+				code.RawStmt("return fmt.Sprintf(\"%s:idx:%s:%s\", r.prefix, field, data)"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "indexes",
+			Comment:        "// indexes returns the values of the indexed fields of the entity.",
+			MethodReceiver: g.receiver,
+			Params:         code.Params{code.Param{Name: "e", Type: entityType}},
+			Returns:        code.Returns{indexesType},
+			MethodBody:     indexes,
+		},
+		&template.MethodRender{
+			Name:           "encode",
+			MethodReceiver: g.receiver,
+			Params:         code.Params{code.Param{Name: "e", Type: entityType}},
+			Returns:        code.Returns{valuesType, code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt("values := make(map[string]string)"),
+				code.RawStmt(fmt.Sprintf("for name, field := range map[string]interface{}{\n%s} {\n"+
+					"\tdata, err := json.Marshal(field)\n"+
+					"\tif err != nil {\n"+
+					"\t\treturn nil, err\n"+
+					"\t}\n"+
+					"\tvalues[name] = string(data)\n"+
+					"}", encodeFields)),
+				code.RawStmt("return values, nil"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "decode",
+			MethodReceiver: g.receiver,
+			Params:         code.Params{code.Param{Name: "values", Type: valuesType}},
+			Returns:        code.Returns{entityType, code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt(fmt.Sprintf("e := new(%s)", entityType.(code.StarExprType).RealType.RealName())),
+				code.RawStmt(fmt.Sprintf("for name, field := range map[string]interface{}{\n%s} {\n"+
+					"\tif value, ok := values[name]; ok {\n"+
+					"\t\tif err := json.Unmarshal([]byte(value), field); err != nil {\n"+
+					"\t\t\treturn nil, err\n"+
+					"\t\t}\n"+
+					"\t}\n"+
+					"}", decodeFields)),
+				code.RawStmt("return e, nil"),
+			},
+		},
+		&template.MethodRender{
+			Name: "project",
+			Comment: "// project keeps the key and the projected top-level fields of the entity,\n" +
+				"// the nested fields are projected with their top-level fields.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "e", Type: entityType},
+				code.Param{Name: "fields", Type: code.IdentType("...string")},
+			},
+			Returns: code.Returns{entityType, code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt("values, err := r.encode(e)"),
+				code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+				code.RawStmt("projected := make(map[string]string, len(fields)+1)"),
+				code.RawStmt(fmt.Sprintf("for _, field := range append(fields, %s) {\n"+
+					"\tfield = strings.Split(field, \".\")[0]\n"+
+					"\tprojected[field] = values[field]\n"+
+					"}", strconv.Quote(g.keyField.mongoName))),
+				code.RawStmt("return r.decode(projected)"),
+			},
+		},
+		&template.MethodRender{
+			Name: "insert",
+			Comment: "// insert saves the new entity, the key is generated by the sequence prefix:seq if it is not set,\n" +
+				"// an error is returned if the key exists.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "e", Type: entityType},
+			},
+			Returns: code.Returns{code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt(fmt.Sprintf("if %s == %s {\n"+
+					"\tseq, err := r.client.Incr(ctx, r.prefix+\":seq\").Result()\n"+
+					"\tif err != nil {\n"+
+					"\t\treturn err\n"+
+					"\t}\n"+
+					"\t%s\n"+
+					"}", key, zero, seq)),
+				code.RawStmt(fmt.Sprintf("if exists, err := r.client.Exists(ctx, r.key(%s)).Result(); err != nil {\n"+
+					"\treturn err\n"+
+					"} else if exists > 0 {\n"+
+					"\treturn fmt.Errorf(\"%%s already exists\", r.key(%s))\n"+
+					"}", key, key)),
+				code.RawStmt("_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {\n" +
+					"\treturn r.save(ctx, pipe, nil, e)\n" +
+					"})"),
+				code.RawStmt("return err"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "save",
+			Comment:        "// save writes the entity and replaces the old index values with the current ones.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "pipe", Type: pipeType},
+				code.Param{Name: "old", Type: indexesType},
+				code.Param{Name: "e", Type: entityType},
+			},
+			Returns: code.Returns{code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt("values, err := r.encode(e)"),
+				code.RawStmt("if err != nil {\n\treturn err\n}"),
+				code.RawStmt(fmt.Sprintf("id := fmt.Sprint(%s)", key)),
+				code.RawStmt("for field, value := range old {\n\tpipe.SRem(ctx, r.indexKey(field, value), id)\n}"),
+				code.RawStmt("fields := make([]interface{}, 0, 2*len(values))"),
+				code.RawStmt("for name, value := range values {\n\tfields = append(fields, name, value)\n}"),
+				code.RawStmt("pipe.HSet(ctx, r.key(id), fields...)"),
+				code.RawStmt("pipe.SAdd(ctx, r.prefix+\":ids\", id)"),
+				code.RawStmt("for field, value := range r.indexes(e) {\n\tpipe.SAdd(ctx, r.indexKey(field, value), id)\n}"),
+				code.RawStmt("return nil"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "remove",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "pipe", Type: pipeType},
+				code.Param{Name: "e", Type: entityType},
+			},
+			MethodBody: code.Body{
+				code.RawStmt(fmt.Sprintf("id := fmt.Sprint(%s)", key)),
+				code.RawStmt("pipe.Del(ctx, r.key(id))"),
+				code.RawStmt("pipe.SRem(ctx, r.prefix+\":ids\", id)"),
+				code.RawStmt("for field, value := range r.indexes(e) {\n\tpipe.SRem(ctx, r.indexKey(field, value), id)\n}"),
+			},
+		},
+		&template.MethodRender{
+			Name: "find",
+			Comment: "// find returns the entities which satisfy match in the intersection of the index sets,\n" +
+				"// all entities are evaluated if no index set is specified.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "match", Type: matchFunc},
+				code.Param{Name: "sets", Type: code.IdentType("...string")},
+			},
+			Returns: code.Returns{code.SliceType{ElementType: entityType}, code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt("var ids []string"),
+				code.RawStmt("var err error"),
+				code.RawStmt("switch len(sets) {\n" +
+					"case 0:\n" +
+					"\tids, err = r.client.SMembers(ctx, r.prefix+\":ids\").Result()\n" +
+					"case 1:\n" +
+					"\tids, err = r.client.SMembers(ctx, sets[0]).Result()\n" +
+					"default:\n" +
+					"\tids, err = r.client.SInter(ctx, sets...).Result()\n" +
+					"}"),
+				code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+				code.RawStmt("return r.load(ctx, ids, match)"),
+			},
+		},
+		&template.MethodRender{
+			Name:           "load",
+			Comment:        "// load returns the entities which satisfy match in the order of the ids.",
+			MethodReceiver: g.receiver,
+			Params: code.Params{
+				code.Param{Name: "ctx", Type: ctxType},
+				code.Param{Name: "ids", Type: code.SliceType{ElementType: code.IdentType("string")}},
+				code.Param{Name: "match", Type: matchFunc},
+			},
+			Returns: code.Returns{code.SliceType{ElementType: entityType}, code.IdentType("error")},
+			MethodBody: code.Body{
+				code.RawStmt("sort.Strings(ids)"),
+				code.RawStmt("pipe := r.client.Pipeline()"),
+				code.RawStmt("cmds := make([]*redis.MapStringStringCmd, 0, len(ids))"),
+				code.RawStmt("for _, id := range ids {\n\tcmds = append(cmds, pipe.HGetAll(ctx, r.key(id)))\n}"),
+				code.RawStmt("if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {\n\treturn nil, err\n}"),
+				code.RawStmt(fmt.Sprintf("entities := make([]%s, 0, len(cmds))", entity)),
+				code.RawStmt("for _, cmd := range cmds {\n" +
+					"\tvalues, err := cmd.Result()\n" +
+					"\tif err != nil {\n" +
+					"\t\treturn nil, err\n" +
+					"\t}\n" +
+					"\t// the entity is removed after the ids are read\n" +
+					"\tif len(values) == 0 {\n" +
+					"\t\tcontinue\n" +
+					"\t}\n" +
+					"\te, err := r.decode(values)\n" +
+					"\tif err != nil {\n" +
+					"\t\treturn nil, err\n" +
+					"\t}\n" +
+					"\tif match(e) {\n" +
+					"\t\tentities = append(entities, e)\n" +
+					"\t}\n" +
+					"}"),
+				code.RawStmt("return entities, nil"),
+			},
+		},
+	}
+}
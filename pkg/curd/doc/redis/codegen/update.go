@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/parse"
+)
+
+func (g *redisGenerator) updateCodegen(update *parse.UpdateParse) (code.Body, error) {
+	zero, result := "0", "len(entities)"
+	if update.OperateMode == parse.OperateOne {
+		zero, result = "false", "len(entities) > 0"
+	}
+
+	body, err := g.matchedCodegen(update.Query, update.CtxParamName, zero)
+	if err != nil {
+		return nil, err
+	}
+	if update.OperateMode == parse.OperateOne {
+		body = append(body, code.RawStmt("if len(entities) > 1 {\n\tentities = entities[:1]\n}"))
+	}
+
+	sets, err := g.setCodegen(update)
+	if err != nil {
+		return nil, err
+	}
+
+	// upsert inserts the entity built from the equality conditions and the updated fields,
+	// the result is the same as the mongo implementation which only counts the matched entities
+	if update.Upsert {
+		seeds := ""
+		if update.Query.QueryMode == parse.By {
+			for _, leaf := range getAndLeaves(update.Query.ConnectionOpTree) {
+				value, ok := g.indexValue(leaf)
+				if !ok {
+					continue
+				}
+				field, err := g.getField(leaf.MongoFieldName)
+				if err != nil {
+					return nil, err
+				}
+				seeds += g.assignCodegen(field, value)
+			}
+		}
+		body = append(body, code.RawStmt(fmt.Sprintf("if len(entities) == 0 {\n"+
+			"\te := new(%s)\n"+
+			"%s%s"+
+			"\tif err = r.insert(%s, e); err != nil {\n"+
+			"\t\treturn %s, err\n"+
+			"\t}\n"+
+			"\treturn %s, nil\n"+
+			"}", g.entityType.(code.StarExprType).RealType.RealName(), seeds, sets, update.CtxParamName, zero, zero)))
+	}
+
+	return append(body,
+		code.RawStmt(fmt.Sprintf("if _, err = r.client.TxPipelined(%s, func(pipe redis.Pipeliner) error {\n"+
+			"\tfor _, e := range entities {\n"+
+			"\t\told := r.indexes(e)\n"+
+			"%s"+
+			"\t\tif err := r.save(%s, pipe, old, e); err != nil {\n"+
+			"\t\t\treturn err\n"+
+			"\t\t}\n"+
+			"\t}\n"+
+			"\treturn nil\n"+
+			"}); err != nil {\n"+
+			"\treturn %s, err\n"+
+			"}", update.CtxParamName, sets, update.CtxParamName, zero)),
+		code.RawStmt(fmt.Sprintf("return %s, nil", result)),
+	), nil
+}
+
+// setCodegen returns the statements which update the fields of the entity e.
+func (g *redisGenerator) setCodegen(update *parse.UpdateParse) (string, error) {
+	if update.UpdateStructObjName != "" {
+		obj := update.UpdateStructObjName
+		if _, ok := getParamType(update.BelongedToMethod, obj).(code.StarExprType); ok {
+			obj = "*" + obj
+		}
+		key := g.keyField.access("e")
+		return fmt.Sprintf("id := %s\n*e = %s\n%s = id\n", key, obj, key), nil
+	}
+
+	result := ""
+	for _, updateField := range update.UpdateFields {
+		field, err := g.getField(updateField.MongoFieldName)
+		if err != nil {
+			return "", err
+		}
+		result += g.assignCodegen(field, updateField.ParamName)
+	}
+	return result, nil
+}
+
+// assignCodegen returns the statement which assigns the value to the field of the entity e,
+// the nil structure pointers passed from the entity to the field are allocated first.
+func (g *redisGenerator) assignCodegen(field *redisField, value string) string {
+	result := ""
+	for index, parent := range field.parents {
+		parentType := field.parentTypes[index].(code.StarExprType).RealType
+		realType := parentType.RealName()
+		if selector, ok := parentType.(code.SelectorExprType); ok {
+			realType = g.aliasCodegen(selector)
+		}
+		result += fmt.Sprintf("if e.%s == nil {\n\te.%s = new(%s)\n}\n", parent, parent, realType)
+	}
+	return result + fmt.Sprintf("%s = %s\n", field.access("e"), value)
+}
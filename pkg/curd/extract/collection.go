@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	mongoCollection = "mongo.collection"
+	mongoDatabase   = "mongo.database"
+)
+
+// GetCollectionName returns the collection name declared by the mongo.collection annotation,
+// the snake case of the structure name by default, such as user_info.
+func (st *IdlExtractStruct) GetCollectionName() string {
+	if st.Collection != "" {
+		return st.Collection
+	}
+	return GetPkgName(st.Name)
+}
+
+// parseCollectionName checks the collection name against the naming restrictions of mongodb.
+func parseCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%s should not be empty", mongoCollection)
+	}
+	if strings.ContainsAny(name, "$\x00") || strings.HasPrefix(name, "system.") {
+		return "", fmt.Errorf("invalid collection name %s in %s, it should not contain $ or start with system.",
+			name, mongoCollection)
+	}
+	return name, nil
+}
+
+// parseDatabaseName checks the database name against the naming restrictions of mongodb.
+func parseDatabaseName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%s should not be empty", mongoDatabase)
+	}
+	if strings.ContainsAny(name, "/\\. \"$*<>:|?\x00") || len(name) >= 64 {
+		return "", fmt.Errorf("invalid database name %s in %s, it should be shorter than 64 characters "+
+			"without /\\. \"$*<>:|?", name, mongoDatabase)
+	}
+	return name, nil
+}
+
+// linkModels makes the structures in the idl referenced by each other, so that the transactions can run
+// on the collections of the other models.
+func linkModels(structs []*IdlExtractStruct) {
+	models := make(map[string]*IdlExtractStruct, len(structs))
+	for _, st := range structs {
+		models[st.Name] = st
+	}
+	for _, st := range structs {
+		st.Models = models
+	}
+}
@@ -17,6 +17,8 @@
 package extract
 
 import (
+	"encoding/json"
+	"fmt"
 	"go/ast"
 	astParser "go/parser"
 	"go/token"
@@ -33,8 +35,21 @@ import (
 
 type IdlExtractStruct struct {
 	Name          string
+	ModelPkg      string // the go package name of the generated model
+	ModelDir      string // the directory of the generated model package
 	StructFields  []*StructField
 	InterfaceInfo *InterfaceInfo
+	Indexes       []*Index
+	Options       ModelOptions
+	// Collection and Database are declared by the mongo.collection and mongo.database annotations,
+	// they are empty if they are not declared
+	Collection string
+	Database   string
+	// Models are the structures with the repositories in the same idl indexed by the names,
+	// which are referenced by the Collection of the transactions
+	Models map[string]*IdlExtractStruct `json:"-"`
+	// Generic is true if the repository embeds the generic repository, it is decided by the mongo backend
+	Generic bool `json:"-"`
 	UpdateInfo
 }
 
@@ -48,23 +63,65 @@ type InterfaceMethod struct {
 	ParsedTokens     string
 	Params           code.Params
 	Returns          code.Returns
-	BelongedToStruct *IdlExtractStruct
+	BelongedToStruct *IdlExtractStruct `json:"-"`
+	// Pos is the position of the annotation which declares the method, it is invalid if it is not found
+	Pos Position
+	// QueryAnnotation is not nil if the Find operation of the method is declared by the query annotations
+	QueryAnnotation *QueryAnnotation
+	// TransactionOptions is not nil if the options of the Transaction method are declared
+	TransactionOptions *TransactionOptions
 }
 
 type StructField struct {
-	Name               string
-	Type               code.Type
-	Tag                reflect.StructTag
+	Name string
+	Type code.Type
+	Tag  reflect.StructTag
+	// OmitEmpty is true if the bson tag has the omitempty option which is removed from Tag
+	OmitEmpty bool
+	// Required is true if the field is declared required in the idl
+	Required bool
+	// Enum is the values of the enum type of the field or the elements of the field, nil if it is not an enum
+	Enum               []int64
 	IsBelongedToStruct bool
 	BelongedToStruct   *IdlExtractStruct
+	// Oneof is not nil if the field is the oneof of proto, whose type is the unexported interface of the wrappers
+	Oneof *Oneof
+	// ElementStruct is the message of the elements of the repeated or map field of proto,
+	// whose fields are not queried by the dotted paths
+	ElementStruct *IdlExtractStruct
 }
 
 type UpdateInfo struct {
 	Update                bool
 	UpdateCurdFileContent []byte
 	UpdateIfFileContent   []byte
-	PreMethodNamesMap     map[string]struct{}
-	PreIfMethods          []*InterfaceMethod
+	// UserMethodNamesMap records the methods outside the generated regions of the repository file,
+	// the methods of the idl with the same names are not generated and they are stored in PreIfMethods
+	UserMethodNamesMap map[string]struct{}
+	PreIfMethods       []*InterfaceMethod
+}
+
+// nestedStruct is the encoding of the structures of the fields, the states of the repository files
+// are only encoded by the structures with the repositories.
+type nestedStruct struct {
+	Name         string
+	StructFields []*StructField
+}
+
+func newNestedStruct(st *IdlExtractStruct) *nestedStruct {
+	if st == nil {
+		return nil
+	}
+	return &nestedStruct{Name: st.Name, StructFields: st.StructFields}
+}
+
+func (sf StructField) MarshalJSON() ([]byte, error) {
+	type field StructField
+	return json.Marshal(struct {
+		field
+		BelongedToStruct *nestedStruct
+		ElementStruct    *nestedStruct
+	}{field(sf), newNestedStruct(sf.BelongedToStruct), newNestedStruct(sf.ElementStruct)})
 }
 
 func newIdlExtractStruct(name string) *IdlExtractStruct {
@@ -75,8 +132,8 @@ func newIdlExtractStruct(name string) *IdlExtractStruct {
 			Methods: make([]*InterfaceMethod, 0, 10),
 		},
 		UpdateInfo: UpdateInfo{
-			PreMethodNamesMap: map[string]struct{}{},
-			PreIfMethods:      []*InterfaceMethod{},
+			UserMethodNamesMap: map[string]struct{}{},
+			PreIfMethods:       []*InterfaceMethod{},
 		},
 	}
 }
@@ -106,12 +163,16 @@ func (st *IdlExtractStruct) recordMongoIfInfo(daoDir string) error {
 				return err
 			}
 
-			preMethodNames, err := getInterfaceMethodNames(string(st.UpdateIfFileContent))
+			regions, err := GetGeneratedRegions(string(st.UpdateCurdFileContent))
+			if err != nil {
+				return fmt.Errorf("%s: %s", fileMongoName, err.Error())
+			}
+			userMethodNames, err := getUserMethodNames(string(st.UpdateCurdFileContent), regions)
 			if err != nil {
 				return err
 			}
-			for _, methodName := range preMethodNames {
-				st.PreMethodNamesMap[methodName] = struct{}{}
+			for _, methodName := range userMethodNames {
+				st.UserMethodNamesMap[methodName] = struct{}{}
 			}
 		}
 	}
@@ -119,7 +180,7 @@ func (st *IdlExtractStruct) recordMongoIfInfo(daoDir string) error {
 	return nil
 }
 
-func extractIdlInterface(rawInterface string, rawStruct *IdlExtractStruct, tokens []string) error {
+func extractIdlInterface(rawInterface string, rawStruct *IdlExtractStruct, tokens []string, positions []Position) error {
 	fSet := token.NewFileSet()
 	f, err := astParser.ParseFile(fSet, "", rawInterface, astParser.ParseComments)
 	if err != nil {
@@ -137,7 +198,7 @@ func extractIdlInterface(rawInterface string, rawStruct *IdlExtractStruct, token
 			case *ast.TypeSpec:
 				switch t := spec.Type.(type) {
 				case *ast.InterfaceType:
-					rawStruct.InterfaceInfo = extractInterfaceType(spec.Name.Name, t, tokens, rawStruct)
+					rawStruct.InterfaceInfo = extractInterfaceType(spec.Name.Name, t, tokens, positions, rawStruct)
 				}
 			}
 		}
@@ -146,7 +207,9 @@ func extractIdlInterface(rawInterface string, rawStruct *IdlExtractStruct, token
 	return nil
 }
 
-func extractInterfaceType(ifName string, interfaceType *ast.InterfaceType, tokens []string, rawStruct *IdlExtractStruct) *InterfaceInfo {
+func extractInterfaceType(ifName string, interfaceType *ast.InterfaceType, tokens []string, positions []Position,
+	rawStruct *IdlExtractStruct,
+) *InterfaceInfo {
 	intf := &InterfaceInfo{
 		Name:    ifName,
 		Methods: []*InterfaceMethod{},
@@ -165,20 +228,23 @@ func extractInterfaceType(ifName string, interfaceType *ast.InterfaceType, token
 		}
 
 		if rawStruct.Update {
-			if _, ok = rawStruct.PreMethodNamesMap[name]; !ok {
+			if _, ok = rawStruct.UserMethodNamesMap[name]; !ok {
 				meth := extractFunction(name, funcType, tokens[index])
 				meth.BelongedToStruct = rawStruct
+				meth.Pos = positions[index]
 
 				intf.Methods = append(intf.Methods, meth)
 			} else {
 				meth := extractFunction(name, funcType, tokens[index])
 				meth.BelongedToStruct = rawStruct
+				meth.Pos = positions[index]
 
 				rawStruct.PreIfMethods = append(rawStruct.PreIfMethods, meth)
 			}
 		} else {
 			meth := extractFunction(name, funcType, tokens[index])
 			meth.BelongedToStruct = rawStruct
+			meth.Pos = positions[index]
 
 			intf.Methods = append(intf.Methods, meth)
 		}
@@ -256,6 +322,33 @@ func getType(expr ast.Expr, pkgName string, isPbCall bool) code.Type {
 		valueType := getType(expr.Value, pkgName, isPbCall)
 		return code.MapType{KeyType: keyType, ValueType: valueType}
 
+	case *ast.ChanType:
+		elementType := getType(expr.Value, pkgName, isPbCall)
+		switch expr.Dir {
+		case ast.SEND:
+			return code.ChanType{Dir: code.ChanSend, ElementType: elementType}
+		case ast.RECV:
+			return code.ChanType{Dir: code.ChanRecv, ElementType: elementType}
+		default:
+			return code.ChanType{Dir: code.ChanBoth, ElementType: elementType}
+		}
+
+	case *ast.FuncType:
+		funcType := code.FuncType{}
+		for _, param := range expr.Params.List {
+			for i := 0; i < len(param.Names) || i == 0; i++ {
+				funcType.Params = append(funcType.Params, getType(param.Type, pkgName, isPbCall))
+			}
+		}
+		if expr.Results != nil {
+			for _, result := range expr.Results.List {
+				for i := 0; i < len(result.Names) || i == 0; i++ {
+					funcType.Returns = append(funcType.Returns, getType(result.Type, pkgName, isPbCall))
+				}
+			}
+		}
+		return funcType
+
 	case *ast.InterfaceType:
 		return code.InterfaceType{}
 	}
@@ -263,6 +356,56 @@ func getType(expr ast.Expr, pkgName string, isPbCall bool) code.Type {
 	return nil
 }
 
+// GetFieldByMongoName is used to get the go field access path and type of the field
+// specified by mongo field name, such as contact.city ==> Contact.City.
+func (st *IdlExtractStruct) GetFieldByMongoName(mongoName string) (string, code.Type, error) {
+	fields, err := st.GetFieldsByMongoName(mongoName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	goPath := ""
+	for index, field := range fields {
+		if index == 0 {
+			goPath = field.Name
+		} else {
+			goPath += "." + field.Name
+		}
+	}
+	return goPath, fields[len(fields)-1].Type, nil
+}
+
+// GetFieldsByMongoName is used to get the fields passed from the outermost structure to the field
+// specified by mongo field name, such as contact.city ==> [Contact, City].
+func (st *IdlExtractStruct) GetFieldsByMongoName(mongoName string) ([]*StructField, error) {
+	names := strings.Split(mongoName, ".")
+	result := make([]*StructField, 0, len(names))
+	cur := st
+	for index, name := range names {
+		var found *StructField
+		for _, field := range cur.StructFields {
+			if field.Tag.Get(bson) == name {
+				found = field
+				break
+			}
+		}
+		if found == nil {
+			break
+		}
+		result = append(result, found)
+
+		if index == len(names)-1 {
+			return result, nil
+		}
+		if !found.IsBelongedToStruct {
+			break
+		}
+		cur = found.BelongedToStruct
+	}
+
+	return nil, fmt.Errorf("no field name corresponding to %s found", mongoName)
+}
+
 func GetFileName(structName, prefix string) (fileMongoName, fileIfName string) {
 	dir := GetPkgName(structName)
 	fileMongoName = filepath.Join(prefix, dir, dir+"_repo_mongo.go")
@@ -270,6 +413,62 @@ func GetFileName(structName, prefix string) (fileMongoName, fileIfName string) {
 	return
 }
 
+// GetMockFileName returns the file names of the gomock mock and the in-memory fake repository.
+func GetMockFileName(structName, prefix string) (fileMockName, fileFakeName string) {
+	dir := GetPkgName(structName)
+	fileMockName = filepath.Join(prefix, dir, dir+"_repo_mock.go")
+	fileFakeName = filepath.Join(prefix, dir, dir+"_repo_fake.go")
+	return
+}
+
+// GetCacheFileName returns the file name of the cache decorator of the mongo repository.
+func GetCacheFileName(structName, prefix string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, dir+"_repo_cache.go")
+}
+
+// GetHookFileName returns the file name of the hook decorator of the mongo repository.
+func GetHookFileName(structName, prefix string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, dir+"_repo_hook.go")
+}
+
+// GetSchemaFileName returns the file name of the $jsonSchema validator of the mongo collection.
+func GetSchemaFileName(structName, prefix string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, dir+"_schema.go")
+}
+
+// RegistryFileName is the file of the bson registry generated into the model package.
+const RegistryFileName = "bson_registry.go"
+
+// GetTestFileName returns the file name of the integration tests of the mongo repository.
+func GetTestFileName(structName, prefix string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, dir+"_repo_mongo_test.go")
+}
+
+// GetRedisFileName returns the file name of the redis implementation of the repository.
+func GetRedisFileName(structName, prefix string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, dir+"_repo_redis.go")
+}
+
+// GetGormFileName returns the file name of the gorm implementation of the repository.
+func GetGormFileName(structName, prefix string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, dir+"_repo_gorm.go")
+}
+
+// GetElasticsearchFileName returns the file names of the elasticsearch implementation of the repository
+// and the mapping of its index.
+func GetElasticsearchFileName(structName, prefix string) (fileImplName, fileMappingName string) {
+	dir := GetPkgName(structName)
+	fileImplName = filepath.Join(prefix, dir, dir+"_repo_elasticsearch.go")
+	fileMappingName = filepath.Join(prefix, dir, dir+"_mapping_elasticsearch.json")
+	return
+}
+
 func GetPkgName(structName string) string {
 	tokens := camelcase.Split(structName)
 	dir := ""
@@ -282,40 +481,3 @@ func GetPkgName(structName string) string {
 	}
 	return dir
 }
-
-func getInterfaceMethodNames(data string) (result []string, err error) {
-	fSet := token.NewFileSet()
-	f, err := astParser.ParseFile(fSet, "", data, astParser.ParseComments)
-	if err != nil {
-		return
-	}
-
-	for _, decl := range f.Decls {
-		genDecl, ok := decl.(*ast.GenDecl)
-		if !ok {
-			continue
-		}
-
-		for _, spec := range genDecl.Specs {
-			switch spec := spec.(type) {
-			case *ast.TypeSpec:
-				switch t := spec.Type.(type) {
-				case *ast.InterfaceType:
-					for _, method := range t.Methods.List {
-						_, ok = method.Type.(*ast.FuncType)
-						if !ok {
-							continue
-						}
-
-						for _, n := range method.Names {
-							result = append(result, n.Name)
-							break
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return
-}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	mongoPrefix = "mongo."
+	mongoIndex  = "mongo.index"
+)
+
+// Index stores the index declared by the mongo.index annotation of the structure or the field.
+type Index struct {
+	Name string
+	Keys []IndexKey
+
+	Unique bool
+	Sparse bool
+
+	// ExpireAfterSeconds is used by TTL index, 0 means not a TTL index
+	ExpireAfterSeconds int32
+
+	// PartialFilter is used by partial index, nil means not a partial index
+	PartialFilter map[string]interface{}
+}
+
+type IndexKey struct {
+	MongoFieldName string
+	Desc           bool
+}
+
+const (
+	indexUnique  = "unique"
+	indexSparse  = "sparse"
+	indexDesc    = "desc"
+	indexTTL     = "ttl"
+	indexName    = "name"
+	indexPartial = "partial"
+)
+
+// isMongoOptionKey reports whether the annotation key is an option such as mongo.index
+// rather than a method, method tokens always start with an upper case operation name.
+func isMongoOptionKey(key string) bool {
+	if strings.Index(key, mongoPrefix) != 0 || len(key) <= len(mongoPrefix) {
+		return false
+	}
+	c := key[len(mongoPrefix)]
+	return c >= 'a' && c <= 'z'
+}
+
+// parseIndex is used to parse the index declaration.
+//
+//	declaration description:
+//	structure: "city,-created_at;unique;name=city_created_at"
+//	field: "desc;ttl=3600"
+//	fields are separated by commas and prefixed with - for descending order,
+//	options are separated by semicolons and support unique, sparse, desc(field only),
+//	ttl=seconds, name=indexName, partial=JSON filter.
+//
+//	input params description:
+//	decl: the index declaration
+//	fieldName: the mongo field name when declared on a field, empty when declared on a structure
+func parseIndex(decl, fieldName string) (*Index, error) {
+	idx := &Index{Keys: []IndexKey{}}
+	segments := strings.Split(decl, ";")
+
+	if fieldName == "" {
+		for _, f := range strings.Split(segments[0], ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				return nil, fmt.Errorf("index %s has an empty field name", decl)
+			}
+			if strings.HasPrefix(f, "-") {
+				idx.Keys = append(idx.Keys, IndexKey{MongoFieldName: f[1:], Desc: true})
+			} else {
+				idx.Keys = append(idx.Keys, IndexKey{MongoFieldName: strings.TrimPrefix(f, "+")})
+			}
+		}
+		segments = segments[1:]
+	} else {
+		idx.Keys = append(idx.Keys, IndexKey{MongoFieldName: fieldName})
+	}
+
+	for _, seg := range segments {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			continue
+		}
+
+		key, value := seg, ""
+		if eqIndex := strings.Index(seg, "="); eqIndex != -1 {
+			key, value = strings.TrimSpace(seg[:eqIndex]), strings.TrimSpace(seg[eqIndex+1:])
+		}
+
+		switch key {
+		case indexUnique:
+			idx.Unique = true
+		case indexSparse:
+			idx.Sparse = true
+		case indexDesc:
+			if fieldName == "" {
+				return nil, fmt.Errorf("index %s: desc can only be used on field, use -field instead", decl)
+			}
+			idx.Keys[0].Desc = true
+		case indexTTL:
+			ttl, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || ttl <= 0 {
+				return nil, fmt.Errorf("index %s: ttl should be a positive number of seconds", decl)
+			}
+			idx.ExpireAfterSeconds = int32(ttl)
+		case indexName:
+			if value == "" {
+				return nil, fmt.Errorf("index %s: name is empty", decl)
+			}
+			idx.Name = value
+		case indexPartial:
+			decoder := json.NewDecoder(strings.NewReader(value))
+			decoder.UseNumber()
+			if err := decoder.Decode(&idx.PartialFilter); err != nil || idx.PartialFilter == nil {
+				return nil, fmt.Errorf("index %s: partial should be a JSON object filter", decl)
+			}
+		default:
+			return nil, fmt.Errorf("index %s: unsupported option %s, should be unique, sparse, desc, ttl, name, partial",
+				decl, key)
+		}
+	}
+
+	return idx, nil
+}
+
+// checkIndexes is used to check whether the fields of the indexes can be found in the structure.
+func (st *IdlExtractStruct) checkIndexes() error {
+	for _, idx := range st.Indexes {
+		for _, key := range idx.Keys {
+			if !st.hasMongoField(key.MongoFieldName) {
+				return fmt.Errorf("the field %s of the index declared in %s is not found", key.MongoFieldName, st.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (st *IdlExtractStruct) hasMongoField(mongoName string) bool {
+	if mongoName == "_id" {
+		return true
+	}
+	_, _, err := st.GetFieldByMongoName(mongoName)
+	return err == nil
+}
+
+// addSubStructIndexes is used to add the field indexes of the nested structure with the dotted field name.
+func (st *IdlExtractStruct) addSubStructIndexes(sub *IdlExtractStruct, mongoName string) {
+	for _, idx := range sub.Indexes {
+		newIdx := *idx
+		newIdx.Keys = make([]IndexKey, 0, len(idx.Keys))
+		for _, key := range idx.Keys {
+			newIdx.Keys = append(newIdx.Keys, IndexKey{
+				MongoFieldName: mongoName + "." + key.MongoFieldName,
+				Desc:           key.Desc,
+			})
+		}
+		st.Indexes = append(st.Indexes, &newIdx)
+	}
+}
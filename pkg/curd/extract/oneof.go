@@ -0,0 +1,144 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+)
+
+// Oneof is the oneof field of proto, which is stored as the document of the member set in it,
+// such as {"payment": {"card": "..."}}.
+type Oneof struct {
+	// InterfaceName is the unexported interface implemented by the wrappers, such as isOrder_Payment
+	InterfaceName string
+	Members       []*OneofMember
+}
+
+// OneofMember is the member of the oneof, the wrapper has the only field of the member,
+// such as Order_Card{Card: "..."}.
+type OneofMember struct {
+	WrapperType code.Type
+	Field       *StructField
+}
+
+// extractPbOneof extracts the oneof field and its wrappers generated by protoc-gen-go, the oneof and
+// the members are stored by the names in proto unless the members are tagged by go.tag.
+func (info *PbUsedInfo) extractPbOneof(field *ast.Field, oneofName string, astFile *ast.File,
+	visiting map[*ast.StructType]bool,
+) (*StructField, error) {
+	ident, ok := field.Type.(*ast.Ident)
+	if !ok || len(field.Names) == 0 {
+		return nil, fmt.Errorf("unsupported oneof %s", oneofName)
+	}
+	oneof := &Oneof{InterfaceName: ident.Name}
+
+	for _, file := range info.getSamePackageFiles(astFile) {
+		for _, wrapperName := range getOneofWrapperNames(file, ident.Name) {
+			node := getStructNodeByName(file, wrapperName)
+			if node == nil || len(node.Fields.List) != 1 || len(node.Fields.List[0].Names) == 0 {
+				return nil, fmt.Errorf("can not find the wrapper %s of oneof %s", wrapperName, oneofName)
+			}
+			wrapperField := node.Fields.List[0]
+
+			bsonTag := fmt.Sprintf("bson:%q", getPbFieldName(wrapperField))
+			if wrapperField.Comment != nil && strings.Contains(wrapperField.Comment.Text(), "go.tag") {
+				if bsonTag = getMongoStTag(wrapperField.Comment.Text()); bsonTag == "" {
+					return nil, fmt.Errorf("there are grammar errors in %s", wrapperField.Comment.Text())
+				}
+			}
+			addPbBsonTag(wrapperField, bsonTag)
+
+			sf := &StructField{
+				Name: wrapperField.Names[0].Name,
+				Type: getType(wrapperField.Type, file.Name.Name, true),
+				Tag:  handleTagOmitempty(bsonTag),
+			}
+			// the fields of the message members are tagged, but they are not queried
+			node, name, f, err := info.getPbStruct(wrapperField.Type, file)
+			if err != nil {
+				return nil, fmt.Errorf("can not find the member %s of oneof %s: %s", sf.Name, oneofName, err.Error())
+			}
+			if node != nil && !visiting[node] {
+				rs := &IdlExtractStruct{
+					Name:         name,
+					StructFields: make([]*StructField, 0, 10),
+				}
+				visiting[node] = true
+				if err = info.extractPbGoStructFields(node, rs, f, visiting); err != nil {
+					return nil, err
+				}
+				delete(visiting, node)
+				sf.IsBelongedToStruct = true
+				sf.BelongedToStruct = rs
+			}
+
+			oneof.Members = append(oneof.Members, &OneofMember{
+				WrapperType: code.StarExprType{
+					RealType: code.SelectorExprType{
+						X:   file.Name.Name,
+						Sel: wrapperName,
+					},
+				},
+				Field: sf,
+			})
+		}
+	}
+	if len(oneof.Members) == 0 {
+		return nil, fmt.Errorf("can not find the wrappers of oneof %s", oneofName)
+	}
+
+	bsonTag := fmt.Sprintf("bson:%q", oneofName)
+	addPbBsonTag(field, bsonTag)
+	return &StructField{
+		Name:  field.Names[0].Name,
+		Type:  code.InterfaceType{},
+		Tag:   reflect.StructTag(bsonTag),
+		Oneof: oneof,
+	}, nil
+}
+
+// getOneofWrapperNames returns the wrappers implementing the oneof interface by the method with the same name,
+// such as func (*Order_Card) isOrder_Payment() {}.
+func getOneofWrapperNames(file *ast.File, interfaceName string) (names []string) {
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 || funcDecl.Name.Name != interfaceName {
+			continue
+		}
+		if starExpr, ok := funcDecl.Recv.List[0].Type.(*ast.StarExpr); ok {
+			if ident, ok := starExpr.X.(*ast.Ident); ok {
+				names = append(names, ident.Name)
+			}
+		}
+	}
+	return
+}
+
+// getPbFieldName returns the name of the field in proto, such as card of protobuf:"bytes,5,opt,name=card,proto3,oneof".
+func getPbFieldName(field *ast.Field) string {
+	for _, option := range strings.Split(getPbTag(field, "protobuf"), ",") {
+		if strings.HasPrefix(option, "name=") {
+			return strings.TrimPrefix(option, "name=")
+		}
+	}
+	return strings.ToLower(field.Names[0].Name)
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+)
+
+const mongoOptions = "mongo.options"
+
+const (
+	optionTimestamps = "timestamps"
+	optionSoftDelete = "soft_delete"
+	optionVersion    = "version"
+)
+
+// The mongo field names of the fields maintained by the model options.
+const (
+	CreatedAtField = "created_at"
+	UpdatedAtField = "updated_at"
+	DeletedAtField = "deleted_at"
+	VersionField   = "version"
+)
+
+// ModelOptions stores the options declared by the mongo.options annotation of the structure, such as
+// "timestamps,soft_delete,version".
+//
+//	timestamps: Insert sets created_at and updated_at, Update sets updated_at, they are int64 unix milliseconds,
+//	  the whole entity keeps created_at of the stored document by the update pipeline which requires MongoDB 4.2
+//	soft_delete: Delete sets deleted_at instead of removing the documents, the queries skip the documents with deleted_at
+//	version: Update increases version, the update of the whole entity also requires the version to be matched
+type ModelOptions struct {
+	Timestamps bool
+	SoftDelete bool
+	Version    bool
+}
+
+func (o ModelOptions) IsEmpty() bool {
+	return !o.Timestamps && !o.SoftDelete && !o.Version
+}
+
+// parseModelOptions is used to parse the options separated by commas into options.
+func parseModelOptions(decl string, options *ModelOptions) error {
+	for _, option := range strings.Split(decl, ",") {
+		switch strings.TrimSpace(option) {
+		case optionTimestamps:
+			options.Timestamps = true
+		case optionSoftDelete:
+			options.SoftDelete = true
+		case optionVersion:
+			options.Version = true
+		case "":
+		default:
+			return fmt.Errorf("unknown option %s in mongo.options, should be %s, %s or %s",
+				strings.TrimSpace(option), optionTimestamps, optionSoftDelete, optionVersion)
+		}
+	}
+	return nil
+}
+
+// checkOptions checks the fields required by the model options.
+func (st *IdlExtractStruct) checkOptions() error {
+	if st.Options.Timestamps {
+		for _, name := range []string{CreatedAtField, UpdatedAtField} {
+			if err := st.checkOptionField(optionTimestamps, name, isInt64Type); err != nil {
+				return err
+			}
+		}
+	}
+	if st.Options.SoftDelete {
+		if err := st.checkOptionField(optionSoftDelete, DeletedAtField, isInt64Type); err != nil {
+			return err
+		}
+		// the queries skip the documents with deleted_at, so it must be absent before the deletion
+		field := st.getField(DeletedAtField)
+		if _, ok := field.Type.(code.StarExprType); !ok && !field.OmitEmpty {
+			return fmt.Errorf("%s: the field %s required by %s must be a pointer or tagged with omitempty",
+				st.Name, DeletedAtField, optionSoftDelete)
+		}
+	}
+	if st.Options.Version {
+		isIntegerType := func(t code.Type) bool {
+			return t.RealName() == "int32" || t.RealName() == "int64"
+		}
+		if err := st.checkOptionField(optionVersion, VersionField, isIntegerType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *IdlExtractStruct) checkOptionField(option, mongoName string, isValidType func(t code.Type) bool) error {
+	field := st.getField(mongoName)
+	if field == nil {
+		return fmt.Errorf("%s: %s requires the field tagged with bson %s", st.Name, option, mongoName)
+	}
+	if !isValidType(field.Type) {
+		return fmt.Errorf("%s: the type %s of the field %s is not supported by %s", st.Name, field.Type.RealName(),
+			mongoName, option)
+	}
+	return nil
+}
+
+// getField returns the top-level field of the mongo field name, nil if it is not found.
+func (st *IdlExtractStruct) getField(mongoName string) *StructField {
+	for _, field := range st.StructFields {
+		if field.Tag.Get(bson) == mongoName {
+			return field
+		}
+	}
+	return nil
+}
+
+func isInt64Type(t code.Type) bool {
+	if starType, ok := t.(code.StarExprType); ok {
+		t = starType.RealType
+	}
+	return t.RealName() == "int64"
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Position is the position of the annotation which declares a method in the idl file.
+type Position struct {
+	Filename string
+	Line     int // starting at 1, the position is invalid if it is 0
+	Column   int // starting at 1, in bytes
+}
+
+// Overlay maps the paths of the idl files to the contents replacing the files on the disk,
+// such as the unsaved buffers of the editors.
+type Overlay map[string]string
+
+// ReadFile returns the content of the file in the overlay, or the file on the disk if it is not in the overlay.
+func (o Overlay) ReadFile(filename string) ([]byte, error) {
+	if content, ok := o[filename]; ok {
+		return []byte(content), nil
+	}
+	return os.ReadFile(filename)
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return p.Filename
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// locateThriftAnnotations returns the positions of the annotations of the struct in the thrift file,
+// the annotations are searched after the declaration of the struct in order.
+func locateThriftAnnotations(overlay Overlay, filename, structName string, tags []string) []Position {
+	content, err := overlay.ReadFile(filename)
+	if err != nil {
+		return make([]Position, len(tags))
+	}
+	data := string(content)
+	declaration := regexp.MustCompile(`\bstruct\s+` + regexp.QuoteMeta(structName) + `\b`).FindStringIndex(data)
+	if declaration == nil {
+		return make([]Position, len(tags))
+	}
+
+	positions := make([]Position, 0, len(tags))
+	offset := declaration[1]
+	for _, tag := range tags {
+		index := indexAnnotation(data[offset:], mongoPrefix+tag)
+		if index == -1 {
+			positions = append(positions, Position{Filename: filename})
+			continue
+		}
+		offset += index
+		positions = append(positions, getPosition(filename, data, offset))
+	}
+	return positions
+}
+
+// locateProtoAnnotations returns the positions of the annotations of the message in the proto file,
+// the annotations are searched in the comments before the declaration of the message.
+func locateProtoAnnotations(overlay Overlay, filename, messageName string, tags []string) []Position {
+	positions := make([]Position, len(tags))
+	content, err := overlay.ReadFile(filename)
+	if err != nil {
+		return positions
+	}
+	data := string(content)
+	declaration := regexp.MustCompile(`\bmessage\s+` + regexp.QuoteMeta(messageName) + `\b`).FindStringIndex(data)
+	if declaration == nil {
+		return positions
+	}
+
+	end := declaration[0]
+	for index := len(tags) - 1; index >= 0; index-- {
+		offset := lastIndexAnnotation(data[:end], mongoPrefix+tags[index])
+		if offset == -1 {
+			positions[index] = Position{Filename: filename}
+			continue
+		}
+		end = offset
+		positions[index] = getPosition(filename, data, offset)
+	}
+	return positions
+}
+
+// indexAnnotation returns the index of the first annotation key in s which is not a prefix of another key.
+func indexAnnotation(s, key string) int {
+	for offset := 0; ; {
+		index := strings.Index(s[offset:], key)
+		if index == -1 {
+			return -1
+		}
+		next := offset + index + len(key)
+		if next == len(s) || !isIdentifierByte(s[next]) {
+			return offset + index
+		}
+		offset = next
+	}
+}
+
+// lastIndexAnnotation returns the index of the last annotation key in s which is not a prefix of another key.
+func lastIndexAnnotation(s, key string) int {
+	for end := len(s); ; {
+		index := strings.LastIndex(s[:end], key)
+		if index == -1 {
+			return -1
+		}
+		next := index + len(key)
+		if next == len(s) || !isIdentifierByte(s[next]) {
+			return index
+		}
+		end = index
+	}
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+func getPosition(filename, data string, offset int) Position {
+	line := strings.Count(data[:offset], "\n") + 1
+	return Position{
+		Filename: filename,
+		Line:     line,
+		Column:   offset - strings.LastIndex(data[:offset], "\n"),
+	}
+}
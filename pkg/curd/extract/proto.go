@@ -25,6 +25,8 @@ import (
 	"go/token"
 	"io/fs"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/cwgo/pkg/consts"
@@ -39,6 +41,8 @@ type PbUsedInfo struct {
 	DocArgs     *config.DocArgument
 	astFiles    []*pbGoFileInfo
 	ImportPaths []string
+	// Overlay is the contents of the idl files which are not saved, the annotations are located in them
+	Overlay Overlay
 }
 
 type pbGoFileInfo struct {
@@ -71,6 +75,8 @@ func (info *PbUsedInfo) ParsePbIdl() (rawStructs []*IdlExtractStruct, err error)
 									continue
 								}
 								rawStruct := newIdlExtractStruct(tp.Name.Name)
+								rawStruct.ModelPkg = astFile.astFile.Name.Name
+								rawStruct.ModelDir = filepath.Dir(astFile.path)
 								if err = info.extractPbGoStruct(stp, rawStruct, astFile.astFile); err != nil {
 									return nil, err
 								}
@@ -82,16 +88,71 @@ func (info *PbUsedInfo) ParsePbIdl() (rawStructs []*IdlExtractStruct, err error)
 										return nil, err
 									}
 
-									tokens, methods, err := getMongoIfTag(stc.Doc.Text())
+									tags, values, err := getMongoIfTag(stc.Doc.Text())
 									if err != nil {
 										return nil, err
 									}
+									tokens := make([]string, 0, len(tags))
 									ifMethods := ""
-									for _, m := range methods {
-										ifMethods += m + "\n"
+									queryAnnotations := make(map[string]*QueryAnnotation)
+									txnOptions := make(map[string]*TransactionOptions)
+									for i, tag := range tags {
+										if mongoPrefix+tag == mongoIndex {
+											idx, err := parseIndex(values[i], "")
+											if err != nil {
+												return nil, err
+											}
+											rawStruct.Indexes = append(rawStruct.Indexes, idx)
+											continue
+										}
+										if mongoPrefix+tag == mongoOptions {
+											if err = parseModelOptions(values[i], &rawStruct.Options); err != nil {
+												return nil, err
+											}
+											continue
+										}
+										if mongoPrefix+tag == mongoCollection {
+											if rawStruct.Collection, err = parseCollectionName(values[i]); err != nil {
+												return nil, err
+											}
+											continue
+										}
+										if mongoPrefix+tag == mongoDatabase {
+											if rawStruct.Database, err = parseDatabaseName(values[i]); err != nil {
+												return nil, err
+											}
+											continue
+										}
+										if addQueryAnnotation(queryAnnotations, mongoPrefix+tag, values[i]) {
+											continue
+										}
+										if ok, err := addTransactionOptions(txnOptions, mongoPrefix+tag, values[i]); ok {
+											if err != nil {
+												return nil, err
+											}
+											continue
+										}
+										if isMongoOptionKey(mongoPrefix + tag) {
+											continue
+										}
+										tokens = append(tokens, tag)
+										ifMethods += values[i] + "\n"
+									}
+									if err = rawStruct.checkIndexes(); err != nil {
+										return nil, err
+									}
+									if err = rawStruct.checkOptions(); err != nil {
+										return nil, err
 									}
 									rawInterface := fmt.Sprintf("package main\ntype %sInterface interface{\n%s\n}", tp.Name.Name, ifMethods)
-									if err = extractIdlInterface(rawInterface, rawStruct, tokens); err != nil {
+									positions := locateProtoAnnotations(info.Overlay, info.DocArgs.IdlPath, tp.Name.Name, tokens)
+									if err = extractIdlInterface(rawInterface, rawStruct, tokens, positions); err != nil {
+										return nil, err
+									}
+									if err = rawStruct.bindQueryAnnotations(queryAnnotations); err != nil {
+										return nil, err
+									}
+									if err = rawStruct.bindTransactionOptions(txnOptions); err != nil {
 										return nil, err
 									}
 								}
@@ -102,11 +163,30 @@ func (info *PbUsedInfo) ParsePbIdl() (rawStructs []*IdlExtractStruct, err error)
 			}
 		}
 	}
+	linkModels(rawStructs)
 	return
 }
 
 func (info *PbUsedInfo) extractPbGoStruct(stNode *ast.StructType, rawStruct *IdlExtractStruct, astFile *ast.File) error {
+	return info.extractPbGoStructFields(stNode, rawStruct, astFile, map[*ast.StructType]bool{stNode: true})
+}
+
+// extractPbGoStructFields extracts the fields of the structure, the structures being extracted are recorded in
+// visiting, so the recursive messages are extracted as the plain fields.
+func (info *PbUsedInfo) extractPbGoStructFields(stNode *ast.StructType, rawStruct *IdlExtractStruct, astFile *ast.File,
+	visiting map[*ast.StructType]bool,
+) error {
 	for _, field := range stNode.Fields.List {
+		// the oneof fields have no comments, they are stored by the names of the oneofs
+		if oneofName := getPbTag(field, "protobuf_oneof"); oneofName != "" {
+			sf, err := info.extractPbOneof(field, oneofName, astFile, visiting)
+			if err != nil {
+				return err
+			}
+			rawStruct.StructFields = append(rawStruct.StructFields, sf)
+			continue
+		}
+
 		if field.Comment != nil {
 			if strings.Contains(field.Comment.Text(), "go.tag") &&
 				strings.Contains(field.Comment.Text(), bson) {
@@ -114,96 +194,225 @@ func (info *PbUsedInfo) extractPbGoStruct(stNode *ast.StructType, rawStruct *Idl
 				if comment == "" {
 					return fmt.Errorf("there are grammar errors in %s", field.Comment.Text())
 				}
+				addPbBsonTag(field, comment)
 
-				if field.Tag == nil {
-					field.Tag = &ast.BasicLit{Kind: token.STRING, Value: comment}
-				} else {
-					if !strings.Contains(field.Tag.Value, bson) {
-						field.Tag = &ast.BasicLit{Kind: token.STRING, Value: field.Tag.Value[0:len(field.Tag.Value)-1] + " " + comment + "`"}
+				tag := handleTagOmitempty(comment)
+
+				if indexDecl, ok := getMongoFieldIndexTag(field.Comment.Text()); ok {
+					idx, err := parseIndex(indexDecl, tag.Get(bson))
+					if err != nil {
+						return err
 					}
+					rawStruct.Indexes = append(rawStruct.Indexes, idx)
 				}
 
-				tag := handleTagOmitempty(comment)
-
 				fieldName := field.Names[0].Name
-				t := getType(field.Type, astFile.Name.Name, true)
-				if tt, ok := field.Type.(*ast.StarExpr); ok {
-					// *Struct
-					if ttt, ok := tt.X.(*ast.Ident); ok {
-						rs := &IdlExtractStruct{
-							Name:         ttt.Name,
-							StructFields: make([]*StructField, 0, 10),
-						}
+				sf := &StructField{
+					Name:      fieldName,
+					Type:      getType(field.Type, astFile.Name.Name, true),
+					Tag:       tag,
+					OmitEmpty: isTagOmitempty(comment),
+					// the required fields of proto2 are tagged with req
+					Required: field.Tag != nil && strings.Contains(field.Tag.Value, ",req,"),
+					Enum:     info.getPbEnumValues(field.Type, astFile),
+				}
 
-						node := getStructNodeByName(astFile, ttt.Name)
-						if node == nil {
-							return fmt.Errorf("can not find %s field in struct %s", fieldName, rawStruct.Name)
-						}
-						if err := info.extractPbGoStruct(node, rs, astFile); err != nil {
-							return err
-						}
-						rawStruct.StructFields = append(rawStruct.StructFields, &StructField{
-							Name:               fieldName,
-							Type:               t,
-							Tag:                tag,
-							IsBelongedToStruct: true,
-							BelongedToStruct:   rs,
-						})
+				// the fields of the nested messages are queried by the dotted paths, such as address.city,
+				// the well-known types and the scalars of proto3 optional are the plain fields
+				node, name, f, err := info.getPbStruct(field.Type, astFile)
+				if err != nil {
+					return fmt.Errorf("can not find %s field in struct %s: %s", fieldName, rawStruct.Name, err.Error())
+				}
+				if node != nil && !visiting[node] {
+					rs := &IdlExtractStruct{
+						Name:         name,
+						StructFields: make([]*StructField, 0, 10),
 					}
-					// *pkgName.Struct
-					if ttt, ok := tt.X.(*ast.SelectorExpr); ok {
-						tttX := ttt.X.(*ast.Ident)
-						astFiles := info.getAstFileByDir(tttX.Name)
-						// provided by proto
-						if len(astFiles) == 0 {
-							rawStruct.StructFields = append(rawStruct.StructFields, &StructField{
-								Name: fieldName,
-								Type: t,
-								Tag:  tag,
-							})
-						} else {
-							var node *ast.StructType
-							var f *ast.File
-							for _, file := range astFiles {
-								node = getStructNodeByName(file, ttt.Sel.Name)
-								if node != nil {
-									f = file
-									break
-								}
-							}
-							if node == nil {
-								return fmt.Errorf("can not find %s field in struct %s", fieldName, rawStruct.Name)
-							}
-
-							rs := &IdlExtractStruct{
-								Name:         ttt.Sel.Name,
-								StructFields: make([]*StructField, 0, 10),
-							}
-							if err := info.extractPbGoStruct(node, rs, f); err != nil {
-								return err
-							}
-							rawStruct.StructFields = append(rawStruct.StructFields, &StructField{
-								Name:               fieldName,
-								Type:               t,
-								Tag:                tag,
-								IsBelongedToStruct: true,
-								BelongedToStruct:   rs,
-							})
-						}
+					visiting[node] = true
+					if err = info.extractPbGoStructFields(node, rs, f, visiting); err != nil {
+						return err
 					}
-				} else {
-					rawStruct.StructFields = append(rawStruct.StructFields, &StructField{
-						Name: fieldName,
-						Type: t,
-						Tag:  tag,
-					})
+					delete(visiting, node)
+					rawStruct.addSubStructIndexes(rs, tag.Get(bson))
+					sf.Enum = nil
+					sf.IsBelongedToStruct = true
+					sf.BelongedToStruct = rs
+				}
+				if sf.ElementStruct, err = info.extractPbElementStruct(field.Type, astFile, visiting); err != nil {
+					return fmt.Errorf("can not find %s field in struct %s: %s", fieldName, rawStruct.Name, err.Error())
 				}
+				rawStruct.StructFields = append(rawStruct.StructFields, sf)
+			}
+		}
+	}
+	return nil
+}
+
+// getPbStruct returns the message of the pointer type and the file declaring it, nil if the type is not
+// a pointer to the message of the idl, such as the pointers to the scalars or the well-known types.
+func (info *PbUsedInfo) getPbStruct(expr ast.Expr, astFile *ast.File) (*ast.StructType, string, *ast.File, error) {
+	starExpr, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return nil, "", nil, nil
+	}
+
+	var name string
+	var files []*ast.File
+	switch t := starExpr.X.(type) {
+	// *Struct
+	case *ast.Ident:
+		if isGoBaseType(t.Name) {
+			return nil, "", nil, nil
+		}
+		name, files = t.Name, info.getSamePackageFiles(astFile)
+	// *pkgName.Struct
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, "", nil, nil
+		}
+		name, files = t.Sel.Name, info.getAstFileByDir(x.Name)
+		// provided by proto
+		if len(files) == 0 {
+			return nil, "", nil, nil
+		}
+	default:
+		return nil, "", nil, nil
+	}
+
+	for _, file := range files {
+		if node := getStructNodeByName(file, name); node != nil {
+			return node, name, file, nil
+		}
+	}
+	return nil, "", nil, fmt.Errorf("message %s is not found", name)
+}
+
+// extractPbElementStruct extracts the message of the elements of the repeated or map field, nil if the elements
+// are not the messages of the idl.
+func (info *PbUsedInfo) extractPbElementStruct(expr ast.Expr, astFile *ast.File, visiting map[*ast.StructType]bool,
+) (*IdlExtractStruct, error) {
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		expr = t.Elt
+	case *ast.MapType:
+		expr = t.Value
+	default:
+		return nil, nil
+	}
+
+	node, name, f, err := info.getPbStruct(expr, astFile)
+	if err != nil || node == nil || visiting[node] {
+		return nil, err
+	}
+	rs := &IdlExtractStruct{
+		Name:         name,
+		StructFields: make([]*StructField, 0, 10),
+	}
+	visiting[node] = true
+	defer delete(visiting, node)
+	if err = info.extractPbGoStructFields(node, rs, f, visiting); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// getSamePackageFiles returns the pb go files in the directory of the file, the messages of the
+// proto files with the same go package are referenced without the package names.
+func (info *PbUsedInfo) getSamePackageFiles(astFile *ast.File) []*ast.File {
+	for _, pbGo := range info.astFiles {
+		if pbGo.astFile == astFile {
+			return info.getAstFileByDir(pbGo.belongedToDir)
+		}
+	}
+	return []*ast.File{astFile}
+}
+
+// getPbTag returns the value of the key in the struct tag of the field.
+func getPbTag(field *ast.Field, key string) string {
+	if field.Tag == nil {
+		return ""
+	}
+	return reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get(key)
+}
+
+// addPbBsonTag adds the bson tag to the struct tag of the field, which is rewritten to the pb go file.
+func addPbBsonTag(field *ast.Field, bsonTag string) {
+	if field.Tag == nil {
+		field.Tag = &ast.BasicLit{Kind: token.STRING, Value: "`" + strings.Trim(bsonTag, "`") + "`"}
+		return
+	}
+	if !strings.Contains(field.Tag.Value, bson) {
+		field.Tag = &ast.BasicLit{Kind: token.STRING, Value: field.Tag.Value[0:len(field.Tag.Value)-1] + " " + bsonTag + "`"}
+	}
+}
+
+// getPbEnumValues returns the values of the enum type, or the enum type of the elements of the slices,
+// nil if it is not an enum.
+func (info *PbUsedInfo) getPbEnumValues(expr ast.Expr, astFile *ast.File) []int64 {
+	for {
+		arrayType, ok := expr.(*ast.ArrayType)
+		if !ok {
+			break
+		}
+		expr = arrayType.Elt
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		for _, file := range info.getSamePackageFiles(astFile) {
+			if values := getEnumValuesByName(file, t.Name); values != nil {
+				return values
+			}
+		}
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		for _, file := range info.getAstFileByDir(x.Name) {
+			if values := getEnumValuesByName(file, t.Sel.Name); values != nil {
+				return values
 			}
 		}
 	}
 	return nil
 }
 
+// getEnumValuesByName returns the values of the constants of the enum type generated by protoc,
+// such as Status_ACTIVE Status = 1.
+func getEnumValuesByName(astFile *ast.File, name string) (values []int64) {
+	for _, decl := range astFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec, ok := spec.(*ast.ValueSpec)
+			if !ok || len(valueSpec.Values) != 1 {
+				continue
+			}
+			if ident, ok := valueSpec.Type.(*ast.Ident); !ok || ident.Name != name {
+				continue
+			}
+			value, sign := valueSpec.Values[0], int64(1)
+			if unary, ok := value.(*ast.UnaryExpr); ok && unary.Op == token.SUB {
+				value, sign = unary.X, -1
+			}
+			lit, ok := value.(*ast.BasicLit)
+			if !ok || lit.Kind != token.INT {
+				continue
+			}
+			v, err := strconv.ParseInt(lit.Value, 0, 64)
+			if err != nil {
+				continue
+			}
+			values = append(values, sign*v)
+		}
+	}
+	return
+}
+
 func getMongoStTag(s string) (r string) {
 	index := strings.Index(s, "go.tag")
 	leftIndex, rightIndex := -1, -1
@@ -226,6 +435,26 @@ func getMongoStTag(s string) (r string) {
 	}
 }
 
+// getMongoFieldIndexTag is used to get the index declaration in the field comment,
+// such as go.tag = |bson:"age"| mongo.index = |unique|.
+func getMongoFieldIndexTag(s string) (string, bool) {
+	index := strings.Index(s, mongoIndex)
+	if index == -1 {
+		return "", false
+	}
+
+	leftIndex := strings.Index(s[index:], "|")
+	if leftIndex == -1 {
+		return "", false
+	}
+	leftIndex += index
+	rightIndex := strings.Index(s[leftIndex+1:], "|")
+	if rightIndex == -1 {
+		return "", false
+	}
+	return s[leftIndex+1 : leftIndex+1+rightIndex], true
+}
+
 func getMongoIfTag(s string) (tokens, methods []string, err error) {
 	if s == "" {
 		return
@@ -314,6 +543,14 @@ func getPbGoFiles(dir, prefix string) (result []*pbGoFileInfo, paths []string, e
 	return
 }
 
+// WellKnownImportPaths are the import paths of the well-known types of proto indexed by the package names,
+// they are stored as the values by the generated codecs, such as the timestamps as the dates.
+var WellKnownImportPaths = map[string]string{
+	"timestamppb": "google.golang.org/protobuf/types/known/timestamppb",
+	"durationpb":  "google.golang.org/protobuf/types/known/durationpb",
+	"wrapperspb":  "google.golang.org/protobuf/types/known/wrapperspb",
+}
+
 func isGoBaseType(s string) bool {
 	return s == "bool" || s == "int8" || s == "int16" || s == "int32" || s == "int64" || s == "int" ||
 		s == "uint8" || s == "uint16" || s == "uint32" || s == "uint64" || s == "uint" || s == "float32" ||
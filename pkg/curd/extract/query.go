@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	mongoQuery   = "mongo.query."
+	mongoSort    = "mongo.sort."
+	mongoProject = "mongo.project."
+	mongoSkip    = "mongo.skip."
+	mongoLimit   = "mongo.limit."
+)
+
+// QueryAnnotation stores the annotations which declare the Find operation of the method explicitly
+// instead of its method tokens, the annotation keys are suffixed with the method name, such as
+//
+//	mongo.ListActiveAdults = "ListActiveAdults(ctx context.Context, age int32, limit int64) ([]*user.User, error)"
+//	mongo.query.ListActiveAdults = "{banned: false, age: {$gt: $1}}"
+//	mongo.sort.ListActiveAdults = "{age: -1}"
+//	mongo.project.ListActiveAdults = "{username: 1, age: 1}"
+//	mongo.limit.ListActiveAdults = "$2"
+//
+// $N is the placeholder of the Nth parameter of the method after context.Context.
+type QueryAnnotation struct {
+	Query   string
+	Sort    string
+	Project string
+	Skip    string
+	Limit   string
+}
+
+// addQueryAnnotation records the annotation if the key is one of the query annotations.
+func addQueryAnnotation(annotations map[string]*QueryAnnotation, key, value string) bool {
+	for _, prefix := range []string{mongoQuery, mongoSort, mongoProject, mongoSkip, mongoLimit} {
+		if strings.Index(key, prefix) != 0 || len(key) == len(prefix) {
+			continue
+		}
+		methodName := key[len(prefix):]
+		annotation, ok := annotations[methodName]
+		if !ok {
+			annotation = &QueryAnnotation{}
+			annotations[methodName] = annotation
+		}
+		switch prefix {
+		case mongoQuery:
+			annotation.Query = value
+		case mongoSort:
+			annotation.Sort = value
+		case mongoProject:
+			annotation.Project = value
+		case mongoSkip:
+			annotation.Skip = value
+		case mongoLimit:
+			annotation.Limit = value
+		}
+		return true
+	}
+	return false
+}
+
+// bindQueryAnnotations binds the query annotations to the methods with the same names.
+func (st *IdlExtractStruct) bindQueryAnnotations(annotations map[string]*QueryAnnotation) error {
+	methods := make([]*InterfaceMethod, 0, len(st.InterfaceInfo.Methods)+len(st.PreIfMethods))
+	methods = append(methods, st.InterfaceInfo.Methods...)
+	methods = append(methods, st.PreIfMethods...)
+
+	methodNames := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		methodNames[method.Name] = struct{}{}
+		if annotation, ok := annotations[method.Name]; ok {
+			method.QueryAnnotation = annotation
+		}
+	}
+	for methodName := range annotations {
+		if _, ok := methodNames[methodName]; !ok {
+			return fmt.Errorf("%s: the query annotations of %s are not bound to any method", st.Name, methodName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"go/ast"
+	astParser "go/parser"
+	"go/token"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	regionBeginRegexp = regexp.MustCompile(`^// Code generated by cwgo \((\w+), ([0-9a-f]{8})\)\. DO NOT EDIT\.$`)
+	regionEndRegexp   = regexp.MustCompile(`^// End of code generated by cwgo \((\w+)\)\.$`)
+)
+
+// GeneratedRegion is the region of the method generated by cwgo in the repository file, it is enclosed by
+//
+//	// Code generated by cwgo (FindByUsername, 1a2b3c4d). DO NOT EDIT.
+//	func (r *UserRepositoryMongo) FindByUsername(...) ...
+//
+//	// End of code generated by cwgo (FindByUsername).
+//
+// The hash is the checksum of the code when it is generated, so the code edited by hand does not match it.
+// The methods outside the regions are written by hand and they are never modified by cwgo.
+type GeneratedRegion struct {
+	Name string
+	Hash string
+	// Start is the offset of the begin marker and End is the offset after the end marker line
+	Start int
+	End   int
+	// Code is the code between the markers
+	Code string
+}
+
+// IsEdited reports whether the code in the region is edited by hand after it is generated.
+func (r *GeneratedRegion) IsEdited() bool {
+	return HashGeneratedCode(r.Code) != r.Hash
+}
+
+// GetGeneratedRegion returns the code of the method enclosed by the markers, the end marker is separated
+// by a blank line as gofmt does.
+func GetGeneratedRegion(name, code string) string {
+	return fmt.Sprintf("// Code generated by cwgo (%s, %s). DO NOT EDIT.\n%s\n// End of code generated by cwgo (%s).\n",
+		name, HashGeneratedCode(code), code, name)
+}
+
+// HashGeneratedCode returns the checksum of the code, the whitespaces are ignored because the code is formatted
+// together with the whole file.
+func HashGeneratedCode(code string) string {
+	h := fnv.New32a()
+	for _, r := range code {
+		if !unicode.IsSpace(r) {
+			h.Write([]byte(string(r)))
+		}
+	}
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// GetGeneratedRegions returns the generated regions in the order of their occurrences in the file content.
+func GetGeneratedRegions(content string) (regions []*GeneratedRegion, err error) {
+	var current *GeneratedRegion
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		lineStart := offset
+		offset += len(line)
+		trimmed := strings.TrimSpace(line)
+
+		if match := regionBeginRegexp.FindStringSubmatch(trimmed); match != nil {
+			if current != nil {
+				return nil, fmt.Errorf("the generated region of %s is not ended before the region of %s",
+					current.Name, match[1])
+			}
+			current = &GeneratedRegion{
+				Name:  match[1],
+				Hash:  match[2],
+				Start: lineStart,
+			}
+			continue
+		}
+
+		if match := regionEndRegexp.FindStringSubmatch(trimmed); match != nil {
+			if current == nil || current.Name != match[1] {
+				return nil, fmt.Errorf("the end of the generated region of %s has no beginning", match[1])
+			}
+			current.End = offset
+			current.Code = content[current.Start+strings.Index(content[current.Start:], "\n")+1 : lineStart]
+			regions = append(regions, current)
+			current = nil
+		}
+	}
+	if current != nil {
+		return nil, fmt.Errorf("the generated region of %s is not ended", current.Name)
+	}
+
+	return regions, nil
+}
+
+// getUserMethodNames returns the names of the methods outside the generated regions, which are written by hand
+// or generated by the versions of cwgo without the regions.
+func getUserMethodNames(content string, regions []*GeneratedRegion) (result []string, err error) {
+	fSet := token.NewFileSet()
+	f, err := astParser.ParseFile(fSet, "", content, astParser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	for _, decl := range f.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil {
+			continue
+		}
+
+		offset := fSet.Position(funcDecl.Pos()).Offset
+		isGenerated := false
+		for _, region := range regions {
+			if offset >= region.Start && offset < region.End {
+				isGenerated = true
+				break
+			}
+		}
+		if !isGenerated {
+			result = append(result, funcDecl.Name.Name)
+		}
+	}
+
+	return
+}
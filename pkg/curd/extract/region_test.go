@@ -0,0 +1,140 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const regionCode = "func (r *UserRepositoryMongo) Ping() error {\n\treturn nil\n}\n"
+
+func TestGetGeneratedRegions(t *testing.T) {
+	region := GetGeneratedRegion("Ping", regionCode)
+	edited := strings.Replace(region, "return nil", "// edited by hand\n\treturn nil", 1)
+	begin := strings.SplitAfter(region, "\n")[0]
+	end := "// End of code generated by cwgo (Ping).\n"
+
+	tests := []struct {
+		name    string
+		content string
+		names   []string
+		edited  []bool
+		wantErr string
+	}{
+		{
+			name:    "generated",
+			content: "package user\n\n" + region,
+			names:   []string{"Ping"},
+			edited:  []bool{false},
+		},
+		{
+			name:    "reformatted",
+			content: "package user\n\n" + strings.Replace(region, "\treturn nil", "        return   nil", 1),
+			names:   []string{"Ping"},
+			edited:  []bool{false},
+		},
+		{
+			name:    "edited by hand",
+			content: "package user\n\n" + edited,
+			names:   []string{"Ping"},
+			edited:  []bool{true},
+		},
+		{
+			name:    "several regions",
+			content: "package user\n\n" + region + "\n" + GetGeneratedRegion("Close", "func Close() {}\n"),
+			names:   []string{"Ping", "Close"},
+			edited:  []bool{false, false},
+		},
+		{
+			name:    "no regions",
+			content: "package user\n\n" + regionCode,
+		},
+		{
+			name:    "damaged hash",
+			content: "package user\n\n" + strings.Replace(region, "DO NOT EDIT.", "DO NOT EDIT", 1),
+			wantErr: "the end of the generated region of Ping has no beginning",
+		},
+		{
+			name:    "missing end",
+			content: "package user\n\n" + begin + regionCode,
+			wantErr: "the generated region of Ping is not ended",
+		},
+		{
+			name:    "missing beginning",
+			content: "package user\n\n" + regionCode + end,
+			wantErr: "the end of the generated region of Ping has no beginning",
+		},
+		{
+			name:    "nested",
+			content: "package user\n\n" + begin + GetGeneratedRegion("Close", "func Close() {}\n") + end,
+			wantErr: "the generated region of Ping is not ended before the region of Close",
+		},
+		{
+			name:    "mismatched end",
+			content: "package user\n\n" + begin + regionCode + "// End of code generated by cwgo (Close).\n",
+			wantErr: "the end of the generated region of Close has no beginning",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions, err := GetGeneratedRegions(tt.content)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			var edited []bool
+			for _, region := range regions {
+				names = append(names, region.Name)
+				edited = append(edited, region.IsEdited())
+				if !strings.HasPrefix(tt.content[region.Start:region.End], "// Code generated by cwgo ("+region.Name) {
+					t.Errorf("region %s starts at %q", region.Name, tt.content[region.Start:region.End])
+				}
+			}
+			if !reflect.DeepEqual(names, tt.names) || !reflect.DeepEqual(edited, tt.edited) {
+				t.Errorf("expected regions %v edited %v, got %v edited %v", tt.names, tt.edited, names, edited)
+			}
+		})
+	}
+}
+
+func TestGetUserMethodNames(t *testing.T) {
+	content := "package user\n\n" +
+		GetGeneratedRegion("Ping", regionCode) + "\n" +
+		"// Close is written by hand.\nfunc (r *UserRepositoryMongo) Close() error {\n\treturn nil\n}\n\n" +
+		"func helper() {}\n"
+	regions, err := GetGeneratedRegions(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := getUserMethodNames(content, regions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"Close"}) {
+		t.Errorf("expected [Close], got %v", names)
+	}
+}
@@ -25,6 +25,8 @@ import (
 	"go/token"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/cwgo/pkg/consts"
@@ -45,15 +47,24 @@ type ThriftUsedInfo struct {
 	Req         *plugin.Request
 	DocArgs     *config.DocArgument
 	ImportPaths []string
+	// Overlay is the contents of the idl files which are not saved, the annotations are located in them
+	Overlay Overlay
 }
 
 func (info *ThriftUsedInfo) ParseThriftIdl() (rawStructs []*IdlExtractStruct, err error) {
 	info.ImportPaths = make([]string, 0, 10)
 
+	// the idls included by several idls are parsed once
+	visited := make(map[*parser.Thrift]bool)
 	var getGenGoFilePath func(file *parser.Thrift) error
 	getGenGoFilePath = func(file *parser.Thrift) error {
+		if visited[file] {
+			return nil
+		}
+		visited[file] = true
+
 		importPath := filepath.Join(info.DocArgs.PackagePrefix,
-			strings.ReplaceAll(file.Namespaces[0].Name, ".", consts.Slash))
+			strings.ReplaceAll(file.GetNamespaceOrReferenceName("go"), ".", consts.Slash))
 		importPath = strings.ReplaceAll(importPath, consts.BackSlash, consts.Slash)
 		info.ImportPaths = append(info.ImportPaths, importPath)
 
@@ -67,6 +78,9 @@ func (info *ThriftUsedInfo) ParseThriftIdl() (rawStructs []*IdlExtractStruct, er
 			}
 			if hasInterface {
 				rawStruct := newIdlExtractStruct(util.CamelString(st.Name))
+				rawStruct.ModelPkg = getThriftGoPkgName(file)
+				rawStruct.ModelDir = filepath.Join(info.DocArgs.ModelDir,
+					strings.ReplaceAll(file.GetNamespaceOrReferenceName("go"), ".", consts.Slash))
 				if err = extractIdlStruct(st, file, rawStruct); err != nil {
 					return err
 				}
@@ -76,19 +90,76 @@ func (info *ThriftUsedInfo) ParseThriftIdl() (rawStructs []*IdlExtractStruct, er
 
 					tokens := make([]string, 0, 10)
 					methods := ""
+					queryAnnotations := make(map[string]*QueryAnnotation)
+					txnOptions := make(map[string]*TransactionOptions)
 					for _, anno := range st.Annotations {
+						if anno.Key == mongoIndex {
+							for _, value := range anno.GetValues() {
+								idx, err := parseIndex(value, "")
+								if err != nil {
+									return err
+								}
+								rawStruct.Indexes = append(rawStruct.Indexes, idx)
+							}
+							continue
+						}
+						if anno.Key == mongoOptions {
+							for _, value := range anno.GetValues() {
+								if err = parseModelOptions(value, &rawStruct.Options); err != nil {
+									return err
+								}
+							}
+							continue
+						}
+						if anno.Key == mongoCollection {
+							if rawStruct.Collection, err = parseCollectionName(anno.GetValues()[0]); err != nil {
+								return err
+							}
+							continue
+						}
+						if anno.Key == mongoDatabase {
+							if rawStruct.Database, err = parseDatabaseName(anno.GetValues()[0]); err != nil {
+								return err
+							}
+							continue
+						}
+						if addQueryAnnotation(queryAnnotations, anno.Key, anno.GetValues()[0]) {
+							continue
+						}
+						if ok, err := addTransactionOptions(txnOptions, anno.Key, anno.GetValues()[0]); ok {
+							if err != nil {
+								return err
+							}
+							continue
+						}
+						if isMongoOptionKey(anno.Key) {
+							continue
+						}
 						if strings.Index(anno.Key, "mongo.") == 0 {
 							methods += anno.GetValues()[0] + "\n"
 							tokens = append(tokens, anno.Key[6:])
 						}
 					}
+					if err = rawStruct.checkIndexes(); err != nil {
+						return err
+					}
+					if err = rawStruct.checkOptions(); err != nil {
+						return err
+					}
 
 					if err = rawStruct.recordMongoIfInfo(info.DocArgs.DaoDir); err != nil {
 						return err
 					}
 
 					rawInterface := fmt.Sprintf("package main\ntype %sInterface interface{\n%s\n}", st.Name, methods)
-					if err = extractIdlInterface(rawInterface, rawStruct, tokens); err != nil {
+					positions := locateThriftAnnotations(info.Overlay, file.Filename, st.Name, tokens)
+					if err = extractIdlInterface(rawInterface, rawStruct, tokens, positions); err != nil {
+						return err
+					}
+					if err = rawStruct.bindQueryAnnotations(queryAnnotations); err != nil {
+						return err
+					}
+					if err = rawStruct.bindTransactionOptions(txnOptions); err != nil {
 						return err
 					}
 				}
@@ -104,229 +175,220 @@ func (info *ThriftUsedInfo) ParseThriftIdl() (rawStructs []*IdlExtractStruct, er
 	if err = getGenGoFilePath(info.Req.AST); err != nil {
 		return
 	}
+	linkModels(rawStructs)
 	return
 }
 
 func extractIdlStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *IdlExtractStruct) error {
+	return extractThriftStruct(st, file, rawStruct, map[*parser.StructLike]bool{st: true})
+}
+
+// extractThriftStruct extracts the fields of the structure, the structures being extracted are recorded in
+// visiting, so the recursive structures are extracted as the plain fields.
+func extractThriftStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *IdlExtractStruct,
+	visiting map[*parser.StructLike]bool,
+) error {
 	for _, field := range st.Fields {
 		fag := field.Annotations.Get("go.tag")
 		if len(field.Annotations) > 0 && fag != nil && strings.Contains(fag[0], bson) {
 			tag := handleTagOmitempty(fag[0])
 
-			t := convertThriftType(field.Type, file)
+			t := convertThriftFieldType(field, file)
 			if t == nil {
 				return fmt.Errorf("unsupported type: %s", field.Type.Name)
 			}
-			if isThriftBaseType(field.Type.Name) || isThriftContainerType(field.Type.Name) {
-				sf := &StructField{
-					Name: util.CamelString(field.Name),
-					Type: t,
-					Tag:  tag,
-				}
-				rawStruct.StructFields = append(rawStruct.StructFields, sf)
-			} else if strings.Contains(field.Type.Name, ".") {
-				index := strings.Index(field.Type.Name, ".")
-				fileName := field.Type.Name[:index]
-				structName := field.Type.Name[index+1:]
-
-				var subStruct *parser.StructLike
-				var f *parser.Include
-				for _, f = range file.Includes {
-					name := filepath.Base(f.Reference.Filename)
-					if strings.Contains(name, fileName) {
-						for _, s := range f.Reference.Structs {
-							if s.Name == structName {
-								subStruct = s
-								break
-							}
-						}
-						break
-					}
-				}
 
-				// enum
-				if subStruct == nil {
-					sf := &StructField{
-						Name: util.CamelString(field.Name),
-						Type: t,
-						Tag:  tag,
-					}
-					rawStruct.StructFields = append(rawStruct.StructFields, sf)
-				} else {
-					rs := &IdlExtractStruct{
-						Name:         subStruct.Name,
-						StructFields: make([]*StructField, 0, 10),
-					}
-					if err := extractIdlStruct(subStruct, f.Reference, rs); err != nil {
-						return err
-					}
-					sf := &StructField{
-						Name:               util.CamelString(field.Name),
-						Type:               t,
-						Tag:                tag,
-						IsBelongedToStruct: true,
-						BelongedToStruct:   rs,
-					}
-					rawStruct.StructFields = append(rawStruct.StructFields, sf)
-				}
-			} else {
-				var subStruct *parser.StructLike
-				for _, s := range file.Structs {
-					if field.Type.Name == s.Name {
-						subStruct = s
-						break
-					}
+			for _, value := range field.Annotations.Get(mongoIndex) {
+				idx, err := parseIndex(value, tag.Get(bson))
+				if err != nil {
+					return err
 				}
+				rawStruct.Indexes = append(rawStruct.Indexes, idx)
+			}
 
-				// enum
-				if subStruct == nil {
-					sf := &StructField{
-						Name: util.CamelString(field.Name),
-						Type: t,
-						Tag:  tag,
-					}
-					rawStruct.StructFields = append(rawStruct.StructFields, sf)
-				} else {
-					rs := &IdlExtractStruct{
-						Name:         subStruct.Name,
-						StructFields: make([]*StructField, 0, 10),
-					}
-					if err := extractIdlStruct(subStruct, file, rs); err != nil {
-						return err
-					}
-					sf := &StructField{
-						Name:               util.CamelString(field.Name),
-						Type:               t,
-						Tag:                tag,
-						IsBelongedToStruct: true,
-						BelongedToStruct:   rs,
-					}
-					rawStruct.StructFields = append(rawStruct.StructFields, sf)
+			sf := &StructField{
+				Name:      util.CamelString(field.Name),
+				Type:      t,
+				Tag:       tag,
+				OmitEmpty: isTagOmitempty(fag[0]),
+				Required:  field.Requiredness.IsRequired(),
+				Enum:      getThriftEnumValues(field.Type, file),
+			}
+			// the fields of the nested structures are queried by the dotted paths, such as address.city
+			if subStruct, scope := getThriftStruct(field.Type, file); subStruct != nil && !visiting[subStruct] {
+				rs := &IdlExtractStruct{
+					Name:         subStruct.Name,
+					StructFields: make([]*StructField, 0, 10),
+				}
+				visiting[subStruct] = true
+				if err := extractThriftStruct(subStruct, scope, rs, visiting); err != nil {
+					return err
 				}
+				delete(visiting, subStruct)
+				rawStruct.addSubStructIndexes(rs, tag.Get(bson))
+				sf.IsBelongedToStruct = true
+				sf.BelongedToStruct = rs
 			}
+			rawStruct.StructFields = append(rawStruct.StructFields, sf)
 		}
 	}
 	return nil
 }
 
-func isThriftBaseType(t string) bool {
-	return t == "byte" || t == "i8" || t == "i16" || t == "i32" || t == "i64" ||
-		t == "bool" || t == "string" || t == "double" || t == "binary"
+// resolveThriftType resolves the typedefs of the type, the returned file is the idl declaring the resolved type,
+// in which the names of the resolved type are looked up.
+func resolveThriftType(t *parser.Type, file *parser.Thrift) (*parser.Type, *parser.Thrift) {
+	// thrift rejects the circular typedefs, the depth limit only prevents the malformed ast from looping
+	for depth := 0; depth < 64; depth++ {
+		if t.KeyType != nil || t.ValueType != nil {
+			return t, file
+		}
+		scope, name := lookupThriftScope(t.Name, file)
+		if scope == nil {
+			return t, file
+		}
+		typedef, ok := scope.GetTypedef(name)
+		if !ok {
+			return t, file
+		}
+		t, file = typedef.Type, scope
+	}
+	return t, file
+}
+
+// lookupThriftScope returns the idl declaring the type name and the name in it, the types of the includes
+// are referenced by the base names of the included files, such as base.Address of base.thrift,
+// nil is returned if the include is not found.
+func lookupThriftScope(name string, file *parser.Thrift) (*parser.Thrift, string) {
+	index := strings.LastIndex(name, ".")
+	if index == -1 {
+		return file, name
+	}
+	scope, ok := file.GetReference(name[:index])
+	if !ok {
+		return nil, ""
+	}
+	return scope, name[index+1:]
+}
+
+// getThriftStruct returns the structure of the type and the idl declaring it, nil if it is not a structure.
+func getThriftStruct(t *parser.Type, file *parser.Thrift) (*parser.StructLike, *parser.Thrift) {
+	t, file = resolveThriftType(t, file)
+	if t.KeyType != nil || t.ValueType != nil {
+		return nil, nil
+	}
+	scope, name := lookupThriftScope(t.Name, file)
+	if scope == nil {
+		return nil, nil
+	}
+	for _, st := range scope.GetStructLikes() {
+		if st.Name == name {
+			return st, scope
+		}
+	}
+	return nil, nil
 }
 
-func isThriftContainerType(t string) bool {
-	return t == "map" || t == "set" || t == "list"
+// getThriftGoPkgName returns the go package name of the idl, which is the last part of the go namespace.
+func getThriftGoPkgName(file *parser.Thrift) string {
+	names := strings.Split(file.GetNamespaceOrReferenceName("go"), ".")
+	return names[len(names)-1]
+}
+
+// getThriftEnumValues returns the values of the enum type, or the enum type of the elements of the containers,
+// nil if it is not an enum.
+func getThriftEnumValues(t *parser.Type, file *parser.Thrift) []int64 {
+	t, file = resolveThriftType(t, file)
+	for t.ValueType != nil {
+		t, file = resolveThriftType(t.ValueType, file)
+	}
+
+	scope, name := lookupThriftScope(t.Name, file)
+	if scope == nil {
+		return nil
+	}
+	enum, ok := scope.GetEnum(name)
+	if !ok {
+		return nil
+	}
+	values := make([]int64, 0, len(enum.Values))
+	for _, value := range enum.Values {
+		values = append(values, value.Value)
+	}
+	return values
+}
+
+// convertThriftFieldType returns the go type of the field generated by thriftgo, the optional fields of the base
+// types and the enums without the default values are pointers.
+func convertThriftFieldType(field *parser.Field, file *parser.Thrift) code.Type {
+	t := convertThriftType(field.Type, file)
+	if !field.Requiredness.IsOptional() || field.IsSetDefault() {
+		return t
+	}
+	switch t.(type) {
+	case code.IdentType, code.SelectorExprType:
+		return code.StarExprType{RealType: t}
+	}
+	return t
 }
 
 func convertThriftType(node *parser.Type, file *parser.Thrift) code.Type {
 	if node == nil {
 		return nil
 	}
+	node, file = resolveThriftType(node, file)
 
-	if node.KeyType == nil && node.ValueType == nil {
-		if v, ok := thriftBaseTypeMap[node.Name]; ok {
-			return code.IdentType(v)
-		} else if node.Name == "binary" {
-			return code.SliceType{
-				ElementType: code.IdentType("byte"),
-			}
-		} else if strings.Contains(node.Name, ".") {
-			index := strings.Index(node.Name, ".")
-			fileName := node.Name[:index]
-			structName := node.Name[index+1:]
-
-			var ff *parser.Include
-			isSt := false
-			isEnum := false
-			for _, f := range file.Includes {
-				name := filepath.Base(f.Reference.Filename)
-				if strings.Contains(name, fileName) {
-					for _, s := range f.Reference.Structs {
-						if s.Name == structName {
-							ff = f
-							isSt = true
-							break
-						}
-					}
-					for _, s := range f.Reference.Enums {
-						if s.Name == structName {
-							ff = f
-							isEnum = true
-							break
-						}
-					}
-					break
-				}
-			}
-
-			if ff == nil {
-				return nil
-			}
-			includePackageName := strings.Split(ff.Reference.Namespaces[0].Name, ".")
-			if isSt {
-				return code.StarExprType{
-					RealType: code.SelectorExprType{
-						X:   includePackageName[len(includePackageName)-1],
-						Sel: node.Name[index+1:],
-					},
-				}
-			}
-			if isEnum {
-				return code.SelectorExprType{
-					X:   includePackageName[len(includePackageName)-1],
-					Sel: node.Name[index+1:],
-				}
-			}
-		} else {
-			curPackageName := strings.Split(file.Namespaces[0].Name, ".")
-
-			isSt := false
-			isEnum := false
-			for _, s := range file.Structs {
-				if s.Name == node.Name {
-					isSt = true
-					break
-				}
-			}
-			for _, s := range file.Enums {
-				if s.Name == node.Name {
-					isEnum = true
-					break
-				}
-			}
-			if isSt {
-				// struct
-				return code.StarExprType{
-					RealType: code.SelectorExprType{
-						X:   curPackageName[len(curPackageName)-1],
-						Sel: node.Name,
-					},
-				}
-			} else if isEnum {
-				// enum
-				return code.SelectorExprType{
-					X:   curPackageName[len(curPackageName)-1],
-					Sel: node.Name,
-				}
-			} else {
-				return nil
-			}
+	// map
+	if node.KeyType != nil && node.ValueType != nil {
+		keyType := convertThriftType(node.KeyType, file)
+		valueType := convertThriftType(node.ValueType, file)
+		if keyType == nil || valueType == nil {
+			return nil
+		}
+		return code.MapType{
+			KeyType:   keyType,
+			ValueType: valueType,
 		}
 	}
 
-	if node.KeyType == nil && node.ValueType != nil {
+	// list and set
+	if node.ValueType != nil {
+		elementType := convertThriftType(node.ValueType, file)
+		if elementType == nil {
+			return nil
+		}
 		return code.SliceType{
-			ElementType: convertThriftType(node.ValueType, file),
+			ElementType: elementType,
 		}
 	}
 
-	if node.KeyType != nil && node.ValueType != nil {
-		return code.MapType{
-			KeyType:   convertThriftType(node.KeyType, file),
-			ValueType: convertThriftType(node.ValueType, file),
+	if v, ok := thriftBaseTypeMap[node.Name]; ok {
+		return code.IdentType(v)
+	}
+	if node.Name == "binary" {
+		return code.SliceType{
+			ElementType: code.IdentType("byte"),
 		}
 	}
 
+	scope, name := lookupThriftScope(node.Name, file)
+	if scope == nil {
+		return nil
+	}
+	if st, _ := getThriftStruct(node, file); st != nil {
+		return code.StarExprType{
+			RealType: code.SelectorExprType{
+				X:   getThriftGoPkgName(scope),
+				Sel: name,
+			},
+		}
+	}
+	if _, ok := scope.GetEnum(name); ok {
+		return code.SelectorExprType{
+			X:   getThriftGoPkgName(scope),
+			Sel: name,
+		}
+	}
 	return nil
 }
 
@@ -341,15 +403,27 @@ var thriftBaseTypeMap = map[string]string{
 	"double": "float64",
 }
 
+// isTagOmitempty reports whether the bson tag has the omitempty option.
+func isTagOmitempty(s string) bool {
+	value := reflect.StructTag(strings.Trim(s, "`")).Get(bson)
+	for _, option := range strings.Split(value, ",")[1:] {
+		if option == "omitempty" {
+			return true
+		}
+	}
+	return false
+}
+
+// handleTagOmitempty returns the struct tag whose bson value is the field name without the options such as omitempty,
+// the tags of the other keys such as gorm are kept.
 func handleTagOmitempty(s string) reflect.StructTag {
-	commaIndex := strings.Index(s, ",")
-	var tag reflect.StructTag
-	if commaIndex == -1 {
-		tag = reflect.StructTag(s)
-	} else {
-		tag = reflect.StructTag(s[:commaIndex] + "\"")
+	tag := strings.Trim(s, "`")
+	value, ok := reflect.StructTag(tag).Lookup(bson)
+	if !ok {
+		return reflect.StructTag(tag)
 	}
-	return tag
+	name := strings.Split(value, ",")[0]
+	return reflect.StructTag(strings.Replace(tag, bson+":"+strconv.Quote(value), bson+":"+strconv.Quote(name), 1))
 }
 
 func AddMongoModelImports(data string, impt []string) (string, error) {
@@ -359,8 +433,15 @@ func AddMongoModelImports(data string, impt []string) (string, error) {
 		return "", err
 	}
 
-	for _, imp := range impt {
-		if strings.Contains(data, filepath.Base(imp)+".") {
+	// the well-known types of proto are referenced by the models and the methods
+	imports := make([]string, 0, len(impt)+len(WellKnownImportPaths))
+	imports = append(imports, impt...)
+	for _, imp := range WellKnownImportPaths {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports[len(impt):])
+	for _, imp := range imports {
+		if isPackageUsed(file, filepath.Base(imp)) {
 			flag := false
 			ast.Inspect(file, func(n ast.Node) bool {
 				if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == imp {
@@ -382,3 +463,19 @@ func AddMongoModelImports(data string, impt []string) (string, error) {
 
 	return buf.String(), nil
 }
+
+// isPackageUsed reports whether the selectors of the package are used in the file, the identifiers
+// resolved to the local declarations such as the parameters named by the package are skipped,
+// and so are the strings containing the package name such as the bson path address.city.
+func isPackageUsed(file *ast.File, pkgName string) bool {
+	used := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if selector, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok && ident.Name == pkgName && ident.Obj == nil {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
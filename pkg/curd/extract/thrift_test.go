@@ -0,0 +1,43 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandleTagOmitempty(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want reflect.StructTag
+	}{
+		{name: "bson", tag: `bson:"username"`, want: `bson:"username"`},
+		{name: "omitempty", tag: `bson:"username,omitempty"`, want: `bson:"username"`},
+		{name: "other tags kept", tag: `json:"name,omitempty" bson:"username,omitempty" gorm:"column:user_name"`,
+			want: `json:"name,omitempty" bson:"username" gorm:"column:user_name"`},
+		{name: "backquoted", tag: "`bson:\"username,omitempty\" gorm:\"size:64\"`", want: `bson:"username" gorm:"size:64"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := handleTagOmitempty(tt.tag); got != tt.want {
+				t.Errorf("handleTagOmitempty(%s) = %s, want %s", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
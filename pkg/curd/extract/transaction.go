@@ -0,0 +1,174 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const mongoTransaction = "mongo.transaction."
+
+const (
+	txnReadConcern    = "read_concern"
+	txnWriteConcern   = "write_concern"
+	txnReadPreference = "read_preference"
+	txnMaxCommitTime  = "max_commit_time"
+	txnMaxRetries     = "max_retries"
+	txnBackoff        = "backoff"
+	txnMaxBackoff     = "max_backoff"
+)
+
+const (
+	defaultTxnBackoff    = 100 * time.Millisecond
+	defaultTxnMaxBackoff = time.Second
+)
+
+// TransactionOptions stores the options of the Transaction method declared by the annotation suffixed with
+// the method name, the options are separated by semicolons, such as
+//
+//	mongo.transaction.TransferBalance = "read_concern=snapshot;write_concern=majority;max_commit_time=5s;max_retries=3"
+//
+//	read_concern: local, majority or snapshot
+//	write_concern: majority or the number of the acknowledged members
+//	read_preference: primary, primaryPreferred, secondary, secondaryPreferred or nearest
+//	max_commit_time: the max time of the commit, such as 500ms
+//	max_retries: the max times of the retries when the error is labeled with TransientTransactionError,
+//	             the commit labeled with UnknownTransactionCommitResult is also retried, 0 means no retry
+//	backoff: the delay before the first retry of the transaction which is doubled after each retry, 100ms by default
+//	max_backoff: the bound of the delay, 1s by default
+type TransactionOptions struct {
+	ReadConcern    string
+	WriteConcern   string
+	ReadPreference string
+	MaxCommitTime  time.Duration
+	MaxRetries     int
+	Backoff        time.Duration
+	MaxBackoff     time.Duration
+}
+
+// HasOptions reports whether the options of options.Transaction() are declared.
+func (o *TransactionOptions) HasOptions() bool {
+	return o.ReadConcern != "" || o.WriteConcern != "" || o.ReadPreference != "" || o.MaxCommitTime != 0
+}
+
+// addTransactionOptions parses and records the options if the key is the transaction annotation.
+func addTransactionOptions(options map[string]*TransactionOptions, key, value string) (bool, error) {
+	if strings.Index(key, mongoTransaction) != 0 || len(key) == len(mongoTransaction) {
+		return false, nil
+	}
+	methodName := key[len(mongoTransaction):]
+	txnOptions, err := parseTransactionOptions(value)
+	if err != nil {
+		return true, fmt.Errorf("%s: %s", key, err.Error())
+	}
+	options[methodName] = txnOptions
+	return true, nil
+}
+
+func parseTransactionOptions(decl string) (*TransactionOptions, error) {
+	options := &TransactionOptions{
+		Backoff:    defaultTxnBackoff,
+		MaxBackoff: defaultTxnMaxBackoff,
+	}
+	for _, option := range strings.Split(decl, ";") {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			return nil, fmt.Errorf("option %s should be key=value", option)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case txnReadConcern:
+			if value != "local" && value != "majority" && value != "snapshot" {
+				return nil, fmt.Errorf("unknown %s %s, should be local, majority or snapshot", key, value)
+			}
+			options.ReadConcern = value
+		case txnWriteConcern:
+			if w, err := strconv.Atoi(value); value != "majority" && (err != nil || w < 0) {
+				return nil, fmt.Errorf("unknown %s %s, should be majority or a non-negative number", key, value)
+			}
+			options.WriteConcern = value
+		case txnReadPreference:
+			switch value {
+			case "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+			default:
+				return nil, fmt.Errorf("unknown %s %s, should be primary, primaryPreferred, secondary, "+
+					"secondaryPreferred or nearest", key, value)
+			}
+			options.ReadPreference = value
+		case txnMaxCommitTime:
+			options.MaxCommitTime, err = parsePositiveDuration(key, value)
+		case txnMaxRetries:
+			options.MaxRetries, err = strconv.Atoi(value)
+			if err != nil || options.MaxRetries < 0 {
+				return nil, fmt.Errorf("%s should be a non-negative number", key)
+			}
+		case txnBackoff:
+			options.Backoff, err = parsePositiveDuration(key, value)
+		case txnMaxBackoff:
+			options.MaxBackoff, err = parsePositiveDuration(key, value)
+		default:
+			return nil, fmt.Errorf("unknown option %s, should be %s, %s, %s, %s, %s, %s or %s", key, txnReadConcern,
+				txnWriteConcern, txnReadPreference, txnMaxCommitTime, txnMaxRetries, txnBackoff, txnMaxBackoff)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.Backoff > options.MaxBackoff {
+		return nil, fmt.Errorf("%s %s is greater than %s %s", txnBackoff, options.Backoff, txnMaxBackoff,
+			options.MaxBackoff)
+	}
+	return options, nil
+}
+
+func parsePositiveDuration(key, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s should be a positive duration, such as 500ms", key)
+	}
+	return d, nil
+}
+
+// bindTransactionOptions binds the transaction options to the methods with the same names.
+func (st *IdlExtractStruct) bindTransactionOptions(options map[string]*TransactionOptions) error {
+	methods := make([]*InterfaceMethod, 0, len(st.InterfaceInfo.Methods)+len(st.PreIfMethods))
+	methods = append(methods, st.InterfaceInfo.Methods...)
+	methods = append(methods, st.PreIfMethods...)
+
+	methodNames := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		methodNames[method.Name] = struct{}{}
+		if txnOptions, ok := options[method.Name]; ok {
+			method.TransactionOptions = txnOptions
+		}
+	}
+	for methodName := range options {
+		if _, ok := methodNames[methodName]; !ok {
+			return fmt.Errorf("%s: the transaction options of %s are not bound to any method", st.Name, methodName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,588 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+// The values of the query annotations are written in the relaxed syntax of the mongo shell,
+// the keys may be unquoted and the strings may be single-quoted, such as
+//
+//	{banned: false, $or: [{age: {$gt: $1}}, {'contact.city': $2}]}
+//
+// the values are parsed into the following types:
+//
+//	document: {key: value, ...} whose keys are in order
+//	[]interface{}: [value, ...]
+//	placeholder: $N which refers to the Nth parameter of the method after context.Context
+//	numberLiteral, stringLiteral, bool and nil: 1.5, 'abc', true and null
+type (
+	document []documentPair
+
+	documentPair struct {
+		key   string
+		value interface{}
+	}
+
+	placeholder   int
+	numberLiteral string
+	stringLiteral string
+)
+
+// queryOperators maps the supported query operators to the comparators of the leaves.
+var queryOperators = map[string]QueryComparator{
+	"$eq":     Equal,
+	"$ne":     NotEqual,
+	"$lt":     LessThan,
+	"$lte":    LessThanEqual,
+	"$gt":     GreaterThan,
+	"$gte":    GreaterThanEqual,
+	"$in":     In,
+	"$nin":    NotIn,
+	"$exists": Exists,
+}
+
+// parseFindAnnotation is used to parse Find whose query, sort, project, skip and limit are
+// declared by the query annotations of the method instead of its tokens.
+func (fp *FindParse) parseFindAnnotation(method *extract.InterfaceMethod) error {
+	if err := fp.check(method); err != nil {
+		return err
+	}
+
+	fp.BelongedToMethod = method
+	ap := &annotationParser{
+		method:     method,
+		referenced: make([]bool, len(method.Params)),
+	}
+	annotation := method.QueryAnnotation
+
+	if err := ap.parseQuery(fp.Query, annotation.Query); err != nil {
+		return newAnnotationError(method, "query", err)
+	}
+	if err := ap.parseSort(fp, annotation.Sort); err != nil {
+		return newAnnotationError(method, "sort", err)
+	}
+	if err := ap.parseProject(fp, annotation.Project); err != nil {
+		return newAnnotationError(method, "project", err)
+	}
+	if annotation.Skip != "" {
+		name, err := ap.parseInt64Param(annotation.Skip)
+		if err != nil {
+			return newAnnotationError(method, "skip", err)
+		}
+		fp.SkipParamName = name
+	}
+	if annotation.Limit != "" {
+		if fp.OperateMode == OperateOne {
+			return newMethodSyntaxError(method.Name, "Limit operation is not supported in Find One mode")
+		}
+		name, err := ap.parseInt64Param(annotation.Limit)
+		if err != nil {
+			return newAnnotationError(method, "limit", err)
+		}
+		fp.LimitParamName = name
+	}
+
+	if err := fp.parseKeyset(method); err != nil {
+		return err
+	}
+
+	for index := 1; index < len(method.Params); index++ {
+		if !ap.referenced[index] {
+			return newMethodSyntaxError(method.Name, fmt.Sprintf("the parameter %s is not referenced by "+
+				"any placeholder, it should be referenced by $%d", method.Params[index].Name, index))
+		}
+	}
+
+	return nil
+}
+
+// newAnnotationError creates syntaxError of the annotation, the syntaxError is returned as it is.
+func newAnnotationError(method *extract.InterfaceMethod, kind string, err error) error {
+	var syntaxErr methodSyntaxError
+	if errors.As(err, &syntaxErr) {
+		return err
+	}
+	return newMethodSyntaxError(method.Name, fmt.Sprintf("mongo.%s.%s: %s", kind, method.Name, err.Error()))
+}
+
+// annotationParser parses the values of the query annotations of the method.
+type annotationParser struct {
+	method *extract.InterfaceMethod
+	// referenced records the parameters referenced by the placeholders
+	referenced []bool
+}
+
+func (ap *annotationParser) parseQuery(q *Query, value string) error {
+	if strings.TrimSpace(value) == "" {
+		q.QueryMode = All
+		return nil
+	}
+
+	doc, err := parseDocument(value)
+	if err != nil {
+		return err
+	}
+	q.ConnectionOpTree, err = ap.documentTree(doc)
+	if err != nil {
+		return err
+	}
+	if q.ConnectionOpTree == nil {
+		q.QueryMode = All
+	} else {
+		q.QueryMode = By
+	}
+	return nil
+}
+
+func (ap *annotationParser) parseSort(fp *FindParse, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	doc, err := parseDocument(value)
+	if err != nil {
+		return err
+	}
+	for _, pair := range doc {
+		if _, _, err = ap.method.BelongedToStruct.GetFieldByMongoName(pair.key); err != nil {
+			return err
+		}
+		switch pair.value {
+		case numberLiteral("1"):
+			fp.Order.Asc = append(fp.Order.Asc, pair.key)
+		case numberLiteral("-1"):
+			fp.Order.Desc = append(fp.Order.Desc, pair.key)
+		default:
+			return fmt.Errorf("the sort order of %s should be 1 or -1", pair.key)
+		}
+	}
+	return nil
+}
+
+func (ap *annotationParser) parseProject(fp *FindParse, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	doc, err := parseDocument(value)
+	if err != nil {
+		return err
+	}
+	for _, pair := range doc {
+		if _, _, err = ap.method.BelongedToStruct.GetFieldByMongoName(pair.key); err != nil {
+			return err
+		}
+		if pair.value != numberLiteral("1") && pair.value != true {
+			return fmt.Errorf("the projection of %s should be 1 or true, the exclusion is not supported", pair.key)
+		}
+		fp.Project = append(fp.Project, pair.key)
+	}
+	return nil
+}
+
+func (ap *annotationParser) parseInt64Param(value string) (string, error) {
+	v, err := parseAnnotationValue(value)
+	if err != nil {
+		return "", err
+	}
+	p, ok := v.(placeholder)
+	if !ok {
+		return "", fmt.Errorf("%s should be a placeholder", strings.TrimSpace(value))
+	}
+	param, err := ap.getParam(p)
+	if err != nil {
+		return "", err
+	}
+	if param.Type.RealName() != "int64" {
+		return "", fmt.Errorf("$%d requires passing in a value of type int64, but %s is %s",
+			p, param.Name, param.Type.RealName())
+	}
+	return param.Name, nil
+}
+
+// documentTree is used to create the query tree of the document whose conditions are connected by And,
+// it is nil if the document is empty.
+func (ap *annotationParser) documentTree(doc document) (*ConnectionOpTree, error) {
+	var result *ConnectionOpTree
+	for _, pair := range doc {
+		node, err := ap.pairTree(pair)
+		if err != nil {
+			return nil, err
+		}
+		result = connectTree(And, result, node)
+	}
+	return result, nil
+}
+
+func (ap *annotationParser) pairTree(pair documentPair) (*ConnectionOpTree, error) {
+	switch {
+	case pair.key == "$and" || pair.key == "$or":
+		docs, ok := pair.value.([]interface{})
+		if !ok || len(docs) == 0 {
+			return nil, fmt.Errorf("%s requires a non-empty array of documents", pair.key)
+		}
+		op := And
+		if pair.key == "$or" {
+			op = Or
+		}
+		var result *ConnectionOpTree
+		for _, value := range docs {
+			doc, ok := value.(document)
+			if !ok {
+				return nil, fmt.Errorf("%s requires a non-empty array of documents", pair.key)
+			}
+			node, err := ap.documentTree(doc)
+			if err != nil {
+				return nil, err
+			}
+			if node == nil {
+				return nil, fmt.Errorf("%s does not support empty documents", pair.key)
+			}
+			result = connectTree(op, result, node)
+		}
+		return result, nil
+
+	case strings.HasPrefix(pair.key, "$"):
+		return nil, fmt.Errorf("the operator %s is not supported at the top level", pair.key)
+	}
+
+	_, t, err := ap.method.BelongedToStruct.GetFieldByMongoName(pair.key)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, ok := pair.value.(document)
+	if !ok {
+		return ap.leafTree(pair.key, t, "$eq", pair.value)
+	}
+	if len(doc) == 0 || !strings.HasPrefix(doc[0].key, "$") {
+		return nil, fmt.Errorf("the embedded document of %s is not supported, compare its fields by the dotted names", pair.key)
+	}
+	var result *ConnectionOpTree
+	for _, operator := range doc {
+		node, err := ap.leafTree(pair.key, t, operator.key, operator.value)
+		if err != nil {
+			return nil, err
+		}
+		result = connectTree(And, result, node)
+	}
+	return result, nil
+}
+
+func (ap *annotationParser) leafTree(fieldName string, t code.Type, operator string, value interface{}) (*ConnectionOpTree, error) {
+	comparator, ok := queryOperators[operator]
+	if !ok {
+		return nil, fmt.Errorf("the operator %s of %s is not supported", operator, fieldName)
+	}
+	leaf := &ConnectionOpTree{MongoFieldName: fieldName}
+
+	if b, ok := value.(bool); ok {
+		switch {
+		case comparator == Exists:
+			leaf.Name = string(Exists)
+			if !b {
+				leaf.Name = string(NotExists)
+			}
+			return leaf, nil
+		case comparator == Equal && t.RealName() == "bool":
+			leaf.Name = string(True)
+			if !b {
+				leaf.Name = string(False)
+			}
+			return leaf, nil
+		}
+	}
+	if comparator == Exists {
+		return nil, fmt.Errorf("$exists of %s requires true or false", fieldName)
+	}
+
+	switch v := value.(type) {
+	case placeholder:
+		param, err := ap.getParam(v)
+		if err != nil {
+			return nil, err
+		}
+		if param.Type.RealName() != t.RealName() {
+			return nil, newParamTypeError(ap.method.Name, param, t)
+		}
+		leaf.ParamNames = []string{param.Name}
+	case numberLiteral:
+		if comparator == In || comparator == NotIn {
+			return nil, fmt.Errorf("%s of %s requires a placeholder", operator, fieldName)
+		}
+		if !isNumberType(t) || (strings.ContainsAny(string(v), ".eE") && !strings.HasPrefix(t.RealName(), "float")) {
+			return nil, fmt.Errorf("the literal %s does not match the field type %s of %s", v, t.RealName(), fieldName)
+		}
+		leaf.Literal = &Literal{Kind: NumberLiteral, Value: string(v), FieldType: t}
+	case stringLiteral:
+		if comparator == In || comparator == NotIn {
+			return nil, fmt.Errorf("%s of %s requires a placeholder", operator, fieldName)
+		}
+		if t.RealName() != "string" {
+			return nil, fmt.Errorf("the literal %s does not match the field type %s of %s",
+				strconv.Quote(string(v)), t.RealName(), fieldName)
+		}
+		leaf.Literal = &Literal{Kind: StringLiteral, Value: string(v), FieldType: t}
+	default:
+		return nil, fmt.Errorf("the value of %s %s should be a placeholder or a literal", fieldName, operator)
+	}
+
+	leaf.Name = string(comparator)
+	return leaf, nil
+}
+
+func (ap *annotationParser) getParam(p placeholder) (code.Param, error) {
+	index := int(p)
+	if index < 1 || index >= len(ap.method.Params) {
+		return code.Param{}, fmt.Errorf("the placeholder $%d is out of range, the method has %d parameters "+
+			"after context.Context", index, len(ap.method.Params)-1)
+	}
+	ap.referenced[index] = true
+	return ap.method.Params[index], nil
+}
+
+// connectTree connects left and right by op, left is nil for the first condition.
+func connectTree(op QueryConnectionOp, left, right *ConnectionOpTree) *ConnectionOpTree {
+	if left == nil {
+		return right
+	}
+	return &ConnectionOpTree{
+		Name:          string(op),
+		LeftChildren:  left,
+		RightChildren: right,
+	}
+}
+
+func isNumberType(t code.Type) bool {
+	switch t.RealName() {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64":
+		return true
+	}
+	return false
+}
+
+func parseDocument(s string) (document, error) {
+	v, err := parseAnnotationValue(s)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := v.(document)
+	if !ok {
+		return nil, fmt.Errorf("%s should be a document", strings.TrimSpace(s))
+	}
+	return doc, nil
+}
+
+func parseAnnotationValue(s string) (interface{}, error) {
+	r := &valueReader{s: s}
+	v, err := r.readValue()
+	if err != nil {
+		return nil, err
+	}
+	r.skipSpaces()
+	if r.pos != len(r.s) {
+		return nil, r.errorf("unexpected %q", r.s[r.pos])
+	}
+	return v, nil
+}
+
+// valueReader reads the values written in the relaxed syntax of the mongo shell.
+type valueReader struct {
+	s   string
+	pos int
+}
+
+func (r *valueReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("there are grammar errors at offset %d in %s: %s", r.pos, strings.TrimSpace(r.s),
+		fmt.Sprintf(format, args...))
+}
+
+func (r *valueReader) skipSpaces() {
+	for r.pos < len(r.s) && strings.IndexByte(" \t\r\n", r.s[r.pos]) != -1 {
+		r.pos++
+	}
+}
+
+func (r *valueReader) readValue() (interface{}, error) {
+	r.skipSpaces()
+	if r.pos == len(r.s) {
+		return nil, r.errorf("unexpected end")
+	}
+
+	switch c := r.s[r.pos]; {
+	case c == '{':
+		return r.readDocument()
+	case c == '[':
+		return r.readArray()
+	case c == '"' || c == '\'':
+		s, err := r.readString()
+		return stringLiteral(s), err
+	case c == '$':
+		r.pos++
+		digits := r.readWhile(isDigit)
+		if digits == "" {
+			return nil, r.errorf("$ should be followed by the index of the parameter")
+		}
+		index, err := strconv.Atoi(digits)
+		if err != nil {
+			return nil, r.errorf(err.Error())
+		}
+		return placeholder(index), nil
+	case c == '-' || isDigit(c):
+		start := r.pos
+		r.pos++
+		r.readWhile(func(c byte) bool {
+			return isDigit(c) || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+		})
+		number := r.s[start:r.pos]
+		if _, err := strconv.ParseFloat(number, 64); err != nil {
+			return nil, r.errorf("invalid number %s", number)
+		}
+		return numberLiteral(number), nil
+	default:
+		switch word := r.readWhile(isKeyByte); word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		case "":
+			return nil, r.errorf("unexpected %q", c)
+		default:
+			return nil, r.errorf("unexpected %s", word)
+		}
+	}
+}
+
+func (r *valueReader) readDocument() (document, error) {
+	// skip {
+	r.pos++
+	doc := document{}
+	for {
+		r.skipSpaces()
+		if r.pos < len(r.s) && r.s[r.pos] == '}' {
+			r.pos++
+			return doc, nil
+		}
+		if len(doc) > 0 {
+			if err := r.expect(','); err != nil {
+				return nil, err
+			}
+			r.skipSpaces()
+		}
+
+		var key string
+		if r.pos < len(r.s) && (r.s[r.pos] == '"' || r.s[r.pos] == '\'') {
+			var err error
+			if key, err = r.readString(); err != nil {
+				return nil, err
+			}
+		} else if key = r.readWhile(isKeyByte); key == "" {
+			return nil, r.errorf("the key is expected")
+		}
+
+		r.skipSpaces()
+		if err := r.expect(':'); err != nil {
+			return nil, err
+		}
+		value, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		doc = append(doc, documentPair{key: key, value: value})
+	}
+}
+
+func (r *valueReader) readArray() ([]interface{}, error) {
+	// skip [
+	r.pos++
+	values := []interface{}{}
+	for {
+		r.skipSpaces()
+		if r.pos < len(r.s) && r.s[r.pos] == ']' {
+			r.pos++
+			return values, nil
+		}
+		if len(values) > 0 {
+			if err := r.expect(','); err != nil {
+				return nil, err
+			}
+		}
+		value, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+}
+
+func (r *valueReader) readString() (string, error) {
+	quote := r.s[r.pos]
+	r.pos++
+	var builder strings.Builder
+	for r.pos < len(r.s) {
+		c := r.s[r.pos]
+		r.pos++
+		switch c {
+		case quote:
+			return builder.String(), nil
+		case '\\':
+			if r.pos == len(r.s) {
+				return "", r.errorf("unterminated string")
+			}
+			builder.WriteByte(r.s[r.pos])
+			r.pos++
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return "", r.errorf("unterminated string")
+}
+
+func (r *valueReader) expect(c byte) error {
+	if r.pos == len(r.s) || r.s[r.pos] != c {
+		return r.errorf("%q is expected", c)
+	}
+	r.pos++
+	return nil
+}
+
+func (r *valueReader) readWhile(f func(c byte) bool) string {
+	start := r.pos
+	for r.pos < len(r.s) && f(r.s[r.pos]) {
+		r.pos++
+	}
+	return r.s[start:r.pos]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isKeyByte(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '.' || c == '$'
+}
@@ -22,16 +22,22 @@ import (
 	"github.com/cloudwego/cwgo/pkg/curd/extract"
 )
 
+// unordered is the token after Bulk, the operations after the failed one are still written if it is specified.
+const unordered = "Unordered"
+
 type BulkParse struct {
 	// Operations defines all the operations contained in the Bulk,
-	// supports Insert One(Many not support), Update One Many, Delete One Many
+	// supports Insert One Many, Update One Many, Replace One, Delete One Many
 	Operations []Operation
 
+	// Unordered is true if the Unordered token is specified after Bulk
+	Unordered bool
+
 	// CtxParamName defines the method's context.Context param name when Bulk is called independently
 	CtxParamName string
 
 	// BelongedToMethod defines the method to which Bulk belongs
-	BelongedToMethod *extract.InterfaceMethod
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
 }
 
 func newBulkParse() *BulkParse {
@@ -58,33 +64,56 @@ func (bp *BulkParse) parseBulk(tokens []string, method *extract.InterfaceMethod,
 
 	bp.BelongedToMethod = method
 
+	// the Unordered token of the Transaction Bulk operation is parsed by Transaction before the parentheses
+	if !isCalled && len(tokens) > 0 && tokens[0] == unordered {
+		bp.Unordered = true
+		tokens = tokens[1:]
+	}
+
 	for index := 0; index < len(tokens); index++ {
-		if tokens[index] == Find || tokens[index] == Count || tokens[index] == Bulk || tokens[index] == Transaction {
+		if tokens[index] == Find || tokens[index] == Count || tokens[index] == Bulk || tokens[index] == Transaction ||
+			tokens[index] == Watch {
 			return newMethodSyntaxError(method.Name, "the Bulk operation does not supports Find, Count, "+
-				"Bulk, Transaction, only supports Insert, Update, Delete")
+				"Bulk, Transaction, Watch, only supports Insert, Update, Replace, Delete")
 		}
 
 		if tokens[index] == Insert {
 			if index == len(tokens)-1 {
-				return newMethodSyntaxError(method.Name, "Insert should be followed by One")
+				return newMethodSyntaxError(method.Name, "Insert should be followed by One or Many")
 			}
 
-			if tokens[index+1] == Many {
-				return newMethodSyntaxError(method.Name, "the Bulk operation does not supports Insert "+
-					"Many, only supports Insert One")
-			}
-
-			if tokens[index+1] == One {
+			if tokens[index+1] == One || tokens[index+1] == Many {
 				ip := newInsertParse()
+				if tokens[index+1] == One {
+					ip.OperateMode = OperateOne
+				} else {
+					ip.OperateMode = OperateMany
+				}
 				if err := ip.parseInsert(method, curParamIndex, true); err != nil {
 					return err
 				}
-				ip.OperateMode = OperateOne
 				index += 1
 				bp.Operations = append(bp.Operations, ip)
 			} else {
-				return newMethodSyntaxError(method.Name, "Insert should be followed by One")
+				return newMethodSyntaxError(method.Name, "Insert should be followed by One or Many")
+			}
+		}
+
+		if tokens[index] == Replace {
+			if index == len(tokens)-1 || tokens[index+1] != One {
+				return newMethodSyntaxError(method.Name, "Replace should be followed by One")
+			}
+			if index+1 == len(tokens)-1 {
+				return newMethodSyntaxError(method.Name, "there is no content after Replace One")
+			}
+
+			noIndex := getNextOperationIndex(tokens, index+2, false)
+			rp := newReplaceParse()
+			if err := rp.parseReplace(tokens[index+2:noIndex], method, curParamIndex); err != nil {
+				return err
 			}
+			index = noIndex - 1
+			bp.Operations = append(bp.Operations, rp)
 		}
 
 		if tokens[index] == Update {
@@ -189,7 +218,7 @@ func getNextOperationIndex(tokens []string, startIndex int, hasCollection bool)
 	noIndex := -1
 	count := 0
 	for i := startIndex; i < len(tokens); i++ {
-		if tokens[i] == Insert || tokens[i] == Find || tokens[i] == Update || tokens[i] == Delete ||
+		if tokens[i] == Insert || tokens[i] == Find || tokens[i] == Update || tokens[i] == Replace || tokens[i] == Delete ||
 			tokens[i] == Count || tokens[i] == Transaction || tokens[i] == Bulk || tokens[i] == collection {
 			if !hasCollection && count == 0 {
 				noIndex = i
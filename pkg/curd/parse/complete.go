@@ -0,0 +1,370 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+type CompletionKind int
+
+const (
+	CompletionKeyword = CompletionKind(iota)
+	CompletionField
+	CompletionComparator
+	CompletionCollection
+)
+
+// Completion is the candidate of the next token of the method name written in the annotation.
+type Completion struct {
+	Label  string
+	Kind   CompletionKind
+	Detail string
+	// Partial is the written prefix of Label before the cursor, which is replaced by Label
+	Partial string
+}
+
+type grammarPos int
+
+const (
+	posOperation = grammarPos(iota)
+	posOperationEnd
+	posInsertMode
+	posReplaceMode
+	posOperateMode
+	posFind
+	posOrderField
+	posOrderNext
+	posOrderDesc
+	posUpdate
+	posUpdateField
+	posQueryMode
+	posQueryField
+	posComparator
+	posConnection
+	posCollection
+	posBulk
+	posTransactionBulk
+	posEnd
+)
+
+const (
+	usedOrder = 1 << iota
+	usedSkip
+	usedLimit
+	usedKeyset
+)
+
+// grammarState is the position in the method name and the context of the position.
+type grammarState struct {
+	pos grammarPos
+	// st is the structure whose fields are completed, it is the model specified by Collection in transactions
+	st *extract.IdlExtractStruct
+	// scope is Bulk or Transaction if the operation is a part of them
+	scope string
+	// inBulk is true between Bulk Lb and Rb of the transaction
+	inBulk bool
+	// op is Update or Delete which is waiting for One or Many
+	op string
+	// depth is the number of the unclosed Lb of the query
+	depth int
+	// used records the find options which are written
+	used int
+	// project is true if the projected fields can be written after Find
+	project bool
+}
+
+type transition struct {
+	phrase string
+	kind   CompletionKind
+	detail string
+	to     grammarState
+}
+
+type completer struct {
+	root   *extract.IdlExtractStruct
+	result []Completion
+	seen   map[Completion]bool
+}
+
+var comparators = []QueryComparator{
+	Equal, NotEqual, LessThan, LessThanEqual, GreaterThan, GreaterThanEqual, Between, NotBetween,
+	In, NotIn, True, False, Exists, NotExists,
+}
+
+// Complete returns the candidates of the next token of the method name of the structure, prefix is the
+// method name written after mongo. before the cursor, whose last token may be incomplete. The nil
+// structure is allowed, only the keywords are completed in this case.
+func Complete(st *extract.IdlExtractStruct, prefix string) []Completion {
+	c := &completer{
+		root: st,
+		seen: make(map[Completion]bool),
+	}
+	c.walk(grammarState{pos: posOperation, st: st}, prefix)
+	return c.result
+}
+
+// walk matches rest with the phrases of the state, the phrases prefixed by rest are the candidates,
+// and the phrases which are the prefixes of rest are consumed, all possible ways are walked.
+func (c *completer) walk(s grammarState, rest string) {
+	transitions := c.transitions(s)
+	for _, t := range transitions {
+		if strings.HasPrefix(t.phrase, rest) {
+			c.add(Completion{Label: t.phrase, Kind: t.kind, Detail: t.detail, Partial: rest})
+		}
+		if strings.HasPrefix(rest, t.phrase) && isTokenBoundary(rest, len(t.phrase)) {
+			c.walk(t.to, rest[len(t.phrase):])
+		}
+	}
+
+	// the collection name after Collection can be any parameter name
+	if s.pos == posCollection {
+		for i := 1; i < len(rest); i++ {
+			if isTokenBoundary(rest, i) {
+				c.walk(grammarState{pos: posOperationEnd, st: c.root, scope: Transaction, op: collection}, rest[i:])
+			}
+		}
+	}
+}
+
+func (c *completer) add(completion Completion) {
+	if c.seen[completion] {
+		return
+	}
+	c.seen[completion] = true
+	c.result = append(c.result, completion)
+}
+
+func isTokenBoundary(s string, index int) bool {
+	return index == len(s) || s[index] >= 'A' && s[index] <= 'Z'
+}
+
+func (c *completer) transitions(s grammarState) (result []transition) {
+	keyword := func(phrase, detail string, to grammarState) {
+		result = append(result, transition{phrase: phrase, kind: CompletionKeyword, detail: detail, to: to})
+	}
+	fields := func(to grammarState) {
+		if s.st == nil {
+			return
+		}
+		for _, name := range getFieldNames(s.st, "", 0) {
+			result = append(result, transition{
+				phrase: name,
+				kind:   CompletionField,
+				detail: "field of " + s.st.Name,
+				to:     to,
+			})
+		}
+	}
+	queryMode := func() {
+		keyword(string(By), "query by the following conditions", s.with(posQueryField))
+		keyword(string(All), "query all documents", c.operationEnd(s))
+	}
+
+	switch s.pos {
+	case posOperation:
+		keyword(Insert, "insert the documents", s.with(posInsertMode))
+		keyword(Find, "find the documents", grammarState{pos: posFind, st: s.st, project: true})
+		keyword(Update, "update the fields of the documents", s.with(posUpdate))
+		keyword(Delete, "delete the documents", s.with(posQueryMode))
+		keyword(Count, "count the documents", s.with(posQueryMode))
+		keyword(Watch, "watch the changes of the documents", s.with(posQueryMode))
+		keyword(Bulk, "write the operations in bulk", grammarState{pos: posBulk, st: s.st, scope: Bulk})
+		keyword(Transaction, "run the operations in a transaction",
+			grammarState{pos: posOperationEnd, st: s.st, scope: Transaction})
+
+	case posOperationEnd:
+		if s.scope == "" {
+			return
+		}
+		keyword(Insert, "insert the documents", s.with(posInsertMode))
+		keyword(Update, "update the fields of the documents", s.waitMode(Update))
+		keyword(Delete, "delete the documents", s.waitMode(Delete))
+		if s.scope == Bulk || s.inBulk {
+			keyword(Replace, "replace the document", s.with(posReplaceMode))
+		}
+		if s.scope == Transaction && !s.inBulk && s.op != collection {
+			keyword(collection, "run the next operation on the collection", s.with(posCollection))
+		}
+		if s.scope == Transaction && !s.inBulk {
+			keyword(Bulk, "write the operations in bulk", s.with(posTransactionBulk))
+		}
+		if s.inBulk {
+			keyword(rightBracket, "end the bulk operations",
+				grammarState{pos: posOperationEnd, st: c.root, scope: Transaction})
+		}
+
+	case posInsertMode:
+		keyword(One, "insert one document", c.operationEnd(s))
+		keyword(Many, "insert many documents", c.operationEnd(s))
+
+	case posReplaceMode:
+		keyword(One, "replace one document by the pointer parameter", s.with(posQueryMode))
+
+	case posOperateMode:
+		to := s.with(posQueryMode)
+		if s.op == Update {
+			to = s.with(posUpdate)
+		}
+		to.op = ""
+		keyword(One, "operate on one document", to)
+		keyword(Many, "operate on many documents", to)
+
+	case posFind:
+		if s.project {
+			fields(s)
+		}
+		c.findOptions(s, keyword)
+		queryMode()
+
+	case posOrderField:
+		fields(s.with(posOrderNext))
+
+	case posOrderNext, posOrderDesc:
+		fields(s.with(posOrderNext))
+		if s.pos == posOrderNext {
+			keyword(desc, "sort the previous field in descending order", s.with(posOrderDesc))
+		}
+		c.findOptions(s, keyword)
+		queryMode()
+
+	case posUpdate:
+		keyword("Upsert", "insert the document if no document matches", s.with(posUpdateField))
+		fields(s.with(posUpdateField))
+		queryMode()
+
+	case posUpdateField:
+		fields(s)
+		queryMode()
+
+	case posQueryMode:
+		queryMode()
+
+	case posQueryField:
+		fields(s.with(posComparator))
+		to := s.with(posQueryField)
+		to.depth++
+		keyword(leftBracket, "open the bracket of the conditions", to)
+
+	case posComparator:
+		for _, comparator := range comparators {
+			result = append(result, transition{
+				phrase: string(comparator),
+				kind:   CompletionComparator,
+				detail: "compare the previous field",
+				to:     s.with(posConnection),
+			})
+		}
+
+	case posConnection:
+		keyword(string(And), "all conditions are met", s.with(posQueryField))
+		keyword(string(Or), "any condition is met", s.with(posQueryField))
+		if s.depth > 0 {
+			to := s.with(posConnection)
+			to.depth--
+			keyword(rightBracket, "close the bracket of the conditions", to)
+		} else {
+			end := c.operationEnd(s)
+			result = append(result, c.transitions(end)...)
+		}
+
+	case posCollection:
+		if s.st == nil {
+			return
+		}
+		// the params of the repositories of the other models are named after the models by default
+		names := make([]string, 0, len(s.st.Models))
+		for name, model := range s.st.Models {
+			if model != c.root {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			to := grammarState{pos: posOperationEnd, st: s.st.Models[name], scope: Transaction, op: collection}
+			result = append(result, transition{
+				phrase: name,
+				kind:   CompletionCollection,
+				detail: "collection of the model, whose repository is the type of the param",
+				to:     to,
+			})
+		}
+
+	case posBulk:
+		keyword(unordered, "write the operations in any order", s.with(posOperationEnd))
+		result = append(result, c.transitions(s.with(posOperationEnd))...)
+
+	case posTransactionBulk:
+		if s.op != unordered {
+			to := s.with(posTransactionBulk)
+			to.op = unordered
+			keyword(unordered, "write the operations in any order", to)
+		}
+		keyword(leftBracket, "open the bulk operations",
+			grammarState{pos: posOperationEnd, st: s.st, scope: Transaction, inBulk: true})
+	}
+	return
+}
+
+// findOptions adds the options of Find which are not written.
+func (c *completer) findOptions(s grammarState, keyword func(phrase, detail string, to grammarState)) {
+	option := func(flag int, pos grammarPos) grammarState {
+		to := s.with(pos)
+		to.used |= flag
+		to.project = false
+		return to
+	}
+	if s.used&usedOrder == 0 {
+		keyword(order, "sort by the following fields", option(usedOrder, posOrderField))
+	}
+	if s.used&usedSkip == 0 {
+		keyword(skip, "skip the documents by the int64 parameter", option(usedSkip, posFind))
+	}
+	if s.used&usedLimit == 0 {
+		keyword(limit, "limit the documents by the int64 parameter", option(usedLimit, posFind))
+	}
+	if s.used&usedKeyset == 0 {
+		keyword(string(After), "paginate after the cursor parameter", option(usedKeyset, posFind))
+		keyword(string(Before), "paginate before the cursor parameter", option(usedKeyset, posFind))
+	}
+}
+
+// operationEnd returns the state after the operation, the operations of Bulk and Transaction may follow it.
+func (c *completer) operationEnd(s grammarState) grammarState {
+	if s.scope == "" {
+		return grammarState{pos: posEnd}
+	}
+	st := c.root
+	if s.inBulk {
+		// the collection of the bulk operations of the transaction is kept until Rb
+		st = s.st
+	}
+	return grammarState{pos: posOperationEnd, st: st, scope: s.scope, inBulk: s.inBulk}
+}
+
+func (s grammarState) with(pos grammarPos) grammarState {
+	s.pos = pos
+	return s
+}
+
+// waitMode returns the state after Update or Delete of Bulk and Transaction, which is followed by One or Many.
+func (s grammarState) waitMode(op string) grammarState {
+	return grammarState{pos: posOperateMode, st: s.st, scope: s.scope, inBulk: s.inBulk, op: op}
+}
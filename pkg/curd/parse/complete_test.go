@@ -0,0 +1,170 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+// testOrderIdl is the other model in the idl of User, which is completed after Collection in the transactions.
+const testOrderIdl = `
+struct Order {
+    1: i64 Id (go.tag="bson:\"id,omitempty\"")
+    2: i64 Amount (go.tag="bson:\"amount\"")
+}(
+mongo.InsertOne = "I(ctx context.Context, o *order.Order) (interface{}, error)"
+)
+`
+
+func TestComplete(t *testing.T) {
+	annotation := `mongo.InsertOne = "I(ctx context.Context, u *user.User) (interface{}, error)"`
+	idl := fmt.Sprintf(testIdl, annotation) + testOrderIdl
+	var user *extract.IdlExtractStruct
+	for _, st := range extractTestStructs(t, filepath.Join(t.TempDir(), "user.thrift"), idl) {
+		if st.Name == "User" {
+			user = st
+		}
+	}
+	if user == nil {
+		t.Fatal("the structure User is not extracted")
+	}
+	fields := []string{"Id", "Username", "Age", "Contact", "ContactPhone", "ContactCity", "CreatedAt", "AfterSaleId"}
+
+	tests := []struct {
+		name    string
+		st      *extract.IdlExtractStruct
+		prefix  string
+		labels  []string
+		kind    CompletionKind
+		partial string
+	}{
+		{
+			name:   "operations",
+			st:     user,
+			labels: []string{"Insert", "Find", "Update", "Delete", "Count", "Watch", "Bulk", "Transaction"},
+			kind:   CompletionKeyword,
+		},
+		{
+			name:    "partial operation",
+			st:      user,
+			prefix:  "Fi",
+			labels:  []string{"Find"},
+			kind:    CompletionKeyword,
+			partial: "Fi",
+		},
+		{
+			name:   "query fields",
+			st:     user,
+			prefix: "DeleteBy",
+			labels: fields,
+			kind:   CompletionField,
+		},
+		{
+			name:    "partial nested field",
+			st:      user,
+			prefix:  "FindByContactC",
+			labels:  []string{"ContactCity"},
+			kind:    CompletionField,
+			partial: "ContactC",
+		},
+		{
+			name:   "comparators",
+			st:     user,
+			prefix: "CountByAge",
+			labels: []string{"Equal", "NotEqual", "LessThan", "LessThanEqual", "GreaterThan", "GreaterThanEqual",
+				"Between", "NotBetween", "In", "NotIn", "True", "False", "Exists", "NotExists"},
+			kind: CompletionComparator,
+		},
+		{
+			name:    "partial comparator",
+			st:      user,
+			prefix:  "FindByAgeGreat",
+			labels:  []string{"GreaterThan", "GreaterThanEqual"},
+			kind:    CompletionComparator,
+			partial: "Great",
+		},
+		{
+			name:    "connection",
+			st:      user,
+			prefix:  "FindByAgeEqualO",
+			labels:  []string{"Or"},
+			kind:    CompletionKeyword,
+			partial: "O",
+		},
+		{
+			name:   "collections",
+			st:     user,
+			prefix: "TransactionInsertOneCollection",
+			labels: []string{"Order"},
+			kind:   CompletionCollection,
+		},
+		{
+			// Order may also be the *mongo.Collection param of the collection of User
+			name:   "fields of the collection",
+			st:     user,
+			prefix: "TransactionInsertOneCollectionOrderDeleteOneBy",
+			labels: append([]string{"Id", "Amount"}, fields...),
+			kind:   CompletionField,
+		},
+		{
+			name:    "operation after the collection param",
+			st:      user,
+			prefix:  "TransactionInsertOneCollectionAccountIn",
+			labels:  []string{"Insert"},
+			kind:    CompletionKeyword,
+			partial: "In",
+		},
+		{
+			name:   "keywords without the structure",
+			prefix: "Find",
+			labels: []string{"Orderby", "Skip", "Limit", "After", "Before", "By", "All"},
+			kind:   CompletionKeyword,
+		},
+		{
+			name:   "invalid operation",
+			st:     user,
+			prefix: "Deleteee",
+		},
+		{
+			name:   "used option",
+			st:     user,
+			prefix: "FindOrderbyAgeLimitL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completions := Complete(tt.st, tt.prefix)
+			if tt.labels == nil && len(completions) != 0 {
+				t.Errorf("completions = %v, want none", completions)
+			}
+			var labels []string
+			for _, completion := range completions {
+				if completion.Kind == tt.kind && completion.Partial == tt.partial {
+					labels = append(labels, completion.Label)
+				}
+			}
+			if !reflect.DeepEqual(labels, tt.labels) {
+				t.Errorf("labels = %v, want %v", labels, tt.labels)
+			}
+		})
+	}
+}
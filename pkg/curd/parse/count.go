@@ -30,7 +30,7 @@ type CountParse struct {
 	CtxParamName string
 
 	// BelongedToMethod defines the method to which Count belongs
-	BelongedToMethod *extract.InterfaceMethod
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
 }
 
 func newCountParse() *CountParse {
@@ -98,7 +98,7 @@ func (cp *CountParse) check(method *extract.InterfaceMethod) error {
 func (cp *CountParse) parseQuery(tokens []string, method *extract.InterfaceMethod, curParamIndex *int) error {
 	fqIndex, err := getFirstQueryIndex(tokens)
 	if err != nil {
-		return newMethodSyntaxError(method.Name, err.Error())
+		return err
 	}
 	if err = cp.Query.parseQuery(tokens[fqIndex:], method, curParamIndex); err != nil {
 		return err
@@ -34,7 +34,7 @@ type DeleteParse struct {
 	CtxParamName string
 
 	// BelongedToMethod defines the method to which Delete belongs
-	BelongedToMethod *extract.InterfaceMethod
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
 }
 
 func newDeleteParse() *DeleteParse {
@@ -116,7 +116,7 @@ func (dp *DeleteParse) check(method *extract.InterfaceMethod) error {
 func (dp *DeleteParse) parseQuery(tokens []string, method *extract.InterfaceMethod, curParamIndex *int) error {
 	fqIndex, err := getFirstQueryIndex(tokens)
 	if err != nil {
-		return newMethodSyntaxError(method.Name, err.Error())
+		return err
 	}
 	if err = dp.Query.parseQuery(tokens[fqIndex:], method, curParamIndex); err != nil {
 		return err
@@ -16,21 +16,316 @@
 
 package parse
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
 
 // newMethodSyntaxError creates syntaxError
 func newMethodSyntaxError(methodName, errReason string) error {
 	return methodSyntaxError{
 		methodName: methodName,
 		errReason:  errReason,
+		tokenIndex: -1,
+	}
+}
+
+// newParamTypeError creates syntaxError whose suggestion is the expected type of the param.
+func newParamTypeError(methodName string, param code.Param, expected code.Type) error {
+	return methodSyntaxError{
+		methodName: methodName,
+		errReason: fmt.Sprintf("the field type in the parameter transfer: %s, the actual required field type: %s",
+			param.Type.RealName(), expected.RealName()),
+		tokenIndex: -1,
+		suggestion: fmt.Sprintf("declare the parameter %s as %s", param.Name, expected.RealName()),
 	}
 }
 
 type methodSyntaxError struct {
 	methodName string
 	errReason  string
+	// pos is the position of the annotation which declares the method
+	pos extract.Position
+	// tokenIndex is the index of the offending token in the camel-cased tokens of the method, -1 if unknown
+	tokenIndex int
+	token      string
+	suggestion string
 }
 
 func (err methodSyntaxError) Error() string {
-	return fmt.Sprintf("method %s has syntax errors, specific reasons: %s", err.methodName, err.errReason)
+	result := fmt.Sprintf("method %s has syntax errors, specific reasons: %s", err.methodName, err.errReason)
+	if err.pos.IsValid() {
+		result = err.pos.String() + ": " + result
+	}
+	if err.tokenIndex >= 0 {
+		result += fmt.Sprintf(" (token %d %q)", err.tokenIndex, err.token)
+	}
+	if err.suggestion != "" {
+		result += ", " + err.suggestion
+	}
+	return result
+}
+
+// Diagnostic is the syntax error of the method reported by cwgo doc check and cwgo doc lsp.
+type Diagnostic struct {
+	Method string
+	Reason string
+	// Pos is the position of the annotation which declares the method, it is invalid if unknown
+	Pos extract.Position
+	// TokenIndex is the index of the offending token in the camel-cased tokens of the method, -1 if unknown
+	TokenIndex int
+	Token      string
+	Suggestion string
+}
+
+// String returns the diagnostic in the same format as the error returned by HandleOperations.
+func (d Diagnostic) String() string {
+	if d.Method == "" {
+		if d.Pos.Filename != "" {
+			return d.Pos.String() + ": " + d.Reason
+		}
+		return d.Reason
+	}
+	return methodSyntaxError{
+		methodName: d.Method,
+		errReason:  d.Reason,
+		pos:        d.Pos,
+		tokenIndex: d.TokenIndex,
+		token:      d.Token,
+		suggestion: d.Suggestion,
+	}.Error()
+}
+
+// GetDiagnostics flattens the syntax errors of all methods returned by HandleOperations,
+// the other errors are returned as the diagnostics without the method.
+func GetDiagnostics(err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+	var errs syntaxErrors
+	if errors.As(err, &errs) {
+		diagnostics := make([]Diagnostic, 0, len(errs))
+		for _, e := range errs {
+			diagnostics = append(diagnostics, GetDiagnostics(e)...)
+		}
+		return diagnostics
+	}
+	var syntaxErr methodSyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []Diagnostic{{
+			Method:     syntaxErr.methodName,
+			Reason:     syntaxErr.errReason,
+			Pos:        syntaxErr.pos,
+			TokenIndex: syntaxErr.tokenIndex,
+			Token:      syntaxErr.token,
+			Suggestion: syntaxErr.suggestion,
+		}}
+	}
+	return []Diagnostic{{Reason: err.Error(), TokenIndex: -1}}
+}
+
+// fieldNameError is returned when the tokens can not be located to the fields of the structure,
+// it records the unmatched tokens to locate the offending token and suggest the closest field.
+type fieldNameError struct {
+	errReason string
+	tokens    []string
+	st        *extract.IdlExtractStruct
+}
+
+func (err *fieldNameError) Error() string {
+	return err.errReason
+}
+
+// tokenError is returned when the tokens are not the expected keywords or comparators,
+// it records the offending tokens to locate them and the closest keyword to suggest.
+type tokenError struct {
+	errReason string
+	tokens    []string
+	keyword   string
+}
+
+func (err *tokenError) Error() string {
+	return err.errReason
+}
+
+// syntaxErrors collects the syntax errors of all methods.
+type syntaxErrors []error
+
+func (errs syntaxErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// toMethodSyntaxError adds the position of the method, the offending token and the suggestion to err.
+func toMethodSyntaxError(method *extract.InterfaceMethod, tokens []string, err error) error {
+	result := methodSyntaxError{
+		methodName: method.Name,
+		errReason:  err.Error(),
+		tokenIndex: -1,
+	}
+	var fieldErr *fieldNameError
+	var tokenErr *tokenError
+	switch {
+	case errors.As(err, &result):
+	case errors.As(err, &tokenErr):
+		result.tokenIndex = indexTokens(tokens, tokenErr.tokens)
+		if tokenErr.keyword != "" {
+			result.suggestion = fmt.Sprintf("did you mean %s?", tokenErr.keyword)
+		}
+	case errors.As(err, &fieldErr):
+		result.tokenIndex = indexTokens(tokens, fieldErr.tokens)
+		if name := suggestFieldName(fieldErr.st, fieldErr.tokens); name != "" {
+			result.suggestion = fmt.Sprintf("did you mean %s?", name)
+		} else if index, keyword := suggestKeywordToken(fieldErr.tokens, keywords); keyword != "" {
+			// the misspelled keyword is taken as the field name
+			result.tokenIndex = indexTokens(tokens, fieldErr.tokens[index:])
+			result.suggestion = fmt.Sprintf("did you mean %s?", keyword)
+		}
+	}
+	if result.tokenIndex >= 0 {
+		result.token = tokens[result.tokenIndex]
+	}
+	result.pos = method.Pos
+	return result
+}
+
+// indexTokens returns the index of the first occurrence of sub in tokens, -1 if it is not found.
+func indexTokens(tokens, sub []string) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := 0; i+len(sub) <= len(tokens); i++ {
+		matched := true
+		for j := range sub {
+			if tokens[i+j] != sub[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i
+		}
+	}
+	return -1
+}
+
+// suggestFieldName returns the field name closest to the longest prefix of the tokens which is close to a field name
+// by the edit distance, the nested fields are named by the concatenation of the field names. It is empty if no name
+// is close enough.
+func suggestFieldName(st *extract.IdlExtractStruct, tokens []string) string {
+	names := getFieldNames(st, "", 0)
+	best := ""
+	prefix := ""
+	for _, token := range tokens {
+		prefix += token
+		if name := closestName(prefix, names); name != "" {
+			best = name
+		}
+	}
+	return best
+}
+
+// closestName returns the name closest to s by the edit distance, it is empty if no name is close enough.
+func closestName(s string, names []string) string {
+	best, bestDistance := "", -1
+	for _, name := range names {
+		distance := editDistance(s, name)
+		if distance > len(name)/3+1 {
+			continue
+		}
+		if bestDistance == -1 || distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// keywords are the keywords of the method names except the comparators, which are suggested for the misspelled tokens.
+var keywords = []string{
+	string(By), string(All), string(And), string(Or), order, desc, skip, limit, string(After), string(Before),
+}
+
+// suggestKeyword returns the keyword closest to s by the edit distance, it is empty if s is a keyword
+// or no keyword is close enough.
+func suggestKeyword(s string, keywords []string) string {
+	best, bestDistance := "", -1
+	for _, keyword := range keywords {
+		distance := editDistance(s, keyword)
+		if distance == 0 {
+			return ""
+		}
+		if distance > len(keyword)/4+1 {
+			continue
+		}
+		if bestDistance == -1 || distance < bestDistance {
+			best, bestDistance = keyword, distance
+		}
+	}
+	return best
+}
+
+// suggestKeywordToken returns the index of the first token which is a misspelled keyword and the keyword,
+// the keyword is empty if there is no such token.
+func suggestKeywordToken(tokens, keywords []string) (int, string) {
+	for index, token := range tokens {
+		if keyword := suggestKeyword(token, keywords); keyword != "" {
+			return index, keyword
+		}
+	}
+	return -1, ""
+}
+
+func getFieldNames(st *extract.IdlExtractStruct, prefix string, depth int) []string {
+	names := make([]string, 0, len(st.StructFields))
+	for _, field := range st.StructFields {
+		names = append(names, prefix+field.Name)
+		// the depth is limited in case of the recursive structures
+		if field.IsBelongedToStruct && field.BelongedToStruct != nil && depth < 3 {
+			names = append(names, getFieldNames(field.BelongedToStruct, prefix+field.Name, depth+1)...)
+		}
+	}
+	return names
+}
+
+// editDistance returns the optimal string alignment distance of a and b, which is the levenshtein distance
+// counting the transposition of two adjacent characters as one edit, e.g. Eqaul is one edit from Equal.
+func editDistance(a, b string) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = minInt(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = minInt(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
 }
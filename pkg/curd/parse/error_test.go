@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetDiagnostics(t *testing.T) {
+	type diagnostic struct {
+		method     string
+		line       int
+		tokenIndex int
+		token      string
+		reason     string
+		suggestion string
+	}
+	tests := []struct {
+		name        string
+		annotations []string
+		diagnostics []diagnostic
+	}{
+		{
+			name:        "misspelled field",
+			annotations: []string{`mongo.FindByUsernmeEqual = "F(ctx context.Context, username string) ([]*user.User, error)"`},
+			diagnostics: []diagnostic{{method: "F", line: 16, tokenIndex: 2, token: "Usernme",
+				reason: "no field name corresponding to [Usernme] found", suggestion: "did you mean Username?"}},
+		},
+		{
+			name:        "misspelled nested field",
+			annotations: []string{`mongo.FindByContactCtyEqual = "F(ctx context.Context, city string) ([]*user.User, error)"`},
+			diagnostics: []diagnostic{{method: "F", line: 16, tokenIndex: 2, token: "Contact",
+				reason: "no field name corresponding to [Contact Cty] found", suggestion: "did you mean ContactCity?"}},
+		},
+		{
+			name:        "misspelled comparator",
+			annotations: []string{`mongo.FindByAgeGreatThan = "F(ctx context.Context, age int32) ([]*user.User, error)"`},
+			diagnostics: []diagnostic{{method: "F", line: 16, tokenIndex: 3, token: "Great",
+				reason: "[Great Than] is not a comparator", suggestion: "did you mean GreaterThan?"}},
+		},
+		{
+			name:        "misspelled keyword",
+			annotations: []string{`mongo.FindOrderbyIdLimt = "F(ctx context.Context, limit int64) ([]*user.User, error)"`},
+			diagnostics: []diagnostic{{method: "F", line: 16, tokenIndex: 3, token: "Limt",
+				reason: "no By or All specified", suggestion: "did you mean Limit?"}},
+		},
+		{
+			name:        "mismatched param type",
+			annotations: []string{`mongo.FindByAgeEqual = "F(ctx context.Context, age string) ([]*user.User, error)"`},
+			diagnostics: []diagnostic{{method: "F", line: 16, tokenIndex: -1,
+				reason:     "the field type in the parameter transfer: string, the actual required field type: int32",
+				suggestion: "declare the parameter age as int32"}},
+		},
+		{
+			name:        "wrong operation",
+			annotations: []string{`mongo.Fnd = "F(ctx context.Context) ([]*user.User, error)"`},
+			diagnostics: []diagnostic{{method: "F", line: 16, tokenIndex: -1,
+				reason: "wrong operation name, should be Insert, Find, Update, Delete, Count, Transaction, Bulk, Watch"}},
+		},
+		{
+			name: "located after the valid annotation",
+			annotations: []string{
+				`mongo.InsertOne = "I(ctx context.Context, u *user.User) (interface{}, error)"`,
+				`mongo.DeleteByIdEqul = "D(ctx context.Context, id int64) (bool, error)"`,
+			},
+			diagnostics: []diagnostic{{method: "D", line: 17, tokenIndex: 3, token: "Equl",
+				reason: "[Equl] is not a comparator", suggestion: "did you mean Equal?"}},
+		},
+		{
+			name: "reported together",
+			annotations: []string{
+				`mongo.FindByUsernmeEqual = "F(ctx context.Context, username string) ([]*user.User, error)"`,
+				`mongo.InsertOne = "I(ctx context.Context, u *user.User) (interface{}, error)"`,
+				`mongo.DeleteByIdEqul = "D(ctx context.Context, id int64) (bool, error)"`,
+			},
+			diagnostics: []diagnostic{
+				{method: "F", line: 16, tokenIndex: 2, token: "Usernme",
+					reason: "no field name corresponding to [Usernme] found", suggestion: "did you mean Username?"},
+				{method: "D", line: 18, tokenIndex: 3, token: "Equl",
+					reason: "[Equl] is not a comparator", suggestion: "did you mean Equal?"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HandleAllOperations(getTestUser(t, strings.Join(tt.annotations, "\n")))
+			if err == nil {
+				t.Fatal("want the syntax errors, got nil")
+			}
+			diagnostics := GetDiagnostics(err)
+			if len(diagnostics) != len(tt.diagnostics) {
+				t.Fatalf("got %d diagnostics %v, want %d", len(diagnostics), diagnostics, len(tt.diagnostics))
+			}
+			for i, want := range tt.diagnostics {
+				got := diagnostics[i]
+				if got.Method != want.method || got.Reason != want.reason || got.Suggestion != want.suggestion {
+					t.Errorf("diagnostic %d = %q %q %q, want %q %q %q", i, got.Method, got.Reason, got.Suggestion,
+						want.method, want.reason, want.suggestion)
+				}
+				if got.Pos.Line != want.line || got.Pos.Column != 1 || !strings.HasSuffix(got.Pos.Filename, "user.thrift") {
+					t.Errorf("diagnostic %d pos = %+v, want user.thrift:%d:1", i, got.Pos, want.line)
+				}
+				if got.TokenIndex != want.tokenIndex || got.Token != want.token {
+					t.Errorf("diagnostic %d token = %d %q, want %d %q", i, got.TokenIndex, got.Token,
+						want.tokenIndex, want.token)
+				}
+			}
+		})
+	}
+}
@@ -36,14 +36,21 @@ type FindParse struct {
 	SkipParamName  string
 	LimitParamName string
 
+	// Keyset defines the cursor-based pagination information contained in the Find operation,
+	// it is nil when neither After nor Before is specified
+	Keyset *Keyset
+
 	// CtxParamName defines the method's context.Context param name
 	CtxParamName string
 
 	// ReturnType defines the method's first return parameter's Type which Find belongs
 	ReturnType code.Type
 
+	// ReturnCursor is true when the method returns (items, nextCursor, error)
+	ReturnCursor bool
+
 	// BelongedToMethod defines the method to which Find belongs
-	BelongedToMethod *extract.InterfaceMethod
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
 }
 
 type Order struct {
@@ -51,6 +58,62 @@ type Order struct {
 	Desc []string
 }
 
+type KeysetMode string
+
+const (
+	After  = KeysetMode("After")
+	Before = KeysetMode("Before")
+)
+
+// Keyset stores the keyset(cursor) used to paginate on the only sort field.
+type Keyset struct {
+	// KeysetMode After or Before
+	KeysetMode KeysetMode
+
+	// MongoFieldName defines the sort field name used as the keyset
+	MongoFieldName string
+
+	// GoFieldPath defines the go field access path of the sort field, such as Contact.City
+	GoFieldPath string
+
+	// FieldType defines the go type of the cursor, which is the type of the sort field or the pointer to it
+	FieldType code.Type
+
+	// ParamName defines the method's cursor param name
+	ParamName string
+
+	// Desc is true when the sort field is sorted in descending order
+	Desc bool
+
+	// Nullable is true when the cursor is a pointer, the keyset condition is skipped when the cursor is nil
+	// to query the first page, so it is not combined with the Query tree
+	Nullable bool
+
+	// Indirect is true when the cursor is the pointer to the type of the sort field
+	Indirect bool
+}
+
+// Condition returns the $gt or $lt condition of the keyset on the sort field.
+func (k *Keyset) Condition() *ConnectionOpTree {
+	comparator := GreaterThan
+	if k.Desc == (k.KeysetMode == After) {
+		comparator = LessThan
+	}
+	return &ConnectionOpTree{
+		Name:           string(comparator),
+		MongoFieldName: k.MongoFieldName,
+		ParamNames:     []string{k.ParamName},
+	}
+}
+
+// NextCursor returns the next cursor taken from the entity.
+func (k *Keyset) NextCursor(entity string) string {
+	if k.Indirect {
+		return "&" + entity + "." + k.GoFieldPath
+	}
+	return entity + "." + k.GoFieldPath
+}
+
 const (
 	order = "Orderby"
 	skip  = "Skip"
@@ -97,13 +160,17 @@ func (fp *FindParse) parseFind(tokens []string, method *extract.InterfaceMethod,
 
 	fqIndex, err := getFirstQueryIndex(tokens[tokenIndex:])
 	if err != nil {
-		return newMethodSyntaxError(method.Name, err.Error())
+		return err
 	}
 
 	if err = fp.Query.parseQuery(tokens[tokenIndex+fqIndex:], method, curParamIndex); err != nil {
 		return err
 	}
 
+	if err = fp.parseKeyset(method); err != nil {
+		return err
+	}
+
 	if *curParamIndex < len(method.Params) {
 		return newMethodSyntaxError(method.Name, fmt.Sprintf("too many method parameters written, "+
 			"%v and subsequent parameters are useless", method.Params[*curParamIndex].Name))
@@ -117,8 +184,8 @@ func (fp *FindParse) check(method *extract.InterfaceMethod) error {
 		return newMethodSyntaxError(method.Name, "less than one input parameters")
 	}
 
-	if len(method.Returns) != 2 {
-		return newMethodSyntaxError(method.Name, "return parameter not equal to 2")
+	if len(method.Returns) != 2 && len(method.Returns) != 3 {
+		return newMethodSyntaxError(method.Name, "return parameter not equal to 2 or 3")
 	}
 
 	if method.Params[0].Type.RealName() != "context.Context" {
@@ -126,10 +193,11 @@ func (fp *FindParse) check(method *extract.InterfaceMethod) error {
 			"should be context.Context")
 	}
 
-	if method.Returns[1].RealName() != "error" {
-		return newMethodSyntaxError(method.Name, "the second parameter in the return parameters "+
+	if method.Returns[len(method.Returns)-1].RealName() != "error" {
+		return newMethodSyntaxError(method.Name, "the last parameter in the return parameters "+
 			"should be error")
 	}
+	fp.ReturnCursor = len(method.Returns) == 3
 
 	if _, ok := method.Returns[0].(code.StarExprType); ok {
 		fp.OperateMode = OperateOne
@@ -170,6 +238,10 @@ func (fp *FindParse) parseFindOptions(tokens []string, method *extract.Interface
 	orderFlag, skipFlag, limitFlag := 0, 0, 0
 
 	for index, token := range tokens {
+		if token == string(By) || token == string(All) {
+			break
+		}
+
 		if token == order {
 			if orderFlag == 1 {
 				return newMethodSyntaxError(method.Name, "Orderby can only be used once")
@@ -180,14 +252,14 @@ func (fp *FindParse) parseFindOptions(tokens []string, method *extract.Interface
 
 			tokenIndex, err := getNextTokenIndex(tokens, index+1)
 			if err != nil {
-				return newMethodSyntaxError(method.Name, err.Error())
+				return err
 			}
 
 			if index+1 == tokenIndex {
 				return newMethodSyntaxError(method.Name, "there are no sorted fields after the Orderby")
 			}
 			if err = fp.getSortFields(tokens[index+1:tokenIndex], method.BelongedToStruct); err != nil {
-				return newMethodSyntaxError(method.Name, err.Error())
+				return err
 			}
 			orderFlag = 1
 		}
@@ -228,6 +300,79 @@ func (fp *FindParse) parseFindOptions(tokens []string, method *extract.Interface
 			*curParamIndex += 1
 			limitFlag = 1
 		}
+
+		if isKeysetToken(tokens, index) {
+			if fp.OperateMode == OperateOne {
+				return newMethodSyntaxError(method.Name, fmt.Sprintf("%s operation is not supported in Find One mode", token))
+			}
+			if fp.Keyset != nil {
+				return newMethodSyntaxError(method.Name, "After or Before can only be used once")
+			}
+			if index == len(tokens)-1 {
+				return newMethodSyntaxError(method.Name, fmt.Sprintf("there are no other fields after %s, such as By or All", token))
+			}
+			if *curParamIndex >= len(method.Params) {
+				return newMethodSyntaxError(method.Name, fmt.Sprintf("%s requires passing in a cursor value", token))
+			}
+
+			// the type of the cursor is checked in parseKeyset after all the sorted fields are known
+			fp.Keyset = &Keyset{
+				KeysetMode: KeysetMode(token),
+				ParamName:  method.Params[*curParamIndex].Name,
+				FieldType:  method.Params[*curParamIndex].Type,
+			}
+			*curParamIndex += 1
+		}
+	}
+
+	return nil
+}
+
+// parseKeyset is used to bind the keyset to the only sort field and check the cursor types.
+func (fp *FindParse) parseKeyset(method *extract.InterfaceMethod) error {
+	if fp.Keyset == nil {
+		if fp.ReturnCursor {
+			return newMethodSyntaxError(method.Name, "the next cursor can only be returned when After or Before is specified")
+		}
+		return nil
+	}
+
+	if len(fp.Order.Asc)+len(fp.Order.Desc) != 1 {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("%s requires exactly one sorted field after the Orderby",
+			fp.Keyset.KeysetMode))
+	}
+	if len(fp.Order.Asc) == 1 {
+		fp.Keyset.MongoFieldName = fp.Order.Asc[0]
+	} else {
+		fp.Keyset.MongoFieldName = fp.Order.Desc[0]
+		fp.Keyset.Desc = true
+	}
+
+	goFieldPath, t, err := method.BelongedToStruct.GetFieldByMongoName(fp.Keyset.MongoFieldName)
+	if err != nil {
+		return newMethodSyntaxError(method.Name, err.Error())
+	}
+	cursorType := fp.Keyset.FieldType
+	var pointerType code.Type = code.StarExprType{RealType: t}
+	if _, ok := t.(code.StarExprType); ok {
+		pointerType = t
+	}
+	if cursorType.RealName() != t.RealName() && cursorType.RealName() != pointerType.RealName() {
+		return newMethodSyntaxError(method.Name,
+			fmt.Sprintf("the cursor type in the parameter transfer: %s, the actual required type: %s or %s",
+				cursorType.RealName(), t.RealName(), pointerType.RealName()))
+	}
+	if fp.ReturnCursor && method.Returns[1].RealName() != cursorType.RealName() {
+		return newMethodSyntaxError(method.Name,
+			fmt.Sprintf("the second parameter in the return parameters should be the next cursor of type %s",
+				cursorType.RealName()))
+	}
+	fp.Keyset.GoFieldPath = goFieldPath
+	_, fp.Keyset.Nullable = cursorType.(code.StarExprType)
+	fp.Keyset.Indirect = cursorType.RealName() != t.RealName()
+
+	if !fp.Keyset.Nullable {
+		fp.Query.combineKeyset(fp.Keyset)
 	}
 
 	return nil
@@ -312,7 +457,7 @@ func (fp *FindParse) getSortFields(tokens []string, extractStruct *extract.IdlEx
 func getNextTokenIndex(tokens []string, startIndex int) (int, error) {
 	tokenIndex := -1
 	for i := startIndex; i < len(tokens); i++ {
-		if tokens[i] == order || tokens[i] == skip || tokens[i] == limit ||
+		if tokens[i] == order || tokens[i] == skip || tokens[i] == limit || isKeysetToken(tokens, i) ||
 			tokens[i] == string(By) || tokens[i] == string(All) {
 			tokenIndex = i
 			break
@@ -320,8 +465,21 @@ func getNextTokenIndex(tokens []string, startIndex int) (int, error) {
 	}
 
 	if tokenIndex == -1 {
-		return 0, errors.New("no By or All specified")
+		return 0, newNoQueryError(tokens[startIndex:])
 	}
 
 	return tokenIndex, nil
 }
+
+// isKeysetToken reports whether the token is the keyset clause After or Before, which is followed
+// by another Find option or the query, so that the fields starting with After or Before are not split.
+func isKeysetToken(tokens []string, index int) bool {
+	if tokens[index] != string(After) && tokens[index] != string(Before) || index == len(tokens)-1 {
+		return false
+	}
+	switch tokens[index+1] {
+	case order, skip, limit, string(After), string(Before), string(By), string(All):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKeyset(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		keyset     *Keyset
+		// query is the condition of the query tree combined with the keyset, such as And(banned False, created_at LessThan)
+		query        string
+		returnCursor bool
+		nextCursor   string
+		wantErr      string
+	}{
+		{
+			name:       "after descending",
+			annotation: `mongo.FindOrderbyCreatedAtDescLimitAfterAll = "List(ctx context.Context, limit int64, cursor int64) ([]*user.User, error)"`,
+			keyset: &Keyset{KeysetMode: After, MongoFieldName: "created_at", GoFieldPath: "CreatedAt",
+				ParamName: "cursor", Desc: true},
+			query:      "created_at LessThan [cursor]",
+			nextCursor: "entity.CreatedAt",
+		},
+		{
+			name:       "before ascending with the conditions",
+			annotation: `mongo.FindOrderbyCreatedAtLimitBeforeByAgeGreaterThan = "List(ctx context.Context, limit int64, before int64, age int32) ([]*user.User, error)"`,
+			keyset:     &Keyset{KeysetMode: Before, MongoFieldName: "created_at", GoFieldPath: "CreatedAt", ParamName: "before"},
+			query:      "And(age GreaterThan [age], created_at LessThan [before])",
+			nextCursor: "entity.CreatedAt",
+		},
+		{
+			name:       "after ascending",
+			annotation: `mongo.FindOrderbyIdAfterAll = "List(ctx context.Context, cursor int64) ([]*user.User, error)"`,
+			keyset:     &Keyset{KeysetMode: After, MongoFieldName: "id", GoFieldPath: "Id", ParamName: "cursor"},
+			query:      "id GreaterThan [cursor]",
+			nextCursor: "entity.Id",
+		},
+		{
+			name:       "nullable cursor returned",
+			annotation: `mongo.FindOrderbyCreatedAtDescLimitAfterAll = "List(ctx context.Context, limit int64, cursor *int64) ([]*user.User, *int64, error)"`,
+			keyset: &Keyset{KeysetMode: After, MongoFieldName: "created_at", GoFieldPath: "CreatedAt",
+				ParamName: "cursor", Desc: true, Nullable: true, Indirect: true},
+			returnCursor: true,
+			nextCursor:   "&entity.CreatedAt",
+		},
+		{
+			name:         "nested sort field",
+			annotation:   `mongo.FindOrderbyContactCityAfterAll = "List(ctx context.Context, city string) ([]*user.User, string, error)"`,
+			keyset:       &Keyset{KeysetMode: After, MongoFieldName: "contact.city", GoFieldPath: "Contact.City", ParamName: "city"},
+			query:        "contact.city GreaterThan [city]",
+			returnCursor: true,
+			nextCursor:   "entity.Contact.City",
+		},
+		{
+			name:       "field name starting with After",
+			annotation: `mongo.FindOrderbyAfterSaleIdDescAfterAll = "List(ctx context.Context, cursor int64) ([]*user.User, error)"`,
+			keyset: &Keyset{KeysetMode: After, MongoFieldName: "after_sale_id", GoFieldPath: "AfterSaleId",
+				ParamName: "cursor", Desc: true},
+			query:      "after_sale_id LessThan [cursor]",
+			nextCursor: "entity.AfterSaleId",
+		},
+		{
+			name:       "no keyset",
+			annotation: `mongo.FindOrderbyIdLimitAll = "List(ctx context.Context, limit int64) ([]*user.User, error)"`,
+		},
+		{
+			name:       "used twice",
+			annotation: `mongo.FindOrderbyIdAfterBeforeAll = "List(ctx context.Context, after int64, before int64) ([]*user.User, error)"`,
+			wantErr:    "After or Before can only be used once",
+		},
+		{
+			name:       "no sorted field",
+			annotation: `mongo.FindLimitAfterAll = "List(ctx context.Context, limit int64, cursor int64) ([]*user.User, error)"`,
+			wantErr:    "After requires exactly one sorted field after the Orderby",
+		},
+		{
+			name:       "several sorted fields",
+			annotation: `mongo.FindOrderbyAgeIdBeforeAll = "List(ctx context.Context, cursor int64) ([]*user.User, error)"`,
+			wantErr:    "Before requires exactly one sorted field after the Orderby",
+		},
+		{
+			name:       "cursor of another type",
+			annotation: `mongo.FindOrderbyIdAfterAll = "List(ctx context.Context, cursor string) ([]*user.User, error)"`,
+			wantErr:    "the cursor type in the parameter transfer: string, the actual required type: int64 or *int64",
+		},
+		{
+			name:       "next cursor of another type",
+			annotation: `mongo.FindOrderbyIdAfterAll = "List(ctx context.Context, cursor int64) ([]*user.User, *int64, error)"`,
+			wantErr:    "the second parameter in the return parameters should be the next cursor of type int64",
+		},
+		{
+			name:       "next cursor without keyset",
+			annotation: `mongo.FindOrderbyIdAll = "List(ctx context.Context) ([]*user.User, int64, error)"`,
+			wantErr:    "the next cursor can only be returned when After or Before is specified",
+		},
+		{
+			name:       "find one",
+			annotation: `mongo.FindOrderbyIdAfterAll = "Get(ctx context.Context, cursor int64) (*user.User, error)"`,
+			wantErr:    "After operation is not supported in Find One mode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ifOperation, err := HandleAllOperations(getTestUser(t, tt.annotation))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("HandleAllOperations() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HandleAllOperations() error = %v", err)
+			}
+			find := ifOperation.Operations[0].(*FindParse)
+			if tt.keyset == nil {
+				if find.Keyset != nil {
+					t.Fatalf("Keyset = %+v, want nil", find.Keyset)
+				}
+				return
+			}
+
+			keyset := *find.Keyset
+			keyset.FieldType = nil
+			if keyset != *tt.keyset {
+				t.Errorf("Keyset = %+v, want %+v", keyset, *tt.keyset)
+			}
+			if find.ReturnCursor != tt.returnCursor {
+				t.Errorf("ReturnCursor = %v, want %v", find.ReturnCursor, tt.returnCursor)
+			}
+			if got := find.Keyset.NextCursor("entity"); got != tt.nextCursor {
+				t.Errorf("NextCursor() = %s, want %s", got, tt.nextCursor)
+			}
+			// the nullable cursor is not combined with the query, it is skipped for the first page
+			query := ""
+			if find.Query.QueryMode == By {
+				query = formatQuery(find.Query.ConnectionOpTree)
+			}
+			if query != tt.query {
+				t.Errorf("query = %s, want %s", query, tt.query)
+			}
+		})
+	}
+}
+
+// formatQuery returns the query tree in the form And(left, right), the leaves are the field, comparator and params.
+func formatQuery(node *ConnectionOpTree) string {
+	if node.LeftChildren != nil {
+		return node.Name + "(" + formatQuery(node.LeftChildren) + ", " + formatQuery(node.RightChildren) + ")"
+	}
+	return node.MongoFieldName + " " + node.Name + " [" + strings.Join(node.Values(), ", ") + "]"
+}
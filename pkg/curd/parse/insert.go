@@ -17,6 +17,8 @@
 package parse
 
 import (
+	"fmt"
+
 	"github.com/cloudwego/cwgo/pkg/curd/code"
 	"github.com/cloudwego/cwgo/pkg/curd/extract"
 )
@@ -29,7 +31,7 @@ type InsertParse struct {
 	MethodParamNames [2]string
 
 	// BelongedToMethod defines the method to which Insert belongs
-	BelongedToMethod *extract.InterfaceMethod
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
 }
 
 func newInsertParse() *InsertParse {
@@ -59,6 +61,9 @@ func (ip *InsertParse) parseInsert(method *extract.InterfaceMethod, curParamInde
 			method.Params[*curParamIndex+1].Name,
 		}
 	} else {
+		if err := ip.checkCalled(method, *curParamIndex); err != nil {
+			return err
+		}
 		ip.MethodParamNames = [2]string{
 			method.Params[*curParamIndex].Name,
 		}
@@ -108,3 +113,26 @@ func (ip *InsertParse) check(method *extract.InterfaceMethod) error {
 
 	return nil
 }
+
+// checkCalled checks the param of Insert called by Bulk or Transaction, whose OperateMode is specified by the tokens.
+func (ip *InsertParse) checkCalled(method *extract.InterfaceMethod, curParamIndex int) error {
+	mode := One
+	if ip.OperateMode == OperateMany {
+		mode = Many
+	}
+	if curParamIndex >= len(method.Params) {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("no parameter specified for Insert %s", mode))
+	}
+
+	param := method.Params[curParamIndex]
+	if ip.OperateMode == OperateOne {
+		if _, ok := param.Type.(code.StarExprType); !ok {
+			return newMethodSyntaxError(method.Name, fmt.Sprintf("inconsistent types, the parameter %s of "+
+				"Insert One should be a structure pointer", param.Name))
+		}
+	} else if _, ok := param.Type.(code.SliceType); !ok {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("inconsistent types, the parameter %s of "+
+			"Insert Many should be a slice", param.Name))
+	}
+	return nil
+}
@@ -16,6 +16,34 @@
 
 package parse
 
+import "github.com/cloudwego/cwgo/pkg/curd/extract"
+
 type Operation interface {
 	GetOperationName() string
 }
+
+// GetBelongedToMethod returns the interface method which the operation is parsed from.
+func GetBelongedToMethod(operation Operation) *extract.InterfaceMethod {
+	switch op := operation.(type) {
+	case *InsertParse:
+		return op.BelongedToMethod
+	case *FindParse:
+		return op.BelongedToMethod
+	case *UpdateParse:
+		return op.BelongedToMethod
+	case *DeleteParse:
+		return op.BelongedToMethod
+	case *CountParse:
+		return op.BelongedToMethod
+	case *ReplaceParse:
+		return op.BelongedToMethod
+	case *BulkParse:
+		return op.BelongedToMethod
+	case *TransactionParse:
+		return op.BelongedToMethod
+	case *WatchParse:
+		return op.BelongedToMethod
+	default:
+		return nil
+	}
+}
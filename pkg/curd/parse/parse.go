@@ -17,6 +17,7 @@
 package parse
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -41,6 +42,7 @@ const (
 	Count       = "Count"
 	Transaction = "Transaction"
 	Bulk        = "Bulk"
+	Watch       = "Watch"
 )
 
 type OperateMode int
@@ -55,99 +57,169 @@ const (
 	Many = "Many"
 )
 
+// MarshalJSON encodes the mode as One or Many, such as the dumps of cwgo doc --dump-ast.
+func (m OperateMode) MarshalJSON() ([]byte, error) {
+	if m == OperateMany {
+		return json.Marshal(Many)
+	}
+	return json.Marshal(One)
+}
+
 func HandleOperations(structs []*extract.IdlExtractStruct) (result []*InterfaceOperation, err error) {
+	var errs syntaxErrors
 	for _, st := range structs {
 		ifo := newInterfaceOperation()
-		if err = ifo.parseInterfaceMethod(st); err != nil {
-			return nil, err
+		if err = ifo.parseInterfaceMethod(st, st.InterfaceInfo.Methods); err != nil {
+			// the errors of all structures are reported together
+			errs = append(errs, err)
+			continue
 		}
 		result = append(result, ifo)
 	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
 	return
 }
 
+// HandleAllOperations is used to parse all methods of the structure, including the methods
+// generated before in update mode, for codegen which regenerates the whole file every time.
+func HandleAllOperations(st *extract.IdlExtractStruct) (*InterfaceOperation, error) {
+	methods := make([]*extract.InterfaceMethod, 0, len(st.PreIfMethods)+len(st.InterfaceInfo.Methods))
+	methods = append(methods, st.PreIfMethods...)
+	methods = append(methods, st.InterfaceInfo.Methods...)
+
+	ifo := newInterfaceOperation()
+	if err := ifo.parseInterfaceMethod(st, methods); err != nil {
+		return nil, err
+	}
+	ifo.BelongedToStruct = st
+	return ifo, nil
+}
+
 func newInterfaceOperation() *InterfaceOperation {
 	return &InterfaceOperation{Operations: []Operation{}}
 }
 
-func (ifo *InterfaceOperation) parseInterfaceMethod(extractStruct *extract.IdlExtractStruct) error {
-	for _, method := range extractStruct.InterfaceInfo.Methods {
+func (ifo *InterfaceOperation) parseInterfaceMethod(extractStruct *extract.IdlExtractStruct,
+	methods []*extract.InterfaceMethod,
+) error {
+	var errs syntaxErrors
+	for _, method := range methods {
 		tokens := camelcase.Split(method.ParsedTokens)
-		switch tokens[0] {
-		case Insert:
-			curParamIndex := new(int)
-			*curParamIndex = 0
-			ip := newInsertParse()
-			if err := ip.parseInsert(method, curParamIndex, false); err != nil {
-				return err
-			}
-			ifo.BelongedToStruct = extractStruct
-			ifo.Operations = append(ifo.Operations, ip)
-
-		case Find:
-			curParamIndex := new(int)
-			*curParamIndex = 1
-			fp := newFindParse()
-			if err := fp.parseFind(tokens[1:], method, curParamIndex); err != nil {
-				return err
-			}
-			ifo.BelongedToStruct = extractStruct
-			ifo.Operations = append(ifo.Operations, fp)
-
-		case Update:
-			curParamIndex := new(int)
-			*curParamIndex = 1
-			up := newUpdateParse()
-			if err := up.parseUpdate(tokens[1:], method, curParamIndex, false); err != nil {
-				return err
-			}
-			ifo.BelongedToStruct = extractStruct
-			ifo.Operations = append(ifo.Operations, up)
-
-		case Delete:
-			curParamIndex := new(int)
-			*curParamIndex = 1
-			dp := newDeleteParse()
-			if err := dp.parseDelete(tokens[1:], method, curParamIndex, false); err != nil {
-				return err
-			}
-			ifo.BelongedToStruct = extractStruct
-			ifo.Operations = append(ifo.Operations, dp)
-
-		case Count:
-			curParamIndex := new(int)
-			*curParamIndex = 1
-			cp := newCountParse()
-			if err := cp.parseCount(tokens[1:], method, curParamIndex); err != nil {
-				return err
-			}
-			ifo.BelongedToStruct = extractStruct
-			ifo.Operations = append(ifo.Operations, cp)
-
-		case Transaction:
-			curParamIndex := new(int)
-			*curParamIndex = 2
-			tp := newTransactionParse()
-			if err := tp.parseTransaction(tokens[1:], method, curParamIndex); err != nil {
-				return err
-			}
-			ifo.BelongedToStruct = extractStruct
-			ifo.Operations = append(ifo.Operations, tp)
-
-		case Bulk:
-			curParamIndex := new(int)
-			*curParamIndex = 1
-			bp := newBulkParse()
-			if err := bp.parseBulk(tokens[1:], method, curParamIndex, false); err != nil {
-				return err
-			}
-			ifo.BelongedToStruct = extractStruct
-			ifo.Operations = append(ifo.Operations, bp)
+		if err := ifo.parseMethod(extractStruct, method, tokens); err != nil {
+			errs = append(errs, toMethodSyntaxError(method, tokens, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 
-		default:
-			return newMethodSyntaxError(method.Name, "wrong operation name, should be Insert, Find, "+
-				"Update, Delete, Count, Transaction, Bulk")
+	return nil
+}
+
+// parseMethod is used to parse the method whose tokens are split from the parsed tokens.
+func (ifo *InterfaceOperation) parseMethod(extractStruct *extract.IdlExtractStruct, method *extract.InterfaceMethod,
+	tokens []string,
+) error {
+	if method.QueryAnnotation != nil {
+		fp := newFindParse()
+		if err := fp.parseFindAnnotation(method); err != nil {
+			return err
 		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, fp)
+		return nil
+	}
+
+	if method.TransactionOptions != nil && tokens[0] != Transaction {
+		return newMethodSyntaxError(method.Name, "the transaction options are only supported by the Transaction method")
+	}
+
+	switch tokens[0] {
+	case Insert:
+		curParamIndex := new(int)
+		*curParamIndex = 0
+		ip := newInsertParse()
+		if err := ip.parseInsert(method, curParamIndex, false); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, ip)
+
+	case Find:
+		curParamIndex := new(int)
+		*curParamIndex = 1
+		fp := newFindParse()
+		if err := fp.parseFind(tokens[1:], method, curParamIndex); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, fp)
+
+	case Update:
+		curParamIndex := new(int)
+		*curParamIndex = 1
+		up := newUpdateParse()
+		if err := up.parseUpdate(tokens[1:], method, curParamIndex, false); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, up)
+
+	case Delete:
+		curParamIndex := new(int)
+		*curParamIndex = 1
+		dp := newDeleteParse()
+		if err := dp.parseDelete(tokens[1:], method, curParamIndex, false); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, dp)
+
+	case Count:
+		curParamIndex := new(int)
+		*curParamIndex = 1
+		cp := newCountParse()
+		if err := cp.parseCount(tokens[1:], method, curParamIndex); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, cp)
+
+	case Transaction:
+		curParamIndex := new(int)
+		*curParamIndex = 2
+		tp := newTransactionParse()
+		if err := tp.parseTransaction(tokens[1:], method, curParamIndex); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, tp)
+
+	case Bulk:
+		curParamIndex := new(int)
+		*curParamIndex = 1
+		bp := newBulkParse()
+		if err := bp.parseBulk(tokens[1:], method, curParamIndex, false); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, bp)
+
+	case Watch:
+		curParamIndex := new(int)
+		*curParamIndex = 1
+		wp := newWatchParse()
+		if err := wp.parseWatch(tokens[1:], method, curParamIndex); err != nil {
+			return err
+		}
+		ifo.BelongedToStruct = extractStruct
+		ifo.Operations = append(ifo.Operations, wp)
+
+	default:
+		return newMethodSyntaxError(method.Name, "wrong operation name, should be Insert, Find, "+
+			"Update, Delete, Count, Transaction, Bulk, Watch")
 	}
 
 	return nil
@@ -183,7 +255,11 @@ func getFieldNameType(tokens []string, extractStruct *extract.IdlExtractStruct,
 					}
 				}
 				if hasFieldFlag == 0 {
-					return nil, nil, fmt.Errorf("partially equal but unable to fully locate field name in %v", tokens[i:])
+					return nil, nil, &fieldNameError{
+						errReason: fmt.Sprintf("partially equal but unable to fully locate field name in %v", tokens[i:]),
+						tokens:    tokens[i:],
+						st:        extractStruct,
+					}
 				}
 
 				flag = 1
@@ -200,7 +276,11 @@ func getFieldNameType(tokens []string, extractStruct *extract.IdlExtractStruct,
 						break
 					}
 					if len(r) != 1 {
-						return nil, nil, fmt.Errorf("no field name corresponding to %v found", tokens[i:])
+						return nil, nil, &fieldNameError{
+							errReason: fmt.Sprintf("no field name corresponding to %v found", tokens[i:]),
+							tokens:    tokens[i:],
+							st:        extractStruct,
+						}
 					}
 					i += *curIndex
 					names = append(names, field.Tag.Get("bson")+"."+r[0])
@@ -213,7 +293,11 @@ func getFieldNameType(tokens []string, extractStruct *extract.IdlExtractStruct,
 			break
 		}
 		if flag == 0 {
-			return nil, nil, fmt.Errorf("no field name corresponding to %v found", tokens[i:])
+			return nil, nil, &fieldNameError{
+				errReason: fmt.Sprintf("no field name corresponding to %v found", tokens[i:]),
+				tokens:    tokens[i:],
+				st:        extractStruct,
+			}
 		}
 	}
 
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/cwgo/config"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/plugin"
+)
+
+// testIdl is the thrift idl of the tests, the annotations of the methods are appended to the struct User.
+const testIdl = `namespace go user
+
+struct Contact {
+    1: string Phone (go.tag="bson:\"phone\"")
+    2: string City (go.tag="bson:\"city\"")
+}
+
+struct User {
+    1: i64 Id (go.tag="bson:\"id,omitempty\"")
+    2: string Username (go.tag="bson:\"username\"")
+    3: i32 Age (go.tag="bson:\"age\"")
+    4: Contact Contact (go.tag="bson:\"contact\"")
+    5: i64 CreatedAt (go.tag="bson:\"created_at\"")
+    6: i64 AfterSaleId (go.tag="bson:\"after_sale_id\"")
+}(
+%s
+)
+`
+
+// extractTestStructs extracts the structures of the thrift idl in path without reading or writing the idl on the disk.
+func extractTestStructs(t *testing.T, path, idl string) []*extract.IdlExtractStruct {
+	t.Helper()
+	ast, err := parser.ParseString(path, idl)
+	if err != nil {
+		t.Fatalf("parse the idl: %v", err)
+	}
+	info := &extract.ThriftUsedInfo{
+		Req:     &plugin.Request{AST: ast},
+		DocArgs: &config.DocArgument{DaoDir: t.TempDir(), ModelDir: t.TempDir()},
+		Overlay: extract.Overlay{path: idl},
+	}
+	structs, err := info.ParseThriftIdl()
+	if err != nil {
+		t.Fatalf("extract the idl: %v", err)
+	}
+	return structs
+}
+
+// getTestUser returns the structure User of testIdl with the annotations.
+func getTestUser(t *testing.T, annotations string) *extract.IdlExtractStruct {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "user.thrift")
+	for _, st := range extractTestStructs(t, path, fmt.Sprintf(testIdl, annotations)) {
+		if st.Name == "User" {
+			return st
+		}
+	}
+	t.Fatal("the structure User is not extracted")
+	return nil
+}
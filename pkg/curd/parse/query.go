@@ -19,7 +19,10 @@ package parse
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/cloudwego/cwgo/pkg/curd/code"
 	"github.com/cloudwego/cwgo/pkg/curd/extract"
 )
 
@@ -69,7 +72,41 @@ type ConnectionOpTree struct {
 	LeftChildren   *ConnectionOpTree
 	RightChildren  *ConnectionOpTree
 	MongoFieldName string   // if not leaf, empty
-	ParamNames     []string // if not leaf, empty
+	ParamNames     []string // if not leaf or Literal is not nil, empty
+	Literal        *Literal // the literal compared with the field of the query annotation, nil if the params are compared
+}
+
+// Values returns the go expressions of the values compared with the field, which are the params or the literal.
+func (node *ConnectionOpTree) Values() []string {
+	if node.Literal != nil {
+		return []string{node.Literal.Expr()}
+	}
+	return node.ParamNames
+}
+
+type LiteralKind string
+
+const (
+	NumberLiteral = LiteralKind("number")
+	StringLiteral = LiteralKind("string")
+)
+
+// Literal is the number or string literal written in the query annotation instead of the placeholder.
+type Literal struct {
+	Kind LiteralKind
+	// Value is the number as written in the annotation or the unquoted string
+	Value string
+	// FieldType is the go type of the compared field, which the number is converted to
+	FieldType code.Type
+}
+
+// Expr returns the go expression of the literal, the number is converted to the field type
+// to be compared with the field exactly.
+func (l *Literal) Expr() string {
+	if l.Kind == NumberLiteral {
+		return fmt.Sprintf("%s(%s)", l.FieldType.RealName(), l.Value)
+	}
+	return strconv.Quote(l.Value)
 }
 
 const (
@@ -193,70 +230,150 @@ func (q *Query) splitConditionPairs(methodTokens []string, method *extract.Inter
 		return "", "", nil, newMethodSyntaxError(method.Name, fmt.Sprintf("there are grammar errors in %v", methodTokens))
 	}
 
-	for i := len(methodTokens) - 1; i >= 0; i-- {
-		if i-1 >= 0 && methodTokens[i] == "Equal" && methodTokens[i-1] != "Not" && methodTokens[i-1] != "Than" {
-			return q.parseQueryConditionPair(methodTokens[:i], method, curParamIndex, Equal, 1)
+	if _, _, _, ok := matchComparator(methodTokens, len(methodTokens)-1); !ok {
+		return "", "", nil, checkComparatorTokens(methodTokens, method)
+	}
+	comparator, start, paramCount, _ := matchComparator(methodTokens, len(methodTokens)-1)
+	cpName, fieldName, paramNames, err := q.parseQueryConditionPair(methodTokens[:start], method, curParamIndex,
+		comparator, paramCount)
+	var fieldErr *fieldNameError
+	if err != nil && errors.As(err, &fieldErr) {
+		// the misspelled nested field is suggested rather than the comparator its last tokens are close to
+		fieldTokens := methodTokens[:start]
+		if closestName(strings.Join(fieldTokens, ""), getFieldNames(method.BelongedToStruct, "", 0)) != "" {
+			return "", "", nil, &fieldNameError{
+				errReason: fmt.Sprintf("no field name corresponding to %v found", fieldTokens),
+				tokens:    fieldTokens,
+				st:        method.BelongedToStruct,
+			}
 		}
-
-		if i-1 >= 0 && methodTokens[i] == "Equal" && methodTokens[i-1] == "Not" {
-			fmt.Printf("%v\n", methodTokens[:i-1])
-			return q.parseQueryConditionPair(methodTokens[:i-1], method, curParamIndex, NotEqual, 1)
+		if tokenErr := checkFieldTokens(methodTokens[:start], comparator); tokenErr != nil {
+			return "", "", nil, tokenErr
 		}
+	}
+	return cpName, fieldName, paramNames, err
+}
 
-		if i-1 >= 0 && methodTokens[i] == "Than" && methodTokens[i-1] == "Less" {
-			return q.parseQueryConditionPair(methodTokens[:i-1], method, curParamIndex, LessThan, 1)
+// comparatorNames are the names of the comparators, which are suggested for the misspelled comparators.
+var comparatorNames = func() []string {
+	names := make([]string, 0, len(comparators))
+	for _, comparator := range comparators {
+		names = append(names, string(comparator))
+	}
+	return names
+}()
+
+// matchComparator returns the comparator ending at the index of the tokens, the index of its first token and
+// the count of the params it requires, ok is false if there is no comparator ending at the index.
+func matchComparator(tokens []string, i int) (comparator QueryComparator, start, paramCount int, ok bool) {
+	previous := ""
+	if i-1 >= 0 {
+		previous = tokens[i-1]
+	}
+	switch tokens[i] {
+	case "Equal":
+		switch {
+		case previous == "Not":
+			return NotEqual, i - 1, 1, true
+		case previous == "Than" && i-2 >= 0 && tokens[i-2] == "Less":
+			return LessThanEqual, i - 2, 1, true
+		case previous == "Than" && i-2 >= 0 && tokens[i-2] == "Greater":
+			return GreaterThanEqual, i - 2, 1, true
+		case i-1 >= 0 && previous != "Than":
+			return Equal, i, 1, true
 		}
-
-		if i-2 >= 0 && methodTokens[i] == "Equal" &&
-			methodTokens[i-1] == "Than" && methodTokens[i-2] == "Less" {
-			return q.parseQueryConditionPair(methodTokens[:i-2], method, curParamIndex, LessThanEqual, 1)
+	case "Than":
+		switch previous {
+		case "Less":
+			return LessThan, i - 1, 1, true
+		case "Greater":
+			return GreaterThan, i - 1, 1, true
 		}
-
-		if i-1 >= 0 && methodTokens[i] == "Than" && methodTokens[i-1] == "Greater" {
-			return q.parseQueryConditionPair(methodTokens[:i-1], method, curParamIndex, GreaterThan, 1)
+	case "Between":
+		if previous == "Not" {
+			return NotBetween, i - 1, 2, true
 		}
-
-		if i-2 >= 0 && methodTokens[i] == "Equal" &&
-			methodTokens[i-1] == "Than" && methodTokens[i-2] == "Greater" {
-			return q.parseQueryConditionPair(methodTokens[:i-2], method, curParamIndex, GreaterThanEqual, 1)
+		if i-1 >= 0 {
+			return Between, i, 2, true
 		}
-
-		if i-1 >= 0 && methodTokens[i] == "Between" && methodTokens[i-1] != "Not" {
-			return q.parseQueryConditionPair(methodTokens[:i], method, curParamIndex, Between, 2)
+	case "In":
+		if previous == "Not" {
+			return NotIn, i - 1, 1, true
 		}
-
-		if i-1 >= 0 && methodTokens[i] == "Between" && methodTokens[i-1] == "Not" {
-			return q.parseQueryConditionPair(methodTokens[:i-1], method, curParamIndex, NotBetween, 2)
+		if i-1 >= 0 {
+			return In, i, 1, true
 		}
-
-		if i-1 >= 0 && methodTokens[i] == "In" && methodTokens[i-1] != "Not" {
-			return q.parseQueryConditionPair(methodTokens[:i], method, curParamIndex, In, 1)
+	case "True":
+		return True, i, 0, true
+	case "False":
+		return False, i, 0, true
+	case "Exists":
+		if previous == "Not" {
+			return NotExists, i - 1, 0, true
 		}
-
-		if i-1 >= 0 && methodTokens[i] == "In" && methodTokens[i-1] == "Not" {
-			return q.parseQueryConditionPair(methodTokens[:i-1], method, curParamIndex, NotIn, 1)
+		if i-1 >= 0 {
+			return Exists, i, 0, true
 		}
+	}
+	return "", 0, 0, false
+}
 
-		if methodTokens[i] == "True" {
-			return q.parseQueryConditionPair(methodTokens[:i], method, curParamIndex, True, 0)
+// checkComparatorTokens returns the error of the condition pair not ending with a comparator, which suggests
+// the comparator closest to the last tokens, or the keyword closest to the tokens after the last comparator.
+func checkComparatorTokens(tokens []string, method *extract.InterfaceMethod) error {
+	for start := len(tokens) - 1; start > 0; start-- {
+		// the tokens after the last comparator are checked below
+		if _, _, _, ok := matchComparator(tokens, start); ok {
+			break
 		}
-
-		if methodTokens[i] == "False" {
-			return q.parseQueryConditionPair(methodTokens[:i], method, curParamIndex, False, 0)
+		if comparator := suggestKeyword(strings.Join(tokens[start:], ""), comparatorNames); comparator != "" {
+			return &tokenError{
+				errReason: fmt.Sprintf("%v is not a comparator", tokens[start:]),
+				tokens:    tokens[start:],
+				keyword:   comparator,
+			}
 		}
-
-		if i-1 >= 0 && methodTokens[i] == "Exists" && methodTokens[i-1] != "Not" {
-			return q.parseQueryConditionPair(methodTokens[:i], method, curParamIndex, Exists, 0)
+	}
+	for i := len(tokens) - 2; i > 0; i-- {
+		if comparator, _, _, ok := matchComparator(tokens, i); ok {
+			return &tokenError{
+				errReason: fmt.Sprintf("unexpected tokens %v after the comparator %s", tokens[i+1:], comparator),
+				tokens:    tokens[i+1:],
+				keyword:   suggestKeyword(tokens[i+1], keywords),
+			}
 		}
+	}
+	return newMethodSyntaxError(method.Name, fmt.Sprintf("there are grammar errors in %v, "+
+		"not including Equal, NotEqual, LessThan, LessThanEqual, GreaterThan, GreaterThanEqual, Between, NotBetween,"+
+		"In, NotIn, True, False, Exists, NotExists", tokens))
+}
 
-		if i-1 >= 0 && methodTokens[i] == "Exists" && methodTokens[i-1] == "Not" {
-			return q.parseQueryConditionPair(methodTokens[:i-1], method, curParamIndex, NotExists, 0)
+// checkFieldTokens returns the error of the field tokens which can not be located to the fields, it is nil
+// if the tokens are not a misspelled connection op after a comparator or the prefix of a misspelled comparator.
+func checkFieldTokens(tokens []string, comparator QueryComparator) error {
+	for i := 1; i < len(tokens)-1; i++ {
+		if _, _, _, ok := matchComparator(tokens, i); !ok {
+			continue
+		}
+		if keyword := suggestKeyword(tokens[i+1], []string{string(And), string(Or)}); keyword != "" {
+			return &tokenError{
+				errReason: fmt.Sprintf("unexpected tokens %v after the comparator %s", tokens[i+1:], tokens[i]),
+				tokens:    tokens[i+1:],
+				keyword:   keyword,
+			}
 		}
 	}
-
-	return "", "", nil, newMethodSyntaxError(method.Name, fmt.Sprintf("there are grammar errors in %v, "+
-		"not including Equal, NotEqual, LessThan, LessThanEqual, GreaterThan, GreaterThanEqual, Between, NotBetween,"+
-		"In, NotIn, True, False, Exists, NotExists", methodTokens))
+	for start := 1; start < len(tokens); start++ {
+		name := strings.Join(tokens[start:], "") + string(comparator)
+		if suggested := suggestKeyword(name, comparatorNames); suggested != "" {
+			return &tokenError{
+				errReason: fmt.Sprintf("%v is not a comparator", append(tokens[start:len(tokens):len(tokens)], string(comparator))),
+				tokens:    tokens[start:],
+				keyword:   suggested,
+			}
+		}
+	}
+	return nil
 }
 
 // parseQueryConditionPair is used to parse query's condition pair
@@ -289,9 +406,7 @@ func (q *Query) parseQueryConditionPair(methodTokens []string, method *extract.I
 		}
 		for i := *curParamIndex; i < *curParamIndex+paramCount; i++ {
 			if method.Params[i].Type.RealName() != t[0].RealName() {
-				return "", "", nil, newMethodSyntaxError(method.Name,
-					fmt.Sprintf("the field type in the parameter transfer: %s, the actual required field type: %s",
-						method.Params[i].Type.RealName(), t[0].RealName()))
+				return "", "", nil, newParamTypeError(method.Name, method.Params[i], t[0])
 			}
 			values = append(values, method.Params[i].Name)
 		}
@@ -301,6 +416,37 @@ func (q *Query) parseQueryConditionPair(methodTokens []string, method *extract.I
 	return string(queryComparator), result[0], values, nil
 }
 
+// combineKeyset is used to combine the keyset condition with the existing query tree by And.
+func (q *Query) combineKeyset(keyset *Keyset) {
+	leaf := keyset.Condition()
+
+	if q.QueryMode == All {
+		q.QueryMode = By
+		q.ConnectionOpTree = leaf
+		return
+	}
+
+	q.ConnectionOpTree = &ConnectionOpTree{
+		Name:          string(And),
+		LeftChildren:  q.ConnectionOpTree,
+		RightChildren: leaf,
+	}
+}
+
+// newNoQueryError returns the error of the tokens without By or All, which suggests the keyword closest to
+// the first misspelled token.
+func newNoQueryError(tokens []string) error {
+	index, keyword := suggestKeywordToken(tokens, keywords)
+	if keyword == "" {
+		return errors.New("no By or All specified")
+	}
+	return &tokenError{
+		errReason: "no By or All specified",
+		tokens:    tokens[index:],
+		keyword:   keyword,
+	}
+}
+
 func getFirstQueryIndex(tokens []string) (int, error) {
 	firstIndex := -1
 	for index, token := range tokens {
@@ -310,7 +456,7 @@ func getFirstQueryIndex(tokens []string) (int, error) {
 		}
 	}
 	if firstIndex == -1 {
-		return 0, errors.New("no By or All specified")
+		return 0, newNoQueryError(tokens)
 	}
 	return firstIndex, nil
 }
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+// Replace is the token of the Replace One operation, which is only supported by Bulk.
+const Replace = "Replace"
+
+type ReplaceParse struct {
+	// ReplacementParamName defines the method's structure point param name which replaces the matched document
+	ReplacementParamName string
+
+	// Query defines the Query information contained in the Replace operation
+	Query *Query
+
+	// BelongedToMethod defines the method to which Replace belongs
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
+}
+
+func newReplaceParse() *ReplaceParse {
+	return &ReplaceParse{Query: newQuery()}
+}
+
+func (rp *ReplaceParse) GetOperationName() string {
+	return Replace
+}
+
+// parseReplace is called by Bulk, the replacement is the next param of the method, which is followed by
+// the params of the query.
+//
+//	input params description:
+//	tokens: it contains all tokens belonging to Replace except for Replace One tokens
+//	method: the method to which Replace belongs
+//	curParamIndex: current method's param index
+func (rp *ReplaceParse) parseReplace(tokens []string, method *extract.InterfaceMethod, curParamIndex *int) error {
+	rp.BelongedToMethod = method
+
+	fqIndex, err := getFirstQueryIndex(tokens)
+	if err != nil {
+		return err
+	}
+	if fqIndex != 0 {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("Replace One should be followed by By or All, "+
+			"not %s", tokens[0]))
+	}
+
+	if *curParamIndex >= len(method.Params) {
+		return newMethodSyntaxError(method.Name, "no parameter specified for the replacement of Replace One")
+	}
+	param := method.Params[*curParamIndex]
+	if _, ok := param.Type.(code.StarExprType); !ok {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("the replacement parameter %s of Replace One "+
+			"should be a structure pointer", param.Name))
+	}
+	rp.ReplacementParamName = param.Name
+	*curParamIndex += 1
+
+	return rp.Query.parseQuery(tokens, method, curParamIndex)
+}
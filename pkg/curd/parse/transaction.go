@@ -17,9 +17,12 @@
 package parse
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/cloudwego/cwgo/pkg/curd/code"
 	"github.com/cloudwego/cwgo/pkg/curd/extract"
 )
 
@@ -31,27 +34,50 @@ type TransactionParse struct {
 	// CtxParamName defines the method's context.Context param name
 	CtxParamName string
 
-	// ClientParamName defines the method's *mongo.Client param name
+	// ClientParamName defines the method's client param name, whose type is validated by the backend
 	ClientParamName string
 
 	// collectionParamsMap stores the method's *mongo.Collection param names
 	collectionParamsMap map[string]string
 
+	// repositoryParamsMap stores the models whose repositories are the types of the method's params
+	repositoryParamsMap map[string]*extract.IdlExtractStruct
+
 	// BelongedToMethod defines the method to which Transaction belongs
-	BelongedToMethod *extract.InterfaceMethod
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
 }
 
 type TransactionOperation struct {
 	// CollectionParamName stores the collection which the operation belongs,
-	// if not specified, default is r.collection
+	// if not specified, default is r.collection, it is empty if the operation belongs to the Model
 	CollectionParamName string
-	Operation           Operation
+	// Model is the other model in the idl whose collection the operation belongs to,
+	// it is specified by the name of the param whose type is the repository of the model
+	Model *extract.IdlExtractStruct
+	// RepositoryParamName is the name of the param whose type is the repository of Model
+	RepositoryParamName string
+
+	Operation Operation
+}
+
+// MarshalJSON encodes Model as its name, which is the name of the other structure in the same request.
+func (to TransactionOperation) MarshalJSON() ([]byte, error) {
+	type operation TransactionOperation
+	model := ""
+	if to.Model != nil {
+		model = to.Model.Name
+	}
+	return json.Marshal(struct {
+		operation
+		Model string
+	}{operation(to), model})
 }
 
 func newTransactionParse() *TransactionParse {
 	return &TransactionParse{
 		TransactionOperations: []TransactionOperation{},
 		collectionParamsMap:   map[string]string{},
+		repositoryParamsMap:   map[string]*extract.IdlExtractStruct{},
 	}
 }
 
@@ -60,7 +86,8 @@ func (tp *TransactionParse) GetOperationName() string {
 }
 
 const (
-	defaultCollection = "r.collection"
+	// DefaultCollection is the collection of the repository used when no Collection is specified
+	DefaultCollection = "r.collection"
 	collection        = "Collection"
 )
 
@@ -77,37 +104,47 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 
 	tp.BelongedToMethod = method
 
-	// store method's collection param names
+	// store method's collection and repository param names
 	if len(method.Params) > 2 {
 		for _, param := range method.Params {
+			if param.Name == "" {
+				continue
+			}
+			collectionParam := strings.ToUpper(string(param.Name[0])) + param.Name[1:]
 			if param.Type.RealName() == "*mongo.Collection" {
-				collectionParam := ""
-				if len(param.Name) == 1 {
-					collectionParam = strings.ToUpper(string(param.Name[0]))
-				} else {
-					collectionParam = strings.ToUpper(string(param.Name[0])) + param.Name[1:]
-				}
 				tp.collectionParamsMap[collectionParam] = param.Name
 				*curParamIndex += 1
+				continue
+			}
+			model, err := getRepositoryModel(method, param)
+			if err != nil {
+				return err
+			}
+			if model != nil {
+				tp.repositoryParamsMap[collectionParam] = model
+				*curParamIndex += 1
 			}
 		}
 	}
 
 	for index := 0; index < len(tokens); index++ {
-		if tokens[index] == Find || tokens[index] == Count || tokens[index] == Transaction {
+		if tokens[index] == Find || tokens[index] == Count || tokens[index] == Transaction || tokens[index] == Watch {
 			return newMethodSyntaxError(method.Name, "the Transaction operation does not supports Find, Count, "+
-				"Transaction, only supports Insert, Update, Delete, Bulk")
+				"Transaction, Watch, only supports Insert, Update, Delete, Bulk")
+		}
+		if tokens[index] == Replace {
+			return newMethodSyntaxError(method.Name, "the Transaction operation only supports Replace in Bulk")
 		}
 
 		if tokens[index] == Insert {
-			if err := tp.parseTransactionInsert(method, tokens, index, curParamIndex, defaultCollection); err != nil {
+			if err := tp.parseTransactionInsert(method, tokens, index, curParamIndex, DefaultCollection); err != nil {
 				return err
 			}
 			index += 1
 		}
 
 		if tokens[index] == Update {
-			noIndex, err := tp.parseTransactionUpdate(method, tokens, index, curParamIndex, defaultCollection, false)
+			noIndex, err := tp.parseTransactionUpdate(method, tokens, index, curParamIndex, DefaultCollection, false)
 			if err != nil {
 				return err
 			}
@@ -115,7 +152,7 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 		}
 
 		if tokens[index] == Delete {
-			noIndex, err := tp.parseTransactionDelete(method, tokens, index, curParamIndex, defaultCollection, false)
+			noIndex, err := tp.parseTransactionDelete(method, tokens, index, curParamIndex, DefaultCollection, false)
 			if err != nil {
 				return err
 			}
@@ -123,7 +160,7 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 		}
 
 		if tokens[index] == Bulk {
-			noIndex, err := tp.parseTransactionBulk(method, tokens, index, curParamIndex, defaultCollection)
+			noIndex, err := tp.parseTransactionBulk(method, tokens, index, curParamIndex, DefaultCollection)
 			if err != nil {
 				return err
 			}
@@ -155,34 +192,46 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 
 			paramName := strSlice2Str(tokens[index+1 : belongedToOpIndex])
 			v, ok := tp.collectionParamsMap[paramName]
+			var model *extract.IdlExtractStruct
 			if !ok {
-				return newMethodSyntaxError(method.Name, "the collection name specified in tokens was not "+
-					"found in the method parameters")
+				if model, ok = tp.repositoryParamsMap[paramName]; !ok {
+					return newMethodSyntaxError(method.Name, fmt.Sprintf("the collection name %s specified in "+
+						"tokens was not found in the *mongo.Collection or repository parameters of the method%s",
+						paramName, tp.suggestParamName(paramName)))
+				}
+			}
+			// the fields of the operation on the collection of the model are the fields of the model
+			opMethod := method
+			if model != nil {
+				modelMethod := *method
+				modelMethod.BelongedToStruct = model
+				opMethod = &modelMethod
 			}
+			opCount := len(tp.TransactionOperations)
 
 			switch belongedToOpIndexName {
 			case Insert:
-				if err := tp.parseTransactionInsert(method, tokens, belongedToOpIndex, curParamIndex, v); err != nil {
+				if err := tp.parseTransactionInsert(opMethod, tokens, belongedToOpIndex, curParamIndex, v); err != nil {
 					return err
 				}
 				index = belongedToOpIndex + 1
 
 			case Update:
-				noIndex, err := tp.parseTransactionUpdate(method, tokens, belongedToOpIndex, curParamIndex, v, true)
+				noIndex, err := tp.parseTransactionUpdate(opMethod, tokens, belongedToOpIndex, curParamIndex, v, true)
 				if err != nil {
 					return err
 				}
 				index = noIndex - 1
 
 			case Delete:
-				noIndex, err := tp.parseTransactionDelete(method, tokens, belongedToOpIndex, curParamIndex, v, true)
+				noIndex, err := tp.parseTransactionDelete(opMethod, tokens, belongedToOpIndex, curParamIndex, v, true)
 				if err != nil {
 					return err
 				}
 				index = noIndex - 1
 
 			case Bulk:
-				noIndex, err := tp.parseTransactionBulk(method, tokens, belongedToOpIndex, curParamIndex, v)
+				noIndex, err := tp.parseTransactionBulk(opMethod, tokens, belongedToOpIndex, curParamIndex, v)
 				if err != nil {
 					return err
 				}
@@ -190,6 +239,10 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 
 			default:
 			}
+			if model != nil {
+				tp.TransactionOperations[opCount].Model = model
+				tp.TransactionOperations[opCount].RepositoryParamName = strings.ToLower(paramName[:1]) + paramName[1:]
+			}
 		}
 	}
 
@@ -201,6 +254,55 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 	return nil
 }
 
+// getRepositoryModel returns the model whose repository is the type of the param, such as order.OrderRepository,
+// the nil model is returned if the type is not a repository. The repository of a model without the repository
+// in the same output is reported, so are the repositories which can not be imported by the method.
+func getRepositoryModel(method *extract.InterfaceMethod, param code.Param) (*extract.IdlExtractStruct, error) {
+	t, ok := param.Type.(code.SelectorExprType)
+	if !ok || !strings.HasSuffix(t.Sel, "Repository") {
+		return nil, nil
+	}
+	st := method.BelongedToStruct
+	names := make([]string, 0, len(st.Models))
+	for name, model := range st.Models {
+		if t.X == extract.GetPkgName(name) && t.Sel == name+"Repository" {
+			if model == st {
+				return nil, newMethodSyntaxError(method.Name, fmt.Sprintf("the param %s is the repository of "+
+					"the method itself, write the operations on its collection without Collection", param.Name))
+			}
+			if t.X == st.ModelPkg {
+				return nil, newMethodSyntaxError(method.Name, fmt.Sprintf("the repository package %s of the param %s "+
+					"conflicts with the model package", t.X, param.Name))
+			}
+			return model, nil
+		}
+		names = append(names, extract.GetPkgName(name)+"."+name+"Repository")
+	}
+	sort.Strings(names)
+	suggestion := ""
+	if name := suggestKeyword(t.RealName(), names); name != "" {
+		suggestion = fmt.Sprintf(", did you mean %s", name)
+	}
+	return nil, newMethodSyntaxError(method.Name, fmt.Sprintf("the type %s of the param %s is not the repository "+
+		"of a model in the idl, the model should be generated in the same output%s", t.RealName(), param.Name, suggestion))
+}
+
+// suggestParamName returns the suggestion of the collection or repository param closest to the name.
+func (tp *TransactionParse) suggestParamName(name string) string {
+	names := make([]string, 0, len(tp.collectionParamsMap)+len(tp.repositoryParamsMap))
+	for paramName := range tp.collectionParamsMap {
+		names = append(names, paramName)
+	}
+	for paramName := range tp.repositoryParamsMap {
+		names = append(names, paramName)
+	}
+	sort.Strings(names)
+	if suggestion := suggestKeyword(name, names); suggestion != "" {
+		return fmt.Sprintf(", did you mean %s", suggestion)
+	}
+	return ""
+}
+
 func (tp *TransactionParse) check(method *extract.InterfaceMethod) error {
 	if len(method.Params) < 2 {
 		return newMethodSyntaxError(method.Name, "less than two input parameters")
@@ -215,11 +317,6 @@ func (tp *TransactionParse) check(method *extract.InterfaceMethod) error {
 			"should be context.Context")
 	}
 
-	if method.Params[1].Type.RealName() != "*mongo.Client" {
-		return newMethodSyntaxError(method.Name, "the second parameter in the input parameters "+
-			"should be *mongo.Client")
-	}
-
 	if method.Returns[0].RealName() != "error" {
 		return newMethodSyntaxError(method.Name, "the only parameter in the return parameters "+
 			"should be error")
@@ -240,15 +337,14 @@ func (tp *TransactionParse) parseTransactionInsert(method *extract.InterfaceMeth
 
 	if tokens[index+1] == One || tokens[index+1] == Many {
 		ip := newInsertParse()
-		if err := ip.parseInsert(method, curParamIndex, true); err != nil {
-			return err
-		}
-
 		if tokens[index+1] == One {
 			ip.OperateMode = OperateOne
 		} else {
 			ip.OperateMode = OperateMany
 		}
+		if err := ip.parseInsert(method, curParamIndex, true); err != nil {
+			return err
+		}
 
 		tp.TransactionOperations = append(tp.TransactionOperations, TransactionOperation{
 			CollectionParamName: collectionParamName,
@@ -335,6 +431,13 @@ func (tp *TransactionParse) parseTransactionBulk(method *extract.InterfaceMethod
 	}
 
 	bp := newBulkParse()
+	if tokens[index+1] == unordered {
+		bp.Unordered = true
+		index++
+		if index == len(tokens)-1 {
+			return 0, newMethodSyntaxError(method.Name, "no tokens specified after Bulk Unordered")
+		}
+	}
 	if tokens[index+1] != leftBracket {
 		return 0, newMethodSyntaxError(method.Name, "parentheses need to be specified after Transaction "+
 			"Bulk operation")
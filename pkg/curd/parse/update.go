@@ -38,7 +38,7 @@ type UpdateParse struct {
 	CtxParamName string
 
 	// BelongedToMethod defines the method to which Update belongs
-	BelongedToMethod *extract.InterfaceMethod
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
 
 	Upsert       bool
 	UpdateFields []UpdateField
@@ -75,7 +75,7 @@ func (up *UpdateParse) parseUpdate(tokens []string, method *extract.InterfaceMet
 
 	fqIndex, err := getFirstQueryIndex(tokens)
 	if err != nil {
-		return newMethodSyntaxError(method.Name, err.Error())
+		return err
 	}
 
 	if tokens[0] == "Upsert" {
@@ -168,17 +168,15 @@ func (up *UpdateParse) parseUpdateField(tokens []string, method *extract.Interfa
 	}
 
 	for i := 0; i < len(result); i++ {
-		if i+1 >= len(method.Params) {
+		if i+*curParamIndex >= len(method.Params) {
 			return newMethodSyntaxError(method.Name, "insufficient number of input parameters")
 		}
 		if method.Params[i+*curParamIndex].Type.RealName() != t[i].RealName() {
-			return newMethodSyntaxError(method.Name,
-				fmt.Sprintf("the field type in the parameter transfer: %s, the actual required field type: %s",
-					method.Params[i].Type.RealName(), t[0].RealName()))
+			return newParamTypeError(method.Name, method.Params[i+*curParamIndex], t[i])
 		}
 		up.UpdateFields = append(up.UpdateFields, UpdateField{
 			MongoFieldName: result[i],
-			ParamName:      method.Params[i+1].Name,
+			ParamName:      method.Params[i+*curParamIndex].Name,
 		})
 	}
 	*curParamIndex += len(result)
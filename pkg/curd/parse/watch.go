@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+	"github.com/cloudwego/cwgo/pkg/curd/extract"
+)
+
+// ChangeStreamOptionsType is the type of the optional last param of the Watch method, which is used to
+// resume the change stream by the resume token and so on.
+const ChangeStreamOptionsType = "*options.ChangeStreamOptions"
+
+// ErrorHandlerType is the type of the optional param after the options of the Watch method, which receives
+// the errors of decoding the changes and the error which ends the change stream.
+const ErrorHandlerType = "func(error)"
+
+type WatchParse struct {
+	// Query defines the Query information which the full documents of the changes are matched with
+	Query *Query
+
+	// CtxParamName defines the method's context.Context param name
+	CtxParamName string
+
+	// OptionsParamName defines the method's *options.ChangeStreamOptions param name, empty if it is not declared
+	OptionsParamName string
+
+	// ErrorHandlerParamName defines the method's func(error) param name, empty if it is not declared
+	ErrorHandlerParamName string
+
+	// ElementType defines the element type of the returned channel, such as *user.User
+	ElementType code.Type
+
+	// BelongedToMethod defines the method to which Watch belongs
+	BelongedToMethod *extract.InterfaceMethod `json:"-"`
+}
+
+func newWatchParse() *WatchParse {
+	return &WatchParse{Query: newQuery()}
+}
+
+func (wp *WatchParse) GetOperationName() string {
+	return Watch
+}
+
+// parseWatch can be called independently.
+//
+//	input params description:
+//	tokens: it contains all tokens belonging to Watch except for Watch token
+//	method: the method to which Watch belongs
+//	curParamIndex: current method's param index
+func (wp *WatchParse) parseWatch(tokens []string, method *extract.InterfaceMethod, curParamIndex *int) error {
+	if err := wp.check(method); err != nil {
+		return err
+	}
+
+	wp.BelongedToMethod = method
+
+	fqIndex, err := getFirstQueryIndex(tokens)
+	if err != nil {
+		return err
+	}
+	if err = wp.Query.parseQuery(tokens[fqIndex:], method, curParamIndex); err != nil {
+		return err
+	}
+
+	if *curParamIndex < len(method.Params) && method.Params[*curParamIndex].Type.RealName() == ChangeStreamOptionsType {
+		wp.OptionsParamName = method.Params[*curParamIndex].Name
+		*curParamIndex++
+	}
+	if *curParamIndex < len(method.Params) && method.Params[*curParamIndex].Type.RealName() == ErrorHandlerType {
+		wp.ErrorHandlerParamName = method.Params[*curParamIndex].Name
+		*curParamIndex++
+	}
+
+	if *curParamIndex < len(method.Params) {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("too many method parameters written, "+
+			"%v and subsequent parameters are useless", method.Params[*curParamIndex].Name))
+	}
+
+	return nil
+}
+
+func (wp *WatchParse) check(method *extract.InterfaceMethod) error {
+	if len(method.Params) < 1 {
+		return newMethodSyntaxError(method.Name, "less than one input parameters")
+	}
+
+	if len(method.Returns) != 2 {
+		return newMethodSyntaxError(method.Name, "return parameter not equal to 2")
+	}
+
+	if method.Params[0].Type.RealName() != "context.Context" {
+		return newMethodSyntaxError(method.Name, "the first parameter in the input parameters "+
+			"should be context.Context")
+	}
+
+	chanType, ok := method.Returns[0].(code.ChanType)
+	if !ok || chanType.Dir != code.ChanRecv {
+		return newMethodSyntaxError(method.Name, "the first parameter in the return parameters "+
+			"should be a receive-only channel, such as <-chan *user.User")
+	}
+	if _, ok = chanType.ElementType.(code.StarExprType); !ok {
+		return newMethodSyntaxError(method.Name, "the element type of the returned channel should be a pointer")
+	}
+
+	if method.Returns[1].RealName() != "error" {
+		return newMethodSyntaxError(method.Name, "the second parameter in the return parameters "+
+			"should be error")
+	}
+
+	wp.CtxParamName = method.Params[0].Name
+	wp.ElementType = chanType.ElementType
+
+	return nil
+}
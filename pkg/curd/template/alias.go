@@ -0,0 +1,39 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"bytes"
+
+	"github.com/cloudwego/cwgo/pkg/curd/code"
+)
+
+var typeAliasTemplate = `{{.Comment}}
+type {{.Name}} = {{.RealType.RealName}}` + "\n"
+
+type TypeAliasRender struct {
+	Name     string
+	Comment  string
+	RealType code.Type
+}
+
+func (tr *TypeAliasRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "typeAliasTemplate", typeAliasTemplate, tr); err != nil {
+		return err
+	}
+	return nil
+}
@@ -23,16 +23,17 @@ import (
 )
 
 var funcTemplate = `{{.Comment}}
-func {{.Name}}{{.Params.GetCode}} {{.Returns.GetCode}} {
+func {{.Name}}{{.TypeParams.GetCode}}{{.Params.GetCode}} {{.Returns.GetCode}} {
 {{.FuncBody.GetCode}}
 }` + "\n"
 
 type FuncRender struct {
-	Name     string
-	Comment  string
-	Params   code.Params
-	Returns  code.Returns
-	FuncBody code.Body
+	Name       string
+	Comment    string
+	TypeParams code.TypeParams // type parameters of the generic declaration
+	Params     code.Params
+	Returns    code.Returns
+	FuncBody   code.Body
 }
 
 func (fr *FuncRender) RenderObj(buffer *bytes.Buffer) error {
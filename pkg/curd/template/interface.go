@@ -23,14 +23,15 @@ import (
 )
 
 var interfaceTemplate = `{{.Comment}}
-type {{.Name}} interface {
+type {{.Name}}{{.TypeParams.GetCode}} interface {
 {{.Methods.GetCode}}
 }` + "\n"
 
 type InterfaceRender struct {
-	Name    string
-	Comment string
-	Methods code.InterfaceMethods
+	Name       string
+	Comment    string
+	TypeParams code.TypeParams // type parameters of the generic declaration
+	Methods    code.InterfaceMethods
 }
 
 func (ir *InterfaceRender) RenderObj(buffer *bytes.Buffer) error {
@@ -23,13 +23,14 @@ import (
 )
 
 var structTemplate = `{{.Comment}}
-type {{.Name}} struct {
+type {{.Name}}{{.TypeParams.GetCode}} struct {
 {{.StructFields.GetCode}}
 }` + "\n"
 
 type StructRender struct {
 	Name         string
 	Comment      string
+	TypeParams   code.TypeParams // type parameters of the generic declaration
 	StructFields code.StructFields
 }
 